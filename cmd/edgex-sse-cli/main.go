@@ -0,0 +1,188 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+Command edgex-sse-cli tails the events of the SSE application service, for debugging device
+data on a gateway without a browser. It creates a subscription from its flags (or uses an
+existing one), prints the events as they come, and deletes the subscription when interrupted.
+*/
+package main
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/client"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Struct jsonEvent is an event as printed with -json, one per line.
+type jsonEvent struct {
+	Id            string          `json:"id,omitempty"`
+	Type          string          `json:"type,omitempty"`
+	CorrelationId string          `json:"correlationId,omitempty"`
+	Data          json.RawMessage `json:"data"`
+}
+
+/*
+printEvent writes an event for people, with a header line and indented JSON, or as one line of
+JSON for piping into e.g. jq. Payloads that are not JSON are printed, or put in a JSON string, as they are.
+*/
+func printEvent(w io.Writer, event client.Event, asJSON bool, now time.Time) error {
+	data := []byte(event.Data)
+	if asJSON {
+		line := jsonEvent{Id: event.ID, Type: event.Type, CorrelationId: event.CorrelationID, Data: data}
+		if !json.Valid(data) {
+			line.Data, _ = json.Marshal(event.Data)
+		} else {
+			var compact bytes.Buffer
+			if json.Compact(&compact, data) == nil {
+				line.Data = compact.Bytes()
+			}
+		}
+		encoded, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", encoded)
+		return err
+	}
+	header := now.Format(time.RFC3339)
+	eventType := event.Type
+	if eventType == "" {
+		eventType = "generic"
+	}
+	header += " " + eventType
+	if event.ID != "" {
+		header += " id=" + event.ID
+	}
+	if event.CorrelationID != "" {
+		header += " correlation-id=" + event.CorrelationID
+	}
+	var indented bytes.Buffer
+	if json.Indent(&indented, data, "", "  ") == nil {
+		data = indented.Bytes()
+	}
+	_, err := fmt.Fprintf(w, "%s\n%s\n\n", header, data)
+	return err
+}
+
+// splitList (an internal API) splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var rv []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			rv = append(rv, entry)
+		}
+	}
+	return rv
+}
+
+// run is the command, with its arguments and outputs, returning the exit code.
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("edgex-sse-cli", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	apiURL := flags.String("api", "http://localhost:59747", "Base URL of the service's API port")
+	eventsURL := flags.String("events", "http://localhost:59748", "Base URL of the service's events port")
+	token := flags.String("token", os.Getenv("EDGEX_SSE_TOKEN"), "Bearer token (JWT) for secure mode, default from EDGEX_SSE_TOKEN")
+	include := flags.String("include", "", "Comma-separated topic prefixes to include, e.g. edgex/events/device/my-service")
+	exclude := flags.String("exclude", "", "Comma-separated topic prefixes to exclude")
+	format := flags.String("format", "", "How EdgeX events are delivered: edgex, simple or senml")
+	envelope := flags.String("envelope", "", "What payloads are wrapped in: none, cloudevents or delivery")
+	passThrough := flags.Bool("passthrough", false, "Deliver payloads as received, without classifying them")
+	debug := flags.Bool("debug", false, "Have the service log why each topic did or didn't match")
+	deviceLabels := flags.String("device-labels", "", "Comma-separated labels; only deliver EdgeX events from devices that have them all")
+	deviceProfile := flags.String("device-profile", "", "Only deliver EdgeX events from devices with this profile")
+	units := flags.String("units", "", "Comma-separated units to convert readings to, by the service's UnitConversions")
+	decimals := flags.Int("decimals", -1, "Round numeric readings to this many decimal places")
+	significant := flags.Int("significant", 0, "Round numeric readings to this many significant digits")
+	filter := flags.String("filter", "", "Only deliver EdgeX events that pass this SQL-like condition, e.g. \"temperature > 30\"")
+	maxAge := flags.String("max-age", "", "Discard events that waited longer than this to be sent, e.g. 5m")
+	ack := flags.Bool("ack", false, "Acknowledge each event once printed, so those not printed are sent again on reconnect")
+	chunkSize := flags.Int("chunk-size", 0, "Have events longer than this many bytes sent in chunks, at least 1024")
+	media := flags.Bool("media", false, "Have binary readings sent as media events of their own, after their EdgeX event")
+	enumLabels := flags.Bool("enum-labels", false, "Have readings labelled by the value mappings of their device profiles")
+	subscription := flags.String("subscription", "", "Tail this existing subscription instead of creating one")
+	keep := flags.Bool("keep", false, "Don't delete the created subscription when interrupted")
+	asJSON := flags.Bool("json", false, "Print each event as a line of JSON, for piping")
+	heartbeatTimeout := flags.Duration("heartbeat-timeout", 75*time.Second, "Reconnect when nothing comes for this long, 0 to wait forever")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *subscription == "" && *include == "" {
+		fmt.Fprintln(stderr, "Give topic prefixes to -include, or an existing -subscription")
+		return 2
+	}
+
+	sse := client.New(*apiURL, *eventsURL)
+	sse.Token = *token
+	id := *subscription
+	if id == "" {
+		sub := client.Subscription{Include: splitList(*include), Exclude: splitList(*exclude)}
+		sub.Options = &client.Options{PassThrough: *passThrough, Format: *format, Envelope: *envelope, Debug: *debug, Filter: *filter, Units: splitList(*units), MaxAge: *maxAge, Acknowledge: *ack, ChunkSize: *chunkSize, Media: *media, EnumLabels: *enumLabels}
+		if *deviceLabels != "" || *deviceProfile != "" {
+			sub.Options.Devices = &client.DeviceSelector{Labels: splitList(*deviceLabels), Profile: *deviceProfile}
+		}
+		if *significant > 0 {
+			sub.Options.Rounding = &client.Rounding{Digits: *significant, Significant: true}
+		} else if *decimals >= 0 {
+			sub.Options.Rounding = &client.Rounding{Digits: *decimals}
+		}
+		var err error
+		id, err = sse.CreateSubscription(ctx, sub)
+		if err != nil {
+			fmt.Fprintf(stderr, "Could not create subscription: %s\n", err.Error())
+			return 1
+		}
+		fmt.Fprintf(stderr, "Created subscription %s\n", id)
+		if !*keep {
+			defer func() {
+				if err := sse.DeleteSubscription(context.WithoutCancel(ctx), id); err != nil && !client.IsNotFound(err) {
+					fmt.Fprintf(stderr, "Could not delete subscription %s: %s\n", id, err.Error())
+				}
+			}()
+		}
+	}
+
+	for event, err := range sse.Stream(ctx, id, client.StreamOptions{HeartbeatTimeout: *heartbeatTimeout}) {
+		if errors.Is(err, client.ErrSubscriptionGone) {
+			fmt.Fprintf(stderr, "Subscription %s is gone\n", id)
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "Event stream: %s\n", err.Error())
+			continue
+		}
+		if err := printEvent(stdout, event, *asJSON, time.Now()); err != nil {
+			fmt.Fprintf(stderr, "Could not print event: %s\n", err.Error())
+			return 1
+		}
+		if *ack && event.ID != "" {
+			if err := sse.Acknowledge(ctx, id, event.ID); err != nil {
+				fmt.Fprintf(stderr, "Could not acknowledge event %s: %s\n", event.ID, err.Error())
+			}
+		}
+	}
+	// Unless interrupted, the stream only ends when the service refuses it
+	if ctx.Err() == nil {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/client"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintEvent(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	event := client.Event{ID: "7", Type: "simple", Data: "{\"a\": 1}", CorrelationID: "c0ffee"}
+	var out strings.Builder
+	printEvent(&out, event, false, now)
+	if expected := "2025-03-01T12:00:00Z simple id=7 correlation-id=c0ffee\n{\n  \"a\": 1\n}\n\n"; out.String() != expected {
+		t.Fatalf("Wrong output %q, expected %q", out.String(), expected)
+	}
+	out.Reset()
+	printEvent(&out, event, true, now)
+	if expected := "{\"id\":\"7\",\"type\":\"simple\",\"correlationId\":\"c0ffee\",\"data\":{\"a\":1}}\n"; out.String() != expected {
+		t.Fatalf("Wrong output %q, expected %q", out.String(), expected)
+	}
+	out.Reset()
+	printEvent(&out, client.Event{Data: "not json"}, true, now)
+	if expected := "{\"data\":\"not json\"}\n"; out.String() != expected {
+		t.Fatalf("Wrong output %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var patched, deleted bool
+	var patch string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/subscription", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"subscriptionId":"sub1"}`)
+	})
+	mux.HandleFunc("PATCH /api/v3/subscription/id/sub1", func(w http.ResponseWriter, r *http.Request) {
+		patched = true
+		body, _ := io.ReadAll(r.Body)
+		patch = string(body)
+	})
+	mux.HandleFunc("DELETE /api/v3/subscription/id/sub1", func(w http.ResponseWriter, r *http.Request) {
+		deleted = true
+	})
+	mux.HandleFunc("GET /api/v3/events/sub1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event: edgex\ndata: {}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var stdout, stderr strings.Builder
+	code := run(ctx, []string{"-api", server.URL, "-events", server.URL, "-include", "edgex/events", "-device-labels", "line-3", "-json"}, &stdout, &stderr)
+	if code != 0 || !patched || !deleted {
+		t.Fatalf("run returned %d, patched %v, deleted %v: %s", code, patched, deleted, stderr.String())
+	}
+	if !strings.Contains(patch, `"devices":{"labels":["line-3"]}`) {
+		t.Fatalf("Wrong subscription %s", patch)
+	}
+	if stdout.String() != "{\"type\":\"edgex\",\"data\":{}}\n" {
+		t.Fatalf("Wrong output %q", stdout.String())
+	}
+	if code := run(ctx, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("run without -include returned %d", code)
+	}
+}
@@ -0,0 +1,89 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// Sub-buckets per power of two: latencies are kept to within 1/histogramSubBuckets
+const (
+	histogramSubBits    = 4
+	histogramSubBuckets = 1 << histogramSubBits
+)
+
+/*
+Struct histogram counts latencies, in microseconds, in buckets that grow with the value, so
+a soak test of any length takes the same memory. Safe for concurrent use without locking.
+*/
+type histogram struct {
+	buckets [64 * histogramSubBuckets]atomic.Uint64
+	count   atomic.Uint64
+	max     atomic.Uint64
+}
+
+// bucketOf (an internal API) returns the bucket a value in microseconds is counted in.
+func bucketOf(us uint64) int {
+	if us < histogramSubBuckets {
+		return int(us)
+	}
+	shift := bits.Len64(us) - histogramSubBits - 1
+	return (shift+1)*histogramSubBuckets + int(us>>shift) - histogramSubBuckets
+}
+
+// valueOf (an internal API) returns the middle of a bucket's values, in microseconds.
+func valueOf(bucket int) uint64 {
+	if bucket < histogramSubBuckets {
+		return uint64(bucket)
+	}
+	shift := bucket/histogramSubBuckets - 1
+	lower := uint64(bucket%histogramSubBuckets+histogramSubBuckets) << shift
+	return lower + (uint64(1)<<shift)/2
+}
+
+// Record counts one latency. Negative ones, from clocks going backwards, count as zero.
+func (h *histogram) Record(d time.Duration) {
+	us := uint64(max(d, 0) / time.Microsecond)
+	h.buckets[bucketOf(us)].Add(1)
+	h.count.Add(1)
+	for {
+		old := h.max.Load()
+		if us <= old || h.max.CompareAndSwap(old, us) {
+			break
+		}
+	}
+}
+
+// Count returns how many latencies were recorded.
+func (h *histogram) Count() uint64 {
+	return h.count.Load()
+}
+
+// Max returns the largest latency recorded, exactly.
+func (h *histogram) Max() time.Duration {
+	return time.Duration(h.max.Load()) * time.Microsecond
+}
+
+// Quantile returns the latency q (0-1) of those recorded are at or below, zero if there are none.
+func (h *histogram) Quantile(q float64) time.Duration {
+	total := h.count.Load()
+	if total == 0 {
+		return 0
+	}
+	target := max(uint64(math.Ceil(q*float64(total))), 1)
+	var seen uint64
+	for i := range h.buckets {
+		seen += h.buckets[i].Load()
+		if seen >= target {
+			return min(time.Duration(valueOf(i))*time.Microsecond, h.Max())
+		}
+	}
+	return h.Max()
+}
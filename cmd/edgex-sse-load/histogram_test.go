@@ -0,0 +1,45 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuckets(t *testing.T) {
+	for _, us := range []uint64{0, 1, 15, 16, 31, 32, 33, 100, 1000, 123456, 1 << 40} {
+		value := valueOf(bucketOf(us))
+		// Within one sub-bucket
+		if diff := max(value, us) - min(value, us); diff > us/histogramSubBuckets {
+			t.Errorf("%d is counted as %d", us, value)
+		}
+	}
+	if bucketOf(1<<63) >= len(histogram{}.buckets) {
+		t.Fatal("Largest value out of range")
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	var dut histogram
+	if dut.Quantile(0.5) != 0 {
+		t.Fatal("Empty histogram has a median")
+	}
+	for i := 1; i <= 1000; i++ {
+		dut.Record(time.Duration(i) * time.Millisecond)
+	}
+	dut.Record(-time.Second)
+	if dut.Count() != 1001 || dut.Max() != time.Second {
+		t.Fatalf("Wrong count %d or max %v", dut.Count(), dut.Max())
+	}
+	for q, expected := range map[float64]time.Duration{0.5: 500 * time.Millisecond, 0.99: 990 * time.Millisecond, 1: time.Second} {
+		got := dut.Quantile(q)
+		if got < expected*15/16 || got > expected*17/16 {
+			t.Errorf("Quantile %g is %v, expected about %v", q, got, expected)
+		}
+	}
+}
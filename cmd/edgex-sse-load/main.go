@@ -0,0 +1,320 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+Command edgex-sse-load is a load and soak test of the SSE application service's delivery,
+for capacity planning without an EdgeX stack. It generates synthetic EdgeX events at a
+given rate, spread over device topics evenly or skewed towards a few busy devices, puts
+them straight into the service's pipeline, and receives them on subscriptions the way event
+streams do. It reports how many were delivered and dropped, and the delivery latency
+percentiles, from the pipeline getting an event to a subscription's stream receiving it.
+
+Writing to clients' connections is not included, nor is the message bus.
+*/
+package main
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/functions"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Correlation IDs of generated events are this and the time they were generated, in Unix nanoseconds
+const correlationPrefix = "load-"
+
+// Struct loadTest is a load test as given by the flags.
+type loadTest struct {
+	rate          float64
+	duration      time.Duration
+	report        time.Duration
+	devices       int
+	zipf          float64
+	readings      int
+	subscriptions int
+	all           int
+	format        string
+	buffer        uint
+	seed          int64
+}
+
+// topicOf (an internal API) returns the EdgeX event topic, and the names, of a generated device.
+func topicOf(device int) (topic string, deviceName string) {
+	deviceName = "load-device-" + strconv.Itoa(device)
+	return "edgex/events/device/load-service/load-profile/" + deviceName + "/load-source", deviceName
+}
+
+/*
+deviceChooser (an internal API) returns a function picking the device of each event: uniformly,
+or with zipf above 1, by Zipf's law, device 0 being the busiest.
+*/
+func deviceChooser(devices int, zipf float64, seed int64) func() int {
+	random := rand.New(rand.NewSource(seed))
+	if zipf <= 1 {
+		return func() int { return random.Intn(devices) }
+	}
+	z := rand.NewZipf(random, zipf, 1, uint64(devices-1))
+	return func() int { return int(z.Uint64()) }
+}
+
+// eventPayload (an internal API) returns the JSON of a generated EdgeX event.
+func eventPayload(deviceName string, readings int, value int) ([]byte, error) {
+	event := dtos.NewEvent("load-profile", deviceName, "load-source")
+	for i := 0; i < readings; i++ {
+		if err := event.AddSimpleReading("load-resource-"+strconv.Itoa(i), common.ValueTypeInt32, int32(value+i)); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(event)
+}
+
+// latencyOf (an internal API) returns how long ago a delivered message was generated.
+func latencyOf(msg submgr.ChannelMessage, now time.Time) (time.Duration, bool) {
+	sent, ok := strings.CutPrefix(msg.CorrelationID, correlationPrefix)
+	if !ok {
+		return 0, false
+	}
+	nanos, err := strconv.ParseInt(sent, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return now.Sub(time.Unix(0, nanos)), true
+}
+
+// Struct results is what a load test measured.
+type results struct {
+	sent      atomic.Uint64
+	delivered atomic.Uint64
+	latency   histogram
+}
+
+// dropped (an internal API) returns how many messages the subscriptions' buffers had no room for.
+func dropped(subs *submgr.SubscriptionManager) uint64 {
+	var rv uint64
+	for _, sub := range subs.Throughput() {
+		rv += sub.Dropped
+	}
+	return rv
+}
+
+// printLatency (an internal API) writes the latency percentiles.
+func printLatency(w io.Writer, h *histogram) {
+	fmt.Fprintf(w, "latency p50 %v p90 %v p99 %v p99.9 %v max %v\n",
+		h.Quantile(0.5), h.Quantile(0.9), h.Quantile(0.99), h.Quantile(0.999), h.Max())
+}
+
+// subscribe (an internal API) creates a listening subscription, and receives its messages until ctx is done.
+func subscribe(ctx context.Context, subs *submgr.SubscriptionManager, include string, format string, rv *results, wg *sync.WaitGroup) error {
+	subid, err := subs.NewSubscription()
+	if err != nil {
+		return err
+	}
+	subInfo := subs.Subscription(subid)
+	if err := subs.Include(subInfo, include); err != nil {
+		return err
+	}
+	if err := subs.SetOptions(subInfo, submgr.SubscriptionOptions{Format: format}); err != nil {
+		return err
+	}
+	rxchan, err := subs.ReceiveChannel(subInfo)
+	if err != nil {
+		return err
+	}
+	subs.SetActive(subInfo, true)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer subs.SetActive(subInfo, false)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-rxchan:
+				if !ok {
+					return
+				}
+				if latency, ok := latencyOf(msg, time.Now()); ok {
+					rv.latency.Record(latency)
+				}
+				rv.delivered.Add(1)
+			}
+		}
+	}()
+	return nil
+}
+
+/*
+generate (an internal API) puts events into the pipeline at the test's rate until ctx is done
+or its duration has passed. The events due are sent every millisecond, rather than one at a
+time on a timer, so high rates are kept.
+*/
+func generate(ctx context.Context, test loadTest, processor *functions.Processor, lc logger.LoggingClient, rv *results) error {
+	choose := deviceChooser(test.devices, test.zipf, test.seed)
+	start := time.Now()
+	tick := time.NewTicker(time.Millisecond)
+	defer tick.Stop()
+	for {
+		elapsed := time.Since(start)
+		if test.duration > 0 && elapsed >= test.duration {
+			return nil
+		}
+		due := uint64(elapsed.Seconds()*test.rate) - rv.sent.Load()
+		for ; due > 0; due-- {
+			topic, deviceName := topicOf(choose())
+			payload, err := eventPayload(deviceName, test.readings, int(rv.sent.Load()))
+			if err != nil {
+				return err
+			}
+			correlationID := correlationPrefix + strconv.FormatInt(time.Now().UnixNano(), 10)
+			processor.Process(pkg.NewAppFuncContextForTest(correlationID, lc), topic, payload)
+			rv.sent.Add(1)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+	}
+}
+
+// run is the command, with its arguments and outputs, returning the exit code.
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	var test loadTest
+	flags := flag.NewFlagSet("edgex-sse-load", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Float64Var(&test.rate, "rate", 1000, "Events per second")
+	flags.DurationVar(&test.duration, "duration", 30*time.Second, "How long to generate events, 0 until interrupted (soak test)")
+	flags.DurationVar(&test.report, "report", 10*time.Second, "Print progress this often, 0 for only at the end")
+	flags.IntVar(&test.devices, "devices", 100, "Devices the events come from, each its own topic")
+	flags.Float64Var(&test.zipf, "zipf", 0, "Above 1, skew events towards a few devices by Zipf's law with this exponent; otherwise spread them evenly")
+	flags.IntVar(&test.readings, "readings", 1, "Readings per event")
+	flags.IntVar(&test.subscriptions, "subscriptions", 10, "Subscriptions to one device each, round robin")
+	flags.IntVar(&test.all, "all", 1, "Subscriptions to all the devices")
+	flags.StringVar(&test.format, "format", "", "How subscriptions get EdgeX events: edgex, simple or senml")
+	flags.UintVar(&test.buffer, "buffer", 0, "Events buffered per subscription, default as in the service configuration")
+	flags.Int64Var(&test.seed, "seed", 1, "Seed of the device choice, for repeatable runs")
+	logLevel := flags.String("log-level", "WARN", "Log level of the service's code")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if test.rate <= 0 || test.devices <= 0 || test.readings <= 0 || test.subscriptions < 0 || test.all < 0 {
+		fmt.Fprintln(stderr, "-rate, -devices and -readings must be positive, -subscriptions and -all not negative")
+		return 2
+	}
+	if err := (submgr.SubscriptionOptions{Format: test.format}).Validate(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+
+	// The service as configured by default, with enough room for the test's subscriptions
+	cfg := &configuration.Config{}
+	cfg.SetDefaults()
+	cfg.SSE.SubscriptionLimit = uint32(max(test.subscriptions+test.all, 1))
+	if test.buffer > 0 {
+		cfg.SSE.EventBuffer = test.buffer
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	lc := logger.NewClient("edgex-sse-load", *logLevel)
+	subs := &submgr.SubscriptionManager{}
+	durations := cfg.SSE.Durations()
+	if err := subs.Init(cfg.SSE.SubscriptionLimit, cfg.SSE.PrefixesLimit, cfg.SSE.EventBuffer, durations.SubscriptionIdleExpiration, durations.SubscriptionExpirationCheckInterval); err != nil {
+		fmt.Fprintf(stderr, "Could not start subscription manager: %s\n", err.Error())
+		return 1
+	}
+	defer subs.Close()
+	processor := functions.NewProcessor(lc, subs, cfg)
+
+	receiving, stopReceiving := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	var rv results
+	for i := 0; i < test.subscriptions+test.all; i++ {
+		include := "edgex/events/device/"
+		if i < test.subscriptions {
+			include, _ = topicOf(i % test.devices)
+		}
+		if err := subscribe(receiving, subs, include, test.format, &rv, &wg); err != nil {
+			fmt.Fprintf(stderr, "Could not subscribe: %s\n", err.Error())
+			stopReceiving()
+			wg.Wait()
+			return 1
+		}
+	}
+
+	fmt.Fprintf(stdout, "Generating %g events/s, %d readings each, from %d devices, for %d+%d subscriptions\n",
+		test.rate, test.readings, test.devices, test.subscriptions, test.all)
+	start := time.Now()
+	if test.report > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tick := time.NewTicker(test.report)
+			defer tick.Stop()
+			for {
+				select {
+				case <-receiving.Done():
+					return
+				case <-tick.C:
+					fmt.Fprintf(stdout, "%v: sent %d, delivered %d, dropped %d, ", time.Since(start).Round(time.Second), rv.sent.Load(), rv.delivered.Load(), dropped(subs))
+					printLatency(stdout, &rv.latency)
+				}
+			}
+		}()
+	}
+	err := generate(ctx, test, &processor, lc, &rv)
+	elapsed := time.Since(start)
+	// What is still buffered is received, unless interrupted again
+	drain := time.NewTimer(time.Second)
+	defer drain.Stop()
+	for buffered := true; buffered; {
+		buffered = false
+		for _, sub := range subs.AllSubscriptions() {
+			buffered = buffered || subs.ChannelDepth(sub) > 0
+		}
+		select {
+		case <-drain.C:
+			buffered = false
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	stopReceiving()
+	wg.Wait()
+	if err != nil {
+		fmt.Fprintf(stderr, "Could not generate events: %s\n", err.Error())
+		return 1
+	}
+
+	sent := rv.sent.Load()
+	fmt.Fprintf(stdout, "Sent %d events in %v (%.1f/s), delivered %d, dropped %d\n", sent, elapsed.Round(time.Millisecond), float64(sent)/elapsed.Seconds(), rv.delivered.Load(), dropped(subs))
+	printLatency(stdout, &rv.latency)
+	return 0
+}
@@ -0,0 +1,67 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"bytes"
+	"context"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDeviceChooser(t *testing.T) {
+	counts := make([]int, 10)
+	choose := deviceChooser(len(counts), 2, 1)
+	for i := 0; i < 1000; i++ {
+		counts[choose()]++
+	}
+	if counts[0] < counts[1] || counts[1] < counts[9] {
+		t.Fatalf("Not skewed %v", counts)
+	}
+	choose = deviceChooser(3, 0, 1)
+	for i := 0; i < 100; i++ {
+		if device := choose(); device < 0 || device >= 3 {
+			t.Fatalf("Chose device %d", device)
+		}
+	}
+}
+
+func TestLatencyOf(t *testing.T) {
+	now := time.Now()
+	msg := submgr.ChannelMessage{CorrelationID: correlationPrefix + strconv.FormatInt(now.Add(-time.Millisecond).UnixNano(), 10)}
+	if latency, ok := latencyOf(msg, now); !ok || latency != time.Millisecond {
+		t.Fatalf("latencyOf returned %v, %v", latency, ok)
+	}
+	if _, ok := latencyOf(submgr.ChannelMessage{CorrelationID: "5b5bd3c3-1c21-4ac2-a4b6-1d2c3b8f1d5e"}, now); ok {
+		t.Fatal("Latency of a message that wasn't generated")
+	}
+}
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-rate", "500", "-duration", "200ms", "-report", "50ms", "-devices", "4", "-subscriptions", "4", "-format", "simple"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("run returned %d: %s", code, stderr.String())
+	}
+	// Each event goes to the subscription to its device, and the one to all
+	summary := regexp.MustCompile(`Sent (\d+) events .* delivered (\d+), dropped 0\n`).FindStringSubmatch(stdout.String())
+	if summary == nil {
+		t.Fatalf("No summary in %s", stdout.String())
+	}
+	sent, _ := strconv.Atoi(summary[1])
+	delivered, _ := strconv.Atoi(summary[2])
+	if sent < 50 || delivered != 2*sent {
+		t.Fatalf("Wrong summary %s", summary[0])
+	}
+
+	if code := run(context.Background(), []string{"-format", "xml"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("run with a bad format returned %d", code)
+	}
+}
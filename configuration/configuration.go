@@ -11,20 +11,803 @@
 package configuration
 
 import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
 	"errors"
+	"fmt"
+	"maps"
 	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Values for SseConfig.CBORDelivery
+const (
+	// CBOR messages are decoded and delivered as JSON, like any other message
+	CBORDeliveryJSON = "json"
+	// CBOR messages are delivered as a JSON envelope holding the base64 of the CBOR
+	CBORDeliveryBase64 = "base64"
+)
+
+// Values for SseConfig.BinaryReadings
+const (
+	// Binary readings are delivered as they are
+	BinaryReadingsKeep = "keep"
+	// Binary readings are delivered with their value replaced by its size
+	BinaryReadingsSummarize = "summarize"
+	// Binary readings are removed from events
+	BinaryReadingsDrop = "drop"
+)
+
+// Values for SseConfig.InvalidEvents
+const (
+	// Messages that look like Events but fail validation are delivered as whatever else they can be
+	InvalidEventsGeneric = "generic"
+	// Messages that look like Events but fail validation are dropped, with a warning
+	InvalidEventsDrop = "drop"
+	// Messages that look like Events but fail validation are delivered as "invalid" events, with the error
+	InvalidEventsAnnotate = "annotate"
+)
+
+// Values for SseConfig.ProfileValidation
+const (
+	// Readings are not checked against their device profiles
+	ProfileValidationOff = "off"
+	// Events with readings their device profiles don't allow are dropped, with a warning
+	ProfileValidationDrop = "drop"
+	// Events with readings their device profiles don't allow are delivered with the violations
+	ProfileValidationAnnotate = "annotate"
+)
+
+// Values for SseConfig.PersistenceBackend
+const (
+	// Subscriptions created through the API are lost when the service restarts
+	PersistenceNone = "none"
+	// Subscriptions created through the API are kept in Redis, as set by SseConfig.Redis
+	PersistenceRedis = "redis"
+	// Subscriptions created through the API are kept in Core Keeper, the configuration provider, as set by SseConfig.Keeper
+	PersistenceKeeper = "keeper"
+	// Subscriptions created through the API are kept in a JSON file, as set by SseConfig.File, for running without EdgeX
+	PersistenceFile = "file"
+)
+
+// Smallest EventBuffer, below which BackpressureMark can't tell a filling buffer from a busy one
+const MinEventBuffer = 10
+
+// What support-notifications takes as a category: RFC 3986 unreserved characters
+var notificationCategory = regexp.MustCompile(`^[A-Za-z0-9._~-]+$`)
+
+// Names of the functions for WritableConfig.PipelineFunctions
+const (
+	FunctionFilterByDevice  = "filter-by-device"
+	FunctionStripBinary     = "strip-binary"
+	FunctionTransformSimple = "transform-simple"
+	FunctionPublish         = "publish"
+)
+
+/*
+Struct WritableConfig holds the settings that can be changed in the Configuration Provider
+while the service runs.
+*/
+type WritableConfig struct {
+	// Comma-separated functions every message goes through, in order, ending with publish
+	PipelineFunctions string
+	// Comma-separated device names whose Events filter-by-device lets through
+	FilterDevices     string
+	// Messages a minute whose match decisions are logged for every subscription, 0 for none
+	MatchDebugSamples uint
+}
+
+// FunctionList returns PipelineFunctions as a list.
+func (w WritableConfig) FunctionList() []string {
+	return splitList(w.PipelineFunctions)
+}
+
+// DeviceList returns FilterDevices as a list.
+func (w WritableConfig) DeviceList() []string {
+	return splitList(w.FilterDevices)
+}
+
+/*
+Validate returns an error if PipelineFunctions has a function we don't know, doesn't end
+with publish or has it more than once, or if filter-by-device has no devices to let through.
+All the problems found are joined into the error.
+*/
+func (w WritableConfig) Validate() error {
+	var errs []error
+	functions := w.FunctionList()
+	if len(functions) == 0 || functions[len(functions)-1] != FunctionPublish {
+		errs = append(errs, errors.New("PipelineFunctions must end with publish"))
+	}
+	for i, function := range functions {
+		switch function {
+		case FunctionFilterByDevice:
+			if len(w.DeviceList()) == 0 {
+				errs = append(errs, errors.New("FilterDevices must not be empty when PipelineFunctions has filter-by-device"))
+			}
+		case FunctionStripBinary, FunctionTransformSimple:
+		case FunctionPublish:
+			if i != len(functions)-1 {
+				errs = append(errs, errors.New("PipelineFunctions must have publish only once, at the end"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("PipelineFunctions has unknown function %s, must be filter-by-device, strip-binary, transform-simple or publish", function))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+/*
+Struct PipelineConfig describes one per-topic pipeline, for consuming from more than one
+base topic with different processing. Options left empty take the value from the SSE section.
+*/
+type PipelineConfig struct {
+	// Comma-separated topics the pipeline handles, relative to the message bus base topic
+	// prefix like the trigger's SubscribeTopics. The trigger must subscribe to them too.
+	Topics                     string
+	CBORDelivery               string
+	CommandResponseTopicPrefix string
+	BinaryReadings             string
+}
+
+// TopicList returns the pipeline's topics as a list.
+func (p PipelineConfig) TopicList() []string {
+	return splitList(p.Topics)
+}
+
+// splitList (an internal API) returns the entries of a comma-separated setting, trimmed, leaving out empty ones.
+func splitList(list string) []string {
+	rv := make([]string, 0)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			rv = append(rv, entry)
+		}
+	}
+	return rv
+}
+
+/*
+Struct ExternalMQTTConfig describes a second, non-EdgeX MQTT broker to take messages from.
+They are processed like messages from the EdgeX message bus, matched against subscriptions
+by their topic as received.
+*/
+type ExternalMQTTConfig struct {
+	Enabled       bool
+	// Broker URL, e.g. tcp://broker:1883 or ssl://broker:8883
+	BrokerAddress string
+	ClientId      string
+	// Comma-separated topics (MQTT filters, e.g. factory/#) to subscribe to
+	Topics        string
+	QoS           byte
+	// Leave empty for brokers without authentication
+	Username      string
+	Password      string
+	// Secret in the secret provider with username and password, used instead of
+	// Username and Password, and re-read when rotated
+	SecretName    string
+}
+
+// TopicList returns the topics to subscribe to as a list.
+func (e ExternalMQTTConfig) TopicList() []string {
+	return splitList(e.Topics)
+}
+
+/*
+Struct StaticSubscriptionConfig describes a subscription the service creates at startup.
+Its name, the key in SseConfig.StaticSubscriptions, is its ID, so it is the same every
+time the service starts.
+*/
+type StaticSubscriptionConfig struct {
+	// Comma-separated topic prefixes, like the include and exclude lists of a subscription request
+	Include     string
+	Exclude     string
+	PassThrough bool
+	Format      string
+	Envelope    string
+	Debug       bool
+}
+
+// IncludeList returns the topic prefixes to include as a list.
+func (s StaticSubscriptionConfig) IncludeList() []string {
+	return splitList(s.Include)
+}
+
+// ExcludeList returns the topic prefixes to exclude as a list.
+func (s StaticSubscriptionConfig) ExcludeList() []string {
+	return splitList(s.Exclude)
+}
+
+// Options returns the subscription's delivery options.
+func (s StaticSubscriptionConfig) Options() submgr.SubscriptionOptions {
+	return submgr.SubscriptionOptions{PassThrough: s.PassThrough, Format: s.Format, Envelope: s.Envelope, Debug: s.Debug}
+}
+
+/*
+Struct RoleConfig gives the callers with the listed identities (the subjects of their JWTs)
+their own limits. Subscriptions they create count against the role's SubscriptionLimit,
+shared by all its callers, instead of the SSE section's, which applies to everyone else.
+*/
+type RoleConfig struct {
+	// Comma-separated identities of the callers in the role
+	Identities        string
+	SubscriptionLimit uint32
+	PrefixesLimit     uint
+	// Comma-separated topic prefixes the role's subscriptions are limited to. Empty for any.
+	AllowedTopics     string
+}
+
+// IdentityList returns the identities in the role as a list.
+func (r RoleConfig) IdentityList() []string {
+	return splitList(r.Identities)
+}
+
+/*
+Struct UnitConversionConfig converts readings in units From to units To, as value * Scale +
+Offset, for subscriptions whose units option asks for To.
+*/
+type UnitConversionConfig struct {
+	From   string
+	To     string
+	Scale  float64
+	Offset float64
+}
+
+// ConvertsTo reports whether one of the UnitConversions converts readings to units.
+func (c SseConfig) ConvertsTo(units string) bool {
+	for _, conversion := range c.UnitConversions {
+		if conversion.To == units {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Struct ReplayConfig sets how many of the messages sent to each subscription are kept, so
+a client reconnecting with Last-Event-ID gets what it missed. Count and Bytes are both
+zero to disable it, or both set; at most Bytes times the number of subscriptions is used.
+*/
+type ReplayConfig struct {
+	// Number of messages kept per subscription
+	Count  uint
+	// Total size of the messages kept per subscription, in bytes
+	Bytes  uint
+	// How long messages are kept
+	MaxAge string
+}
+
+/*
+Struct TracingConfig sets where OpenTelemetry spans of message processing and delivery are
+exported to, with OTLP over HTTP (JSON). A message's trace ID is its EdgeX correlation ID.
+*/
+type TracingConfig struct {
+	Enabled     bool
+	// Base URL of the OTLP/HTTP receiver, e.g. http://otel-collector:4318; /v1/traces is added
+	Endpoint    string
+	// Fraction of messages traced, 0 to 1
+	SampleRatio float64
+}
+
+// Struct RedisConfig sets the Redis instance, and the hash in it, subscriptions are persisted to.
+type RedisConfig struct {
+	Host       string
+	Port       uint
+	// Secret in the secret provider with "username" and "password" keys. Empty for a Redis
+	// without authentication, which is also used if the secret isn't there, e.g. in non-secure mode.
+	SecretName string
+	// Hash the subscriptions are kept in, a field per subscription ID
+	Key        string
+}
+
+/*
+Struct KeeperConfig sets the Core Keeper instance, and the keys in it, subscriptions are
+persisted to. Core Keeper is the only configuration provider of EdgeX 4, Consul is no more.
+*/
+type KeeperConfig struct {
+	Host     string
+	Port     uint
+	// Subscriptions are kept in keys below this path, a key per subscription ID
+	BasePath string
+}
+
+// Struct FileConfig sets the JSON file subscriptions are persisted to, and how often it is written.
+type FileConfig struct {
+	// Path of the file, in a directory the service can write to, e.g. a volume in a container
+	Path             string
+	// How often the file is written, when subscriptions have changed. It is written on shutdown too.
+	SnapshotInterval string
+}
+
+// Struct TopicRewrite is one entry of SseConfig.TopicRewrites.
+type TopicRewrite struct {
+	From string
+	To   string
+}
+
+/*
+Struct Durations holds the SSE section's durations, parsed from their strings once, by
+SetDefaults(), UpdateFromRaw() and Validate(), so users don't each parse them again.
+*/
+type Durations struct {
+	SubscriptionIdleExpiration          time.Duration
+	SubscriptionExpirationCheckInterval time.Duration
+	MaxIdleExpiration                   time.Duration
+	AgeOutGrace                         time.Duration
+	TopicIdleExpiration                 time.Duration
+	HeartbeatInterval                   time.Duration
+	WriteTimeout                        time.Duration
+	MaxConnectionAge                    time.Duration
+	ReconnectDelay                      time.Duration
+	ReplayMaxAge                        time.Duration
+	StreamTokenLifetime                 time.Duration
+	LookupLockout                       time.Duration
+	MessageBusIdleLimit                 time.Duration
+	DropWarningInterval                 time.Duration
+	ThroughputSummaryInterval           time.Duration
+	PersistenceWriteDelay               time.Duration
+	FileSnapshotInterval                time.Duration
+	ReplicationSyncInterval             time.Duration
+	ExpiryNotice                        time.Duration
+	AlertInterval                       time.Duration
+	OverflowAlertWindow                 time.Duration
+	EventsBindRetryInterval             time.Duration
+}
+
 // Structure of our config file section
 type SseConfig struct {
 	SubscriptionLimit                   uint32
 	PrefixesLimit                       uint
 	EventBuffer                         uint
+	// Largest SubscriptionLimit, PrefixesLimit and EventBuffer that can be set at LimitsPath
+	// while the service runs. 0 for the configured value, which can then only be lowered.
+	MaxSubscriptionLimit                uint32
+	MaxPrefixesLimit                    uint
+	MaxEventBuffer                      uint
+	// Percentage of EventBuffer that, when an event stream's buffer fills past it, has a
+	// backpressure event sent to the stream, so the client can slow down. 0 to disable.
+	BackpressureMark                    uint
+	// Workers marshaling, transforming and sending messages to subscriptions, in parallel
+	// and off the pipeline, and how many messages can wait for each. Messages on a topic all
+	// go to the same worker, so stay in order. With its queue full a message is dropped.
+	// 0 DeliveryWorkers to deliver on the pipeline goroutine.
+	DeliveryWorkers                     uint
+	DeliveryQueue                       uint
+	// Largest subscription request body accepted, in bytes. Larger ones get 413.
+	MaxRequestBodySize                  uint
+	// Requests per second each client can make to create or change subscriptions, and how
+	// many at once. More get 429. Zero ManagementRate for no limit.
+	ManagementRate                      float64
+	ManagementBurst                     uint
+	// Comma-separated addresses to serve the events port on: IP addresses, e.g. 0.0.0.0 for
+	// all IPv4 interfaces, :: for all of both, or a zoned IPv6 link-local one, or hostnames,
+	// each optionally with a port ("[::1]:8443"), else EventsPort
 	EventsAddr                          string
 	EventsPort                          uint
+	// How many more times to try an events address that can't be bound at startup, e.g.
+	// while the port is still held by the instance being replaced, waiting
+	// EventsBindRetryInterval, doubled each time. Meanwhile the service runs, reported
+	// unhealthy. 0 to refuse to start instead.
+	EventsBindRetries                   uint
+	EventsBindRetryInterval             string
+	// Event streams each client address can have open at once. More get 429. Zero for no limit.
+	ConnectionsPerClient                uint
+	// Allow one event stream per subscription. Another gets 409, unless it asks to take
+	// it over, e.g. a refreshed browser tab whose old connection isn't noticed gone yet.
+	ExclusiveStreams                    bool
+	// Compress event streams with gzip for clients that accept it, e.g. on constrained links.
+	// While more events wait for a stream, they are compressed together, up to
+	// CompressionBlockSize bytes, before it is flushed. 0 to flush after each event.
+	CompressEvents                      bool
+	CompressionBlockSize                uint
+	// Secret in the secret provider with the cert and key (PEM) to serve the events port
+	// with HTTPS. Re-read when rotated. Empty for plain HTTP.
+	EventsTLSSecretName                 string
+	// Base paths of the endpoints, e.g. to match what a path-rewriting ingress passes on.
+	// Subscription IDs follow EventsPath and SubscriptionPath + "/id", topics TriggerPath.
+	// ConnectionsPath lists the open event streams, HealthPath reports the service's health,
+	// OpenAPIPath serves the API's OpenAPI document, DebugUIPath the debug page if DebugUI is set,
+	// LimitsPath the limits in force, which callers in the AdminRole can change there.
+	// ConfigPath, on the events port, the SSE section in force, for operators to check.
+	EventsPath                          string
+	SubscriptionPath                    string
+	TriggerPath                         string
+	ConnectionsPath                     string
+	HealthPath                          string
+	OpenAPIPath                         string
+	DebugUIPath                         string
+	LimitsPath                          string
+	ConfigPath                          string
+	// Serve a page at DebugUIPath that subscribes to topics and shows their events, for
+	// checking in the field that data flows, without installing anything
+	DebugUI                             bool
 	SubscriptionIdleExpiration          string
 	SubscriptionExpirationCheckInterval string
+	// Longest idleExpiration option subscriptions may set, to be kept longer with nobody
+	// listening than SubscriptionIdleExpiration. The shortest is twice the check interval.
+	MaxIdleExpiration                   string
+	// How long an idle subscription is kept after SubscriptionIdleExpiration, once its
+	// client is told (with ExpiryNotifications) it is in grace, for a stream that reconnects
+	// to save it, so slow clients don't lose it by seconds. 0 to delete it right away.
+	AgeOutGrace                         string
+	// Most topics tracked in the topic activity index, for debugging. 0, the default, disables it.
+	TopicIndexLimit                     uint
+	TopicIdleExpiration                 string
+	// How often to send a comment on idle event streams, so proxies keep them open. 0 to disable.
+	HeartbeatInterval                   string
+	// Send the heartbeat as a ping event with the service's time and the stream's buffer
+	// depth and drops, rather than a comment EventSource doesn't show
+	HeartbeatEvents                     bool
+	// How long a write to an event stream may block before the client is given up on. 0 to disable.
+	WriteTimeout                        string
+	// How long an event stream stays open before it is ended cleanly, for the client to
+	// reconnect, e.g. through a load balancer that recycles connections. Each stream gets up
+	// to a tenth more, so those opened together don't all reconnect together. 0 to disable.
+	MaxConnectionAge                    string
+	// Sent as the retry field before a stream is ended at MaxConnectionAge, for clients to
+	// reconnect after. 0 to leave their reconnection delay as it is.
+	ReconnectDelay                      string
+	CBORDelivery                        string
+	CommandResponseTopicPrefix          string
+	BinaryReadings                      string
+	// Per-topic pipelines keyed by pipeline ID. If empty, one default pipeline handles all topics.
+	Pipelines                           map[string]PipelineConfig
+	// How long the stream tokens for browser EventSources are good for
+	StreamTokenLifetime                 string
+	// Refuse event stream requests without a stream token
+	RequireStreamToken                  bool
+	// Requests for subscription IDs that don't exist a client can make within LookupLockout,
+	// the last of which locks it out for LookupLockout. Zero for no lockouts.
+	LookupFailureLimit                  uint
+	LookupLockout                       string
+	// The service is reported unhealthy when nothing comes from the message bus for this long,
+	// as the trigger's subscription may have died. 0 to not check.
+	MessageBusIdleLimit                 string
+	// Events dropped for full subscription buffers are warned about at most once in this
+	// long, the warning counting those dropped since the last. 0 to warn about every drop.
+	DropWarningInterval                 string
+	// How often messages in, out and dropped, overall and for each busy subscription, are
+	// logged. 0 to not log them.
+	ThroughputSummaryInterval           string
+	// Comma-separated addresses or CIDR ranges of reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers are believed, to find the real client address
+	TrustedProxies                      string
+	// Limits for callers other than anonymous dashboards, keyed by role name
+	Roles                               map[string]RoleConfig
+	// Comma-separated topic prefixes the subscriptions of callers not in a role are limited to. Empty for any.
+	AllowedTopics                       string
+	// Role whose callers can manage any subscription, not just their own. Empty for none.
+	AdminRole                           string
+	Replay                              ReplayConfig
+	// Subscriptions created at startup, keyed by name, which is also their ID
+	StaticSubscriptions                 map[string]StaticSubscriptionConfig
+	ExternalMQTT                        ExternalMQTTConfig
+	Tracing                             TracingConfig
+	// Add the labels, location and states of an Event's device, from core-metadata, to the Event
+	DeviceMetadata                      bool
+	// Fill in the units of readings that have none, from their device profiles in core-metadata
+	ReadingUnits                        bool
+	// Don't deliver Events from devices core-metadata has as Locked or Down, following their
+	// system events, so clients don't show data operators have disabled
+	SuppressDisabledDevices             bool
+	// Conversions of reading units subscriptions can ask for, keyed by name
+	UnitConversions                     map[string]UnitConversionConfig
+	// Removed from the start of received topics before they are matched against subscriptions
+	StripTopicPrefix                    string
+	// Comma-separated from=to topic prefix rewrites, applied after StripTopicPrefix
+	TopicRewrites                       string
+	// What to do with messages that look like Events but fail validation
+	InvalidEvents                       string
+	// What to do with Events whose readings don't match the value type, minimum or maximum of
+	// their resources in the device profiles from core-metadata: off, drop or annotate
+	ProfileValidation                   string
+	// Message bus topic, under the base topic prefix, to republish dropped messages on. Empty to disable.
+	DeadLetterTopic                     string
+	// Where subscriptions created through the API are kept, so they are still there, under the
+	// same IDs, after the service restarts or is upgraded: none, redis, keeper or file
+	PersistenceBackend                  string
+	// How long changes to subscriptions are gathered before they are persisted, so a burst
+	// of them is one write. 0 to persist each at once.
+	PersistenceWriteDelay               string
+	Redis                               RedisConfig
+	Keeper                              KeeperConfig
+	File                                FileConfig
+	// Run as one of several replicas behind a load balancer, any of which can serve any
+	// subscription: they share subscriptions through the Redis of PersistenceBackend redis.
+	// Each replica must get every message from the message bus. Event IDs start with a
+	// random ID of the replica, as each numbers events by itself.
+	Replication                         bool
+	// How often replicas pick up each other's subscription changes, and record which
+	// subscriptions they stream, so the others don't age them out
+	ReplicationSyncInterval             string
+	// Secret in the secret provider with the "key" stream tokens are signed with, at least
+	// 32 bytes, so replicas take each other's. Re-read when rotated. Empty for a random key.
+	StreamTokenSecretName               string
+	// Let subscriptions have their expiryNotify option: a webhook, called by the service, or
+	// support-notifications, told when they are about to be aged out, and when they have been
+	ExpiryNotifications                 bool
+	// How long before a subscription nobody listens to is aged out it is about to be
+	ExpiryNotice                        string
+	// Category of the service's notifications through support-notifications
+	NotificationCategory                string
+	// Alert operators through support-notifications when the subscription limit is reached,
+	// events are dropped for a whole OverflowAlertWindow, or the events listener stops
+	Alerts                              bool
+	// How long the same alert isn't sent again for
+	AlertInterval                       string
+	OverflowAlertWindow                 string
+	Writable                            WritableConfig
+	durations                           Durations
+	trustedProxies                      []netip.Prefix
+}
+
+// EventsRoute returns EventsPath with a trailing slash, which subscription IDs follow.
+func (c *SseConfig) EventsRoute() string {
+	return strings.TrimSuffix(c.EventsPath, "/") + "/"
+}
+
+// SubscriptionRoute returns SubscriptionPath without a trailing slash.
+func (c *SseConfig) SubscriptionRoute() string {
+	return strings.TrimSuffix(c.SubscriptionPath, "/")
+}
+
+// TriggerRoute returns TriggerPath without a trailing slash.
+func (c *SseConfig) TriggerRoute() string {
+	return strings.TrimSuffix(c.TriggerPath, "/")
+}
+
+// ConnectionsRoute returns ConnectionsPath without a trailing slash.
+func (c *SseConfig) ConnectionsRoute() string {
+	return strings.TrimSuffix(c.ConnectionsPath, "/")
+}
+
+// HealthRoute returns HealthPath without a trailing slash.
+func (c *SseConfig) HealthRoute() string {
+	return strings.TrimSuffix(c.HealthPath, "/")
+}
+
+// OpenAPIRoute returns OpenAPIPath without a trailing slash.
+func (c *SseConfig) OpenAPIRoute() string {
+	return strings.TrimSuffix(c.OpenAPIPath, "/")
+}
+
+// DebugUIRoute returns DebugUIPath without a trailing slash.
+func (c *SseConfig) DebugUIRoute() string {
+	return strings.TrimSuffix(c.DebugUIPath, "/")
+}
+
+/*
+EventsListenAddrs returns the host:port addresses to serve the events port on, one for each
+EventsAddr entry, with EventsPort for those without a port of their own.
+
+Error is returned for the first entry that isn't an IP address, brackets optional for IPv6,
+or a hostname that resolves, with an optional non-reserved port, or that is listed twice.
+*/
+func (c *SseConfig) EventsListenAddrs() ([]string, error) {
+	var rv []string
+	for _, entry := range strings.Split(c.EventsAddr, ",") {
+		entry = strings.TrimSpace(entry)
+		host, port, err := net.SplitHostPort(entry)
+		hasPort := err == nil
+		if !hasPort {
+			// No port, or an IPv6 address without brackets
+			host, port = strings.TrimSuffix(strings.TrimPrefix(entry, "["), "]"), strconv.FormatUint(uint64(c.EventsPort), 10)
+		} else if n, err := strconv.ParseUint(port, 10, 16); err != nil || n < 1024 {
+			return nil, fmt.Errorf("EventsAddr %s must have a non-reserved TCP port number, 1024-65535", entry)
+		}
+		// Only ":port" can leave the host out, for all interfaces
+		valid := host == "" && hasPort
+		if host != "" {
+			_, err := netip.ParseAddr(host)
+			if err != nil {
+				_, err = net.LookupHost(host)
+			}
+			valid = err == nil
+		}
+		if !valid {
+			return nil, fmt.Errorf("EventsAddr %q must be a valid IP address or hostname", entry)
+		}
+		addr := net.JoinHostPort(host, port)
+		if slices.Contains(rv, addr) {
+			return nil, fmt.Errorf("EventsAddr %s is listed twice", addr)
+		}
+		rv = append(rv, addr)
+	}
+	return rv, nil
+}
+
+// LimitsRoute returns LimitsPath without a trailing slash.
+func (c *SseConfig) LimitsRoute() string {
+	return strings.TrimSuffix(c.LimitsPath, "/")
+}
+
+// ConfigRoute returns ConfigPath without a trailing slash.
+func (c *SseConfig) ConfigRoute() string {
+	return strings.TrimSuffix(c.ConfigPath, "/")
+}
+
+/*
+LimitMaxima returns the largest SubscriptionLimit, PrefixesLimit and EventBuffer that can be
+set while the service runs: MaxSubscriptionLimit and the like, or the configured value for
+those that are 0.
+*/
+func (c *SseConfig) LimitMaxima() (uint32, uint, uint) {
+	return max(c.MaxSubscriptionLimit, c.SubscriptionLimit), max(c.MaxPrefixesLimit, c.PrefixesLimit), max(c.MaxEventBuffer, c.EventBuffer)
+}
+
+// Durations returns the durations parsed from the duration strings.
+func (c *SseConfig) Durations() Durations {
+	return c.durations
+}
+
+// Strings returns the durations by setting name, e.g. "1m0s", for showing what is in force.
+func (d Durations) Strings() map[string]string {
+	rv := make(map[string]string)
+	v := reflect.ValueOf(d)
+	for i := 0; i < v.NumField(); i++ {
+		rv[v.Type().Field(i).Name] = time.Duration(v.Field(i).Int()).String()
+	}
+	return rv
+}
+
+/*
+parseDurations parses the duration strings into the values Durations() returns.
+Those that don't parse keep their previous value.
+
+Returns the names of the settings that are not durations, e.g. '30s'.
+*/
+func (c *SseConfig) parseDurations() (invalid []string) {
+	fields := []struct {
+		name  string
+		text  string
+		value *time.Duration
+	}{
+		{"SubscriptionIdleExpiration", c.SubscriptionIdleExpiration, &c.durations.SubscriptionIdleExpiration},
+		{"SubscriptionExpirationCheckInterval", c.SubscriptionExpirationCheckInterval, &c.durations.SubscriptionExpirationCheckInterval},
+		{"MaxIdleExpiration", c.MaxIdleExpiration, &c.durations.MaxIdleExpiration},
+		{"AgeOutGrace", c.AgeOutGrace, &c.durations.AgeOutGrace},
+		{"TopicIdleExpiration", c.TopicIdleExpiration, &c.durations.TopicIdleExpiration},
+		{"HeartbeatInterval", c.HeartbeatInterval, &c.durations.HeartbeatInterval},
+		{"WriteTimeout", c.WriteTimeout, &c.durations.WriteTimeout},
+		{"MaxConnectionAge", c.MaxConnectionAge, &c.durations.MaxConnectionAge},
+		{"ReconnectDelay", c.ReconnectDelay, &c.durations.ReconnectDelay},
+		{"Replay MaxAge", c.Replay.MaxAge, &c.durations.ReplayMaxAge},
+		{"StreamTokenLifetime", c.StreamTokenLifetime, &c.durations.StreamTokenLifetime},
+		{"LookupLockout", c.LookupLockout, &c.durations.LookupLockout},
+		{"MessageBusIdleLimit", c.MessageBusIdleLimit, &c.durations.MessageBusIdleLimit},
+		{"DropWarningInterval", c.DropWarningInterval, &c.durations.DropWarningInterval},
+		{"ThroughputSummaryInterval", c.ThroughputSummaryInterval, &c.durations.ThroughputSummaryInterval},
+		{"PersistenceWriteDelay", c.PersistenceWriteDelay, &c.durations.PersistenceWriteDelay},
+		{"File SnapshotInterval", c.File.SnapshotInterval, &c.durations.FileSnapshotInterval},
+		{"ReplicationSyncInterval", c.ReplicationSyncInterval, &c.durations.ReplicationSyncInterval},
+		{"ExpiryNotice", c.ExpiryNotice, &c.durations.ExpiryNotice},
+		{"AlertInterval", c.AlertInterval, &c.durations.AlertInterval},
+		{"OverflowAlertWindow", c.OverflowAlertWindow, &c.durations.OverflowAlertWindow},
+		{"EventsBindRetryInterval", c.EventsBindRetryInterval, &c.durations.EventsBindRetryInterval},
+	}
+	for _, field := range fields {
+		d, err := time.ParseDuration(field.text)
+		if err != nil {
+			invalid = append(invalid, field.name)
+			continue
+		}
+		*field.value = d
+	}
+	return invalid
+}
+
+/*
+TopicRewriteList parses TopicRewrites, e.g. "site1/edgex=edgex, legacy/sensors=sensors".
+
+Error is returned if an entry is not from=to, from is empty, or either has MQTT wildcards.
+*/
+func (c *SseConfig) TopicRewriteList() ([]TopicRewrite, error) {
+	rv := make([]TopicRewrite, 0)
+	for _, entry := range strings.Split(c.TopicRewrites, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(entry, "=")
+		from = strings.Trim(strings.TrimSpace(from), "/")
+		to = strings.Trim(strings.TrimSpace(to), "/")
+		if !ok || from == "" {
+			return nil, fmt.Errorf("TopicRewrites entry %s must be in the form from=to", entry)
+		}
+		if strings.ContainsAny(from+to, "#+") {
+			return nil, fmt.Errorf("TopicRewrites entry %s must not have wildcards", entry)
+		}
+		rv = append(rv, TopicRewrite{From: from, To: to})
+	}
+	return rv, nil
+}
+
+// TrustedProxyList returns the ranges parsed from TrustedProxies.
+func (c *SseConfig) TrustedProxyList() []netip.Prefix {
+	return c.trustedProxies
+}
+
+/*
+parseTrustedProxies parses TrustedProxies, e.g. "10.0.0.1, 172.16.0.0/12", into the ranges
+TrustedProxyList() returns. Single addresses become ranges with just that address. If any
+entry doesn't parse, no proxy is trusted.
+
+Error is returned if an entry is neither an IP address nor a CIDR range.
+*/
+func (c *SseConfig) parseTrustedProxies() error {
+	c.trustedProxies = nil
+	rv := make([]netip.Prefix, 0)
+	for _, entry := range splitList(c.TrustedProxies) {
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil || addr.Zone() != "" {
+				return fmt.Errorf("TrustedProxies entry %s must be an IP address or CIDR range", entry)
+			}
+			addr = addr.Unmap()
+			rv = append(rv, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return fmt.Errorf("TrustedProxies entry %s must be an IP address or CIDR range", entry)
+		}
+		rv = append(rv, prefix.Masked())
+	}
+	c.trustedProxies = rv
+	return nil
+}
+
+/*
+AllowedTopicList returns the topic prefixes subscriptions created by the role are limited
+to (role "" for callers not in a role), or nil if they can have any topic.
+*/
+func (c *SseConfig) AllowedTopicList(role string) []string {
+	topics := c.AllowedTopics
+	if role != "" {
+		topics = c.Roles[role].AllowedTopics
+	}
+	if strings.TrimSpace(topics) == "" {
+		return nil
+	}
+	return splitList(topics)
+}
+
+// RoleOf returns the name of the role with the given identity, or "" if none has it.
+func (c *SseConfig) RoleOf(identity string) string {
+	if identity == "" {
+		return ""
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Roles)) {
+		if slices.Contains(c.Roles[name].IdentityList(), identity) {
+			return name
+		}
+	}
+	return ""
+}
+
+// Retention returns the Replay settings for the subscription manager.
+func (c *SseConfig) Retention() submgr.Retention {
+	return submgr.Retention{Count: c.Replay.Count, Bytes: c.Replay.Bytes, MaxAge: c.durations.ReplayMaxAge}
+}
+
+// PipelineOptions returns the processing options for the pipeline with the given ID, filling in those it doesn't set.
+func (c *SseConfig) PipelineOptions(id string) PipelineConfig {
+	rv := c.Pipelines[id]
+	if rv.CBORDelivery == "" {
+		rv.CBORDelivery = c.CBORDelivery
+	}
+	if rv.CommandResponseTopicPrefix == "" {
+		rv.CommandResponseTopicPrefix = c.CommandResponseTopicPrefix
+	}
+	if rv.BinaryReadings == "" {
+		rv.BinaryReadings = c.BinaryReadings
+	}
+	return rv
 }
 
 // Must be wrapped in a struct with element named the same as the section name
@@ -37,10 +820,68 @@ func (c *Config) SetDefaults() {
 	c.SSE.SubscriptionLimit = 50
 	c.SSE.PrefixesLimit = 100
 	c.SSE.EventBuffer = 100
+	c.SSE.DeliveryQueue = 1000
+	c.SSE.MaxRequestBodySize = 65536
+	c.SSE.ManagementRate = 5
+	c.SSE.ManagementBurst = 20
 	c.SSE.EventsAddr = "127.0.0.1"
 	c.SSE.EventsPort = 59748
+	c.SSE.ConnectionsPerClient = 16
+	c.SSE.CompressionBlockSize = 32768
+	c.SSE.EventsPath = "/api/v3/events"
+	c.SSE.SubscriptionPath = "/api/v3/subscription"
+	c.SSE.TriggerPath = "/api/v3/trigger"
+	c.SSE.ConnectionsPath = "/api/v3/connections"
+	c.SSE.HealthPath = "/api/v3/health"
+	c.SSE.OpenAPIPath = "/api/v3/openapi"
+	c.SSE.DebugUIPath = "/api/v3/debug/ui"
+	c.SSE.LimitsPath = "/api/v3/limits"
+	c.SSE.ConfigPath = "/api/v3/config"
 	c.SSE.SubscriptionIdleExpiration = "1m"
 	c.SSE.SubscriptionExpirationCheckInterval = "5s"
+	c.SSE.TopicIndexLimit = 0
+	c.SSE.TopicIdleExpiration = "1h"
+	c.SSE.HeartbeatInterval = "30s"
+	c.SSE.WriteTimeout = "30s"
+	c.SSE.MaxConnectionAge = "0s"
+	c.SSE.ReconnectDelay = "1s"
+	c.SSE.EventsBindRetryInterval = "1s"
+	c.SSE.Replay.MaxAge = "5m"
+	c.SSE.Tracing.Endpoint = "http://localhost:4318"
+	c.SSE.Tracing.SampleRatio = 1
+	c.SSE.StreamTokenLifetime = "1m"
+	c.SSE.LookupFailureLimit = 20
+	c.SSE.LookupLockout = "5m"
+	c.SSE.MessageBusIdleLimit = "0s"
+	c.SSE.DropWarningInterval = "1m"
+	c.SSE.ThroughputSummaryInterval = "0s"
+	c.SSE.CBORDelivery = CBORDeliveryJSON
+	c.SSE.CommandResponseTopicPrefix = "edgex/response"
+	c.SSE.BinaryReadings = BinaryReadingsKeep
+	c.SSE.ExternalMQTT.ClientId = "edgex-sse-external"
+	c.SSE.InvalidEvents = InvalidEventsGeneric
+	c.SSE.ProfileValidation = ProfileValidationOff
+	c.SSE.PersistenceBackend = PersistenceNone
+	c.SSE.PersistenceWriteDelay = "1s"
+	c.SSE.Redis.Host = "localhost"
+	c.SSE.Redis.Port = 6379
+	c.SSE.Redis.SecretName = "redisdb"
+	c.SSE.Redis.Key = "edgex-sse:subscriptions"
+	c.SSE.Keeper.Host = "localhost"
+	c.SSE.Keeper.Port = 59890
+	c.SSE.Keeper.BasePath = "edgex/v4/edgex-sse-subscriptions"
+	c.SSE.File.Path = "edgex-sse-subscriptions.json"
+	c.SSE.File.SnapshotInterval = "30s"
+	c.SSE.ReplicationSyncInterval = "5s"
+	c.SSE.ExpiryNotice = "20s"
+	c.SSE.MaxIdleExpiration = "24h"
+	c.SSE.AgeOutGrace = "0s"
+	c.SSE.NotificationCategory = "edgex-sse"
+	c.SSE.AlertInterval = "15m"
+	c.SSE.OverflowAlertWindow = "1m"
+	c.SSE.Writable.PipelineFunctions = FunctionStripBinary + ", " + FunctionPublish
+	c.SSE.parseDurations()
+	_ = c.SSE.parseTrustedProxies()
 }
 
 func (c *Config) UpdateFromRaw(rawConfig interface{}) bool {
@@ -49,42 +890,361 @@ func (c *Config) UpdateFromRaw(rawConfig interface{}) bool {
 		return false
 	}
 	*c = *config
+	// Validate() reports any that don't parse
+	c.SSE.parseDurations()
+	_ = c.SSE.parseTrustedProxies()
 	return true
 }
 
+/*
+Validate checks every setting, returning all the problems found joined into one error,
+one per line, or nil if there are none.
+*/
 func (c *Config) Validate() error {
-	if c.SSE.EventBuffer < 10 {
-		return errors.New("EventBuffer must be at least 10 events")
+	var errs []error
+	if c.SSE.EventBuffer < MinEventBuffer {
+		errs = append(errs, fmt.Errorf("EventBuffer must be at least %d events", MinEventBuffer))
+	}
+	if c.SSE.BackpressureMark >= 100 {
+		errs = append(errs, errors.New("BackpressureMark must be below 100 percent"))
+	}
+	if c.SSE.DeliveryWorkers > 0 && c.SSE.DeliveryQueue == 0 {
+		errs = append(errs, errors.New("DeliveryQueue must be greater than zero when DeliveryWorkers is set"))
 	}
 	if c.SSE.SubscriptionLimit == 0 || c.SSE.PrefixesLimit == 0 {
-		return errors.New("limits must be greater than zero")
+		errs = append(errs, errors.New("limits must be greater than zero"))
+	}
+	if (c.SSE.MaxSubscriptionLimit != 0 && c.SSE.MaxSubscriptionLimit < c.SSE.SubscriptionLimit) ||
+		(c.SSE.MaxPrefixesLimit != 0 && c.SSE.MaxPrefixesLimit < c.SSE.PrefixesLimit) ||
+		(c.SSE.MaxEventBuffer != 0 && c.SSE.MaxEventBuffer < c.SSE.EventBuffer) {
+		errs = append(errs, errors.New("MaxSubscriptionLimit, MaxPrefixesLimit and MaxEventBuffer must be 0 or at least the configured limits"))
+	}
+	if c.SSE.MaxRequestBodySize < 1024 {
+		errs = append(errs, errors.New("MaxRequestBodySize must be at least 1024 bytes"))
+	}
+	if c.SSE.ManagementRate < 0 {
+		errs = append(errs, errors.New("ManagementRate must not be negative"))
+	} else if c.SSE.ManagementRate > 0 && c.SSE.ManagementBurst == 0 {
+		errs = append(errs, errors.New("ManagementBurst must be at least 1 when ManagementRate is set"))
 	}
 	if c.SSE.EventsPort < 1024 || c.SSE.EventsPort > 65535 {
-		return errors.New("EventsPort must be a valid non-reserved TCP port number, 1024-65535")
+		errs = append(errs, errors.New("EventsPort must be a valid non-reserved TCP port number, 1024-65535"))
 	}
-	ip := net.ParseIP(c.SSE.EventsAddr)
-	if ip == nil {
-		_, err := net.LookupHost(c.SSE.EventsAddr)
-		if err != nil {
-			return errors.New("EventsAddr must be a valid IP address or hostname")
+	if _, err := c.SSE.EventsListenAddrs(); err != nil {
+		errs = append(errs, err)
+	}
+	paths := []struct {
+		name string
+		path string
+	}{
+		{"EventsPath", c.SSE.EventsPath},
+		{"SubscriptionPath", c.SSE.SubscriptionPath},
+		{"TriggerPath", c.SSE.TriggerPath},
+		{"ConnectionsPath", c.SSE.ConnectionsPath},
+		{"HealthPath", c.SSE.HealthPath},
+		{"OpenAPIPath", c.SSE.OpenAPIPath},
+		{"DebugUIPath", c.SSE.DebugUIPath},
+		{"LimitsPath", c.SSE.LimitsPath},
+		{"ConfigPath", c.SSE.ConfigPath},
+	}
+	for _, p := range paths {
+		if !validPath(p.path) {
+			errs = append(errs, fmt.Errorf("%s must be an absolute URL path below /, without route parameters or wildcards", p.name))
 		}
 	}
-	d, err := time.ParseDuration(c.SSE.SubscriptionIdleExpiration)
-	if err != nil {
-		return errors.New("SubscriptionIdleExpiration must be in the form of a duration, e.g. '30s'")
+	invalid := c.SSE.parseDurations()
+	for _, name := range invalid {
+		errs = append(errs, fmt.Errorf("%s must be in the form of a duration, e.g. '30s'", name))
 	}
-	if d.Seconds() < 5 {
-		return errors.New("SubscriptionIdleExpiration must be at least 5 seconds")
+	// Range checks only make sense for durations that parsed
+	parsed := func(names ...string) bool {
+		for _, name := range names {
+			if slices.Contains(invalid, name) {
+				return false
+			}
+		}
+		return true
 	}
-	di, err := time.ParseDuration(c.SSE.SubscriptionExpirationCheckInterval)
-	if err != nil {
-		return errors.New("SubscriptionExpirationCheckInterval must be in the form of a duration, e.g. '30s'")
+	d := c.SSE.durations
+	if parsed("SubscriptionIdleExpiration") && d.SubscriptionIdleExpiration < 5*time.Second {
+		errs = append(errs, errors.New("SubscriptionIdleExpiration must be at least 5 seconds"))
 	}
-	if di.Seconds() <= 0 {
-		return errors.New("SubscriptionExpirationCheckInterval must be longer than zero")
+	if parsed("SubscriptionExpirationCheckInterval") && d.SubscriptionExpirationCheckInterval <= 0 {
+		errs = append(errs, errors.New("SubscriptionExpirationCheckInterval must be longer than zero"))
 	}
-	if di.Seconds() * 2 > d.Seconds() {
-		return errors.New("SubscriptionIdleExpiration must be at least twice SubscriptionExpirationCheckInterval")
+	if parsed("SubscriptionIdleExpiration", "SubscriptionExpirationCheckInterval") && d.SubscriptionExpirationCheckInterval*2 > d.SubscriptionIdleExpiration {
+		errs = append(errs, errors.New("SubscriptionIdleExpiration must be at least twice SubscriptionExpirationCheckInterval"))
 	}
-	return nil
+	if parsed("TopicIdleExpiration") && d.TopicIdleExpiration < 0 {
+		errs = append(errs, errors.New("TopicIdleExpiration must not be negative"))
+	}
+	if parsed("HeartbeatInterval") && d.HeartbeatInterval < 0 {
+		errs = append(errs, errors.New("HeartbeatInterval must not be negative"))
+	}
+	if parsed("WriteTimeout") && d.WriteTimeout < 0 {
+		errs = append(errs, errors.New("WriteTimeout must not be negative"))
+	}
+	if parsed("MaxConnectionAge") && d.MaxConnectionAge != 0 && d.MaxConnectionAge < time.Second {
+		errs = append(errs, errors.New("MaxConnectionAge must be 0 or at least 1s"))
+	}
+	if parsed("ReconnectDelay") && (d.ReconnectDelay < 0 || d.ReconnectDelay%time.Millisecond != 0) {
+		errs = append(errs, errors.New("ReconnectDelay must not be negative, and be whole milliseconds"))
+	}
+	if parsed("EventsBindRetryInterval") && c.SSE.EventsBindRetries > 0 && d.EventsBindRetryInterval <= 0 {
+		errs = append(errs, errors.New("EventsBindRetryInterval must be greater than zero when EventsBindRetries is set"))
+	}
+	if parsed("StreamTokenLifetime") && (d.StreamTokenLifetime < time.Second || d.StreamTokenLifetime > time.Hour) {
+		errs = append(errs, errors.New("StreamTokenLifetime must be between 1 second and 1 hour"))
+	}
+	if parsed("LookupLockout") && c.SSE.LookupFailureLimit > 0 && d.LookupLockout <= 0 {
+		errs = append(errs, errors.New("LookupLockout must be longer than zero when LookupFailureLimit is set"))
+	}
+	if parsed("MessageBusIdleLimit") && d.MessageBusIdleLimit < 0 {
+		errs = append(errs, errors.New("MessageBusIdleLimit must not be negative"))
+	}
+	if parsed("DropWarningInterval") && d.DropWarningInterval < 0 {
+		errs = append(errs, errors.New("DropWarningInterval must not be negative"))
+	}
+	if parsed("ThroughputSummaryInterval") && d.ThroughputSummaryInterval < 0 {
+		errs = append(errs, errors.New("ThroughputSummaryInterval must not be negative"))
+	}
+	if parsed("PersistenceWriteDelay") && d.PersistenceWriteDelay < 0 {
+		errs = append(errs, errors.New("PersistenceWriteDelay must not be negative"))
+	}
+	if (c.SSE.Replay.Count == 0) != (c.SSE.Replay.Bytes == 0) {
+		errs = append(errs, errors.New("Replay Count and Bytes must both be zero, to disable replay, or both be set"))
+	} else if c.SSE.Replay.Count > 0 {
+		if c.SSE.Replay.Bytes < 1024 {
+			errs = append(errs, errors.New("Replay Bytes must be at least 1024"))
+		}
+		if c.SSE.Replay.Count > c.SSE.Replay.Bytes {
+			errs = append(errs, errors.New("Replay Count must not be more than Bytes, messages are at least a byte"))
+		}
+		if parsed("Replay MaxAge") && d.ReplayMaxAge <= 0 {
+			errs = append(errs, errors.New("Replay MaxAge must be longer than zero"))
+		}
+	}
+	if !validCBORDelivery(c.SSE.CBORDelivery) {
+		errs = append(errs, errors.New("CBORDelivery must be 'json' or 'base64'"))
+	}
+	if !validBinaryReadings(c.SSE.BinaryReadings) {
+		errs = append(errs, errors.New("BinaryReadings must be 'keep', 'summarize' or 'drop'"))
+	}
+	// In ID order, so the same problems are always reported the same way
+	for _, id := range slices.Sorted(maps.Keys(c.SSE.Pipelines)) {
+		pipeline := c.SSE.Pipelines[id]
+		if len(pipeline.TopicList()) == 0 {
+			errs = append(errs, fmt.Errorf("Pipeline %s must have at least one topic", id))
+		}
+		if pipeline.CBORDelivery != "" && !validCBORDelivery(pipeline.CBORDelivery) {
+			errs = append(errs, fmt.Errorf("Pipeline %s CBORDelivery must be 'json' or 'base64'", id))
+		}
+		if pipeline.BinaryReadings != "" && !validBinaryReadings(pipeline.BinaryReadings) {
+			errs = append(errs, fmt.Errorf("Pipeline %s BinaryReadings must be 'keep', 'summarize' or 'drop'", id))
+		}
+	}
+	if err := c.SSE.parseTrustedProxies(); err != nil {
+		errs = append(errs, err)
+	}
+	roleOf := make(map[string]string)
+	for _, name := range slices.Sorted(maps.Keys(c.SSE.Roles)) {
+		role := c.SSE.Roles[name]
+		if len(role.IdentityList()) == 0 {
+			errs = append(errs, fmt.Errorf("Role %s must have at least one identity", name))
+		}
+		if role.SubscriptionLimit == 0 || role.PrefixesLimit == 0 {
+			errs = append(errs, fmt.Errorf("Role %s limits must be greater than zero", name))
+		}
+		if strings.ContainsAny(role.AllowedTopics, "#+") {
+			errs = append(errs, fmt.Errorf("Role %s AllowedTopics must not have wildcards", name))
+		}
+		for _, identity := range role.IdentityList() {
+			if other, ok := roleOf[identity]; ok {
+				errs = append(errs, fmt.Errorf("Identity %s must only be in one role, is in %s and %s", identity, other, name))
+			}
+			roleOf[identity] = name
+		}
+	}
+	if strings.ContainsAny(c.SSE.AllowedTopics, "#+") {
+		errs = append(errs, errors.New("AllowedTopics must not have wildcards"))
+	}
+	if _, ok := c.SSE.Roles[c.SSE.AdminRole]; c.SSE.AdminRole != "" && !ok {
+		errs = append(errs, fmt.Errorf("AdminRole %s must be one of the Roles", c.SSE.AdminRole))
+	}
+	conversions := make(map[[2]string]string)
+	for _, name := range slices.Sorted(maps.Keys(c.SSE.UnitConversions)) {
+		conversion := c.SSE.UnitConversions[name]
+		if conversion.From == "" || conversion.To == "" || conversion.From == conversion.To {
+			errs = append(errs, fmt.Errorf("UnitConversion %s must have From and To, and they must differ", name))
+		}
+		if conversion.Scale == 0 {
+			errs = append(errs, fmt.Errorf("UnitConversion %s Scale must not be zero", name))
+		}
+		if other, ok := conversions[[2]string{conversion.From, conversion.To}]; ok {
+			errs = append(errs, fmt.Errorf("UnitConversions %s and %s both convert %s to %s", other, name, conversion.From, conversion.To))
+		}
+		conversions[[2]string{conversion.From, conversion.To}] = name
+	}
+	if uint32(len(c.SSE.StaticSubscriptions)) > c.SSE.SubscriptionLimit {
+		errs = append(errs, errors.New("StaticSubscriptions must not have more entries than SubscriptionLimit"))
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.SSE.StaticSubscriptions)) {
+		sub := c.SSE.StaticSubscriptions[name]
+		if !validSubscriptionName(name) {
+			errs = append(errs, fmt.Errorf("StaticSubscriptions name %s must only have letters, digits, '-' and '_'", name))
+		}
+		if len(sub.IncludeList()) == 0 {
+			errs = append(errs, fmt.Errorf("StaticSubscriptions %s must include at least one topic", name))
+		}
+		if uint(len(sub.IncludeList())) > c.SSE.PrefixesLimit || uint(len(sub.ExcludeList())) > c.SSE.PrefixesLimit {
+			errs = append(errs, fmt.Errorf("StaticSubscriptions %s must not have more include or exclude entries than PrefixesLimit", name))
+		}
+		if err := sub.Options().Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("StaticSubscriptions %s %s", name, err.Error()))
+		}
+	}
+	switch c.SSE.InvalidEvents {
+	case InvalidEventsGeneric, InvalidEventsDrop, InvalidEventsAnnotate:
+	default:
+		errs = append(errs, errors.New("InvalidEvents must be 'generic', 'drop' or 'annotate'"))
+	}
+	switch c.SSE.ProfileValidation {
+	case ProfileValidationOff, ProfileValidationDrop, ProfileValidationAnnotate:
+	default:
+		errs = append(errs, errors.New("ProfileValidation must be 'off', 'drop' or 'annotate'"))
+	}
+	if strings.ContainsAny(c.SSE.StripTopicPrefix, "#+") {
+		errs = append(errs, errors.New("StripTopicPrefix must not have wildcards"))
+	}
+	if _, err := c.SSE.TopicRewriteList(); err != nil {
+		errs = append(errs, err)
+	}
+	if strings.ContainsAny(c.SSE.DeadLetterTopic, "#+") {
+		errs = append(errs, errors.New("DeadLetterTopic must not have wildcards"))
+	}
+	errs = append(errs, c.SSE.Writable.Validate())
+	if c.SSE.ExternalMQTT.Enabled {
+		broker, err := url.Parse(c.SSE.ExternalMQTT.BrokerAddress)
+		if err != nil || broker.Host == "" {
+			errs = append(errs, errors.New("ExternalMQTT BrokerAddress must be a URL, e.g. 'tcp://broker:1883'"))
+		} else {
+			switch broker.Scheme {
+			case "tcp", "ssl", "tls", "mqtt", "mqtts", "ws", "wss":
+			default:
+				errs = append(errs, errors.New("ExternalMQTT BrokerAddress scheme must be tcp, ssl, tls, mqtt, mqtts, ws or wss"))
+			}
+		}
+		if c.SSE.ExternalMQTT.ClientId == "" {
+			errs = append(errs, errors.New("ExternalMQTT ClientId must not be empty"))
+		}
+		if len(c.SSE.ExternalMQTT.TopicList()) == 0 {
+			errs = append(errs, errors.New("ExternalMQTT must have at least one topic"))
+		}
+		if c.SSE.ExternalMQTT.QoS > 2 {
+			errs = append(errs, errors.New("ExternalMQTT QoS must be 0, 1 or 2"))
+		}
+	}
+	switch c.SSE.PersistenceBackend {
+	case PersistenceNone:
+	case PersistenceRedis:
+		if c.SSE.Redis.Host == "" || c.SSE.Redis.Port == 0 || c.SSE.Redis.Port > 65535 {
+			errs = append(errs, errors.New("Redis Host must not be empty, and Port must be a TCP port number"))
+		}
+		if c.SSE.Redis.Key == "" {
+			errs = append(errs, errors.New("Redis Key must not be empty"))
+		}
+	case PersistenceKeeper:
+		if c.SSE.Keeper.Host == "" || c.SSE.Keeper.Port == 0 || c.SSE.Keeper.Port > 65535 {
+			errs = append(errs, errors.New("Keeper Host must not be empty, and Port must be a TCP port number"))
+		}
+		if strings.Trim(c.SSE.Keeper.BasePath, "/") == "" {
+			errs = append(errs, errors.New("Keeper BasePath must not be empty"))
+		}
+	case PersistenceFile:
+		if c.SSE.File.Path == "" {
+			errs = append(errs, errors.New("File Path must not be empty"))
+		}
+		if parsed("File SnapshotInterval") && d.FileSnapshotInterval <= 0 {
+			errs = append(errs, errors.New("File SnapshotInterval must be longer than zero"))
+		}
+	default:
+		errs = append(errs, errors.New("PersistenceBackend must be 'none', 'redis', 'keeper' or 'file'"))
+	}
+	if c.SSE.Replication {
+		if c.SSE.PersistenceBackend != PersistenceRedis {
+			errs = append(errs, errors.New("Replication requires PersistenceBackend 'redis'"))
+		}
+		if c.SSE.StreamTokenSecretName == "" {
+			errs = append(errs, errors.New("Replication requires StreamTokenSecretName, so stream tokens work on every replica"))
+		}
+		if parsed("ReplicationSyncInterval") && d.ReplicationSyncInterval <= 0 {
+			errs = append(errs, errors.New("ReplicationSyncInterval must be longer than zero"))
+		}
+		if parsed("ReplicationSyncInterval", "SubscriptionIdleExpiration") && d.ReplicationSyncInterval*2 > d.SubscriptionIdleExpiration {
+			errs = append(errs, errors.New("SubscriptionIdleExpiration must be at least twice ReplicationSyncInterval"))
+		}
+	}
+	if c.SSE.ExpiryNotifications && parsed("ExpiryNotice", "SubscriptionExpirationCheckInterval", "SubscriptionIdleExpiration") {
+		if d.ExpiryNotice <= d.SubscriptionExpirationCheckInterval || d.ExpiryNotice >= d.SubscriptionIdleExpiration {
+			errs = append(errs, errors.New("ExpiryNotice must be longer than SubscriptionExpirationCheckInterval, and shorter than SubscriptionIdleExpiration"))
+		}
+	}
+	if parsed("MaxIdleExpiration", "SubscriptionIdleExpiration") && d.MaxIdleExpiration < d.SubscriptionIdleExpiration {
+		errs = append(errs, errors.New("MaxIdleExpiration must be at least SubscriptionIdleExpiration"))
+	}
+	if parsed("AgeOutGrace", "SubscriptionExpirationCheckInterval") && d.AgeOutGrace != 0 && d.AgeOutGrace < d.SubscriptionExpirationCheckInterval {
+		errs = append(errs, errors.New("AgeOutGrace must be 0, or at least SubscriptionExpirationCheckInterval"))
+	}
+	if !notificationCategory.MatchString(c.SSE.NotificationCategory) {
+		errs = append(errs, errors.New("NotificationCategory must be letters, digits, '-', '.', '_' and '~'"))
+	}
+	if c.SSE.Alerts {
+		if parsed("AlertInterval") && d.AlertInterval < 0 {
+			errs = append(errs, errors.New("AlertInterval must not be negative"))
+		}
+		if parsed("OverflowAlertWindow") && d.OverflowAlertWindow < time.Second {
+			errs = append(errs, errors.New("OverflowAlertWindow must be at least 1s"))
+		}
+	}
+	if c.SSE.Tracing.Enabled {
+		endpoint, err := url.Parse(c.SSE.Tracing.Endpoint)
+		if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+			errs = append(errs, errors.New("Tracing Endpoint must be an http or https URL, e.g. 'http://otel-collector:4318'"))
+		}
+		if c.SSE.Tracing.SampleRatio < 0 || c.SSE.Tracing.SampleRatio > 1 {
+			errs = append(errs, errors.New("Tracing SampleRatio must be between 0 and 1"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validCBORDelivery(value string) bool {
+	return value == CBORDeliveryJSON || value == CBORDeliveryBase64
+}
+
+func validBinaryReadings(value string) bool {
+	switch value {
+	case BinaryReadingsKeep, BinaryReadingsSummarize, BinaryReadingsDrop:
+		return true
+	default:
+		return false
+	}
+}
+
+// validSubscriptionName returns true for a name that can be used as-is as a subscription ID in URL paths.
+func validSubscriptionName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// validPath returns true for a URL path that can have routes added below it.
+func validPath(path string) bool {
+	return strings.HasPrefix(path, "/") && strings.Trim(path, "/") != "" && !strings.ContainsAny(path, ":*?# ")
 }
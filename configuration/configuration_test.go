@@ -7,7 +7,10 @@
 package configuration
 
 import (
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaults(t *testing.T) {
@@ -34,6 +37,30 @@ func TestDefaults(t *testing.T) {
 	if dut.SSE.SubscriptionExpirationCheckInterval != "5s" {
 		t.Fatalf("Wrong default SubscriptionExpirationCheckInterval: %s", dut.SSE.SubscriptionExpirationCheckInterval)		
 	}
+	if dut.SSE.TopicIndexLimit != 0 {
+		t.Fatalf("Wrong default TopicIndexLimit: %d", dut.SSE.TopicIndexLimit)
+	}
+	if dut.SSE.TopicIdleExpiration != "1h" {
+		t.Fatalf("Wrong default TopicIdleExpiration: %s", dut.SSE.TopicIdleExpiration)
+	}
+	if dut.SSE.CBORDelivery != "json" {
+		t.Fatalf("Wrong default CBORDelivery: %s", dut.SSE.CBORDelivery)
+	}
+	if dut.SSE.CommandResponseTopicPrefix != "edgex/response" {
+		t.Fatalf("Wrong default CommandResponseTopicPrefix: %s", dut.SSE.CommandResponseTopicPrefix)
+	}
+	if dut.SSE.BinaryReadings != "keep" {
+		t.Fatalf("Wrong default BinaryReadings: %s", dut.SSE.BinaryReadings)
+	}
+	if dut.SSE.InvalidEvents != "generic" {
+		t.Fatalf("Wrong default InvalidEvents: %s", dut.SSE.InvalidEvents)
+	}
+	if dut.SSE.ProfileValidation != "off" {
+		t.Fatalf("Wrong default ProfileValidation: %s", dut.SSE.ProfileValidation)
+	}
+	if dut.SSE.Writable.PipelineFunctions != "strip-binary, publish" {
+		t.Fatalf("Wrong default PipelineFunctions: %s", dut.SSE.Writable.PipelineFunctions)
+	}
 }
 
 type rawercfg struct {
@@ -181,4 +208,730 @@ func TestValidation(t *testing.T) {
 	if err == nil {
 		t.Fatal("Validate() succeeded with SubscriptionExpirationCheckInterval more than half of SubscriptionIdleExpiration")
 	}
+	dut.SetDefaults()
+	dut.SSE.TopicIdleExpiration = "forever"
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with TopicIdleExpiration forever")
+	}
+	dut.SetDefaults()
+	dut.SSE.TopicIdleExpiration = "-1m"
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with negative TopicIdleExpiration")
+	}
+	dut.SetDefaults()
+	dut.SSE.TopicIdleExpiration = "0s"
+	dut.SSE.TopicIndexLimit = 0
+	err = dut.Validate()
+	if err != nil {
+		t.Fatal("Validate() failed with topic index disabled")
+	}
+	dut.SetDefaults()
+	dut.SSE.CBORDelivery = "hex"
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with CBORDelivery hex")
+	}
+	dut.SSE.CBORDelivery = "base64"
+	err = dut.Validate()
+	if err != nil {
+		t.Fatal("Validate() failed with CBORDelivery base64")
+	}
+	dut.SetDefaults()
+	dut.SSE.BinaryReadings = "shrink"
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with BinaryReadings shrink")
+	}
+	dut.SSE.BinaryReadings = "drop"
+	err = dut.Validate()
+	if err != nil {
+		t.Fatal("Validate() failed with BinaryReadings drop")
+	}
+	dut.SetDefaults()
+	dut.SSE.InvalidEvents = "ignore"
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with InvalidEvents ignore")
+	}
+	dut.SSE.InvalidEvents = "annotate"
+	err = dut.Validate()
+	if err != nil {
+		t.Fatal("Validate() failed with InvalidEvents annotate")
+	}
+	dut.SSE.ProfileValidation = "warn"
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with ProfileValidation warn")
+	}
+	dut.SSE.ProfileValidation = "drop"
+	err = dut.Validate()
+	if err != nil {
+		t.Fatal("Validate() failed with ProfileValidation drop")
+	}
+	dut.SetDefaults()
+	dut.SSE.Pipelines = map[string]PipelineConfig{"factory": {Topics: " , "}}
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with pipeline without topics")
+	}
+	dut.SSE.Pipelines = map[string]PipelineConfig{"factory": {Topics: "factory/#", BinaryReadings: "shrink"}}
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with pipeline BinaryReadings shrink")
+	}
+	dut.SSE.Pipelines = map[string]PipelineConfig{"factory": {Topics: "factory/#", CBORDelivery: "hex"}}
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with pipeline CBORDelivery hex")
+	}
+	dut.SSE.Pipelines = map[string]PipelineConfig{"factory": {Topics: "factory/#", BinaryReadings: "drop"}}
+	err = dut.Validate()
+	if err != nil {
+		t.Fatal("Validate() failed with valid pipeline")
+	}
+	dut.SetDefaults()
+	dut.SSE.ExternalMQTT.Enabled = true
+	dut.SSE.ExternalMQTT.Topics = "factory/#"
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with ExternalMQTT enabled without a broker")
+	}
+	dut.SSE.ExternalMQTT.BrokerAddress = "http://broker:1883"
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with ExternalMQTT broker scheme http")
+	}
+	dut.SSE.ExternalMQTT.BrokerAddress = "tcp://broker:1883"
+	dut.SSE.ExternalMQTT.QoS = 3
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with ExternalMQTT QoS 3")
+	}
+	dut.SSE.ExternalMQTT.QoS = 1
+	dut.SSE.ExternalMQTT.Topics = ""
+	err = dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with ExternalMQTT without topics")
+	}
+	dut.SSE.ExternalMQTT.Topics = "factory/#"
+	err = dut.Validate()
+	if err != nil {
+		t.Fatalf("Validate() failed with valid ExternalMQTT: %s", err.Error())
+	}
+}
+
+func TestPipelineOptions(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.Pipelines = map[string]PipelineConfig{"factory": {Topics: "factory/#, plant/+/status", BinaryReadings: "drop"}}
+	options := dut.SSE.PipelineOptions("factory")
+	if options.BinaryReadings != "drop" || options.CBORDelivery != "json" || options.CommandResponseTopicPrefix != "edgex/response" {
+		t.Fatalf("Wrong pipeline options: %v", options)
+	}
+	topics := options.TopicList()
+	if len(topics) != 2 || topics[0] != "factory/#" || topics[1] != "plant/+/status" {
+		t.Fatalf("Wrong pipeline topics: %v", topics)
+	}
+	options = dut.SSE.PipelineOptions("default-pipeline")
+	if options.BinaryReadings != "keep" || options.Topics != "" {
+		t.Fatalf("Wrong options for unconfigured pipeline: %v", options)
+	}
+}
+
+func TestTopicRewriteList(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.TopicRewrites = "site1/edgex/=edgex, legacy/sensors = sensors,"
+	rewrites, err := dut.SSE.TopicRewriteList()
+	if err != nil {
+		t.Fatalf("TopicRewriteList failed: %s", err.Error())
+	}
+	if len(rewrites) != 2 || rewrites[0] != (TopicRewrite{From: "site1/edgex", To: "edgex"}) || rewrites[1] != (TopicRewrite{From: "legacy/sensors", To: "sensors"}) {
+		t.Fatalf("Wrong rewrites: %v", rewrites)
+	}
+	for _, bad := range []string{"site1", "=edgex", "site1/#=edgex"} {
+		dut.SSE.TopicRewrites = bad
+		if dut.Validate() == nil {
+			t.Fatalf("Validate() succeeded with TopicRewrites %s", bad)
+		}
+	}
+	dut.SetDefaults()
+	dut.SSE.StripTopicPrefix = "site1/+"
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with wildcard StripTopicPrefix")
+	}
+	dut.SetDefaults()
+	dut.SSE.DeadLetterTopic = "sse/dead-letter/#"
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with wildcard DeadLetterTopic")
+	}
+}
+
+func TestWritableValidation(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.Writable.PipelineFunctions = "filter-by-device, strip-binary, transform-simple, publish"
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with filter-by-device and no FilterDevices")
+	}
+	dut.SSE.Writable.FilterDevices = "Random-Integer-Device, Random-Float-Device"
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed with valid PipelineFunctions: %s", err.Error())
+	}
+	devices := dut.SSE.Writable.DeviceList()
+	if len(devices) != 2 || devices[1] != "Random-Float-Device" {
+		t.Fatalf("Wrong device list: %v", devices)
+	}
+	for _, bad := range []string{"", "strip-binary", "publish, strip-binary", "publish, publish", "decompress, publish"} {
+		dut.SSE.Writable.PipelineFunctions = bad
+		if dut.Validate() == nil {
+			t.Fatalf("Validate() succeeded with PipelineFunctions %s", bad)
+		}
+	}
+}
+
+func TestDurations(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	d := dut.SSE.Durations()
+	if d.SubscriptionIdleExpiration != time.Minute || d.SubscriptionExpirationCheckInterval != 5*time.Second || d.TopicIdleExpiration != time.Hour || d.HeartbeatInterval != 30*time.Second || d.WriteTimeout != 30*time.Second {
+		t.Fatalf("Wrong default durations: %v", d)
+	}
+	var u Config
+	u.SetDefaults()
+	u.SSE.SubscriptionIdleExpiration = "5m"
+	u.SSE.HeartbeatInterval = "0s"
+	if !dut.UpdateFromRaw(&u) {
+		t.Fatal("UpdateFromRaw failed")
+	}
+	d = dut.SSE.Durations()
+	if d.SubscriptionIdleExpiration != 5*time.Minute || d.HeartbeatInterval != 0 {
+		t.Fatalf("Durations not updated: %v", d)
+	}
+	dut.SSE.WriteTimeout = "-1s"
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with negative WriteTimeout")
+	}
+	dut.SSE.WriteTimeout = "soon"
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with WriteTimeout not a duration")
+	}
+}
+
+func TestMaxConnectionAge(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.MaxConnectionAge = "1h"
+	if err := dut.Validate(); err != nil || dut.SSE.Durations().MaxConnectionAge != time.Hour || dut.SSE.Durations().ReconnectDelay != time.Second {
+		t.Fatalf("Validate() returned %v with MaxConnectionAge 1h", err)
+	}
+	dut.SSE.MaxConnectionAge = "10ms"
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "MaxConnectionAge") {
+		t.Fatalf("Validate() returned %v with MaxConnectionAge 10ms", err)
+	}
+	dut.SSE.MaxConnectionAge = "0s"
+	dut.SSE.ReconnectDelay = "1500us"
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "ReconnectDelay") {
+		t.Fatalf("Validate() returned %v with ReconnectDelay 1500us", err)
+	}
+}
+
+func TestBackpressureMark(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.BackpressureMark = 80
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() returned %v with BackpressureMark 80", err)
+	}
+	dut.SSE.BackpressureMark = 100
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "BackpressureMark") {
+		t.Fatalf("Validate() returned %v with BackpressureMark 100", err)
+	}
+}
+
+func TestValidationAllErrors(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.EventBuffer = 5
+	dut.SSE.SubscriptionIdleExpiration = "soon"
+	dut.SSE.BinaryReadings = "compress"
+	dut.SSE.Pipelines = map[string]PipelineConfig{"b": {}, "a": {}}
+	dut.SSE.Writable.PipelineFunctions = "decompress"
+	err := dut.Validate()
+	if err == nil {
+		t.Fatal("Validate() succeeded with several bad settings")
+	}
+	problems := strings.Split(err.Error(), "\n")
+	expected := []string{
+		"EventBuffer must be at least 10 events",
+		"SubscriptionIdleExpiration must be in the form of a duration, e.g. '30s'",
+		"BinaryReadings must be 'keep', 'summarize' or 'drop'",
+		"Pipeline a must have at least one topic",
+		"Pipeline b must have at least one topic",
+		"PipelineFunctions must end with publish",
+		"PipelineFunctions has unknown function decompress, must be filter-by-device, strip-binary, transform-simple or publish",
+	}
+	if !slices.Equal(problems, expected) {
+		t.Fatalf("Wrong problems reported:\n%s", err.Error())
+	}
+}
+
+func TestLimitMaxima(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	if sublimit, prefixes, buffer := dut.SSE.LimitMaxima(); sublimit != 50 || prefixes != 100 || buffer != 100 {
+		t.Fatalf("Wrong default maxima %d %d %d", sublimit, prefixes, buffer)
+	}
+	dut.SSE.MaxSubscriptionLimit = 200
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() returned %v with MaxSubscriptionLimit 200", err)
+	}
+	if sublimit, _, _ := dut.SSE.LimitMaxima(); sublimit != 200 {
+		t.Fatalf("Wrong subscription limit maximum %d", sublimit)
+	}
+	dut.SSE.MaxEventBuffer = 10
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "MaxEventBuffer") {
+		t.Fatalf("Validate() returned %v with MaxEventBuffer below EventBuffer", err)
+	}
+}
+
+func TestEventsListenAddrs(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	for addr, expected := range map[string][]string{
+		"127.0.0.1":                  {"127.0.0.1:59748"},
+		"0.0.0.0":                    {"0.0.0.0:59748"},
+		"::":                         {"[::]:59748"},
+		"[::1]":                      {"[::1]:59748"},
+		"fe80::1%eth0":               {"[fe80::1%eth0]:59748"},
+		"[::1]:8443":                 {"[::1]:8443"},
+		":8443":                      {":8443"},
+		"127.0.0.1, ::1, [::1]:8443": {"127.0.0.1:59748", "[::1]:59748", "[::1]:8443"},
+	} {
+		dut.SSE.EventsAddr = addr
+		got, err := dut.SSE.EventsListenAddrs()
+		if err != nil || !slices.Equal(got, expected) {
+			t.Fatalf("EventsAddr %q gave %v, %v", addr, got, err)
+		}
+		if err := dut.Validate(); err != nil {
+			t.Fatalf("Validate() returned %v with EventsAddr %q", err, addr)
+		}
+	}
+	for _, bad := range []string{"", "127.0.0.1,", "[::1]:80", "127.0.0.1:http", "::1, [::1]", "not_a_valid_hostname_or_ip:8443"} {
+		dut.SSE.EventsAddr = bad
+		if _, err := dut.SSE.EventsListenAddrs(); err == nil {
+			t.Fatalf("EventsAddr %q accepted", bad)
+		}
+	}
+}
+
+func TestEventsBindRetries(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.EventsBindRetries = 5
+	if err := dut.Validate(); err != nil || dut.SSE.Durations().EventsBindRetryInterval != time.Second {
+		t.Fatalf("Validate() returned %v with EventsBindRetries, interval %v", err, dut.SSE.Durations().EventsBindRetryInterval)
+	}
+	dut.SSE.EventsBindRetryInterval = "0s"
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "EventsBindRetryInterval") {
+		t.Fatalf("Validate() returned %v with a zero EventsBindRetryInterval", err)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	if dut.SSE.EventsRoute() != "/api/v3/events/" || dut.SSE.SubscriptionRoute() != "/api/v3/subscription" || dut.SSE.TriggerRoute() != "/api/v3/trigger" {
+		t.Fatalf("Wrong default routes: %s %s %s", dut.SSE.EventsRoute(), dut.SSE.SubscriptionRoute(), dut.SSE.TriggerRoute())
+	}
+	dut.SSE.SubscriptionPath = "/sse/subscription/"
+	if dut.SSE.SubscriptionRoute() != "/sse/subscription" {
+		t.Fatalf("Wrong subscription route: %s", dut.SSE.SubscriptionRoute())
+	}
+	if dut.SSE.ConnectionsRoute() != "/api/v3/connections" || dut.SSE.HealthRoute() != "/api/v3/health" {
+		t.Fatalf("Wrong default connections or health route: %s %s", dut.SSE.ConnectionsRoute(), dut.SSE.HealthRoute())
+	}
+	if dut.SSE.LimitsRoute() != "/api/v3/limits" {
+		t.Fatalf("Wrong default limits route: %s", dut.SSE.LimitsRoute())
+	}
+	for _, bad := range []string{"", "/", "sse/events", "/sse/:id", "/sse/*"} {
+		dut.SSE.EventsPath = bad
+		if dut.Validate() == nil {
+			t.Fatalf("Validate() succeeded with EventsPath %q", bad)
+		}
+	}
+}
+
+func TestStaticSubscriptions(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.StaticSubscriptions = map[string]StaticSubscriptionConfig{
+		"dashboard": {Include: "edgex/events, edgex/system-events", Exclude: "edgex/events/device/bad", Format: "simple"},
+	}
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed for valid StaticSubscriptions: %v", err)
+	}
+	sub := dut.SSE.StaticSubscriptions["dashboard"]
+	if len(sub.IncludeList()) != 2 || sub.IncludeList()[1] != "edgex/system-events" || len(sub.ExcludeList()) != 1 {
+		t.Fatalf("Wrong include/exclude lists: %v %v", sub.IncludeList(), sub.ExcludeList())
+	}
+	if sub.Options().Format != "simple" || sub.Options().PassThrough {
+		t.Fatalf("Wrong options: %+v", sub.Options())
+	}
+	bad := map[string]StaticSubscriptionConfig{
+		"bad name":  {Include: "edgex/events"},
+		"noinclude": {Include: " , "},
+		"badformat": {Include: "edgex/events", Format: "xml"},
+		"badenvelope": {Include: "edgex/events", Envelope: "soap"},
+		"toomany":   {Include: "a, b, c, d", Exclude: "e"},
+	}
+	dut.SSE.PrefixesLimit = 3
+	for name, sub := range bad {
+		dut.SSE.StaticSubscriptions = map[string]StaticSubscriptionConfig{name: sub}
+		if dut.Validate() == nil {
+			t.Fatalf("Validate() succeeded with bad static subscription %s", name)
+		}
+	}
+	dut.SSE.PrefixesLimit = 100
+	dut.SSE.SubscriptionLimit = 1
+	dut.SSE.StaticSubscriptions = map[string]StaticSubscriptionConfig{"a": {Include: "x"}, "b": {Include: "y"}}
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with more static subscriptions than SubscriptionLimit")
+	}
+}
+
+func TestRoles(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.Roles = map[string]RoleConfig{
+		"admin":    {Identities: "alice, bob", SubscriptionLimit: 100, PrefixesLimit: 1000},
+		"operator": {Identities: "carol", SubscriptionLimit: 10, PrefixesLimit: 10},
+	}
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed for valid Roles: %v", err)
+	}
+	if dut.SSE.RoleOf("bob") != "admin" || dut.SSE.RoleOf("carol") != "operator" || dut.SSE.RoleOf("mallory") != "" || dut.SSE.RoleOf("") != "" {
+		t.Fatal("Wrong roles from RoleOf()")
+	}
+	dut.SSE.AdminRole = "admin"
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed for valid AdminRole: %v", err)
+	}
+	dut.SSE.AdminRole = "superuser"
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with an AdminRole that isn't a role")
+	}
+	dut.SSE.AdminRole = ""
+	if dut.SSE.AllowedTopicList("operator") != nil || dut.SSE.AllowedTopicList("") != nil {
+		t.Fatal("Topics restricted with no AllowedTopics")
+	}
+	dut.SSE.Roles["operator"] = RoleConfig{Identities: "carol", SubscriptionLimit: 10, PrefixesLimit: 10, AllowedTopics: "edgex/events/device/tenant1, edgex/system-events"}
+	dut.SSE.AllowedTopics = "edgex/events/device/public"
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed for valid AllowedTopics: %v", err)
+	}
+	if topics := dut.SSE.AllowedTopicList("operator"); len(topics) != 2 || topics[1] != "edgex/system-events" {
+		t.Fatalf("Wrong AllowedTopicList %v", topics)
+	}
+	if topics := dut.SSE.AllowedTopicList(""); len(topics) != 1 || topics[0] != "edgex/events/device/public" {
+		t.Fatalf("Wrong AllowedTopicList %v for callers not in a role", topics)
+	}
+	dut.SSE.AllowedTopics = "edgex/events/#"
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with a wildcard in AllowedTopics")
+	}
+	dut.SSE.AllowedTopics = ""
+	bad := []map[string]RoleConfig{
+		{"empty": {Identities: " ", SubscriptionLimit: 1, PrefixesLimit: 1}},
+		{"nolimit": {Identities: "alice", PrefixesLimit: 1}},
+		{"wildcard": {Identities: "alice", SubscriptionLimit: 1, PrefixesLimit: 1, AllowedTopics: "edgex/+/device"}},
+		{"a": {Identities: "alice", SubscriptionLimit: 1, PrefixesLimit: 1}, "b": {Identities: "bob, alice", SubscriptionLimit: 1, PrefixesLimit: 1}},
+	}
+	for _, roles := range bad {
+		dut.SSE.Roles = roles
+		if dut.Validate() == nil {
+			t.Fatalf("Validate() succeeded with bad Roles %v", roles)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.Replay = ReplayConfig{Count: 100, Bytes: 65536, MaxAge: "10m"}
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed for valid Replay: %v", err)
+	}
+	retention := dut.SSE.Retention()
+	if retention.Count != 100 || retention.Bytes != 65536 || retention.MaxAge != 10*time.Minute {
+		t.Fatalf("Wrong retention %+v", retention)
+	}
+	bad := []ReplayConfig{
+		{Count: 100, MaxAge: "10m"},
+		{Bytes: 65536, MaxAge: "10m"},
+		{Count: 100, Bytes: 100, MaxAge: "10m"},
+		{Count: 5000, Bytes: 4096, MaxAge: "10m"},
+		{Count: 100, Bytes: 65536, MaxAge: "0s"},
+		{Count: 100, Bytes: 65536, MaxAge: "ten minutes"},
+	}
+	for _, replay := range bad {
+		dut.SSE.Replay = replay
+		if dut.Validate() == nil {
+			t.Fatalf("Validate() succeeded with bad Replay %+v", replay)
+		}
+	}
+}
+
+func TestTrustedProxyList(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.TrustedProxies = "10.0.0.1, 172.16.0.0/12, ::1"
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+	proxies := dut.SSE.TrustedProxyList()
+	if len(proxies) != 3 {
+		t.Fatalf("Wrong proxies %v", proxies)
+	}
+	if proxies[0].String() != "10.0.0.1/32" || proxies[1].String() != "172.16.0.0/12" || proxies[2].String() != "::1/128" {
+		t.Fatalf("Wrong proxies %v", proxies)
+	}
+	for _, bad := range []string{"proxy.local", "10.0.0.0/33", "10.0.0.1, nope"} {
+		dut.SSE.TrustedProxies = bad
+		if dut.Validate() == nil {
+			t.Fatalf("Validate() succeeded with TrustedProxies %q", bad)
+		}
+		if len(dut.SSE.TrustedProxyList()) != 0 {
+			t.Fatalf("Proxies trusted with TrustedProxies %q", bad)
+		}
+	}
+}
+
+func TestStreamTokenLifetime(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	if dut.SSE.Durations().StreamTokenLifetime != time.Minute {
+		t.Fatalf("Wrong default StreamTokenLifetime %v", dut.SSE.Durations().StreamTokenLifetime)
+	}
+	for _, bad := range []string{"0s", "500ms", "2h", "soon"} {
+		dut.SSE.StreamTokenLifetime = bad
+		if dut.Validate() == nil {
+			t.Fatalf("Validate() succeeded with StreamTokenLifetime %q", bad)
+		}
+	}
+}
+
+func TestManagementRate(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.ManagementRate = 0
+	dut.SSE.ManagementBurst = 0
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed with rate limiting off: %v", err)
+	}
+	dut.SSE.ManagementRate = 1
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with a zero ManagementBurst")
+	}
+	dut.SSE.ManagementRate = -1
+	dut.SSE.ManagementBurst = 10
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with a negative ManagementRate")
+	}
+}
+
+func TestLookupLockout(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	if dut.SSE.Durations().LookupLockout != 5*time.Minute {
+		t.Fatalf("Wrong default LookupLockout %v", dut.SSE.Durations().LookupLockout)
+	}
+	dut.SSE.LookupLockout = "0s"
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with a zero LookupLockout")
+	}
+	dut.SSE.LookupFailureLimit = 0
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed with lockouts off: %v", err)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.Tracing.Enabled = true
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed with default tracing: %v", err)
+	}
+	for _, bad := range []string{"", "localhost:4318", "grpc://collector:4317"} {
+		dut.SSE.Tracing.Endpoint = bad
+		if dut.Validate() == nil {
+			t.Fatalf("Validate() succeeded with tracing Endpoint %q", bad)
+		}
+	}
+	dut.SSE.Tracing.Endpoint = "https://collector:4318"
+	dut.SSE.Tracing.SampleRatio = 1.5
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with a SampleRatio above 1")
+	}
+	dut.SSE.Tracing.Enabled = false
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed with tracing disabled: %v", err)
+	}
+}
+
+func TestLogIntervals(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	if d := dut.SSE.Durations(); d.DropWarningInterval != time.Minute || d.ThroughputSummaryInterval != 0 {
+		t.Fatalf("Wrong default intervals %v, %v", d.DropWarningInterval, d.ThroughputSummaryInterval)
+	}
+	dut.SSE.DropWarningInterval = "-1s"
+	dut.SSE.ThroughputSummaryInterval = "-1s"
+	if err := dut.Validate(); err == nil || strings.Count(err.Error(), "must not be negative") != 2 {
+		t.Fatalf("Validate() returned %v with negative intervals", err)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	if dut.SSE.PersistenceBackend != "none" || dut.SSE.Durations().PersistenceWriteDelay != time.Second {
+		t.Fatalf("Wrong default persistence %s, %v", dut.SSE.PersistenceBackend, dut.SSE.Durations().PersistenceWriteDelay)
+	}
+	dut.SSE.PersistenceBackend = PersistenceRedis
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed with default Redis settings: %v", err)
+	}
+	dut.SSE.Redis.Port = 70000
+	dut.SSE.Redis.Key = ""
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "Port") || !strings.Contains(err.Error(), "Key") {
+		t.Fatalf("Validate() returned %v with bad Redis settings", err)
+	}
+	dut.SSE.PersistenceBackend = PersistenceKeeper
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() failed with default Keeper settings: %v", err)
+	}
+	dut.SSE.Keeper.BasePath = "/"
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "BasePath") {
+		t.Fatalf("Validate() returned %v with an empty Keeper BasePath", err)
+	}
+	dut.SSE.PersistenceBackend = PersistenceFile
+	if err := dut.Validate(); err != nil || dut.SSE.Durations().FileSnapshotInterval != 30*time.Second {
+		t.Fatalf("Validate() returned %v with default File settings", err)
+	}
+	dut.SSE.File.SnapshotInterval = "0s"
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "SnapshotInterval") {
+		t.Fatalf("Validate() returned %v with a zero File SnapshotInterval", err)
+	}
+	dut.SSE.PersistenceBackend = "sqlite"
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with an unknown PersistenceBackend")
+	}
+	dut.SSE.PersistenceBackend = PersistenceNone
+	dut.SSE.PersistenceWriteDelay = "-1s"
+	if dut.Validate() == nil {
+		t.Fatal("Validate() succeeded with a negative PersistenceWriteDelay")
+	}
+}
+
+func TestReplication(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.Replication = true
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "PersistenceBackend") || !strings.Contains(err.Error(), "StreamTokenSecretName") {
+		t.Fatalf("Validate() returned %v with Replication and no Redis or stream token secret", err)
+	}
+	dut.SSE.PersistenceBackend = PersistenceRedis
+	dut.SSE.StreamTokenSecretName = "streamtoken"
+	if err := dut.Validate(); err != nil || dut.SSE.Durations().ReplicationSyncInterval != 5*time.Second {
+		t.Fatalf("Validate() returned %v with default Replication settings", err)
+	}
+	dut.SSE.ReplicationSyncInterval = "0s"
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "ReplicationSyncInterval") {
+		t.Fatalf("Validate() returned %v with a zero ReplicationSyncInterval", err)
+	}
+	dut.SSE.ReplicationSyncInterval = dut.SSE.SubscriptionIdleExpiration
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "twice") {
+		t.Fatalf("Validate() returned %v with a ReplicationSyncInterval as long as SubscriptionIdleExpiration", err)
+	}
+}
+
+func TestExpiryNotifications(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.ExpiryNotifications = true
+	if err := dut.Validate(); err != nil || dut.SSE.Durations().ExpiryNotice != 20*time.Second {
+		t.Fatalf("Validate() returned %v with default expiry notification settings", err)
+	}
+	for _, notice := range []string{"5s", "1m"} {
+		dut.SSE.ExpiryNotice = notice
+		if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "ExpiryNotice") {
+			t.Fatalf("Validate() returned %v with ExpiryNotice %s", err, notice)
+		}
+	}
+	dut.SSE.ExpiryNotice = "20s"
+	for grace, ok := range map[string]bool{"0s": true, "1m": true, "1s": false, "-1m": false} {
+		dut.SSE.AgeOutGrace = grace
+		if err := dut.Validate(); (err == nil) != ok || (!ok && !strings.Contains(err.Error(), "AgeOutGrace")) {
+			t.Fatalf("Validate() returned %v with AgeOutGrace %s", err, grace)
+		}
+	}
+	dut.SSE.AgeOutGrace = "0s"
+	dut.SSE.MaxIdleExpiration = "30s"
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "MaxIdleExpiration") {
+		t.Fatalf("Validate() returned %v with MaxIdleExpiration shorter than SubscriptionIdleExpiration", err)
+	}
+	dut.SSE.MaxIdleExpiration = "24h"
+	dut.SSE.NotificationCategory = "edgex sse"
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "NotificationCategory") {
+		t.Fatalf("Validate() returned %v with a NotificationCategory with a space", err)
+	}
+}
+
+func TestAlerts(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.Alerts = true
+	if err := dut.Validate(); err != nil || dut.SSE.Durations().AlertInterval != 15*time.Minute || dut.SSE.Durations().OverflowAlertWindow != time.Minute {
+		t.Fatalf("Validate() returned %v with the default alert settings", err)
+	}
+	dut.SSE.AlertInterval = "-1m"
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "AlertInterval") {
+		t.Fatalf("Validate() returned %v with a negative AlertInterval", err)
+	}
+	dut.SSE.AlertInterval = "0s"
+	dut.SSE.OverflowAlertWindow = "500ms"
+	if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), "OverflowAlertWindow") {
+		t.Fatalf("Validate() returned %v with a 500ms OverflowAlertWindow", err)
+	}
+	dut.SSE.Alerts = false
+	if err := dut.Validate(); err != nil {
+		t.Fatalf("Validate() returned %v with alerts off", err)
+	}
+}
+
+func TestUnitConversions(t *testing.T) {
+	var dut Config
+	dut.SetDefaults()
+	dut.SSE.UnitConversions = map[string]UnitConversionConfig{
+		"fahrenheit-celsius": {From: "degF", To: "degC", Scale: 5.0 / 9, Offset: -160.0 / 9},
+	}
+	if err := dut.Validate(); err != nil || !dut.SSE.ConvertsTo("degC") || dut.SSE.ConvertsTo("degF") {
+		t.Fatalf("Validate() returned %v with a unit conversion", err)
+	}
+	invalid := map[string]UnitConversionConfig{
+		"From and To":  {From: "degF", Scale: 1},
+		"must differ":  {From: "degF", To: "degF", Scale: 1},
+		"Scale":        {From: "degC", To: "K", Offset: 273.15},
+		"both convert": {From: "degF", To: "degC", Scale: 0.5},
+	}
+	for expected, conversion := range invalid {
+		dut.SSE.UnitConversions["other"] = conversion
+		if err := dut.Validate(); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Validate() returned %v, expected %q", err, expected)
+		}
+	}
 }
@@ -0,0 +1,13 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+Package for filters and transforms a build of the service adds for subscriptions' extensions
+option. It is empty here: a downstream fork adds a file of its own to it, registering them
+from its init() function with pkg/extension's RegisterFilter() and RegisterTransform(), and
+keeps its changes out of the rest of the service. main imports it for those.
+*/
+package extensions
@@ -0,0 +1,145 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package for message sources other than the EdgeX message bus
+package external
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"errors"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// How long to wait for the broker when connecting and subscribing
+const mqttTimeout = 30 * time.Second
+
+// Type MessageHandler is called with each message received, in the MQTT client's goroutine.
+type MessageHandler func(topic string, payload []byte)
+
+/*
+Struct MQTTSource subscribes to topics on an external MQTT broker, passing each
+message received to a handler. It reconnects, and re-subscribes, on its own.
+*/
+type MQTTSource struct {
+	lc      logger.LoggingClient
+	config  configuration.ExternalMQTTConfig
+	handler MessageHandler
+	// Guards client and the credentials, which can be replaced while running
+	lock     sync.Mutex
+	client   mqtt.Client
+	username string
+	password string
+}
+
+// Factory function
+func NewMQTTSource(lc logger.LoggingClient, config configuration.ExternalMQTTConfig, handler MessageHandler) *MQTTSource {
+	s := &MQTTSource{}
+	s.lc = lc
+	s.config = config
+	s.handler = handler
+	s.username = config.Username
+	s.password = config.Password
+	return s
+}
+
+// clientOptions (an internal API) builds the MQTT client options from our configuration. Call under lock.
+func (s *MQTTSource) clientOptions() *mqtt.ClientOptions {
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(s.config.BrokerAddress)
+	opts.SetClientID(s.config.ClientId)
+	opts.SetUsername(s.username)
+	opts.SetPassword(s.password)
+	opts.SetAutoReconnect(true)
+	opts.SetConnectRetry(true)
+	opts.SetConnectTimeout(mqttTimeout)
+	opts.SetOnConnectHandler(s.subscribe)
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		s.lc.Warnf("Lost connection to external MQTT broker %s: %s", s.config.BrokerAddress, err.Error())
+	})
+	return opts
+}
+
+// subscribe (an internal API) subscribes to our topics, each time the client connects.
+func (s *MQTTSource) subscribe(client mqtt.Client) {
+	filters := make(map[string]byte)
+	for _, topic := range s.config.TopicList() {
+		filters[topic] = s.config.QoS
+	}
+	token := client.SubscribeMultiple(filters, func(_ mqtt.Client, msg mqtt.Message) {
+		s.handler(msg.Topic(), msg.Payload())
+	})
+	if !token.WaitTimeout(mqttTimeout) {
+		s.lc.Errorf("Timed out subscribing to topics on external MQTT broker %s", s.config.BrokerAddress)
+		return
+	}
+	if token.Error() != nil {
+		s.lc.Errorf("Could not subscribe to topics on external MQTT broker %s: %s", s.config.BrokerAddress, token.Error().Error())
+		return
+	}
+	s.lc.Infof("Subscribed to %v on external MQTT broker %s", s.config.TopicList(), s.config.BrokerAddress)
+}
+
+/*
+Start connects to the broker. If the broker can't be reached yet, the client keeps
+trying in the background and Start does not fail.
+
+Error is returned if already started.
+*/
+func (s *MQTTSource) Start() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.start()
+}
+
+// start (an internal API) is Start, under lock.
+func (s *MQTTSource) start() error {
+	if s.client != nil {
+		return errors.New("external MQTT source already started")
+	}
+	s.client = mqtt.NewClient(s.clientOptions())
+	token := s.client.Connect()
+	if !token.WaitTimeout(mqttTimeout) || token.Error() != nil {
+		s.lc.Warnf("External MQTT broker %s not reachable yet, will keep trying", s.config.BrokerAddress)
+	}
+	return nil
+}
+
+// Stop disconnects from the broker.
+func (s *MQTTSource) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stop()
+}
+
+// stop (an internal API) is Stop, under lock.
+func (s *MQTTSource) stop() {
+	if s.client == nil {
+		return
+	}
+	s.client.Disconnect(250)
+	s.client = nil
+}
+
+/*
+SetCredentials replaces the username and password, e.g. when they are rotated in the
+secret provider. If started, the source reconnects to the broker with them.
+*/
+func (s *MQTTSource) SetCredentials(username, password string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.username = username
+	s.password = password
+	if s.client == nil {
+		return
+	}
+	s.lc.Infof("Reconnecting to external MQTT broker %s with new credentials", s.config.BrokerAddress)
+	s.stop()
+	_ = s.start()
+}
@@ -0,0 +1,53 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package external
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+func TestClientOptions(t *testing.T) {
+	cfg := configuration.ExternalMQTTConfig{
+		Enabled:       true,
+		BrokerAddress: "tcp://broker:1883",
+		ClientId:      "sse-test",
+		Topics:        "factory/#, plant/+/status",
+		QoS:           1,
+		Username:      "user",
+		Password:      "secret",
+	}
+	dut := NewMQTTSource(logger.NewMockClient(), cfg, func(string, []byte) {})
+	opts := dut.clientOptions()
+	if len(opts.Servers) != 1 || opts.Servers[0].String() != "tcp://broker:1883" {
+		t.Fatalf("Wrong broker list: %v", opts.Servers)
+	}
+	if opts.ClientID != "sse-test" || opts.Username != "user" || opts.Password != "secret" {
+		t.Fatalf("Wrong client ID or credentials: %s %s %s", opts.ClientID, opts.Username, opts.Password)
+	}
+	if !opts.AutoReconnect || opts.OnConnect == nil {
+		t.Fatal("Client will not reconnect and re-subscribe")
+	}
+}
+
+func TestStopNotStarted(t *testing.T) {
+	dut := NewMQTTSource(logger.NewMockClient(), configuration.ExternalMQTTConfig{}, func(string, []byte) {})
+	// Must not panic
+	dut.Stop()
+}
+
+func TestSetCredentials(t *testing.T) {
+	cfg := configuration.ExternalMQTTConfig{BrokerAddress: "tcp://broker:1883", Username: "user", Password: "secret"}
+	dut := NewMQTTSource(logger.NewMockClient(), cfg, func(string, []byte) {})
+	dut.SetCredentials("rotated", "newsecret")
+	opts := dut.clientOptions()
+	if opts.Username != "rotated" || opts.Password != "newsecret" {
+		t.Fatalf("Credentials not replaced: %s %s", opts.Username, opts.Password)
+	}
+}
@@ -0,0 +1,107 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"strings"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+)
+
+/*
+StripBinary is a pipeline function that keeps binary readings (e.g. camera frames)
+out of the event streams, according to the BinaryReadings setting of its pipeline:
+
+  keep: Nothing is changed.
+  summarize: Each binary reading's binaryValue is replaced by binarySize, the number
+  of bytes it held. Its mediaType is kept.
+  drop: Binary readings are removed. An event left with no readings is not published.
+
+It handles Events, AddEventRequests, and batches of them, as raw bytes or decoded.
+In keep mode raw bytes are passed along undecoded. Run it before Publish.
+*/
+func (p *Processor) StripBinary(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	mode := p.options(ctx).BinaryReadings
+	if mode == configuration.BinaryReadingsKeep {
+		return true, data
+	}
+	if raw, ok := data.([]byte); ok {
+		data = decodePayload(contentTypeOf(ctx), raw)
+	}
+	data = stringKeys(data)
+	if batch, ok := batchEvents(data); ok {
+		for _, element := range batch {
+			if eventMap, ok := eventOf(element); ok {
+				stripEventBinary(eventMap, mode)
+			}
+		}
+		return true, data
+	}
+	eventMap, ok := eventOf(data)
+	if !ok {
+		return true, data
+	}
+	if stripEventBinary(eventMap, mode) == 0 {
+		p.lc.Debugf("Event from device %v held only binary readings, not publishing it", eventMap["deviceName"])
+		return false, nil
+	}
+	return true, data
+}
+
+// eventOf returns the Event in generic data - either data itself, or the "event" member of an AddEventRequest.
+func eventOf(data any) (map[string]any, bool) {
+	m, ok := data.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	if event, ok := m["event"].(map[string]any); ok {
+		return event, true
+	}
+	if _, ok := m["readings"]; ok {
+		return m, true
+	}
+	return nil, false
+}
+
+// stripEventBinary summarizes or drops the binary readings of an event in place, returning how many readings remain.
+func stripEventBinary(event map[string]any, mode string) int {
+	readings, ok := event["readings"].([]any)
+	if !ok {
+		return 0
+	}
+	kept := make([]any, 0, len(readings))
+	for _, r := range readings {
+		reading, ok := r.(map[string]any)
+		if !ok || reading["valueType"] != common.ValueTypeBinary {
+			kept = append(kept, r)
+			continue
+		}
+		if mode == configuration.BinaryReadingsDrop {
+			continue
+		}
+		reading["binarySize"] = binarySize(reading["binaryValue"])
+		delete(reading, "binaryValue")
+		kept = append(kept, reading)
+	}
+	event["readings"] = kept
+	return len(kept)
+}
+
+// binarySize returns the number of bytes in a binaryValue: base64 text if it came from JSON, bytes if from CBOR.
+func binarySize(value any) int {
+	switch v := value.(type) {
+	case []byte:
+		return len(v)
+	case string:
+		v = strings.TrimSpace(v)
+		return len(v)/4*3 - (len(v) - len(strings.TrimRight(v, "=")))
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,142 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// An event with one binary reading (5 bytes, "hello") and one simple reading
+const binaryEvent = "{\"apiVersion\":\"v3\",\"id\":\"7d3d60c0-5279-436b-b99d-6ab1de0eb600\",\"deviceName\":\"Camera-01\",\"profileName\":\"Camera\",\"sourceName\":\"Frame\",\"origin\":1661535695202033126,\"readings\":[{\"id\":\"b4f7b655-5dac-4f34-8dc7-caa2f8c1a34d\",\"origin\":1661535695202033126,\"deviceName\":\"Camera-01\",\"resourceName\":\"Frame\",\"profileName\":\"Camera\",\"valueType\":\"Binary\",\"binaryValue\":\"aGVsbG8=\",\"mediaType\":\"image/jpeg\"},{\"id\":\"c4f7b655-5dac-4f34-8dc7-caa2f8c1a34d\",\"origin\":1661535695202033126,\"deviceName\":\"Camera-01\",\"resourceName\":\"Exposure\",\"profileName\":\"Camera\",\"valueType\":\"Uint32\",\"value\":\"74\"}]}"
+
+// strip runs data through StripBinary, returning whether the pipeline continues and the result
+func (tp *testProcessor) strip(data any) (bool, any) {
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	return tp.proc.StripBinary(ctx, data)
+}
+
+func TestStripBinaryKeep(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	cont, result := tp.strip(jsonData(t, binaryEvent))
+	if !cont {
+		t.Fatal("StripBinary stopped the pipeline in keep mode")
+	}
+	text, _ := json.Marshal(result)
+	if !strings.Contains(string(text), "aGVsbG8=") {
+		t.Fatalf("Binary value removed in keep mode: %s", text)
+	}
+}
+
+func TestStripBinarySummarize(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.BinaryReadings = configuration.BinaryReadingsSummarize
+	cont, result := tp.strip(jsonData(t, "{\"apiVersion\":\"v3\",\"event\":"+binaryEvent+"}"))
+	if !cont {
+		t.Fatal("StripBinary stopped the pipeline in summarize mode")
+	}
+	msgs := tp.publish(t, "edgex/events/device/Camera/Camera-01/Frame", result)
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Summarized event not delivered as an edgex event: %v", msgs)
+	}
+	if strings.Contains(msgs[0].Payload, "aGVsbG8=") || !strings.Contains(msgs[0].Payload, "\"binarySize\":5") || !strings.Contains(msgs[0].Payload, "image/jpeg") {
+		t.Fatalf("Binary reading not summarized: %s", msgs[0].Payload)
+	}
+}
+
+func TestStripBinaryDrop(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.BinaryReadings = configuration.BinaryReadingsDrop
+	cont, result := tp.strip(jsonData(t, binaryEvent))
+	if !cont {
+		t.Fatal("StripBinary stopped the pipeline with a simple reading left")
+	}
+	event := result.(map[string]any)
+	readings := event["readings"].([]any)
+	if len(readings) != 1 || readings[0].(map[string]any)["resourceName"] != "Exposure" {
+		t.Fatalf("Binary reading not dropped: %v", readings)
+	}
+	// Only a binary reading: nothing left to publish
+	onlyBinary := jsonData(t, binaryEvent).(map[string]any)
+	onlyBinary["readings"] = onlyBinary["readings"].([]any)[:1]
+	cont, _ = tp.strip(onlyBinary)
+	if cont {
+		t.Fatal("StripBinary continued the pipeline with no readings left")
+	}
+}
+
+func TestStripBinaryPerPipeline(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.Pipelines = map[string]configuration.PipelineConfig{"camera": {Topics: "cameras/#", BinaryReadings: configuration.BinaryReadingsSummarize}}
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	ctx.AddValue(interfaces.PIPELINEID, "camera")
+	_, result := tp.proc.StripBinary(ctx, []byte(binaryEvent))
+	text, _ := json.Marshal(result)
+	if strings.Contains(string(text), "aGVsbG8=") {
+		t.Fatalf("Binary value kept in pipeline set to summarize: %s", text)
+	}
+	ctx.AddValue(interfaces.PIPELINEID, "default-pipeline")
+	_, result = tp.proc.StripBinary(ctx, []byte(binaryEvent))
+	if _, ok := result.([]byte); !ok {
+		t.Fatalf("Default pipeline did not pass raw bytes along in keep mode: %T", result)
+	}
+}
+
+func TestMediaOption(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	subid, _ := tp.subs.NewSubscription()
+	subinfo := tp.subs.Subscription(subid)
+	if err := tp.subs.Include(subinfo, "edgex/events"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	if err := tp.subs.SetOptions(subinfo, submgr.SubscriptionOptions{Media: true}); err != nil {
+		t.Fatalf("Could not set options: %v", err)
+	}
+	tp.subs.SetActive(subinfo, true)
+	mediaChan, _ := tp.subs.ReceiveChannel(subinfo)
+
+	msgs := tp.publish(t, "edgex/events/device/Camera/Camera-01/Frame", []byte(binaryEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" || !strings.Contains(msgs[0].Payload, "aGVsbG8=") {
+		t.Fatalf("Expected the Event as it is without the option, got %v", msgs)
+	}
+	var got []submgr.ChannelMessage
+	for len(mediaChan) > 0 {
+		got = append(got, <-mediaChan)
+	}
+	if len(got) != 2 || got[0].EventType != "edgex" || got[1].EventType != MediaEventType {
+		t.Fatalf("Expected the Event then a media event, got %v", got)
+	}
+	if strings.Contains(got[0].Payload, "aGVsbG8=") || !strings.Contains(got[0].Payload, "\"binarySize\":5") || !strings.Contains(got[0].Payload, "\"value\":\"74\"") {
+		t.Fatalf("Binary value not taken out of the Event: %s", got[0].Payload)
+	}
+	var media dtos.MediaEvent
+	if err := json.Unmarshal([]byte(got[1].Payload), &media); err != nil {
+		t.Fatalf("Media event %s: %v", got[1].Payload, err)
+	}
+	expected := dtos.MediaEvent{EventId: "7d3d60c0-5279-436b-b99d-6ab1de0eb600", Id: "b4f7b655-5dac-4f34-8dc7-caa2f8c1a34d", DeviceName: "Camera-01", ResourceName: "Frame", MediaType: "image/jpeg", Data: "aGVsbG8="}
+	// The origin goes through a float64 when the Event is decoded
+	if media.Origin/1000000 != 1661535695202 {
+		t.Fatalf("Wrong media event origin %d", media.Origin)
+	}
+	media.Origin = 0
+	if media != expected {
+		t.Fatalf("Wrong media event %+v", media)
+	}
+}
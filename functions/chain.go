@@ -0,0 +1,167 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+)
+
+// Struct functionChain is the current PipelineFunctions, with what the functions need from Writable.
+type functionChain struct {
+	functions []string
+	devices   map[string]bool
+	writable  configuration.WritableConfig
+}
+
+// Type simplePayload is an Event already flattened by TransformSimple, delivered by Publish as it is.
+type simplePayload []byte
+
+/*
+SetPipelineFunctions replaces the functions Pipeline runs, and the devices FilterByDevice
+lets through, from the Writable configuration. Messages already in the pipeline finish with
+the functions they started with.
+
+Error is returned, and nothing changed, if the configuration is not valid.
+*/
+func (p *Processor) SetPipelineFunctions(writable configuration.WritableConfig) error {
+	if err := writable.Validate(); err != nil {
+		return err
+	}
+	chain := functionChain{functions: writable.FunctionList(), devices: make(map[string]bool), writable: writable}
+	for _, device := range writable.DeviceList() {
+		chain.devices[device] = true
+	}
+	p.chain.Store(&chain)
+	return nil
+}
+
+// Writable returns the Writable configuration the functions were last set from.
+func (p *Processor) Writable() configuration.WritableConfig {
+	return p.chain.Load().writable
+}
+
+// function returns the pipeline function with the given PipelineFunctions name.
+func (p *Processor) function(name string) interfaces.AppFunction {
+	switch name {
+	case configuration.FunctionFilterByDevice:
+		return p.FilterByDevice
+	case configuration.FunctionStripBinary:
+		return p.StripBinary
+	case configuration.FunctionTransformSimple:
+		return p.TransformSimple
+	default:
+		return p.Publish
+	}
+}
+
+/*
+Pipeline is the pipeline function the service registers. It decompresses compressed
+payloads, then runs each message through the functions in PipelineFunctions, in order,
+so they can change without restarting.
+*/
+func (p *Processor) Pipeline(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	p.delivery.lastFromBus.Store(time.Now().UnixNano())
+	return p.runFunctions(ctx, data)
+}
+
+// runFunctions (an internal API) is Pipeline, for messages from the bus or elsewhere.
+func (p *Processor) runFunctions(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	p.delivery.received.Add(1)
+	span := startPipelineSpan(ctx)
+	defer span.End()
+	data = p.decompress(ctx, data)
+	chain := p.chain.Load()
+	for _, name := range chain.functions {
+		cont, result := p.function(name)(ctx, data)
+		if !cont {
+			return false, result
+		}
+		data = result
+	}
+	return true, data
+}
+
+/*
+FilterByDevice is a pipeline function that only lets through Events, and AddEventRequests,
+from the devices in FilterDevices. Elements of batches are filtered one by one. Messages
+that are not Events are let through.
+*/
+func (p *Processor) FilterByDevice(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	devices := p.chain.Load().devices
+	if raw, ok := data.([]byte); ok {
+		data = decodePayload(contentTypeOf(ctx), raw)
+	}
+	data = stringKeys(data)
+	if batch, ok := batchEvents(data); ok {
+		kept := make([]any, 0, len(batch))
+		for _, element := range batch {
+			if eventMap, ok := eventOf(element); !ok || devices[deviceNameOf(eventMap)] {
+				kept = append(kept, element)
+			}
+		}
+		if len(kept) == 0 {
+			return false, nil
+		}
+		return true, kept
+	}
+	eventMap, ok := eventOf(data)
+	if !ok {
+		return true, data
+	}
+	if !devices[deviceNameOf(eventMap)] {
+		p.lc.Tracef("Event from device %v filtered out", eventMap["deviceName"])
+		return false, nil
+	}
+	return true, data
+}
+
+// deviceNameOf returns the deviceName of a generic Event, or "" if it has none.
+func deviceNameOf(event map[string]any) string {
+	name, _ := event["deviceName"].(string)
+	return name
+}
+
+/*
+TransformSimple is a pipeline function that flattens Events, and the Events of
+AddEventRequests, into the "simple" format for all subscriptions. Batches, and
+messages that are not valid Events, are let through unchanged. Events from suppressed
+devices stop there, as they would not be delivered.
+*/
+func (p *Processor) TransformSimple(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if raw, ok := data.([]byte); ok {
+		data = decodePayload(contentTypeOf(ctx), raw)
+	}
+	data = stringKeys(data)
+	eventMap, ok := eventOf(data)
+	if !ok {
+		return true, data
+	}
+	p.addUnits(ctx, eventMap)
+	event, _, err := decodeEvent(eventMap)
+	if err != nil {
+		return true, data
+	}
+	if p.suppressed(ctx, event.DeviceName) {
+		return false, nil
+	}
+	simple_bytes, err := simplifyEvent(event, nil)
+	if err != nil {
+		p.lc.Errorf("Could not marshal simple Event from device %s: %s", event.DeviceName, err.Error())
+		return true, data
+	}
+	return true, simplePayload(simple_bytes)
+}
+
+// deliverSimple sends an Event TransformSimple flattened to all the subscriptions in chanlist.
+func (p *Processor) deliverSimple(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, simple simplePayload) {
+	p.validation.valid.Add(1)
+	p.deliver(ctx, chanlist, topic, submgr.ChannelMessage{EventType: "simple", Payload: string(simple)})
+}
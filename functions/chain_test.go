@@ -0,0 +1,95 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// process runs payload through the Processor's pipeline functions, returning what was delivered
+func (tp *testProcessor) process(topic string, payload string) []submgr.ChannelMessage {
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	tp.proc.Process(ctx, topic, []byte(payload))
+	rv := make([]submgr.ChannelMessage, 0)
+	for {
+		select {
+		case msg := <-tp.rxchan:
+			rv = append(rv, msg)
+		default:
+			return rv
+		}
+	}
+}
+
+func TestSetPipelineFunctions(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	if tp.proc.SetPipelineFunctions(configuration.WritableConfig{PipelineFunctions: "publish, strip-binary"}) == nil {
+		t.Fatal("SetPipelineFunctions succeeded without publish at the end")
+	}
+	// Still the default functions
+	if writable := tp.proc.Writable(); writable != tp.cfg.SSE.Writable {
+		t.Fatalf("Writable is %+v, expected the configured %+v", writable, tp.cfg.SSE.Writable)
+	}
+	msgs := tp.process("edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", edgexEvent)
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event, got %v", msgs)
+	}
+}
+
+func TestFilterByDevice(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	err := tp.proc.SetPipelineFunctions(configuration.WritableConfig{PipelineFunctions: "filter-by-device, publish", FilterDevices: "Virtual-Bacon-Cape-04"})
+	if err != nil {
+		t.Fatalf("SetPipelineFunctions failed: %v", err)
+	}
+	msgs := tp.process("edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", edgexEvent)
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected the listed device's event, got %v", msgs)
+	}
+	err = tp.proc.SetPipelineFunctions(configuration.WritableConfig{PipelineFunctions: "filter-by-device, publish", FilterDevices: "Random-Integer-Device"})
+	if err != nil {
+		t.Fatalf("SetPipelineFunctions failed: %v", err)
+	}
+	msgs = tp.process("edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", edgexEvent)
+	if len(msgs) != 0 {
+		t.Fatalf("Expected an unlisted device's event to be filtered out, got %v", msgs)
+	}
+	otherEvent := strings.ReplaceAll(edgexEvent, "Virtual-Bacon-Cape-04", "Random-Integer-Device")
+	msgs = tp.process("edgex/events/device", "["+edgexEvent+", "+otherEvent+"]")
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Payload, "Random-Integer-Device") {
+		t.Fatalf("Expected only the listed device's batch element, got %v", msgs)
+	}
+	msgs = tp.process("factory/line1/press", "{\"pressure\":3.2}")
+	if len(msgs) != 1 || msgs[0].Payload != "{\"pressure\":3.2}" {
+		t.Fatalf("Expected a non-event to pass the filter, got %v", msgs)
+	}
+}
+
+func TestTransformSimple(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	err := tp.proc.SetPipelineFunctions(configuration.WritableConfig{PipelineFunctions: "strip-binary, transform-simple, publish"})
+	if err != nil {
+		t.Fatalf("SetPipelineFunctions failed: %v", err)
+	}
+	msgs := tp.process("edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", edgexEvent)
+	if len(msgs) != 1 || msgs[0].EventType != "simple" || !strings.HasPrefix(msgs[0].Payload, "[{\"device\":\"Virtual-Bacon-Cape-04\",\"resource\":\"mPercentLoad\",\"value\":\"74\",\"valueType\":\"Uint32\",") {
+		t.Fatalf("Expected one simple event, got %v", msgs)
+	}
+	msgs = tp.process("factory/line1/press", "{\"pressure\":3.2}")
+	if len(msgs) != 1 || msgs[0].EventType != "" {
+		t.Fatalf("Expected a non-event to be left alone, got %v", msgs)
+	}
+}
@@ -0,0 +1,117 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Context key for the content type of a decompressed payload, which replaces the input content type
+const decompressedContentType = "sse-decompressed-content-type"
+
+// Limit on the size of a decompressed payload, so a small message can't take all our memory
+const maxDecompressedSize = 64 * 1024 * 1024
+
+// contentTypeOf returns the content type of the message in the pipeline, without parameters.
+func contentTypeOf(ctx interfaces.AppFunctionContext) string {
+	if contentType, ok := ctx.GetValue(decompressedContentType); ok {
+		return contentType
+	}
+	return mediaType(ctx.InputContentType())
+}
+
+/*
+decompress detects payloads compressed by the SDK's CompressWithGZIP and CompressWithZLIB
+transforms - base64 of the compressed bytes, usually as text/plain - or plain gzip or zlib
+bytes, and returns them decompressed. The content type of the result is recorded in the
+context for contentTypeOf: JSON or CBOR if it is valid as such, otherwise unchanged.
+
+Other data is returned as it is.
+*/
+func (p *Processor) decompress(ctx interfaces.AppFunctionContext, data any) any {
+	raw, ok := data.([]byte)
+	if !ok {
+		return data
+	}
+	compressed := raw
+	if !isCompressed(compressed) {
+		// Cheap check of the first base64 quantum before decoding the lot
+		if len(raw) < 4 {
+			return data
+		}
+		head := make([]byte, 3)
+		if n, err := base64.StdEncoding.Decode(head, raw[:4]); err != nil || !isCompressed(head[:n]) {
+			return data
+		}
+		compressed = make([]byte, base64.StdEncoding.DecodedLen(len(raw)))
+		n, err := base64.StdEncoding.Decode(compressed, bytes.TrimSpace(raw))
+		if err != nil {
+			return data
+		}
+		compressed = compressed[:n]
+	}
+	decompressed, err := inflate(compressed)
+	if err != nil {
+		p.lc.Debugf("Payload looked compressed but did not decompress: %s", err.Error())
+		return data
+	}
+	contentType := contentTypeOf(ctx)
+	var v any
+	if json.Valid(decompressed) {
+		contentType = common.ContentTypeJSON
+	} else if cbor.Unmarshal(decompressed, &v) == nil {
+		contentType = common.ContentTypeCBOR
+	}
+	ctx.AddValue(decompressedContentType, contentType)
+	p.lc.Tracef("Decompressed %d byte payload to %d bytes of %s", len(raw), len(decompressed), contentType)
+	return decompressed
+}
+
+// isCompressed returns true if b starts with a gzip or zlib header.
+func isCompressed(b []byte) bool {
+	if len(b) < 2 {
+		return false
+	}
+	if b[0] == 0x1f && b[1] == 0x8b {
+		return true
+	}
+	// zlib: deflate with a window of at most 32K, and a header checksum
+	return b[0]&0x0f == 8 && b[0]>>4 <= 7 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
+
+// inflate decompresses gzip or zlib bytes, up to maxDecompressedSize.
+func inflate(compressed []byte) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	if compressed[0] == 0x1f {
+		reader, err = gzip.NewReader(bytes.NewReader(compressed))
+	} else {
+		reader, err = zlib.NewReader(bytes.NewReader(compressed))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decompressed) > maxDecompressedSize {
+		return nil, errors.New("decompressed payload too large")
+	}
+	return decompressed, nil
+}
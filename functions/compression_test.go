@@ -0,0 +1,41 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/transforms"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+func TestDecompress(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	compression := transforms.NewCompression()
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	_, gzipped := compression.CompressWithGZIP(ctx, []byte(edgexEvent))
+	_, zlibbed := compression.CompressWithZLIB(ctx, []byte(edgexEvent))
+	var raw bytes.Buffer
+	writer := gzip.NewWriter(&raw)
+	writer.Write([]byte(edgexEvent))
+	writer.Close()
+	for name, payload := range map[string][]byte{"gzip": gzipped.([]byte), "zlib": zlibbed.([]byte), "raw gzip": raw.Bytes()} {
+		msgs := tp.process("edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", string(payload))
+		if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+			t.Fatalf("Expected one edgex event from %s payload, got %v", name, msgs)
+		}
+	}
+	// Text that only starts like base64 gzip is left alone
+	msgs := tp.process("notes", "H4sI is how gzip looks in base64")
+	if len(msgs) != 1 || msgs[0].EventType != "raw" {
+		t.Fatalf("Expected one raw event, got %v", msgs)
+	}
+}
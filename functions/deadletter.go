@@ -0,0 +1,85 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+)
+
+// Reasons messages are dead-lettered, also the last level of the dead-letter topic
+const (
+	// A subscription's buffer was full
+	DeadLetterOverflow = "overflow"
+	// The message could not be converted to a subscription's format or envelope
+	DeadLetterTransformFailed = "transform-failed"
+	// The message looked like an Event but failed validation, and InvalidEvents is drop
+	DeadLetterInvalid = "invalid"
+	// The Event's readings don't match its device profile, and ProfileValidation is drop
+	DeadLetterProfileViolation = "profile-violation"
+)
+
+// Type DeadLetterPublisher publishes to the message bus, like the SDK's ApplicationService.PublishWithTopic.
+type DeadLetterPublisher func(topic string, data any, contentType string) error
+
+// Struct deadLetter is what is republished for a dropped message.
+type deadLetter struct {
+	Reason        string `json:"reason"`
+	Error         string `json:"error,omitempty"`
+	Topic         string `json:"topic"`
+	EventType     string `json:"eventType,omitempty"`
+	// Number of subscriptions the message was not delivered to
+	Subscriptions int    `json:"subscriptions"`
+	Payload       any    `json:"payload"`
+}
+
+// SetDeadLetterPublisher sets how dropped messages are republished, if DeadLetterTopic is set.
+func (p *Processor) SetDeadLetterPublisher(publish DeadLetterPublisher) {
+	p.deadLetters = publish
+}
+
+/*
+deadLetter republishes a message that was dropped on its way to subscriptions to
+DeadLetterTopic/reason, with the reason, its topic and what went wrong, so it doesn't
+disappear silently. Messages on the dead-letter topic itself are never dead-lettered,
+so a trigger that also subscribes to it can't make a loop.
+*/
+func (p *Processor) deadLetter(reason string, topic any, msg submgr.ChannelMessage, subscriptions int, cause error) {
+	deadLetterTopic := strings.Trim(p.config.SSE.DeadLetterTopic, "/")
+	if deadLetterTopic == "" || p.deadLetters == nil {
+		return
+	}
+	topicText := fmt.Sprint(topic)
+	if strings.Contains("/"+topicText+"/", "/"+deadLetterTopic+"/") {
+		return
+	}
+	letter := deadLetter{
+		Reason:        reason,
+		Topic:         topicText,
+		EventType:     msg.EventType,
+		Subscriptions: subscriptions,
+		Payload:       msg.Payload,
+	}
+	if cause != nil {
+		letter.Error = cause.Error()
+	}
+	if json.Valid([]byte(msg.Payload)) {
+		letter.Payload = json.RawMessage(msg.Payload)
+	}
+	letter_bytes, err := json.Marshal(letter)
+	if err != nil {
+		p.lc.Errorf("Could not marshal dead letter for topic %s: %s", topicText, err.Error())
+		return
+	}
+	if err := p.deadLetters(deadLetterTopic+"/"+reason, letter_bytes, common.ContentTypeJSON); err != nil {
+		p.lc.Warnf("Could not publish dead letter for topic %s: %s", topicText, err.Error())
+	}
+}
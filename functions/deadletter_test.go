@@ -0,0 +1,82 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"encoding/json"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// Struct published is one message given to a DeadLetterPublisher
+type published struct {
+	topic string
+	data  []byte
+}
+
+// capture sets up a DeadLetterPublisher that records what it is given
+func (tp *testProcessor) capture() *[]published {
+	rv := make([]published, 0)
+	tp.proc.SetDeadLetterPublisher(func(topic string, data any, contentType string) error {
+		rv = append(rv, published{topic: topic, data: data.([]byte)})
+		return nil
+	})
+	return &rv
+}
+
+func TestDeadLetterOverflow(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.DeadLetterTopic = "sse/dead-letter"
+	letters := tp.capture()
+	// The test subscription buffers 10 messages, nobody reads them
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	for i := 0; i < 11; i++ {
+		tp.proc.Process(ctx, "factory/line1/press", []byte("{\"pressure\":3.2}"))
+	}
+	if len(*letters) != 1 || (*letters)[0].topic != "sse/dead-letter/overflow" {
+		t.Fatalf("Expected one overflow dead letter, got %v", *letters)
+	}
+	var letter map[string]any
+	if err := json.Unmarshal((*letters)[0].data, &letter); err != nil {
+		t.Fatalf("Dead letter is not JSON: %s", (*letters)[0].data)
+	}
+	payload, ok := letter["payload"].(map[string]any)
+	if letter["reason"] != "overflow" || letter["topic"] != "factory/line1/press" || letter["subscriptions"] != float64(1) || !ok || payload["pressure"] != 3.2 {
+		t.Fatalf("Wrong dead letter: %s", (*letters)[0].data)
+	}
+	// Never for messages on the dead-letter topic
+	for i := 0; i < 2; i++ {
+		tp.proc.Process(ctx, "sse/dead-letter/overflow", (*letters)[0].data)
+	}
+	if len(*letters) != 1 {
+		t.Fatalf("Dead-lettered a dead letter: %v", *letters)
+	}
+}
+
+func TestDeadLetterInvalid(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.InvalidEvents = configuration.InvalidEventsDrop
+	letters := tp.capture()
+	msgs := tp.publish(t, "edgex/events/device/P/D/S", jsonData(t, invalidEvent))
+	if len(msgs) != 0 || len(*letters) != 0 {
+		t.Fatalf("Expected the invalid event dropped without a dead-letter topic, got %v and %v", msgs, *letters)
+	}
+	tp.cfg.SSE.DeadLetterTopic = "sse/dead-letter"
+	msgs = tp.publish(t, "edgex/events/device/P/D/S", jsonData(t, invalidEvent))
+	if len(msgs) != 0 || len(*letters) != 1 || (*letters)[0].topic != "sse/dead-letter/invalid" {
+		t.Fatalf("Expected one invalid dead letter, got %v and %v", msgs, *letters)
+	}
+	var letter map[string]any
+	if err := json.Unmarshal((*letters)[0].data, &letter); err != nil || letter["error"] == nil || letter["error"] == "" {
+		t.Fatalf("Dead letter without the validation error: %s", (*letters)[0].data)
+	}
+}
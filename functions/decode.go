@@ -0,0 +1,93 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Content type used in wrappedPayload for binary payloads that claimed to be JSON
+const contentTypeBinary = "application/octet-stream"
+
+/*
+Decode is a pipeline function that un-marshals a message's raw bytes according to its
+content type, JSON or CBOR, into the generic form the other pipeline functions work on.
+
+The default pipeline doesn't need it: Publish and StripBinary decode lazily, only when a
+message has to be looked at. It is for pipelines with functions that expect decoded data.
+
+Payloads that don't decode (plain text, binary) are not dropped: they become a wrappedPayload,
+which Publish delivers as JSON with the text, or the base64 of the bytes.
+*/
+func (p *Processor) Decode(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	raw, ok := data.([]byte)
+	if !ok {
+		// Already decoded
+		return true, data
+	}
+	return true, decodePayload(contentTypeOf(ctx), raw)
+}
+
+// mediaType returns a content type without its parameters, e.g. "application/json" for "application/json; charset=utf-8".
+func mediaType(contentType string) string {
+	return strings.TrimSpace(strings.Split(contentType, ";")[0])
+}
+
+// decodePayload un-marshals raw bytes of the given content type, or wraps them if they don't decode.
+func decodePayload(contentType string, raw []byte) any {
+	var decoded any
+	switch contentType {
+	case common.ContentTypeCBOR:
+		if err := cbor.Unmarshal(raw, &decoded); err == nil {
+			return decoded
+		}
+	case common.ContentTypeJSON, "":
+		if err := json.Unmarshal(raw, &decoded); err == nil {
+			return decoded
+		}
+	}
+	return wrapPayload(contentType, raw)
+}
+
+/*
+mightBeBatch is a cheap check of raw bytes for whether they could hold a batch of events
+(see batchEvents), so only those are decoded before matching. False positives only cost
+a decode.
+*/
+func mightBeBatch(contentType string, raw []byte) bool {
+	if contentType == common.ContentTypeCBOR {
+		// Major type 4 is an array
+		return (len(raw) > 0 && raw[0]>>5 == 4) || bytes.Contains(raw, []byte("events"))
+	}
+	trimmed := bytes.TrimLeft(raw, " \t\r\n")
+	return (len(trimmed) > 0 && trimmed[0] == '[') || bytes.Contains(raw, []byte("\"events\""))
+}
+
+/*
+wrapPayload wraps a payload we can't decode. Valid UTF-8 is kept as text, with content type
+text/plain if the message claimed to be JSON; anything else is base64 encoded.
+*/
+func wrapPayload(contentType string, raw []byte) wrappedPayload {
+	if utf8.Valid(raw) {
+		if contentType == common.ContentTypeJSON || contentType == "" {
+			contentType = common.ContentTypeText
+		}
+		return wrappedPayload{ContentType: contentType, Text: string(raw)}
+	}
+	if contentType == common.ContentTypeJSON || contentType == "" {
+		contentType = contentTypeBinary
+	}
+	return wrappedPayload{ContentType: contentType, Base64: base64.StdEncoding.EncodeToString(raw)}
+}
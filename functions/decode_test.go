@@ -0,0 +1,71 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// decodeAndPublish runs raw bytes through Decode then Publish, as the pipeline would
+func (tp *testProcessor) decodeAndPublish(t *testing.T, topic string, raw []byte) []submgr.ChannelMessage {
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	cont, data := tp.proc.Decode(ctx, raw)
+	if !cont {
+		t.Fatal("Decode stopped the pipeline")
+	}
+	return tp.publish(t, topic, data)
+}
+
+func TestDecodeJson(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	msgs := tp.decodeAndPublish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event, got %v", msgs)
+	}
+	msgs = tp.decodeAndPublish(t, "sensors/count", []byte("42"))
+	if len(msgs) != 1 || msgs[0].EventType != "" || msgs[0].Payload != "42" {
+		t.Fatalf("Expected one generic event with a bare number, got %v", msgs)
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	msgs := tp.decodeAndPublish(t, "legacy/sensor/3", []byte("T=21.5;H=40"))
+	if len(msgs) != 1 || msgs[0].EventType != "raw" {
+		t.Fatalf("Expected one raw event, got %v", msgs)
+	}
+	var wrapped wrappedPayload
+	if err := json.Unmarshal([]byte(msgs[0].Payload), &wrapped); err != nil {
+		t.Fatalf("Raw event is not JSON: %s", msgs[0].Payload)
+	}
+	if wrapped.ContentType != "text/plain" || wrapped.Text != "T=21.5;H=40" || wrapped.Base64 != "" {
+		t.Fatalf("Wrong raw text envelope: %v", wrapped)
+	}
+}
+
+func TestDecodeBinary(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	msgs := tp.decodeAndPublish(t, "legacy/sensor/4", []byte{0xff, 0x00, 0xfe, 0x01})
+	if len(msgs) != 1 || msgs[0].EventType != "raw" {
+		t.Fatalf("Expected one raw event, got %v", msgs)
+	}
+	var wrapped wrappedPayload
+	if err := json.Unmarshal([]byte(msgs[0].Payload), &wrapped); err != nil {
+		t.Fatalf("Raw event is not JSON: %s", msgs[0].Payload)
+	}
+	if wrapped.ContentType != "application/octet-stream" || wrapped.Base64 != "/wD+AQ==" || wrapped.Text != "" {
+		t.Fatalf("Wrong raw binary envelope: %v", wrapped)
+	}
+}
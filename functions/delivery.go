@@ -0,0 +1,119 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Struct DeliveryCounts counts messages on their way from the message bus to subscriptions.
+type DeliveryCounts struct {
+	// Run through the pipeline functions
+	Received uint64
+	// Matched by at least one subscription. Events in batches count one by one.
+	Matched uint64
+	// Sent to a subscription, once per subscription
+	Delivered uint64
+	// Not sent to a subscription because its buffer, or the DeliveryQueue, was full, once per subscription
+	Dropped uint64
+	// Events not delivered because their device is Locked or Down, with SuppressDisabledDevices
+	Suppressed uint64
+}
+
+// Struct deliveryCounters holds the live counts behind DeliveryCounts.
+type deliveryCounters struct {
+	received   atomic.Uint64
+	matched    atomic.Uint64
+	delivered  atomic.Uint64
+	dropped    atomic.Uint64
+	suppressed atomic.Uint64
+	// UnixNano of the last message from the message bus, or of when counting started
+	lastFromBus atomic.Int64
+}
+
+// Struct dropWarnings rate-limits the warnings about events dropped for full subscription buffers.
+type dropWarnings struct {
+	lock sync.Mutex
+	// When the last warning was logged
+	last time.Time
+	// Events dropped since then, for the next warning
+	dropped uint64
+}
+
+// DeliveryCounts returns the counts of messages received and delivered since the service started.
+func (p *Processor) DeliveryCounts() DeliveryCounts {
+	return DeliveryCounts{
+		Received:   p.delivery.received.Load(),
+		Matched:    p.delivery.matched.Load(),
+		Delivered:  p.delivery.delivered.Load(),
+		Dropped:    p.delivery.dropped.Load(),
+		Suppressed: p.delivery.suppressed.Load(),
+	}
+}
+
+/*
+LastFromBus returns when the last message came from the message bus, through Pipeline(),
+or when the processor was created if none has yet. Messages passed to Process() don't count.
+*/
+func (p *Processor) LastFromBus() time.Time {
+	return time.Unix(0, p.delivery.lastFromBus.Load())
+}
+
+// subscribedChannels (an internal API) is SubscriptionManager.SubscribedChannels, counting and tracing matches.
+func (p *Processor) subscribedChannels(ctx interfaces.AppFunctionContext, topic string) []submgr.SendHandle {
+	_, span := startSpan(ctx, "sse.match", attribute.String("sse.topic", topic))
+	defer span.End()
+	chanlist := p.subscriptions.SubscribedChannels(topic)
+	span.SetAttributes(attribute.Int("sse.subscriptions", len(chanlist)))
+	if len(chanlist) > 0 {
+		p.delivery.matched.Add(1)
+	}
+	return chanlist
+}
+
+/*
+suppressed (an internal API) returns true, counting it, if an Event is not to be delivered
+because SuppressDisabledDevices is enabled and core-metadata has its device as Locked or Down.
+*/
+func (p *Processor) suppressed(ctx interfaces.AppFunctionContext, deviceName string) bool {
+	if !p.config.SSE.SuppressDisabledDevices || !p.devices.disabled(ctx, deviceName) {
+		return false
+	}
+	p.delivery.suppressed.Add(1)
+	p.lc.Tracef("Event from device %s suppressed, it is locked or down", deviceName)
+	return true
+}
+
+/*
+warnDropped (an internal API) warns that an event on topic was dropped for the full buffers
+of the subscriptions with those IDs. Within DropWarningInterval of the last warning, the
+drops are only counted, into the next warning.
+*/
+func (p *Processor) warnDropped(topic any, subIds []string) {
+	interval := p.config.SSE.Durations().DropWarningInterval
+	p.dropWarnings.lock.Lock()
+	p.dropWarnings.dropped += uint64(len(subIds))
+	now := time.Now()
+	if !p.dropWarnings.last.IsZero() && now.Sub(p.dropWarnings.last) < interval {
+		p.dropWarnings.lock.Unlock()
+		return
+	}
+	dropped := p.dropWarnings.dropped
+	p.dropWarnings.dropped = 0
+	p.dropWarnings.last = now
+	p.dropWarnings.lock.Unlock()
+	p.lc.Warn("Events dropped, subscription buffers full", "topic", fmt.Sprint(topic), "subscriptions", strings.Join(subIds, ","),
+		"dropped", dropped, "interval", interval.String())
+}
@@ -0,0 +1,83 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+func TestDeliveryCounts(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	// The subscription's buffer holds 10, the last 2 don't fit
+	for i := 0; i < 12; i++ {
+		ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+		ctx.AddValue(interfaces.RECEIVEDTOPIC, "a/b")
+		tp.proc.Pipeline(ctx, []byte("{\"x\":1}"))
+	}
+	if counts := tp.proc.DeliveryCounts(); counts != (DeliveryCounts{Received: 12, Matched: 12, Delivered: 10, Dropped: 2}) {
+		t.Fatalf("Wrong counts %+v", counts)
+	}
+	if n := tp.subs.ChannelHighWater(); n != 10 {
+		t.Fatalf("Wrong channel high-water mark %d", n)
+	}
+}
+
+// Struct warnLogger keeps the key/value pairs of Warn calls
+type warnLogger struct {
+	logger.LoggingClient
+	warnings [][]any
+}
+
+func (l *warnLogger) Warn(msg string, args ...any) {
+	l.warnings = append(l.warnings, args)
+}
+
+func TestDropWarnings(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	lc := &warnLogger{LoggingClient: logger.NewMockClient()}
+	tp.proc.lc = lc
+	tp.cfg.SSE.DropWarningInterval = "1h"
+	if err := tp.cfg.Validate(); err != nil {
+		t.Fatalf("Invalid configuration: %v", err)
+	}
+	publish := func() {
+		ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+		ctx.AddValue(interfaces.RECEIVEDTOPIC, "a/b")
+		tp.proc.Pipeline(ctx, []byte("{\"x\":1}"))
+	}
+	// The buffer holds 10, the first drop is warned about, the next ones not for an hour
+	for i := 0; i < 13; i++ {
+		publish()
+	}
+	if len(lc.warnings) != 1 || lc.warnings[0][1] != "a/b" || lc.warnings[0][5] != uint64(1) {
+		t.Fatalf("Wrong warnings %v", lc.warnings)
+	}
+	// Then the next warning counts all the drops since
+	tp.proc.dropWarnings.last = time.Now().Add(-2 * time.Hour)
+	publish()
+	if len(lc.warnings) != 2 || lc.warnings[1][5] != uint64(3) {
+		t.Fatalf("Wrong warnings %v", lc.warnings)
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	ctx := pkg.NewAppFuncContextForTest("9f3c2a8e-5b1d-4e7f-8a6c-0d2e4f6a8b1c", logger.NewMockClient())
+	ctx.AddValue(interfaces.RECEIVEDTOPIC, "a/b")
+	tp.proc.Pipeline(ctx, []byte("{\"x\":1}"))
+	if msg := <-tp.rxchan; msg.CorrelationID != "9f3c2a8e-5b1d-4e7f-8a6c-0d2e4f6a8b1c" {
+		t.Fatalf("Wrong correlation ID in %+v", msg)
+	}
+}
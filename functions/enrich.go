@@ -0,0 +1,312 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+)
+
+// Struct deviceMetadata is what we add to Events about their device, as member "deviceMetadata".
+type deviceMetadata struct {
+	Description    string   `json:"description,omitempty"`
+	Labels         []string `json:"labels,omitempty"`
+	Location       any      `json:"location,omitempty"`
+	AdminState     string   `json:"adminState"`
+	OperatingState string   `json:"operatingState"`
+}
+
+// Struct cachedDevice is what deviceCache keeps of a device.
+type cachedDevice struct {
+	// JSON of the deviceMetadata added to Events
+	metadata []byte
+	labels   []string
+	// Name of the device service it belongs to
+	service  string
+	// Locked, or Down, for SuppressDisabledDevices
+	disabled bool
+}
+
+/*
+Struct deviceCache holds device metadata fetched from core-metadata, keyed by device name.
+Entries stay until a core-metadata system event says the device changed.
+*/
+type deviceCache struct {
+	// Access under lock
+	devices map[string]cachedDevice
+	lock    sync.Mutex
+}
+
+func newDeviceCache() *deviceCache {
+	return &deviceCache{devices: make(map[string]cachedDevice)}
+}
+
+// lookup returns the JSON of a device's metadata, fetching it from core-metadata if it isn't cached.
+func (c *deviceCache) lookup(ctx interfaces.AppFunctionContext, name string) ([]byte, bool) {
+	device, ok := c.get(ctx, name)
+	return device.metadata, ok
+}
+
+/*
+disabled returns whether a device is Locked or Down, fetching it from core-metadata if it
+isn't cached. A device that can't be looked up isn't.
+*/
+func (c *deviceCache) disabled(ctx interfaces.AppFunctionContext, name string) bool {
+	device, ok := c.get(ctx, name)
+	return ok && device.disabled
+}
+
+// get returns what is cached of a device, fetching it from core-metadata if nothing is.
+func (c *deviceCache) get(ctx interfaces.AppFunctionContext, name string) (cachedDevice, bool) {
+	c.lock.Lock()
+	cached, ok := c.devices[name]
+	c.lock.Unlock()
+	if ok {
+		return cached, true
+	}
+	client := ctx.DeviceClient()
+	if client == nil {
+		return cachedDevice{}, false
+	}
+	response, err := client.DeviceByName(context.Background(), name)
+	if err != nil {
+		ctx.LoggingClient().Debugf("Could not get metadata of device %s: %s", name, err.Error())
+		return cachedDevice{}, false
+	}
+	device := response.Device
+	metadata, jsonErr := json.Marshal(deviceMetadata{
+		Description:    device.Description,
+		Labels:         device.Labels,
+		Location:       device.Location,
+		AdminState:     device.AdminState,
+		OperatingState: device.OperatingState,
+	})
+	if jsonErr != nil {
+		return cachedDevice{}, false
+	}
+	cached = cachedDevice{metadata: metadata, labels: device.Labels, service: device.ServiceName,
+		disabled: device.AdminState == models.Locked || device.OperatingState == models.Down}
+	c.lock.Lock()
+	c.devices[name] = cached
+	c.lock.Unlock()
+	return cached, true
+}
+
+// forget drops a device from the cache.
+func (c *deviceCache) forget(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.devices, name)
+}
+
+// empty reports whether nothing is cached, so there is nothing to invalidate.
+func (c *deviceCache) empty() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.devices) == 0
+}
+
+/*
+Struct resourceCache holds the properties of device resources (units, value type, range),
+and the value mappings of their device commands, from their device profiles in
+core-metadata, keyed by profile then resource name. Entries stay until a core-metadata
+system event says the profile changed.
+*/
+type resourceCache struct {
+	// Access under lock
+	profiles map[string]map[string]dtos.ResourceProperties
+	// Labels by value, of the resources of a profile that have any - access under lock
+	mappings map[string]map[string]map[string]string
+	lock     sync.Mutex
+}
+
+func newResourceCache() *resourceCache {
+	return &resourceCache{profiles: make(map[string]map[string]dtos.ResourceProperties), mappings: make(map[string]map[string]map[string]string)}
+}
+
+// lookup returns the properties of a device resource, fetching them from core-metadata if they aren't cached.
+func (c *resourceCache) lookup(ctx interfaces.AppFunctionContext, profile string, resource string) (dtos.ResourceProperties, bool) {
+	c.lock.Lock()
+	properties, ok := c.profiles[profile][resource]
+	c.lock.Unlock()
+	if ok {
+		return properties, true
+	}
+	if ctx.DeviceProfileClient() == nil {
+		return properties, false
+	}
+	deviceResource, err := ctx.GetDeviceResource(profile, resource)
+	if err != nil {
+		ctx.LoggingClient().Debugf("Could not get resource %s of profile %s: %s", resource, profile, err.Error())
+		return properties, false
+	}
+	properties = deviceResource.Properties
+	c.lock.Lock()
+	if c.profiles[profile] == nil {
+		c.profiles[profile] = make(map[string]dtos.ResourceProperties)
+	}
+	c.profiles[profile][resource] = properties
+	c.lock.Unlock()
+	return properties, true
+}
+
+/*
+labels returns the labels a device resource's values are mapped to by the resource
+operations of its profile's device commands, fetching the profile from core-metadata if it
+isn't cached, nil if it has none. Where commands map a value differently, the first wins.
+*/
+func (c *resourceCache) labels(ctx interfaces.AppFunctionContext, profile string, resource string) map[string]string {
+	c.lock.Lock()
+	resources, ok := c.mappings[profile]
+	c.lock.Unlock()
+	if ok {
+		return resources[resource]
+	}
+	client := ctx.DeviceProfileClient()
+	if client == nil {
+		return nil
+	}
+	response, err := client.DeviceProfileByName(context.Background(), profile)
+	if err != nil {
+		ctx.LoggingClient().Debugf("Could not get value mappings of profile %s: %s", profile, err.Error())
+		return nil
+	}
+	// Cached even if empty, so profiles without mappings aren't fetched for every Event
+	resources = make(map[string]map[string]string)
+	for _, command := range response.Profile.DeviceCommands {
+		for _, operation := range command.ResourceOperations {
+			for value, label := range operation.Mappings {
+				if resources[operation.DeviceResource] == nil {
+					resources[operation.DeviceResource] = make(map[string]string)
+				}
+				if _, ok := resources[operation.DeviceResource][value]; !ok {
+					resources[operation.DeviceResource][value] = label
+				}
+			}
+		}
+	}
+	c.lock.Lock()
+	c.mappings[profile] = resources
+	c.lock.Unlock()
+	return resources[resource]
+}
+
+// forget drops a profile's resources from the cache.
+func (c *resourceCache) forget(profile string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.profiles, profile)
+	delete(c.mappings, profile)
+}
+
+// empty reports whether nothing is cached, so there is nothing to invalidate.
+func (c *resourceCache) empty() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.profiles) == 0 && len(c.mappings) == 0
+}
+
+/*
+addUnits fills in the units of an Event's readings that have none, from their device
+profiles, if ReadingUnits is enabled. event is the generic form of the Event.
+*/
+func (p *Processor) addUnits(ctx interfaces.AppFunctionContext, event map[string]any) {
+	if !p.config.SSE.ReadingUnits {
+		return
+	}
+	readings, _ := event["readings"].([]any)
+	for _, r := range readings {
+		reading, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+		if units, _ := reading["units"].(string); units != "" {
+			continue
+		}
+		profile, _ := reading["profileName"].(string)
+		resource, _ := reading["resourceName"].(string)
+		if profile == "" || resource == "" {
+			continue
+		}
+		if properties, ok := p.resources.lookup(ctx, profile, resource); ok && properties.Units != "" {
+			reading["units"] = properties.Units
+		}
+	}
+}
+
+/*
+enrichEvent adds the metadata of an Event's device to the Event's JSON, if DeviceMetadata
+is enabled. Returns the JSON unchanged if it's disabled or the device can't be looked up.
+*/
+func (p *Processor) enrichEvent(ctx interfaces.AppFunctionContext, event dtos.Event, eventBytes []byte) []byte {
+	if !p.config.SSE.DeviceMetadata {
+		return eventBytes
+	}
+	metadata, ok := p.devices.lookup(ctx, event.DeviceName)
+	if !ok {
+		return eventBytes
+	}
+	return withMember(eventBytes, "deviceMetadata", metadata)
+}
+
+// withMember returns the JSON of an object with member name, of JSON value, added, or the JSON unchanged if it isn't an object.
+func withMember(object []byte, name string, value []byte) []byte {
+	trimmed := bytes.TrimRight(object, " \t\r\n")
+	if len(trimmed) < 2 || trimmed[len(trimmed)-1] != '}' {
+		return object
+	}
+	rv := make([]byte, 0, len(trimmed)+len(name)+len(value)+4)
+	rv = append(rv, trimmed[:len(trimmed)-1]...)
+	rv = append(rv, []byte(",\""+name+"\":")...)
+	rv = append(rv, value...)
+	return append(rv, '}')
+}
+
+/*
+invalidateMetadata drops a device, or device profile, from the caches when a core-metadata
+system event says it changed, so the next Event from the device looks it up again, and
+subscriptions with the devices option follow its labels, and SuppressDisabledDevices its
+states. It looks at every message on a
+system-events topic, whether or not anyone is subscribed to it, but only when there is
+something cached.
+*/
+func (p *Processor) invalidateMetadata(topic string, contentType string, data any) {
+	if !strings.Contains("/"+topic+"/", "/system-events/") || (p.devices.empty() && p.resources.empty()) {
+		return
+	}
+	if raw, ok := data.([]byte); ok {
+		data = decodePayload(contentType, raw)
+	}
+	eventBytes, err := json.Marshal(stringKeys(data))
+	if err != nil {
+		return
+	}
+	var sysEvent dtos.SystemEvent
+	if json.Unmarshal(eventBytes, &sysEvent) != nil {
+		return
+	}
+	switch sysEvent.Type {
+	case common.DeviceSystemEventType:
+		var device dtos.Device
+		if sysEvent.DecodeDetails(&device) == nil && device.Name != "" {
+			p.devices.forget(device.Name)
+		}
+	case common.DeviceProfileSystemEventType:
+		var profile dtos.DeviceProfile
+		if sysEvent.DecodeDetails(&profile) == nil && profile.Name != "" {
+			p.resources.forget(profile.Name)
+		}
+	}
+}
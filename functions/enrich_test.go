@@ -0,0 +1,117 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+// A core-metadata system event saying Virtual-Bacon-Cape-04 was updated
+const deviceUpdateEvent = "{\"apiVersion\":\"v3\",\"type\":\"device\",\"action\":\"update\",\"source\":\"core-metadata\",\"owner\":\"device-virtual\",\"details\":{\"name\":\"Virtual-Bacon-Cape-04\",\"adminState\":\"LOCKED\",\"operatingState\":\"UP\",\"serviceName\":\"device-virtual\",\"profileName\":\"Bacon-Cape\",\"protocols\":{}},\"timestamp\":1661535695202033126}"
+
+func TestDeviceMetadata(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	// No core-metadata client in tests, so prime the cache
+	tp.proc.devices.devices["Virtual-Bacon-Cape-04"] = cachedDevice{metadata: []byte("{\"labels\":[\"lab\"],\"adminState\":\"UNLOCKED\",\"operatingState\":\"UP\"}"), labels: []string{"lab"}}
+
+	msgs := tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(msgs) != 1 || strings.Contains(msgs[0].Payload, "deviceMetadata") {
+		t.Fatalf("Event enriched with DeviceMetadata disabled: %v", msgs)
+	}
+
+	tp.cfg.SSE.DeviceMetadata = true
+	msgs = tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event, got %v", msgs)
+	}
+	var event map[string]any
+	if err := json.Unmarshal([]byte(msgs[0].Payload), &event); err != nil {
+		t.Fatalf("Enriched event is not JSON: %s", msgs[0].Payload)
+	}
+	metadata, ok := event["deviceMetadata"].(map[string]any)
+	if !ok || metadata["adminState"] != "UNLOCKED" || event["deviceName"] != "Virtual-Bacon-Cape-04" {
+		t.Fatalf("Event not enriched: %s", msgs[0].Payload)
+	}
+
+	// The update invalidates the cache, even though it's also delivered
+	msgs = tp.publish(t, "edgex/system-events/core-metadata/device/update/device-virtual/Bacon-Cape", []byte(deviceUpdateEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "system" {
+		t.Fatalf("Expected one system event, got %v", msgs)
+	}
+	if !tp.proc.devices.empty() {
+		t.Fatal("Device update did not invalidate cached metadata")
+	}
+	// Can't look it up again without core-metadata, so the event goes out as it came
+	msgs = tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(msgs) != 1 || strings.Contains(msgs[0].Payload, "deviceMetadata") {
+		t.Fatalf("Expected event without metadata, got %v", msgs)
+	}
+}
+
+func TestReadingUnits(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.ReadingUnits = true
+	// No core-metadata client in tests, so prime the cache
+	tp.proc.resources.profiles["Bacon-Cape"] = map[string]dtos.ResourceProperties{"mPercentLoad": {Units: "%"}}
+
+	msgs := tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event, got %v", msgs)
+	}
+	if !strings.Contains(msgs[0].Payload, "\"units\":\"%\"") {
+		t.Fatalf("Reading units not added: %s", msgs[0].Payload)
+	}
+
+	profileUpdate := "{\"apiVersion\":\"v3\",\"type\":\"deviceprofile\",\"action\":\"update\",\"source\":\"core-metadata\",\"details\":{\"name\":\"Bacon-Cape\"},\"timestamp\":1661535695202033126}"
+	_ = tp.publish(t, "edgex/system-events/core-metadata/deviceprofile/update/Bacon-Cape", []byte(profileUpdate))
+	if !tp.proc.resources.empty() {
+		t.Fatal("Profile update did not invalidate cached units")
+	}
+}
+
+func TestSuppressDisabledDevices(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	// No core-metadata client in tests, so prime the cache
+	tp.proc.devices.devices["Virtual-Bacon-Cape-04"] = cachedDevice{metadata: []byte("{}"), disabled: true}
+	topic := "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad"
+
+	if msgs := tp.publish(t, topic, []byte(edgexEvent)); len(msgs) != 1 {
+		t.Fatalf("Event suppressed with SuppressDisabledDevices disabled: %v", msgs)
+	}
+	tp.cfg.SSE.SuppressDisabledDevices = true
+	if msgs := tp.publish(t, topic, []byte(edgexEvent)); len(msgs) != 0 {
+		t.Fatalf("Event from a locked device delivered: %v", msgs)
+	}
+	if counts := tp.proc.DeliveryCounts(); counts.Suppressed != 1 {
+		t.Fatalf("Expected one suppressed, got %+v", counts)
+	}
+	// Nor flattened by TransformSimple
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	if cont, _ := tp.proc.TransformSimple(ctx, []byte(edgexEvent)); cont {
+		t.Fatal("TransformSimple let an Event from a locked device through")
+	}
+	// Other messages aren't
+	if msgs := tp.publish(t, "ble/events/alarms", jsonData(t, "{\"deviceName\":\"Virtual-Bacon-Cape-04\"}")); len(msgs) != 1 {
+		t.Fatalf("Expected one generic event, got %v", msgs)
+	}
+
+	// Unlocking the device invalidates the cache; can't look it up again without core-metadata,
+	// so it goes out
+	_ = tp.publish(t, "edgex/system-events/core-metadata/device/update/device-virtual/Bacon-Cape", []byte(deviceUpdateEvent))
+	if msgs := tp.publish(t, topic, []byte(edgexEvent)); len(msgs) != 1 {
+		t.Fatalf("Event suppressed after its device changed: %v", msgs)
+	}
+}
@@ -0,0 +1,66 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/google/uuid"
+)
+
+// Prefix of the CloudEvents type attribute, followed by the SSE event type
+const cloudEventTypePrefix = "org.edgexfoundry.sse."
+
+// Struct cloudEvent is a CloudEvents 1.0 event in structured JSON mode.
+type cloudEvent struct {
+	SpecVersion     string `json:"specversion"`
+	Type            string `json:"type"`
+	Source          string `json:"source"`
+	Id              string `json:"id"`
+	Time            string `json:"time"`
+	DataContentType string `json:"datacontenttype"`
+	// Extension attribute, the EdgeX correlation ID of the message bus message
+	CorrelationId   string `json:"correlationid,omitempty"`
+	Data            any    `json:"data"`
+}
+
+/*
+cloudEventMessage wraps a message in a CloudEvents envelope. The type is the SSE event type
+("generic" if it has none) after cloudEventTypePrefix, the source is the topic, and the data
+is the payload - as JSON if it is JSON, a string otherwise. The correlation ID goes in the
+correlationid extension attribute. The SSE event type and correlation ID are kept.
+*/
+func cloudEventMessage(topic any, msg submgr.ChannelMessage) (submgr.ChannelMessage, error) {
+	eventType := msg.EventType
+	if eventType == "" {
+		eventType = "generic"
+	}
+	envelope := cloudEvent{
+		SpecVersion:     "1.0",
+		Type:            cloudEventTypePrefix + eventType,
+		Source:          fmt.Sprint(topic),
+		Id:              uuid.NewString(),
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType: common.ContentTypeJSON,
+		CorrelationId:   msg.CorrelationID,
+		Data:            msg.Payload,
+	}
+	if json.Valid([]byte(msg.Payload)) {
+		envelope.Data = json.RawMessage(msg.Payload)
+	} else {
+		envelope.DataContentType = common.ContentTypeText
+	}
+	envelope_bytes, err := json.Marshal(envelope)
+	if err != nil {
+		return msg, err
+	}
+	return submgr.ChannelMessage{EventType: msg.EventType, Payload: string(envelope_bytes), CorrelationID: msg.CorrelationID}, nil
+}
@@ -0,0 +1,107 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCloudEventsEnvelope(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	subid, _ := tp.subs.NewSubscription()
+	subinfo := tp.subs.Subscription(subid)
+	if err := tp.subs.Include(subinfo, "edgex/events"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	if err := tp.subs.SetOptions(subinfo, submgr.SubscriptionOptions{Envelope: submgr.EnvelopeCloudEvents}); err != nil {
+		t.Fatalf("Could not set options: %v", err)
+	}
+	tp.subs.SetActive(subinfo, true)
+	wrapped, _ := tp.subs.ReceiveChannel(subinfo)
+
+	topic := "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad"
+	msgs := tp.publish(t, topic, []byte(edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one plain edgex event, got %v", msgs)
+	}
+	var msg submgr.ChannelMessage
+	select {
+	case msg = <-wrapped:
+	default:
+		t.Fatal("Nothing delivered to the cloudevents subscription")
+	}
+	if msg.EventType != "edgex" {
+		t.Fatalf("Expected the SSE event type to be kept, got %v", msg)
+	}
+	var ce map[string]any
+	if err := json.Unmarshal([]byte(msg.Payload), &ce); err != nil {
+		t.Fatalf("CloudEvent is not JSON: %s", msg.Payload)
+	}
+	if ce["specversion"] != "1.0" || ce["type"] != "org.edgexfoundry.sse.edgex" || ce["source"] != topic || ce["datacontenttype"] != "application/json" || ce["id"] == "" {
+		t.Fatalf("Wrong CloudEvent attributes: %s", msg.Payload)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, ce["time"].(string)); err != nil {
+		t.Fatalf("Bad CloudEvent time: %v", ce["time"])
+	}
+	data, ok := ce["data"].(map[string]any)
+	if !ok || data["deviceName"] != "Virtual-Bacon-Cape-04" {
+		t.Fatalf("CloudEvent data is not the event: %s", msg.Payload)
+	}
+}
+
+func TestCloudEventMessageText(t *testing.T) {
+	msg, err := cloudEventMessage("some/topic", submgr.ChannelMessage{Payload: "not json", CorrelationID: "c0ffee"})
+	if err != nil {
+		t.Fatalf("Could not wrap: %v", err)
+	}
+	var ce map[string]any
+	if err := json.Unmarshal([]byte(msg.Payload), &ce); err != nil {
+		t.Fatalf("CloudEvent is not JSON: %s", msg.Payload)
+	}
+	if ce["type"] != "org.edgexfoundry.sse.generic" || ce["datacontenttype"] != "text/plain" || ce["data"] != "not json" {
+		t.Fatalf("Wrong CloudEvent for text: %s", msg.Payload)
+	}
+	if ce["correlationid"] != "c0ffee" || msg.CorrelationID != "c0ffee" {
+		t.Fatalf("Correlation ID not kept: %v", msg)
+	}
+}
+
+func TestDeliveryEnvelopeOption(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	subid, _ := tp.subs.NewSubscription()
+	subinfo := tp.subs.Subscription(subid)
+	if err := tp.subs.Include(subinfo, "edgex/events"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	if err := tp.subs.SetOptions(subinfo, submgr.SubscriptionOptions{Envelope: submgr.EnvelopeDelivery}); err != nil {
+		t.Fatalf("Could not set options: %v", err)
+	}
+	tp.subs.SetActive(subinfo, true)
+	wrapped, _ := tp.subs.ReceiveChannel(subinfo)
+
+	before := time.Now()
+	topic := "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad"
+	msgs := tp.publish(t, topic, []byte(edgexEvent))
+	if len(msgs) != 1 || msgs[0].Delivery {
+		t.Fatalf("Expected one plain edgex event, got %v", msgs)
+	}
+	var msg submgr.ChannelMessage
+	select {
+	case msg = <-wrapped:
+	default:
+		t.Fatal("Nothing delivered to the delivery envelope subscription")
+	}
+	// Wrapped when written, the payload is the Event's
+	if !msg.Delivery || msg.Topic != topic || msg.ReceivedAt.Before(before) || msg.Payload != msgs[0].Payload {
+		t.Fatalf("Not marked for the delivery envelope: %+v", msg)
+	}
+}
@@ -0,0 +1,55 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+/*
+deliverExtended sends an EdgeX Event to the subscriptions in chanlist whose extensions
+option has transforms, transformed by them, each set of transforms once for all the
+subscriptions that share it. Returns the rest of chanlist, which get the Event as it is.
+A subscription whose transforms fail doesn't get it.
+*/
+func (p *Processor) deliverExtended(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, event dtos.Event, msg submgr.ChannelMessage) []submgr.SendHandle {
+	rv := chanlist[:0:0]
+	var keys []string
+	groups := make(map[string][]submgr.SendHandle)
+	for _, ch := range chanlist {
+		key := ch.Extensions().Key()
+		if key == "" {
+			rv = append(rv, ch)
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], ch)
+	}
+	for _, key := range keys {
+		group := groups[key]
+		transformed, err := group[0].Extensions().Transform(event)
+		if err != nil {
+			p.lc.Errorf("Could not transform Event from device %s on topic %s by extensions %s: %s", event.DeviceName, topic, key, err.Error())
+			p.deadLetter(DeadLetterTransformFailed, topic, msg, len(group), err)
+			continue
+		}
+		event_bytes, err := json.Marshal(transformed)
+		if err != nil {
+			p.lc.Errorf("Could not marshal Event transformed by extensions %s on topic %s: %s", key, topic, err.Error())
+			p.deadLetter(DeadLetterTransformFailed, topic, msg, len(group), err)
+			continue
+		}
+		p.deliverPicked(ctx, group, topic, transformed, submgr.ChannelMessage{EventType: msg.EventType, Payload: string(p.enrichEvent(ctx, transformed, event_bytes))})
+	}
+	return rv
+}
@@ -0,0 +1,101 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/extension"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+// Type testSourceFilter matches Events from one source.
+type testSourceFilter string
+
+func (f testSourceFilter) Match(event dtos.Event) bool {
+	return event.SourceName == string(f)
+}
+
+// Type testScaleTransform multiplies the value of readings by 10, as strings, or fails for "fail".
+type testScaleTransform string
+
+func (s testScaleTransform) Transform(event dtos.Event) (dtos.Event, error) {
+	if s == "fail" {
+		return event, errors.New("test transform failed")
+	}
+	readings := make([]dtos.BaseReading, len(event.Readings))
+	for i, reading := range event.Readings {
+		reading.Value += "0"
+		readings[i] = reading
+	}
+	event.Readings = readings
+	return event, nil
+}
+
+func init() {
+	extension.RegisterFilter("test-source", func(args map[string]string) (extension.Filter, error) {
+		return testSourceFilter(args["source"]), nil
+	})
+	extension.RegisterTransform("test-scale", func(args map[string]string) (extension.Transform, error) {
+		return testScaleTransform(args["mode"]), nil
+	})
+}
+
+func TestExtensions(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	receivers := make(map[string]<-chan submgr.ChannelMessage)
+	options := map[string]submgr.SubscriptionOptions{
+		"other":  {Extensions: []extension.Ref{{Name: "test-source", Args: map[string]string{"source": "other"}}}},
+		"scaled": {Extensions: []extension.Ref{{Name: "test-source", Args: map[string]string{"source": "mPercentLoad"}}, {Name: "test-scale"}}},
+		"simple": {Extensions: []extension.Ref{{Name: "test-scale"}}, Format: submgr.FormatSimple},
+		"failed": {Extensions: []extension.Ref{{Name: "test-scale", Args: map[string]string{"mode": "fail"}}}},
+	}
+	for name, option := range options {
+		subid, _ := tp.subs.NewSubscription()
+		subinfo := tp.subs.Subscription(subid)
+		if err := tp.subs.Include(subinfo, "edgex/"); err != nil {
+			t.Fatalf("Could not add include: %v", err)
+		}
+		if err := tp.subs.SetOptions(subinfo, option); err != nil {
+			t.Fatalf("Could not set options: %v", err)
+		}
+		tp.subs.SetActive(subinfo, true)
+		receivers[name], _ = tp.subs.ReceiveChannel(subinfo)
+	}
+	subid, _ := tp.subs.NewSubscription()
+	if err := tp.subs.SetOptions(tp.subs.Subscription(subid), submgr.SubscriptionOptions{Extensions: []extension.Ref{{Name: "test-missing"}}}); err == nil {
+		t.Fatal("Options with an unregistered extension were set")
+	}
+
+	msgs := tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(msgs) != 1 {
+		t.Fatalf("Subscription without extensions got %v", msgs)
+	}
+	var event dtos.Event
+	if err := json.Unmarshal([]byte(msgs[0].Payload), &event); err != nil || event.Readings[0].Value != "74" {
+		t.Fatalf("Subscription without extensions got a transformed Event: %v", msgs[0])
+	}
+	if len(receivers["other"]) != 0 || len(receivers["failed"]) != 0 {
+		t.Fatalf("Event delivered past a filter or failed transform: other %d, failed %d", len(receivers["other"]), len(receivers["failed"]))
+	}
+	if len(receivers["scaled"]) != 1 || len(receivers["simple"]) != 1 {
+		t.Fatalf("Event went to scaled %d, simple %d", len(receivers["scaled"]), len(receivers["simple"]))
+	}
+	msg := <-receivers["scaled"]
+	if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil || msg.EventType != "edgex" || event.Readings[0].Value != "740" {
+		t.Fatalf("Transformed Event delivered as %v", msg)
+	}
+	msg = <-receivers["simple"]
+	if msg.EventType != "simple" || !strings.Contains(msg.Payload, "740") {
+		t.Fatalf("Transformed Event not delivered in its format: %v", msg)
+	}
+}
@@ -0,0 +1,125 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+/*
+enumLabels returns the labels the device profiles map the values of an Event's readings to,
+by the index of the reading, nil if none is mapped. A numeric value matches a mapping of
+the same number written another way, e.g. "1.0" one of "1", as converting units or
+rounding may have rewritten it.
+*/
+func (p *Processor) enumLabels(ctx interfaces.AppFunctionContext, event dtos.Event) map[int]string {
+	var rv map[int]string
+	for i, reading := range event.Readings {
+		profile := reading.ProfileName
+		if profile == "" {
+			profile = event.ProfileName
+		}
+		mappings := p.resources.labels(ctx, profile, reading.ResourceName)
+		if len(mappings) == 0 {
+			continue
+		}
+		label, ok := mappings[reading.Value]
+		if !ok && isNumericValueType(reading.ValueType) {
+			label, ok = numericLabel(mappings, reading.Value)
+		}
+		if !ok {
+			continue
+		}
+		if rv == nil {
+			rv = make(map[int]string)
+		}
+		rv[i] = label
+	}
+	return rv
+}
+
+// numericLabel returns the label of the mapping whose value is the same number as value, and false if there is none.
+func numericLabel(mappings map[string]string, value string) (string, bool) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", false
+	}
+	for mapped, label := range mappings {
+		if n, err := strconv.ParseFloat(mapped, 64); err == nil && n == number {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+/*
+withLabels returns the payload of an Event, the JSON of it, with member "label" added to
+the readings in labels, by their index. Returns false, and the payload as it is, if it
+doesn't have the Event's readings.
+*/
+func withLabels(payload string, event dtos.Event, labels map[int]string) (string, bool) {
+	// The payload has the Event's readings in the same order, and may have more than the Event, e.g. deviceMetadata.
+	// Numbers are kept as they are written, as nanosecond origins don't fit a float64.
+	var data map[string]any
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return payload, false
+	}
+	readings, _ := data["readings"].([]any)
+	if len(readings) != len(event.Readings) {
+		return payload, false
+	}
+	for i, label := range labels {
+		element, ok := readings[i].(map[string]any)
+		if !ok {
+			return payload, false
+		}
+		element["label"] = label
+	}
+	labelled, err := json.Marshal(data)
+	if err != nil {
+		return payload, false
+	}
+	return string(labelled), true
+}
+
+/*
+deliverLabelled sends an EdgeX Event to the subscriptions in chanlist with the enumLabels
+option, with the labels of its readings, in the format each asked for. Returns the rest of
+chanlist, and those too if none of its readings has a label, which get it as it is.
+*/
+func (p *Processor) deliverLabelled(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, event dtos.Event, msg submgr.ChannelMessage) []submgr.SendHandle {
+	rv := chanlist[:0:0]
+	labelled := make([]submgr.SendHandle, 0)
+	for _, ch := range chanlist {
+		if ch.Options().EnumLabels {
+			labelled = append(labelled, ch)
+		} else {
+			rv = append(rv, ch)
+		}
+	}
+	if len(labelled) == 0 {
+		return chanlist
+	}
+	labels := p.enumLabels(ctx, event)
+	if len(labels) == 0 {
+		return chanlist
+	}
+	labelledMsg := msg
+	if payload, ok := withLabels(msg.Payload, event, labels); ok {
+		labelledMsg.Payload = payload
+	}
+	p.sendFormats(ctx, labelled, topic, event, labelledMsg, labels)
+	return rv
+}
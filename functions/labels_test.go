@@ -0,0 +1,102 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+func TestEnumLabels(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	// No core-metadata client in tests, so prime the cache; the temperature is mapped as a float
+	tp.proc.resources.mappings["Bacon-Cape"] = map[string]map[string]string{
+		"mPercentLoad": {"74": "Overloaded", "0": "Idle"},
+		"temperature":  {"212.0": "Boiling"},
+	}
+	receivers := make(map[string]<-chan submgr.ChannelMessage)
+	options := map[string]submgr.SubscriptionOptions{
+		"edgex":  {EnumLabels: true},
+		"simple": {EnumLabels: true, Format: submgr.FormatSimple},
+	}
+	for name, option := range options {
+		subid, _ := tp.subs.NewSubscription()
+		subinfo := tp.subs.Subscription(subid)
+		if err := tp.subs.Include(subinfo, "edgex/"); err != nil {
+			t.Fatalf("Could not add include: %v", err)
+		}
+		if err := tp.subs.SetOptions(subinfo, option); err != nil {
+			t.Fatalf("Could not set options: %v", err)
+		}
+		tp.subs.SetActive(subinfo, true)
+		receivers[name], _ = tp.subs.ReceiveChannel(subinfo)
+	}
+
+	topic := "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/status"
+	msgs := tp.publish(t, topic, []byte(unitsEvent))
+	if len(msgs) != 1 || containsLabel(t, msgs[0].Payload) {
+		t.Fatalf("Subscription without enumLabels got %v", msgs)
+	}
+	var event struct {
+		Readings []map[string]any `json:"readings"`
+	}
+	edgex := <-receivers["edgex"]
+	if err := json.Unmarshal([]byte(edgex.Payload), &event); err != nil || len(event.Readings) != 2 || edgex.EventType != "edgex" {
+		t.Fatalf("Labelled Event is %s", edgex.Payload)
+	}
+	if event.Readings[0]["label"] != "Overloaded" || event.Readings[0]["value"] != "74" || event.Readings[1]["label"] != "Boiling" {
+		t.Fatalf("Readings not labelled: %v", event.Readings)
+	}
+	// Nanosecond origins, of the Event and its readings, come through labelling as they were
+	payload := "{\"origin\":1729080000123456789,\"readings\":[{\"origin\":1729080000123456789,\"value\":\"74\"}]}"
+	labelled, ok := withLabels(payload, dtos.Event{Readings: []dtos.BaseReading{{}}}, map[int]string{0: "Overloaded"})
+	if !ok || strings.Count(labelled, "\"origin\":1729080000123456789") != 2 || !strings.Contains(labelled, "\"label\":\"Overloaded\"") {
+		t.Fatalf("Labelled payload is %s", labelled)
+	}
+	simple := <-receivers["simple"]
+	var readings []simpleReading
+	if err := json.Unmarshal([]byte(simple.Payload), &readings); err != nil || len(readings) != 2 || simple.EventType != "simple" {
+		t.Fatalf("Labelled simple Event is %s", simple.Payload)
+	}
+	if readings[0].Label != "Overloaded" || readings[1].Label != "Boiling" {
+		t.Fatalf("Simple readings not labelled: %+v", readings)
+	}
+
+	// Unmapped values, or profiles, go out as they came
+	tp.proc.resources.forget("Bacon-Cape")
+	tp.proc.resources.mappings["Bacon-Cape"] = map[string]map[string]string{"mPercentLoad": {"0": "Idle"}}
+	msgs = tp.publish(t, topic, []byte(unitsEvent))
+	if edgex := <-receivers["edgex"]; len(msgs) != 1 || edgex.Payload != msgs[0].Payload {
+		t.Fatalf("Event without mapped values changed: %s", edgex.Payload)
+	}
+	<-receivers["simple"]
+	tp.proc.resources.forget("Bacon-Cape")
+	if !tp.proc.resources.empty() {
+		t.Fatal("Value mappings not forgotten with their profile")
+	}
+}
+
+// containsLabel returns whether any reading of the Event in payload has a label
+func containsLabel(t *testing.T, payload string) bool {
+	var event struct {
+		Readings []map[string]any `json:"readings"`
+	}
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatalf("Event is not JSON: %s", payload)
+	}
+	for _, reading := range event.Readings {
+		if _, ok := reading["label"]; ok {
+			return true
+		}
+	}
+	return false
+}
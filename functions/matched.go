@@ -0,0 +1,54 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"slices"
+	"strings"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+)
+
+/*
+Key of the IDs of the subscriptions Publish matched a message to, comma-separated, among the
+function context's values. Subscription IDs have no commas.
+*/
+const MatchedSubscriptionsKey = "sse-matched-subscriptions"
+
+/*
+MatchedSubscriptions returns the IDs of the subscriptions Publish matched the message to,
+in lexicographic order, for pipeline functions added after Pipeline, e.g.
+
+	svc.SetDefaultFunctionsPipeline(processor.Pipeline, exportWatched)
+
+to send northbound what someone is watching. They are those whose include and exclude lists
+match its topic, or for a batch, the topic of any of its Events; their options, e.g. devices
+or filter, may still keep it from them. Empty if there are none, or Publish hasn't run.
+*/
+func MatchedSubscriptions(ctx interfaces.AppFunctionContext) []string {
+	matched, ok := ctx.GetValue(MatchedSubscriptionsKey)
+	if !ok || matched == "" {
+		return nil
+	}
+	return strings.Split(matched, ",")
+}
+
+// recordMatched (an internal API) adds the subscriptions in chanlist to those MatchedSubscriptions() returns.
+func recordMatched(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle) {
+	if len(chanlist) == 0 {
+		return
+	}
+	matched := MatchedSubscriptions(ctx)
+	for _, ch := range chanlist {
+		if subid := ch.SubId(); subid != "" {
+			matched = append(matched, subid)
+		}
+	}
+	slices.Sort(matched)
+	ctx.AddValue(MatchedSubscriptionsKey, strings.Join(slices.Compact(matched), ","))
+}
@@ -0,0 +1,64 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+func TestMatchedSubscriptions(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	everything := tp.subs.SubscriptionId(tp.subs.AllSubscriptions()[0])
+	_ = tp.subscribe(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04")
+	_ = tp.subscribe(t, "alarms")
+	var device04, alarms string
+	for _, subInfo := range tp.subs.AllSubscriptions() {
+		includes, _, _ := tp.subs.SubscriptionInfo(subInfo)
+		switch {
+		case slices.Contains(includes, "alarms/"):
+			alarms = tp.subs.SubscriptionId(subInfo)
+		case len(includes) == 1 && strings.Contains(includes[0], "Virtual-Bacon-Cape-04"):
+			device04 = tp.subs.SubscriptionId(subInfo)
+		}
+	}
+	matched := func(topic string, data any) []string {
+		ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+		ctx.AddValue(interfaces.RECEIVEDTOPIC, topic)
+		if cont, _ := tp.proc.Publish(ctx, data); !cont {
+			t.Fatal("Publish stopped the pipeline")
+		}
+		return MatchedSubscriptions(ctx)
+	}
+
+	expected := []string{everything, device04}
+	slices.Sort(expected)
+	if got := matched("edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", jsonData(t, edgexEvent)); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Matched %v, expected %v", got, expected)
+	}
+	if got := matched("nothing/here", []byte("x")); !reflect.DeepEqual(got, []string{everything}) {
+		t.Fatalf("Matched %v, expected only %s", got, everything)
+	}
+	// A batch matches what any of its Events does
+	other := strings.Replace(edgexEvent, "Virtual-Bacon-Cape-04", "Virtual-Bacon-Cape-05", -1)
+	if got := matched("edgex/events/device", jsonData(t, "["+other+", "+edgexEvent+"]")); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Batch matched %v, expected %v", got, expected)
+	}
+	if alarms == "" || slices.Contains(expected, alarms) {
+		t.Fatal("Subscriptions not set up")
+	}
+	if got := MatchedSubscriptions(pkg.NewAppFuncContextForTest("", logger.NewMockClient())); got != nil {
+		t.Fatalf("Matched %v before Publish", got)
+	}
+}
@@ -0,0 +1,112 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	sseDtos "github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+)
+
+// Type of the events binary readings are delivered in, with the media option
+const MediaEventType = "media"
+
+// hasMedia reports whether an Event has binary readings with a value.
+func hasMedia(event dtos.Event) bool {
+	return slices.ContainsFunc(event.Readings, func(r dtos.BaseReading) bool {
+		return r.ValueType == common.ValueTypeBinary && len(r.BinaryValue) > 0
+	})
+}
+
+/*
+stripMedia returns the Event without the values of its binary readings, and its payload, the
+JSON of msg, the same way, with binarySize instead of binaryValue like BinaryReadings
+summarize. Returns false, and nothing, if the payload isn't the Event.
+*/
+func stripMedia(event dtos.Event, payload string) (dtos.Event, string, bool) {
+	var data any
+	if err := json.Unmarshal([]byte(payload), &data); err != nil {
+		return event, payload, false
+	}
+	eventMap, ok := eventOf(data)
+	if !ok {
+		return event, payload, false
+	}
+	if readings, _ := eventMap["readings"].([]any); len(readings) != len(event.Readings) {
+		return event, payload, false
+	}
+	stripEventBinary(eventMap, configuration.BinaryReadingsSummarize)
+	strippedPayload, err := json.Marshal(data)
+	if err != nil {
+		return event, payload, false
+	}
+	event.Readings = slices.Clone(event.Readings)
+	for i, reading := range event.Readings {
+		if reading.ValueType == common.ValueTypeBinary {
+			event.Readings[i].BinaryValue = nil
+		}
+	}
+	return event, string(strippedPayload), true
+}
+
+// mediaEvents returns a media event for each binary reading of an Event that has a value.
+func mediaEvents(event dtos.Event) ([]submgr.ChannelMessage, error) {
+	rv := make([]submgr.ChannelMessage, 0)
+	for _, reading := range event.Readings {
+		if reading.ValueType != common.ValueTypeBinary || len(reading.BinaryValue) == 0 {
+			continue
+		}
+		payload, err := json.Marshal(sseDtos.MediaEvent{
+			EventId:      event.Id,
+			Id:           reading.Id,
+			DeviceName:   reading.DeviceName,
+			ResourceName: reading.ResourceName,
+			Origin:       reading.Origin,
+			MediaType:    reading.MediaType,
+			Data:         base64.StdEncoding.EncodeToString(reading.BinaryValue),
+		})
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, submgr.ChannelMessage{EventType: MediaEventType, Payload: string(payload)})
+	}
+	return rv, nil
+}
+
+/*
+deliverMedia sends an EdgeX Event with binary readings to the subscriptions in chanlist,
+which have the media option: the Event without their values first, as it would be without
+the option, then a media event for each binary reading. If its payload isn't the Event,
+it is delivered as it is.
+*/
+func (p *Processor) deliverMedia(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, event dtos.Event, msg submgr.ChannelMessage) {
+	media, err := mediaEvents(event)
+	if err != nil {
+		p.lc.Errorf("Could not marshal media events of Event on topic %s: %s", topic, err.Error())
+		p.deadLetter(DeadLetterTransformFailed, topic, msg, len(chanlist), err)
+		return
+	}
+	strippedEvent, payload, ok := stripMedia(event, msg.Payload)
+	if !ok {
+		p.lc.Debugf("Event on topic %s is not as it was decoded, delivered without media events", topic)
+		p.deliverReadings(ctx, chanlist, topic, event, msg)
+		return
+	}
+	strippedMsg := msg
+	strippedMsg.Payload = payload
+	p.deliverReadings(ctx, chanlist, topic, strippedEvent, strippedMsg)
+	for _, mediaMsg := range media {
+		p.deliver(ctx, chanlist, topic, mediaMsg)
+	}
+}
@@ -0,0 +1,75 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"slices"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+/*
+memberChannels returns those of chanlist that take the Event: subscriptions without the
+devices option, and those whose DeviceSelector picks the Event's device. The device's
+labels and device service come from core-metadata, and are kept current by its system
+events, so devices join and leave subscriptions as they are labelled, added and removed.
+*/
+func (p *Processor) memberChannels(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, event dtos.Event) []submgr.SendHandle {
+	var device cachedDevice
+	looked := false
+	found := false
+	rv := chanlist[:0:0]
+	for _, ch := range chanlist {
+		selector := ch.Options().Devices
+		if selector == nil {
+			rv = append(rv, ch)
+			continue
+		}
+		if selector.Profile != "" && selector.Profile != event.ProfileName {
+			continue
+		}
+		if len(selector.Labels) == 0 && selector.Service == "" {
+			rv = append(rv, ch)
+			continue
+		}
+		if !looked {
+			device, found = p.devices.get(ctx, event.DeviceName)
+			looked = true
+		}
+		if !found || !hasLabels(device.labels, selector.Labels) || (selector.Service != "" && selector.Service != device.service) {
+			continue
+		}
+		rv = append(rv, ch)
+	}
+	return rv
+}
+
+// hasLabels reports whether labels has every one of wanted.
+func hasLabels(labels []string, wanted []string) bool {
+	for _, label := range wanted {
+		if !slices.Contains(labels, label) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+filteredChannels returns those of chanlist whose subscriptions have no filter option, or one
+the Event passes, and whose extension filters, if any, it passes.
+*/
+func filteredChannels(chanlist []submgr.SendHandle, event dtos.Event) []submgr.SendHandle {
+	rv := chanlist[:0:0]
+	for _, ch := range chanlist {
+		if expression := ch.Filter(); (expression == nil || expression.Match(event)) && ch.Extensions().Match(event) {
+			rv = append(rv, ch)
+		}
+	}
+	return rv
+}
@@ -0,0 +1,115 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"testing"
+)
+
+func TestDeviceMembership(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	// No core-metadata client in tests, so prime the cache
+	tp.proc.devices.devices["Virtual-Bacon-Cape-04"] = cachedDevice{metadata: []byte("{}"), labels: []string{"line-3", "lab"}, service: "device-virtual"}
+
+	receivers := make(map[string]<-chan submgr.ChannelMessage)
+	selectors := map[string]*submgr.DeviceSelector{
+		"labelled": {Labels: []string{"line-3"}},
+		"profile":  {Profile: "Bacon-Cape"},
+		"both":     {Labels: []string{"line-3", "lab"}, Profile: "Bacon-Cape"},
+		"other":    {Labels: []string{"line-4"}},
+		"elsewise": {Labels: []string{"line-3"}, Profile: "Random-Integer-Device"},
+		"service":  {Service: "device-virtual"},
+		"modbus":   {Service: "device-modbus", Profile: "Bacon-Cape"},
+	}
+	for name, selector := range selectors {
+		subid, _ := tp.subs.NewSubscription()
+		subinfo := tp.subs.Subscription(subid)
+		if err := tp.subs.Include(subinfo, "edgex/"); err != nil {
+			t.Fatalf("Could not add include: %v", err)
+		}
+		if err := tp.subs.SetOptions(subinfo, submgr.SubscriptionOptions{Devices: selector}); err != nil {
+			t.Fatalf("Could not set options: %v", err)
+		}
+		tp.subs.SetActive(subinfo, true)
+		receivers[name], _ = tp.subs.ReceiveChannel(subinfo)
+	}
+	received := func() map[string]int {
+		rv := make(map[string]int)
+		for name, rx := range receivers {
+			for len(rx) > 0 {
+				<-rx
+				rv[name]++
+			}
+		}
+		return rv
+	}
+
+	topic := "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad"
+	if msgs := tp.publish(t, topic, []byte(edgexEvent)); len(msgs) != 1 {
+		t.Fatalf("Subscription without devices got %v", msgs)
+	}
+	if got := received(); len(got) != 4 || got["labelled"] != 1 || got["profile"] != 1 || got["both"] != 1 || got["service"] != 1 {
+		t.Fatalf("Event went to %v", got)
+	}
+
+	// Other messages aren't filtered by device, e.g. the system event telling the device changed
+	tp.publish(t, "edgex/system-events/core-metadata/device/update/device-virtual/Bacon-Cape", []byte(deviceUpdateEvent))
+	if got := received(); len(got) != len(selectors) {
+		t.Fatalf("System event went to %v", got)
+	}
+	// Its labels are now unknown, as they can't be looked up again without core-metadata
+	tp.publish(t, topic, []byte(edgexEvent))
+	if got := received(); len(got) != 1 || got["profile"] != 1 {
+		t.Fatalf("Event from a device with unknown labels went to %v", got)
+	}
+	tp.proc.devices.devices["Virtual-Bacon-Cape-04"] = cachedDevice{metadata: []byte("{}"), labels: []string{"line-4"}, service: "device-modbus"}
+	tp.publish(t, topic, []byte(edgexEvent))
+	if got := received(); len(got) != 3 || got["profile"] != 1 || got["other"] != 1 || got["modbus"] != 1 {
+		t.Fatalf("Event from a relabelled device, moved to another service, went to %v", got)
+	}
+}
+
+func TestFilterOption(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	receivers := make(map[string]<-chan submgr.ChannelMessage)
+	filters := map[string]string{
+		"loaded": "SELECT * FROM demo WHERE mPercentLoad > 50",
+		"idle":   "mPercentLoad < 10",
+		"device": "meta(deviceName) LIKE 'Virtual-%' AND profileName = 'Bacon-Cape'",
+	}
+	for name, text := range filters {
+		subid, _ := tp.subs.NewSubscription()
+		subinfo := tp.subs.Subscription(subid)
+		if err := tp.subs.Include(subinfo, "edgex/"); err != nil {
+			t.Fatalf("Could not add include: %v", err)
+		}
+		if err := tp.subs.SetOptions(subinfo, submgr.SubscriptionOptions{Filter: text, Format: submgr.FormatSimple}); err != nil {
+			t.Fatalf("Could not set options: %v", err)
+		}
+		tp.subs.SetActive(subinfo, true)
+		receivers[name], _ = tp.subs.ReceiveChannel(subinfo)
+	}
+
+	tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(receivers["loaded"]) != 1 || len(receivers["device"]) != 1 || len(receivers["idle"]) != 0 {
+		t.Fatalf("Event with load 74 went to loaded %d, device %d, idle %d", len(receivers["loaded"]), len(receivers["device"]), len(receivers["idle"]))
+	}
+	if msg := <-receivers["loaded"]; msg.EventType != "simple" {
+		t.Fatalf("Filtered subscription got %v, not its format", msg)
+	}
+	<-receivers["device"]
+	// Not an Event, not filtered
+	tp.publish(t, "edgex/system-events/core-metadata/device/update/device-virtual/Bacon-Cape", []byte(deviceUpdateEvent))
+	for name, rx := range receivers {
+		if len(rx) != 1 {
+			t.Fatalf("Subscription %s got %d system events", name, len(rx))
+		}
+	}
+}
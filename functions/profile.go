@@ -0,0 +1,85 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+/*
+profileViolations returns what is wrong with an Event's readings by their resources in the
+device profiles: a value type other than the resource's, or a numeric value that isn't a
+number or is outside the resource's minimum and maximum. Readings of resources that can't
+be looked up aren't checked.
+*/
+func (p *Processor) profileViolations(ctx interfaces.AppFunctionContext, event dtos.Event) []string {
+	var rv []string
+	for _, reading := range event.Readings {
+		profile := reading.ProfileName
+		if profile == "" {
+			profile = event.ProfileName
+		}
+		properties, ok := p.resources.lookup(ctx, profile, reading.ResourceName)
+		if !ok {
+			continue
+		}
+		if properties.ValueType != "" && !strings.EqualFold(reading.ValueType, properties.ValueType) {
+			rv = append(rv, fmt.Sprintf("reading %s has valueType %s, profile %s says %s", reading.ResourceName, reading.ValueType, profile, properties.ValueType))
+			continue
+		}
+		if !isNumericValueType(reading.ValueType) || (properties.Minimum == nil && properties.Maximum == nil) {
+			continue
+		}
+		value, err := strconv.ParseFloat(reading.Value, 64)
+		switch {
+		case err != nil:
+			rv = append(rv, fmt.Sprintf("reading %s value %q is not a %s", reading.ResourceName, reading.Value, reading.ValueType))
+		case properties.Minimum != nil && value < *properties.Minimum:
+			rv = append(rv, fmt.Sprintf("reading %s value %s is below minimum %v", reading.ResourceName, reading.Value, *properties.Minimum))
+		case properties.Maximum != nil && value > *properties.Maximum:
+			rv = append(rv, fmt.Sprintf("reading %s value %s is above maximum %v", reading.ResourceName, reading.Value, *properties.Maximum))
+		}
+	}
+	return rv
+}
+
+/*
+checkProfile applies ProfileValidation to an EdgeX Event on its way to the subscriptions in
+chanlist. Returns msg, with member "profileViolations" added to its payload if it has any
+and ProfileValidation is annotate, and false if it has any and ProfileValidation is drop.
+*/
+func (p *Processor) checkProfile(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, event dtos.Event, msg submgr.ChannelMessage) (submgr.ChannelMessage, bool) {
+	mode := p.config.SSE.ProfileValidation
+	if mode == "" || mode == configuration.ProfileValidationOff {
+		return msg, true
+	}
+	violations := p.profileViolations(ctx, event)
+	if len(violations) == 0 {
+		return msg, true
+	}
+	p.validation.profileViolations.Add(1)
+	if mode == configuration.ProfileValidationDrop {
+		p.lc.Warnf("Dropped Event from device %s on topic %s not matching its profile: %s", event.DeviceName, topic, strings.Join(violations, "; "))
+		p.deadLetter(DeadLetterProfileViolation, topic, msg, len(chanlist), errors.New(strings.Join(violations, "; ")))
+		return msg, false
+	}
+	violations_bytes, err := json.Marshal(violations)
+	if err != nil {
+		return msg, true
+	}
+	msg.Payload = string(withMember([]byte(msg.Payload), "profileViolations", violations_bytes))
+	return msg, true
+}
@@ -0,0 +1,67 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+func TestProfileValidation(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	topic := "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad"
+	maximum := 50.0
+	// No core-metadata client in tests, so prime the cache
+	tp.proc.resources.profiles["Bacon-Cape"] = map[string]dtos.ResourceProperties{"mPercentLoad": {ValueType: "Uint32", Maximum: &maximum}}
+
+	if msgs := tp.publish(t, topic, []byte(edgexEvent)); len(msgs) != 1 || strings.Contains(msgs[0].Payload, "profileViolations") {
+		t.Fatalf("Event checked with ProfileValidation off: %v", msgs)
+	}
+
+	tp.cfg.SSE.ProfileValidation = configuration.ProfileValidationAnnotate
+	msgs := tp.publish(t, topic, []byte(edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event, got %v", msgs)
+	}
+	var event struct {
+		DeviceName        string
+		ProfileViolations []string
+	}
+	if err := json.Unmarshal([]byte(msgs[0].Payload), &event); err != nil {
+		t.Fatalf("Annotated event is not JSON: %s", msgs[0].Payload)
+	}
+	if event.DeviceName != "Virtual-Bacon-Cape-04" || len(event.ProfileViolations) != 1 || !strings.Contains(event.ProfileViolations[0], "above maximum 50") {
+		t.Fatalf("Event not annotated: %s", msgs[0].Payload)
+	}
+
+	tp.cfg.SSE.ProfileValidation = configuration.ProfileValidationDrop
+	if msgs := tp.publish(t, topic, []byte(edgexEvent)); len(msgs) != 0 {
+		t.Fatalf("Event over its maximum delivered: %v", msgs)
+	}
+	tp.proc.resources.profiles["Bacon-Cape"]["mPercentLoad"] = dtos.ResourceProperties{ValueType: "Float32"}
+	if msgs := tp.publish(t, topic, []byte(edgexEvent)); len(msgs) != 0 {
+		t.Fatalf("Event of the wrong value type delivered: %v", msgs)
+	}
+	if counts := tp.proc.ValidationCounts(); counts.ProfileViolations != 3 {
+		t.Fatalf("Expected 3 profile violations, got %+v", counts)
+	}
+	// Fine by the profile, or not in one, goes out as it came
+	tp.proc.resources.profiles["Bacon-Cape"]["mPercentLoad"] = dtos.ResourceProperties{ValueType: "Uint32", Maximum: &maximum}
+	maximum = 100
+	if msgs := tp.publish(t, topic, []byte(edgexEvent)); len(msgs) != 1 || strings.Contains(msgs[0].Payload, "profileViolations") {
+		t.Fatalf("Event within its profile not delivered as it came: %v", msgs)
+	}
+	delete(tp.proc.resources.profiles, "Bacon-Cape")
+	if msgs := tp.publish(t, topic, []byte(edgexEvent)); len(msgs) != 1 {
+		t.Fatalf("Event without a profile not delivered: %v", msgs)
+	}
+}
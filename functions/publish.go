@@ -8,69 +8,323 @@
 package functions
 
 import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
 	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"github.com/edgexfoundry-holding/edgex-sse/tracing"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // Object to hold the functions and the state they need
 type Processor struct {
 	lc            logger.LoggingClient
 	subscriptions *submgr.SubscriptionManager
+	config        *configuration.Config
 	warnedAboutJson bool
+	devices       *deviceCache
+	resources     *resourceCache
+	conversions   unitConversions
+	validation    *validationCounters
+	delivery      *deliveryCounters
+	dropWarnings  *dropWarnings
+	chain         *atomic.Pointer[functionChain]
+	deadLetters   DeadLetterPublisher
+	// nil without DeliveryWorkers, to deliver on the pipeline goroutine
+	workers       *deliveryWorkers
 }
 
 // Factory function
-func NewProcessor(logger logger.LoggingClient, mgr *submgr.SubscriptionManager) Processor {
+func NewProcessor(logger logger.LoggingClient, mgr *submgr.SubscriptionManager, cfg *configuration.Config) Processor {
 	p := Processor{}
 	p.lc = logger
 	p.subscriptions = mgr
+	p.config = cfg
 	p.warnedAboutJson = false
+	p.devices = newDeviceCache()
+	p.resources = newResourceCache()
+	p.conversions = newUnitConversions(cfg.SSE.UnitConversions)
+	p.validation = &validationCounters{}
+	p.delivery = &deliveryCounters{}
+	p.delivery.lastFromBus.Store(time.Now().UnixNano())
+	p.dropWarnings = &dropWarnings{}
+	p.chain = &atomic.Pointer[functionChain]{}
+	if cfg.SSE.DeliveryWorkers > 0 {
+		p.workers = newDeliveryWorkers(cfg.SSE.DeliveryWorkers, cfg.SSE.DeliveryQueue)
+	}
+	if err := p.SetPipelineFunctions(cfg.SSE.Writable); err != nil {
+		// Validate() should have caught this, fall back to the default functions
+		logger.Errorf("Invalid PipelineFunctions, using strip-binary and publish: %s", err.Error())
+		p.chain.Store(&functionChain{
+			functions: []string{configuration.FunctionStripBinary, configuration.FunctionPublish},
+			writable:  configuration.WritableConfig{PipelineFunctions: configuration.FunctionStripBinary + ", " + configuration.FunctionPublish},
+		})
+	}
 	return p
 }
 
-// Event pipeline function.
-func (p *Processor) Publish(ctx interfaces.AppFunctionContext, incoming_data interface{}) (bool, interface{}) {
-	var dstEvent dtos.Event
-	var msg submgr.ChannelMessage
+// Struct wrappedPayload is the JSON envelope for payloads delivered as something other than JSON.
+type wrappedPayload struct {
+	ContentType string `json:"contentType"`
+	Text        string `json:"text,omitempty"`
+	Base64      string `json:"base64,omitempty"`
+}
+
+/*
+Process runs a message that did not come through the SDK's trigger (e.g. from an
+external broker) through the same functions as the default pipeline, as if it had
+been received on topic.
+*/
+func (p *Processor) Process(ctx interfaces.AppFunctionContext, topic string, payload []byte) {
+	ctx.AddValue(interfaces.RECEIVEDTOPIC, topic)
+	p.runFunctions(ctx, payload)
+}
 
-	topic, ok := ctx.GetValue(interfaces.RECEIVEDTOPIC)
+/*
+normalizeTopic applies StripTopicPrefix, then the longest matching TopicRewrites prefix,
+to a received topic, so subscriptions don't depend on a deployment's base topic.
+Prefixes only match whole topic levels.
+*/
+func (p *Processor) normalizeTopic(topic string) string {
+	if prefix := strings.Trim(p.config.SSE.StripTopicPrefix, "/"); prefix != "" {
+		if topic == prefix {
+			topic = ""
+		} else {
+			topic = strings.TrimPrefix(topic, prefix+"/")
+		}
+	}
+	if p.config.SSE.TopicRewrites == "" {
+		return topic
+	}
+	rewrites, err := p.config.SSE.TopicRewriteList()
+	if err != nil {
+		return topic
+	}
+	var best *configuration.TopicRewrite
+	for i, rewrite := range rewrites {
+		if (topic == rewrite.From || strings.HasPrefix(topic, rewrite.From+"/")) && (best == nil || len(rewrite.From) > len(best.From)) {
+			best = &rewrites[i]
+		}
+	}
+	if best == nil {
+		return topic
+	}
+	rest := strings.TrimPrefix(topic, best.From)
+	if best.To == "" {
+		return strings.TrimPrefix(rest, "/")
+	}
+	return best.To + rest
+}
+
+// options returns the processing options for the pipeline running a function.
+func (p *Processor) options(ctx interfaces.AppFunctionContext) configuration.PipelineConfig {
+	return p.config.SSE.PipelineOptions(ctx.PipelineId())
+}
+
+/*
+stringKeys converts the generic CBOR decoding of a message (maps keyed by any)
+into the generic JSON form (maps keyed by string), so both can be processed
+and marshaled as JSON the same way.
+*/
+func stringKeys(in any) any {
+	switch v := in.(type) {
+	case map[any]any:
+		rv := make(map[string]any, len(v))
+		for key, value := range v {
+			rv[fmt.Sprint(key)] = stringKeys(value)
+		}
+		return rv
+	case map[string]any:
+		for key, value := range v {
+			v[key] = stringKeys(value)
+		}
+		return v
+	case []any:
+		for i, value := range v {
+			v[i] = stringKeys(value)
+		}
+		return v
+	default:
+		return in
+	}
+}
+
+/*
+Event pipeline function.
+
+The SDK hands the pipeline each message's raw bytes. Publish only un-marshals them when
+at least one subscription matches and wants the message classified; subscriptions with
+the PassThrough option get the bytes as received. Batches are decoded up front, since
+their events are matched one by one. The IDs of the subscriptions matched are left with
+the message for later pipeline functions, see MatchedSubscriptions().
+*/
+func (p *Processor) Publish(ctx interfaces.AppFunctionContext, incoming_data interface{}) (bool, interface{}) {
+	receivedTopic, ok := ctx.GetValue(interfaces.RECEIVEDTOPIC)
 	if !ok {
 		p.lc.Error("Message received with no topic, ignoring")
 		return true, incoming_data
 	}
-	chanlist := p.subscriptions.SubscribedChannels(topic)
+	topic := p.normalizeTopic(receivedTopic)
+	_, span := startSpan(ctx, "sse.publish", attribute.String("sse.topic", topic))
+	defer span.End()
+	contentType := contentTypeOf(ctx)
+	decoded := incoming_data
+	raw, isRaw := incoming_data.([]byte)
+	_, isCbor := incoming_data.(map[any]any)
+	isCbor = isCbor || contentType == common.ContentTypeCBOR
+	options := p.options(ctx)
+	wrapCbor := isCbor && options.CBORDelivery == configuration.CBORDeliveryBase64
+	p.invalidateMetadata(topic, contentType, incoming_data)
+
+	// A batch is matched event by event, so it can't take the short-cut below
+	if !wrapCbor {
+		if isRaw && mightBeBatch(contentType, raw) {
+			decoded = decodePayload(contentType, raw)
+			isRaw = false
+		}
+		if batch, ok := batchEvents(decoded); ok {
+			p.publishBatch(ctx, topic, batch)
+			return true, incoming_data
+		}
+	}
+
+	chanlist := p.subscribedChannels(ctx, topic)
+	recordMatched(ctx, chanlist)
 	p.lc.Tracef("Message received on topic %s, %d active subscriptions", topic, len(chanlist))
 	// Short-circuit since it's rather likely nobody is subscribed to this, don't bother casting,
 	// marshalling, etc.
 	if len(chanlist) == 0 {
 		return true, incoming_data
 	}
-	
-	data, ok := incoming_data.(map[string]any)
+	p.dispatch(ctx, topic, chanlist, func(ctx interfaces.AppFunctionContext) {
+		p.publishMatched(ctx, topic, chanlist, decoded, contentType, options, wrapCbor)
+	})
+	return true, incoming_data
+}
+
+/*
+publishMatched is Publish, once the subscriptions a message goes to are found: decoded
+is the message, as received or as decoded to look for a batch. It runs on a delivery
+worker, if there are DeliveryWorkers.
+*/
+func (p *Processor) publishMatched(ctx interfaces.AppFunctionContext, topic string, chanlist []submgr.SendHandle, decoded any, contentType string, options configuration.PipelineConfig, wrapCbor bool) {
+	var msg submgr.ChannelMessage
+	raw, isRaw := decoded.([]byte)
+	if simple, ok := decoded.(simplePayload); ok {
+		p.deliverSimple(ctx, chanlist, topic, simple)
+		return
+	}
+
+	passThrough := make([]submgr.SendHandle, 0)
+	classified := make([]submgr.SendHandle, 0, len(chanlist))
+	for _, ch := range chanlist {
+		if ch.Options().PassThrough {
+			passThrough = append(passThrough, ch)
+		} else {
+			classified = append(classified, ch)
+		}
+	}
+	if len(passThrough) > 0 {
+		if passMsg, ok := p.passThroughMessage(topic, contentType, decoded); ok {
+			p.deliver(ctx, passThrough, topic, passMsg)
+		}
+	}
+	if len(classified) == 0 {
+		return
+	}
+	chanlist = classified
+
+	if wrapCbor {
+		// Deliver the CBOR as-is, wrapped so it can travel in an event stream
+		cborBytes := raw
+		if !isRaw {
+			var err error
+			cborBytes, err = cbor.Marshal(decoded)
+			if err != nil {
+				p.lc.Errorf("Could not re-encode CBOR message on topic %s: %s", topic, err.Error())
+				return
+			}
+		}
+		wrapped, err := json.Marshal(wrappedPayload{ContentType: common.ContentTypeCBOR, Base64: base64.StdEncoding.EncodeToString(cborBytes)})
+		if err != nil {
+			return
+		}
+		msg.Payload = string(wrapped)
+		msg.EventType = "cbor"
+		p.deliver(ctx, chanlist, topic, msg)
+		return
+	}
+
+	if isRaw {
+		decoded = decodePayload(contentType, raw)
+	}
+
+	if wrapped, ok := decoded.(wrappedPayload); ok {
+		// The payload could not be un-marshaled, deliver it wrapped
+		wrapped_bytes, err := json.Marshal(wrapped)
+		if err != nil {
+			return
+		}
+		msg.Payload = string(wrapped_bytes)
+		msg.EventType = "raw"
+		p.deliver(ctx, chanlist, topic, msg)
+		return
+	}
+
+	data, ok := stringKeys(decoded).(map[string]any)
 	if !ok {
-		p.lc.Error("Received function call that was not an unmarshaled message, something is wrong")
-		return true, incoming_data
+		// Valid, but not an object (e.g. a bare number), can only be generic
+		event_bytes, err := json.Marshal(stringKeys(decoded))
+		if err != nil {
+			p.lc.Errorf("Could not marshal message on topic %s: %s", topic, err.Error())
+			return
+		}
+		msg.Payload = string(event_bytes)
+		p.deliver(ctx, chanlist, topic, msg)
+		return
+	}
+
+	if isCommandResponse(options.CommandResponseTopicPrefix, topic, data) {
+		// Deliver the whole response, not just any Event inside it - the client
+		// needs the requestId and statusCode to match it to its command
+		event_bytes, err := json.Marshal(data)
+		if err == nil {
+			msg.Payload = string(event_bytes)
+			msg.EventType = "response"
+			p.deliver(ctx, chanlist, topic, msg)
+		}
+		return
+	}
+
+	if eventMap, ok := eventOf(data); ok {
+		p.addUnits(ctx, eventMap)
 	}
 
+	// Messages that look like Events but fail validation are handled according to InvalidEvents
+	var invalid error
+	var edgexEvent dtos.Event
 	event, ok := data["event"]
 	// If this has an "event" member then it is likely an AddEventRequest, we want to return the Event
 	// contained therein.
 	if (ok) {
-		intermediate, err := json.Marshal(event)
+		decodedEvent, event_bytes, err := decodeEvent(event)
 		if err == nil {
-			err := json.Unmarshal(intermediate, &dstEvent)
-			if err == nil {
-				err := common.Validate(dstEvent)
-				if err == nil {
-					msg.Payload = string(intermediate)
-					msg.EventType = "edgex"
-				}
-			}
+			edgexEvent = decodedEvent
+			msg.Payload = string(p.enrichEvent(ctx, edgexEvent, event_bytes))
+			msg.EventType = "edgex"
+		} else if looksLikeEvent(event) {
+			invalid = err
 		}
 	}
 
@@ -78,14 +332,55 @@ func (p *Processor) Publish(ctx interfaces.AppFunctionContext, incoming_data int
 		// Still unsure. See if it is an event in itself.
 		_, ok := data["readings"]
 		if ok {
+			decodedEvent, event_bytes, err := decodeEvent(data)
+			if err == nil {
+				edgexEvent = decodedEvent
+				msg.Payload = string(p.enrichEvent(ctx, edgexEvent, event_bytes))
+				msg.EventType = "edgex"
+				invalid = nil
+			} else {
+				invalid = err
+			}
+		}
+	}
+
+	if msg.EventType == "edgex" {
+		p.validation.valid.Add(1)
+	} else if invalid != nil && !p.handleInvalidEvent(ctx, chanlist, topic, data, invalid) {
+		return
+	}
+
+	if msg.EventType == "" {
+		// Maybe a system event, e.g. core-metadata telling us a device was added
+		_, hasType := data["type"]
+		_, hasAction := data["action"]
+		if hasType && hasAction {
 			event_bytes, err := json.Marshal(data)
 			if err == nil {
-				err := json.Unmarshal(event_bytes, &dstEvent)
+				var sysEvent dtos.SystemEvent
+				err := json.Unmarshal(event_bytes, &sysEvent)
+				if err == nil && isMetadataSystemEvent(sysEvent) {
+					msg.Payload = string(event_bytes)
+					msg.EventType = "system"
+				}
+			}
+		}
+	}
+
+	if msg.EventType == "" {
+		// Maybe a service metric from a telemetry topic
+		_, hasName := data["name"]
+		_, hasFields := data["fields"]
+		if hasName && hasFields {
+			event_bytes, err := json.Marshal(data)
+			if err == nil {
+				var metric dtos.Metric
+				err := json.Unmarshal(event_bytes, &metric)
 				if err == nil {
-					err := common.Validate(dstEvent)
+					err := common.Validate(metric)
 					if err == nil {
 						msg.Payload = string(event_bytes)
-						msg.EventType = "edgex"
+						msg.EventType = "metric"
 					}
 				}
 			}
@@ -96,14 +391,241 @@ func (p *Processor) Publish(ctx interfaces.AppFunctionContext, incoming_data int
 		// Not an EdgeX event, just put together the JSON string
 		event_bytes, err := json.Marshal(data)
 		if err != nil {
-			return true, incoming_data
+			return
 		}
 		msg.Payload = string(event_bytes)
 	}
 
-	for _, ch := range chanlist {
-		ch <- msg
+	if msg.EventType == "edgex" {
+		p.deliverEvent(ctx, chanlist, topic, edgexEvent, msg)
+	} else {
+		p.deliver(ctx, chanlist, topic, msg)
 	}
+}
 
-	return true, incoming_data
+/*
+passThroughMessage builds the message for PassThrough subscriptions. Raw JSON is
+delivered untouched, as a generic event. Raw CBOR, and anything else that can't
+travel in an event stream as-is, is wrapped the same way as for classified delivery.
+Data an earlier pipeline function already decoded is marshaled as JSON.
+*/
+func (p *Processor) passThroughMessage(topic any, contentType string, data any) (submgr.ChannelMessage, bool) {
+	var msg submgr.ChannelMessage
+	var payload []byte
+	var err error
+	raw, isRaw := data.([]byte)
+	switch {
+	case isRaw && contentType == common.ContentTypeCBOR:
+		payload, err = json.Marshal(wrappedPayload{ContentType: common.ContentTypeCBOR, Base64: base64.StdEncoding.EncodeToString(raw)})
+		msg.EventType = "cbor"
+	case isRaw && (contentType == common.ContentTypeJSON || contentType == "") && json.Valid(raw):
+		payload = raw
+	case isRaw:
+		payload, err = json.Marshal(wrapPayload(contentType, raw))
+		msg.EventType = "raw"
+	default:
+		if wrapped, ok := data.(wrappedPayload); ok {
+			msg.EventType = "raw"
+			payload, err = json.Marshal(wrapped)
+		} else {
+			payload, err = json.Marshal(stringKeys(data))
+		}
+	}
+	if err != nil {
+		p.lc.Errorf("Could not marshal message on topic %s: %s", topic, err.Error())
+		return msg, false
+	}
+	msg.Payload = string(payload)
+	return msg, true
+}
+
+/*
+decodeEvent checks whether v (generic un-marshaled JSON) is a valid EdgeX Event.
+
+Returns the Event and its JSON if so, or why not.
+*/
+func decodeEvent(v any) (dtos.Event, []byte, error) {
+	var dstEvent dtos.Event
+	event_bytes, err := json.Marshal(v)
+	if err != nil {
+		return dstEvent, nil, err
+	}
+	if err := json.Unmarshal(event_bytes, &dstEvent); err != nil {
+		return dstEvent, nil, err
+	}
+	if err := common.Validate(dstEvent); err != nil {
+		return dstEvent, nil, err
+	}
+	return dstEvent, event_bytes, nil
+}
+
+/*
+batchEvents checks whether a message is a batch of events: either a JSON array,
+or an object whose "events" member is an array. Each element may be an Event or
+an AddEventRequest.
+
+Returns the elements, converted to the generic JSON form, and true if so.
+*/
+func batchEvents(incoming_data any) ([]any, bool) {
+	var list []any
+	switch v := incoming_data.(type) {
+	case []any:
+		list = v
+	case map[string]any:
+		list, _ = v["events"].([]any)
+	case map[any]any:
+		list, _ = v["events"].([]any)
+	}
+	if list == nil {
+		return nil, false
+	}
+	return stringKeys(list).([]any), true
+}
+
+/*
+publishBatch delivers each valid Event in a batch on its own, on the delivery workers if
+there are DeliveryWorkers.
+
+Each Event is matched as if it had been published on the batch topic with
+/<profileName>/<deviceName>/<sourceName> appended, the same way EdgeX names
+the topics of individual events. Elements that are not valid Events are skipped.
+*/
+func (p *Processor) publishBatch(ctx interfaces.AppFunctionContext, topic string, batch []any) {
+	p.lc.Tracef("Batch of %d messages received on topic %s", len(batch), topic)
+	for i, element := range batch {
+		if request, ok := element.(map[string]any); ok {
+			if event, ok := request["event"]; ok {
+				element = event
+			}
+		}
+		if eventMap, ok := element.(map[string]any); ok {
+			p.addUnits(ctx, eventMap)
+		}
+		event, event_bytes, err := decodeEvent(element)
+		if err != nil {
+			if !looksLikeEvent(element) {
+				p.lc.Debugf("Element %d of batch on topic %s is not an Event, skipped", i, topic)
+				continue
+			}
+			// Without a valid Event we can't tell its own topic, it goes out on the batch topic
+			chanlist := p.subscribedChannels(ctx, topic)
+			if len(chanlist) == 0 {
+				continue
+			}
+			recordMatched(ctx, chanlist)
+			data, _ := element.(map[string]any)
+			p.dispatch(ctx, topic, chanlist, func(ctx interfaces.AppFunctionContext) {
+				if p.handleInvalidEvent(ctx, chanlist, topic, data, err) {
+					if event_bytes, err := json.Marshal(data); err == nil {
+						p.deliver(ctx, chanlist, topic, submgr.ChannelMessage{Payload: string(event_bytes)})
+					}
+				}
+			})
+			continue
+		}
+		p.validation.valid.Add(1)
+		eventTopic := strings.TrimSuffix(topic, "/") + "/" + event.ProfileName + "/" + event.DeviceName + "/" + event.SourceName
+		chanlist := p.subscribedChannels(ctx, eventTopic)
+		if len(chanlist) == 0 {
+			continue
+		}
+		recordMatched(ctx, chanlist)
+		p.dispatch(ctx, eventTopic, chanlist, func(ctx interfaces.AppFunctionContext) {
+			p.deliverEvent(ctx, chanlist, eventTopic, event, submgr.ChannelMessage{EventType: "edgex", Payload: string(p.enrichEvent(ctx, event, event_bytes))})
+		})
+	}
+}
+
+/*
+isCommandResponse reports whether data is a command response: it arrived on a topic
+beneath prefix, the CommandResponseTopicPrefix (where responses go to
+<prefix>/<service>/<requestId>), and looks like a BaseResponse.
+*/
+func isCommandResponse(prefix string, topic any, data map[string]any) bool {
+	topicString, ok := topic.(string)
+	if !ok || prefix == "" {
+		return false
+	}
+	if !strings.HasPrefix(topicString, strings.TrimSuffix(prefix, "/")+"/") {
+		return false
+	}
+	_, hasStatus := data["statusCode"]
+	_, hasVersion := data["apiVersion"]
+	return hasStatus && hasVersion
+}
+
+// isMetadataSystemEvent reports whether e is a core-metadata system event we know how to classify.
+func isMetadataSystemEvent(e dtos.SystemEvent) bool {
+	if e.Source == "" {
+		return false
+	}
+	switch e.Type {
+	case common.DeviceSystemEventType, common.DeviceProfileSystemEventType, common.DeviceServiceSystemEventType, common.ProvisionWatcherSystemEventType:
+	default:
+		return false
+	}
+	switch e.Action {
+	case common.SystemEventActionAdd, common.SystemEventActionUpdate, common.SystemEventActionDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+deliver sends the message, with the correlation ID of the message it came from, to all the
+subscriptions in chanlist, wrapped in an envelope for those that asked for one. What can't be delivered because of a full buffer, or wrapped,
+is dead-lettered. The delivery envelope is put on when the message is written, once it is numbered.
+*/
+func (p *Processor) deliver(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, msg submgr.ChannelMessage) {
+	var cloudMsg *submgr.ChannelMessage
+	var cloudErr error
+	var overflowed []string
+	msg.CorrelationID = ctx.CorrelationID()
+	received := time.Now()
+	for _, ch := range chanlist {
+		toSend := msg
+		if ch.Options().Envelope == submgr.EnvelopeDelivery {
+			toSend.Delivery = true
+			toSend.Topic = fmt.Sprint(topic)
+			toSend.ReceivedAt = received
+		}
+		if ch.Options().Envelope == submgr.EnvelopeCloudEvents {
+			if cloudMsg == nil && cloudErr == nil {
+				wrapped, err := cloudEventMessage(topic, msg)
+				if err != nil {
+					p.lc.Errorf("Could not wrap message on topic %s in a CloudEvent: %s", topic, err.Error())
+					cloudErr = err
+					p.deadLetter(DeadLetterTransformFailed, topic, msg, 1, err)
+				} else {
+					cloudMsg = &wrapped
+				}
+			}
+			if cloudErr != nil {
+				continue
+			}
+			toSend = *cloudMsg
+		}
+		toSend.ChunkSize = ch.Options().ChunkSize
+		spanCtx, span := startSpan(ctx, "sse.deliver", attribute.String("sse.event_type", toSend.EventType))
+		toSend.TraceParent = tracing.TraceParent(spanCtx)
+		err := ch.TrySend(toSend)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		if err != nil {
+			p.lc.Debugf("Message on topic %s not delivered to a subscription: %s", topic, err.Error())
+			if err == submgr.ErrBufferFull {
+				overflowed = append(overflowed, ch.SubId())
+			}
+			continue
+		}
+		p.delivery.delivered.Add(1)
+	}
+	if len(overflowed) > 0 {
+		p.delivery.dropped.Add(uint64(len(overflowed)))
+		p.warnDropped(topic, overflowed)
+		p.deadLetter(DeadLetterOverflow, topic, msg, len(overflowed), nil)
+	}
 }
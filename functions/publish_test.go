@@ -0,0 +1,330 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// actual event copypasta
+const edgexEvent = "{\"apiVersion\":\"v3\",\"id\":\"7d3d60c0-5279-436b-b99d-6ab1de0eb600\",\"deviceName\":\"Virtual-Bacon-Cape-04\",\"profileName\":\"Bacon-Cape\",\"sourceName\":\"mPercentLoad\",\"origin\":1661535695202033126,\"readings\":[{\"id\":\"b4f7b655-5dac-4f34-8dc7-caa2f8c1a34d\",\"origin\":1661535695202033126,\"deviceName\":\"Virtual-Bacon-Cape-04\",\"resourceName\":\"mPercentLoad\",\"profileName\":\"Bacon-Cape\",\"valueType\":\"Uint32\",\"value\":\"74\"}]}"
+
+// Object holding a Processor plus one active subscription to everything
+type testProcessor struct {
+	cfg    configuration.Config
+	subs   submgr.SubscriptionManager
+	proc   Processor
+	rxchan <-chan submgr.ChannelMessage
+}
+
+func newTestProcessor(t *testing.T) *testProcessor {
+	tp := &testProcessor{}
+	tp.cfg.SetDefaults()
+	if err := tp.subs.Init(10, 10, 10, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	tp.proc = NewProcessor(logger.NewMockClient(), &tp.subs, &tp.cfg)
+	subid, err := tp.subs.NewSubscription()
+	if err != nil {
+		t.Fatalf("Could not add a subscription: %v", err)
+	}
+	subinfo := tp.subs.Subscription(subid)
+	if err := tp.subs.Include(subinfo, ""); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	tp.subs.SetActive(subinfo, true)
+	tp.rxchan, _ = tp.subs.ReceiveChannel(subinfo)
+	return tp
+}
+
+// publish runs data through the pipeline function as if received on topic, returning what was delivered
+func (tp *testProcessor) publish(t *testing.T, topic string, data any) []submgr.ChannelMessage {
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	ctx.AddValue(interfaces.RECEIVEDTOPIC, topic)
+	cont, result := tp.proc.Publish(ctx, data)
+	if !cont {
+		t.Fatal("Publish stopped the pipeline")
+	}
+	if result == nil {
+		t.Fatal("Publish did not pass its input along")
+	}
+	rv := make([]submgr.ChannelMessage, 0)
+	for {
+		select {
+		case msg := <-tp.rxchan:
+			rv = append(rv, msg)
+		default:
+			return rv
+		}
+	}
+}
+
+// jsonData returns the generic JSON un-marshaling of text, as the SDK would pass it to Publish
+func jsonData(t *testing.T, text string) any {
+	var rv any
+	if err := json.Unmarshal([]byte(text), &rv); err != nil {
+		t.Fatalf("Bad test JSON: %v", err)
+	}
+	return rv
+}
+
+// cborData returns the generic CBOR un-marshaling of the JSON text, as the SDK would pass it to Publish
+func cborData(t *testing.T, text string) any {
+	encoded, err := cbor.Marshal(jsonData(t, text))
+	if err != nil {
+		t.Fatalf("Could not encode test CBOR: %v", err)
+	}
+	var rv any
+	if err := cbor.Unmarshal(encoded, &rv); err != nil {
+		t.Fatalf("Could not decode test CBOR: %v", err)
+	}
+	return rv
+}
+
+func TestPublishJson(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	msgs := tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", jsonData(t, edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event, got %v", msgs)
+	}
+	msgs = tp.publish(t, "ble/events/alarms", jsonData(t, "{\"deviceId\":1, \"state\": \"CLOSED\"}"))
+	if len(msgs) != 1 || msgs[0].EventType != "" || msgs[0].Payload != "{\"deviceId\":1,\"state\":\"CLOSED\"}" {
+		t.Fatalf("Expected one generic event, got %v", msgs)
+	}
+}
+
+func TestPublishCbor(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	data := cborData(t, edgexEvent)
+	if _, ok := data.(map[any]any); !ok {
+		t.Fatalf("Test CBOR decoded as %T, expected map[any]any", data)
+	}
+	msgs := tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", data)
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event, got %v", msgs)
+	}
+	var event map[string]any
+	if err := json.Unmarshal([]byte(msgs[0].Payload), &event); err != nil || event["deviceName"] != "Virtual-Bacon-Cape-04" {
+		t.Fatalf("CBOR event not re-encoded as JSON: %s", msgs[0].Payload)
+	}
+
+	tp.cfg.SSE.CBORDelivery = configuration.CBORDeliveryBase64
+	msgs = tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", cborData(t, edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "cbor" {
+		t.Fatalf("Expected one cbor event, got %v", msgs)
+	}
+	var wrapped wrappedPayload
+	if err := json.Unmarshal([]byte(msgs[0].Payload), &wrapped); err != nil || wrapped.ContentType != "application/cbor" {
+		t.Fatalf("Bad CBOR envelope: %s", msgs[0].Payload)
+	}
+	raw, err := base64.StdEncoding.DecodeString(wrapped.Base64)
+	if err != nil {
+		t.Fatalf("Bad base64 in CBOR envelope: %v", err)
+	}
+	var decoded map[string]any
+	if err := cbor.Unmarshal(raw, &decoded); err != nil || decoded["deviceName"] != "Virtual-Bacon-Cape-04" {
+		t.Fatalf("Wrapped CBOR did not decode to the event: %v", decoded)
+	}
+}
+
+func TestPublishSystemEvent(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	sysEvent := "{\"apiVersion\":\"v3\",\"type\":\"device\",\"action\":\"add\",\"source\":\"core-metadata\",\"owner\":\"device-virtual\",\"tags\":{\"device-profile-name\":\"Random-Integer-Device\"},\"details\":{\"name\":\"Random-Integer-Device\"},\"timestamp\":1661535695202033126}"
+	msgs := tp.publish(t, "edgex/system-events/core-metadata/device/add/device-virtual/Random-Integer-Device", jsonData(t, sysEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "system" {
+		t.Fatalf("Expected one system event, got %v", msgs)
+	}
+	// Looks similar, but not a system event we know
+	msgs = tp.publish(t, "factory/alarms", jsonData(t, "{\"type\":\"door\",\"action\":\"open\",\"source\":\"sensor-3\"}"))
+	if len(msgs) != 1 || msgs[0].EventType != "" {
+		t.Fatalf("Expected one generic event, got %v", msgs)
+	}
+}
+
+func TestPublishMetric(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	metric := "{\"apiVersion\":\"v3\",\"name\":\"EventsPersisted\",\"fields\":[{\"name\":\"count\",\"value\":1294}],\"tags\":[{\"name\":\"service\",\"value\":\"core-data\"}],\"timestamp\":1661535695202033126}"
+	msgs := tp.publish(t, "edgex/telemetry/core-data/EventsPersisted", jsonData(t, metric))
+	if len(msgs) != 1 || msgs[0].EventType != "metric" {
+		t.Fatalf("Expected one metric event, got %v", msgs)
+	}
+	// No fields, fails validation
+	msgs = tp.publish(t, "edgex/telemetry/core-data/EventsPersisted", jsonData(t, "{\"name\":\"EventsPersisted\",\"fields\":[],\"timestamp\":1}"))
+	if len(msgs) != 1 || msgs[0].EventType != "" {
+		t.Fatalf("Expected one generic event, got %v", msgs)
+	}
+}
+
+func TestPublishCommandResponse(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	response := "{\"apiVersion\":\"v3\",\"requestId\":\"e6e8a2f4-eb14-4649-9e2b-175247911369\",\"statusCode\":200,\"event\":" + edgexEvent + "}"
+	msgs := tp.publish(t, "edgex/response/edgex-ui/e6e8a2f4-eb14-4649-9e2b-175247911369", jsonData(t, response))
+	if len(msgs) != 1 || msgs[0].EventType != "response" {
+		t.Fatalf("Expected one response event, got %v", msgs)
+	}
+	var delivered map[string]any
+	if err := json.Unmarshal([]byte(msgs[0].Payload), &delivered); err != nil || delivered["requestId"] != "e6e8a2f4-eb14-4649-9e2b-175247911369" {
+		t.Fatalf("Response delivered without its requestId: %s", msgs[0].Payload)
+	}
+	// Same payload elsewhere is just an AddEventRequest-like message
+	msgs = tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", jsonData(t, response))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event, got %v", msgs)
+	}
+}
+
+// subscribe adds another active subscription including prefix, returning its receive channel
+func (tp *testProcessor) subscribe(t *testing.T, prefix string) <-chan submgr.ChannelMessage {
+	subid, err := tp.subs.NewSubscription()
+	if err != nil {
+		t.Fatalf("Could not add a subscription: %v", err)
+	}
+	subinfo := tp.subs.Subscription(subid)
+	if err := tp.subs.Include(subinfo, prefix); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	tp.subs.SetActive(subinfo, true)
+	rxchan, _ := tp.subs.ReceiveChannel(subinfo)
+	return rxchan
+}
+
+func TestPublishBatch(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	device04 := tp.subscribe(t, "edgex/events/batch/Bacon-Cape/Virtual-Bacon-Cape-04")
+	other := strings.Replace(edgexEvent, "Virtual-Bacon-Cape-04", "Virtual-Bacon-Cape-05", -1)
+	batch := "[" + edgexEvent + ", {\"apiVersion\":\"v3\",\"event\":" + other + "}, {\"not\":\"an event\"}]"
+	msgs := tp.publish(t, "edgex/events/batch", jsonData(t, batch))
+	if len(msgs) != 2 || msgs[0].EventType != "edgex" || msgs[1].EventType != "edgex" {
+		t.Fatalf("Expected two edgex events, got %v", msgs)
+	}
+	if !strings.Contains(msgs[1].Payload, "Virtual-Bacon-Cape-05") || strings.Contains(msgs[1].Payload, "\"event\"") {
+		t.Fatalf("AddEventRequest in batch not unwrapped: %s", msgs[1].Payload)
+	}
+	select {
+	case msg := <-device04:
+		if !strings.Contains(msg.Payload, "Virtual-Bacon-Cape-04") {
+			t.Fatalf("Wrong event matched by device subscription: %s", msg.Payload)
+		}
+	default:
+		t.Fatal("Device subscription did not receive its event from the batch")
+	}
+	select {
+	case msg := <-device04:
+		t.Fatalf("Device subscription received another device's event: %s", msg.Payload)
+	default:
+	}
+	// Envelope form
+	msgs = tp.publish(t, "edgex/events/batch", jsonData(t, "{\"events\":["+other+"]}"))
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Payload, "Virtual-Bacon-Cape-05") {
+		t.Fatalf("Expected one event from batch envelope, got %v", msgs)
+	}
+}
+
+func TestPublishRawBytes(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	msgs := tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event, got %v", msgs)
+	}
+	batch := "[" + edgexEvent + "]"
+	msgs = tp.publish(t, "edgex/events/batch", []byte(batch))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event from raw batch, got %v", msgs)
+	}
+	msgs = tp.publish(t, "legacy/sensor/3", []byte("T=21.5;H=40"))
+	if len(msgs) != 1 || msgs[0].EventType != "raw" {
+		t.Fatalf("Expected one raw event, got %v", msgs)
+	}
+}
+
+func TestPublishPassThrough(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	// Swap the test subscription for a pass-through one
+	for _, sub := range tp.subs.AllSubscriptions() {
+		if err := tp.subs.SetOptions(sub, submgr.SubscriptionOptions{PassThrough: true}); err != nil {
+			t.Fatalf("Could not set options: %v", err)
+		}
+	}
+	payload := "{ \"deviceId\": 1,\n  \"state\": \"CLOSED\" }"
+	msgs := tp.publish(t, "ble/events/alarms", []byte(payload))
+	if len(msgs) != 1 || msgs[0].EventType != "" || msgs[0].Payload != payload {
+		t.Fatalf("Expected the payload untouched, got %v", msgs)
+	}
+	msgs = tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "" || msgs[0].Payload != edgexEvent {
+		t.Fatalf("Expected the event untouched and unclassified, got %v", msgs)
+	}
+	msgs = tp.publish(t, "legacy/sensor/4", []byte{0xff, 0x00})
+	if len(msgs) != 1 || msgs[0].EventType != "raw" {
+		t.Fatalf("Expected binary wrapped as a raw event, got %v", msgs)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	tp.proc.Process(ctx, "factory/line1/press", []byte("{\"pressure\":3.2}"))
+	select {
+	case msg := <-tp.rxchan:
+		if msg.EventType != "" || msg.Payload != "{\"pressure\":3.2}" {
+			t.Fatalf("Wrong message delivered: %v", msg)
+		}
+	default:
+		t.Fatal("Processed message not delivered")
+	}
+}
+
+func TestNormalizeTopic(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.StripTopicPrefix = "site1/"
+	tp.cfg.SSE.TopicRewrites = "edgex=bus, edgex/events/device=devices, legacy="
+	cases := map[string]string{
+		"site1/edgex/events/device/P/D/S": "devices/P/D/S",
+		"site1/edgex/system-events/x":     "bus/system-events/x",
+		"site1/legacy/sensor":             "sensor",
+		"site10/edgex/events":             "site10/edgex/events",
+		"edgexfoo/events":                 "edgexfoo/events",
+		"site1":                           "",
+	}
+	for in, expected := range cases {
+		if out := tp.proc.normalizeTopic(in); out != expected {
+			t.Fatalf("Topic %s normalized to %s, expected %s", in, out, expected)
+		}
+	}
+	// Matching is on the normalized topic
+	devices := tp.subscribe(t, "devices/Bacon-Cape")
+	_ = tp.publish(t, "site1/edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	select {
+	case msg := <-devices:
+		if msg.EventType != "edgex" {
+			t.Fatalf("Expected an edgex event, got %v", msg)
+		}
+	default:
+		t.Fatal("Event not matched on its normalized topic")
+	}
+}
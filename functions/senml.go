@@ -0,0 +1,69 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"encoding/json"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+// Struct senmlRecord is one SenML (RFC 8428) record in JSON.
+type senmlRecord struct {
+	BaseName string      `json:"bn,omitempty"`
+	Name     string      `json:"n"`
+	Unit     string      `json:"u,omitempty"`
+	Value    json.Number `json:"v"`
+	Time     float64     `json:"t,omitempty"`
+}
+
+// isNumericValueType returns true for the EdgeX value types of single numbers.
+func isNumericValueType(valueType string) bool {
+	switch valueType {
+	case common.ValueTypeUint8, common.ValueTypeUint16, common.ValueTypeUint32, common.ValueTypeUint64,
+		common.ValueTypeInt8, common.ValueTypeInt16, common.ValueTypeInt32, common.ValueTypeInt64,
+		common.ValueTypeFloat32, common.ValueTypeFloat64:
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+senmlEvent converts the numeric readings of an Event into a SenML pack. The device name is the
+base name of the pack, the resource name the name of each record, and the reading origin the
+time, in seconds since the epoch. Other readings are left out; senmlEvent returns nil if none
+are left.
+*/
+func senmlEvent(event dtos.Event) ([]byte, error) {
+	records := make([]senmlRecord, 0, len(event.Readings))
+	for _, r := range event.Readings {
+		if !isNumericValueType(r.ValueType) {
+			continue
+		}
+		// Keep the value as EdgeX wrote it, as long as it is a JSON number
+		var value json.Number
+		if err := json.Unmarshal([]byte(r.Value), &value); err != nil {
+			continue
+		}
+		record := senmlRecord{
+			Name:  r.ResourceName,
+			Unit:  r.Units,
+			Value: value,
+			Time:  float64(r.Origin) / 1e9,
+		}
+		if len(records) == 0 {
+			record.BaseName = event.DeviceName + ":"
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(records)
+}
@@ -0,0 +1,70 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+func TestSenMLFormat(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	subid, _ := tp.subs.NewSubscription()
+	subinfo := tp.subs.Subscription(subid)
+	if err := tp.subs.Include(subinfo, "edgex/events"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	if err := tp.subs.SetOptions(subinfo, submgr.SubscriptionOptions{Format: submgr.FormatSenML}); err != nil {
+		t.Fatalf("Could not set options: %v", err)
+	}
+	tp.subs.SetActive(subinfo, true)
+	senml, _ := tp.subs.ReceiveChannel(subinfo)
+
+	msgs := tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event for the default format, got %v", msgs)
+	}
+	var msg submgr.ChannelMessage
+	select {
+	case msg = <-senml:
+	default:
+		t.Fatal("Nothing delivered to the SenML format subscription")
+	}
+	if msg.EventType != "senml" {
+		t.Fatalf("Expected a senml event, got %v", msg)
+	}
+	var records []map[string]any
+	if err := json.Unmarshal([]byte(msg.Payload), &records); err != nil {
+		t.Fatalf("SenML event is not a JSON list: %s", msg.Payload)
+	}
+	if len(records) != 1 || records[0]["bn"] != "Virtual-Bacon-Cape-04:" || records[0]["n"] != "mPercentLoad" || records[0]["v"] != float64(74) {
+		t.Fatalf("Wrong SenML records: %s", msg.Payload)
+	}
+}
+
+func TestSenMLSkipsNonNumeric(t *testing.T) {
+	event := dtos.Event{DeviceName: "dev", Readings: []dtos.BaseReading{
+		{ResourceName: "name", ValueType: "String", SimpleReading: dtos.SimpleReading{Value: "bacon"}},
+		{ResourceName: "bad", ValueType: "Float64", SimpleReading: dtos.SimpleReading{Value: "NaN"}},
+	}}
+	senml_bytes, err := senmlEvent(event)
+	if err != nil || senml_bytes != nil {
+		t.Fatalf("Expected no SenML for non-numeric readings, got %s, %v", senml_bytes, err)
+	}
+	event.Readings = append(event.Readings, dtos.BaseReading{ResourceName: "temp", ValueType: "Float32", Units: "Cel", Origin: 1500000000000000000, SimpleReading: dtos.SimpleReading{Value: "2.150000e+01"}})
+	senml_bytes, err = senmlEvent(event)
+	if err != nil {
+		t.Fatalf("Could not convert: %v", err)
+	}
+	if string(senml_bytes) != `[{"bn":"dev:","n":"temp","u":"Cel","v":2.150000e+01,"t":1500000000}]` {
+		t.Fatalf("Wrong SenML: %s", senml_bytes)
+	}
+}
@@ -0,0 +1,163 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+)
+
+// Struct simpleReading is one reading in the "simple" format, without the EdgeX bookkeeping.
+type simpleReading struct {
+	Device    string `json:"device"`
+	Resource  string `json:"resource"`
+	Value     any    `json:"value"`
+	ValueType string `json:"valueType"`
+	Units     string `json:"units,omitempty"`
+	// What its device profile maps its value to, with the enumLabels option
+	Label     string `json:"label,omitempty"`
+	Origin    int64  `json:"origin"`
+}
+
+/*
+simplifyEvent flattens an Event into the "simple" format, a list of its readings.
+Binary readings have the base64 of their bytes as value, Object readings the object.
+The readings in labels, by their index, get their label.
+*/
+func simplifyEvent(event dtos.Event, labels map[int]string) ([]byte, error) {
+	readings := make([]simpleReading, 0, len(event.Readings))
+	for i, r := range event.Readings {
+		reading := simpleReading{
+			Device:    r.DeviceName,
+			Resource:  r.ResourceName,
+			Value:     r.Value,
+			ValueType: r.ValueType,
+			Units:     r.Units,
+			Label:     labels[i],
+			Origin:    r.Origin,
+		}
+		switch r.ValueType {
+		case common.ValueTypeBinary:
+			reading.Value = base64.StdEncoding.EncodeToString(r.BinaryValue)
+		case common.ValueTypeObject, common.ValueTypeObjectArray:
+			reading.Value = r.ObjectValue
+		}
+		readings = append(readings, reading)
+	}
+	return json.Marshal(readings)
+}
+
+/*
+deliverEvent sends an EdgeX Event to the subscriptions in chanlist whose devices option, if
+any, picks its device, and whose filter, if any, it passes, with its readings converted
+and rounded as each asked for (after their extensions), in the format each asked for, and its binary readings in
+media events to those that asked for that. Nothing is sent if its device is suppressed,
+or ProfileValidation drops it.
+*/
+func (p *Processor) deliverEvent(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, event dtos.Event, msg submgr.ChannelMessage) {
+	if p.suppressed(ctx, event.DeviceName) {
+		return
+	}
+	msg, ok := p.checkProfile(ctx, chanlist, topic, event, msg)
+	if !ok {
+		return
+	}
+	chanlist = filteredChannels(p.memberChannels(ctx, chanlist, event), event)
+	chanlist = p.deliverExtended(ctx, chanlist, topic, event, msg)
+	p.deliverPicked(ctx, chanlist, topic, event, msg)
+}
+
+// deliverPicked is deliverEvent, once the subscriptions the Event goes to are picked, and it is transformed by their extensions.
+func (p *Processor) deliverPicked(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, event dtos.Event, msg submgr.ChannelMessage) {
+	if hasMedia(event) {
+		media := make([]submgr.SendHandle, 0)
+		others := make([]submgr.SendHandle, 0, len(chanlist))
+		for _, ch := range chanlist {
+			if ch.Options().Media {
+				media = append(media, ch)
+			} else {
+				others = append(others, ch)
+			}
+		}
+		if len(media) > 0 {
+			p.deliverMedia(ctx, media, topic, event, msg)
+			chanlist = others
+		}
+	}
+	p.deliverReadings(ctx, chanlist, topic, event, msg)
+}
+
+// deliverReadings is deliverPicked, for those that don't want its binary readings as media events.
+func (p *Processor) deliverReadings(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, event dtos.Event, msg submgr.ChannelMessage) {
+	if len(chanlist) == 0 {
+		return
+	}
+	for _, ch := range chanlist {
+		if transformsReadings(ch.Options()) {
+			p.deliverTransformed(ctx, chanlist, topic, event, msg)
+			return
+		}
+	}
+	p.deliverFormats(ctx, chanlist, topic, event, msg)
+}
+
+/*
+deliverFormats sends an EdgeX Event to the subscriptions in chanlist in the format each
+asked for: msg as it is, the Event flattened into the "simple" format as a "simple" event,
+or its numeric readings as SenML records in a "senml" event, with the labels of its
+readings for those with the enumLabels option.
+*/
+func (p *Processor) deliverFormats(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, event dtos.Event, msg submgr.ChannelMessage) {
+	chanlist = p.deliverLabelled(ctx, chanlist, topic, event, msg)
+	p.sendFormats(ctx, chanlist, topic, event, msg, nil)
+}
+
+/*
+sendFormats is deliverFormats, once msg has the labels of the Event's readings in labels,
+if the subscriptions in chanlist get any. SenML has no place for them.
+*/
+func (p *Processor) sendFormats(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, event dtos.Event, msg submgr.ChannelMessage, labels map[int]string) {
+	simple := make([]submgr.SendHandle, 0)
+	senml := make([]submgr.SendHandle, 0)
+	edgex := make([]submgr.SendHandle, 0, len(chanlist))
+	for _, ch := range chanlist {
+		switch ch.Options().Format {
+		case submgr.FormatSimple:
+			simple = append(simple, ch)
+		case submgr.FormatSenML:
+			senml = append(senml, ch)
+		default:
+			edgex = append(edgex, ch)
+		}
+	}
+	p.deliver(ctx, edgex, topic, msg)
+	if len(simple) > 0 {
+		simple_bytes, err := simplifyEvent(event, labels)
+		if err != nil {
+			p.lc.Errorf("Could not marshal simple Event on topic %s: %s", topic, err.Error())
+			p.deadLetter(DeadLetterTransformFailed, topic, msg, len(simple), err)
+		} else {
+			p.deliver(ctx, simple, topic, submgr.ChannelMessage{EventType: "simple", Payload: string(simple_bytes)})
+		}
+	}
+	if len(senml) > 0 {
+		senml_bytes, err := senmlEvent(event)
+		if err != nil {
+			p.lc.Errorf("Could not marshal SenML Event on topic %s: %s", topic, err.Error())
+			p.deadLetter(DeadLetterTransformFailed, topic, msg, len(senml), err)
+		} else if senml_bytes == nil {
+			p.lc.Debugf("Event on topic %s has no numeric readings, not delivered as SenML", topic)
+		} else {
+			p.deliver(ctx, senml, topic, submgr.ChannelMessage{EventType: "senml", Payload: string(senml_bytes)})
+		}
+	}
+}
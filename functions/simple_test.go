@@ -0,0 +1,52 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"testing"
+)
+
+func TestSimpleFormat(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	subid, _ := tp.subs.NewSubscription()
+	subinfo := tp.subs.Subscription(subid)
+	if err := tp.subs.Include(subinfo, "edgex/events"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	if err := tp.subs.SetOptions(subinfo, submgr.SubscriptionOptions{Format: submgr.FormatSimple}); err != nil {
+		t.Fatalf("Could not set options: %v", err)
+	}
+	tp.subs.SetActive(subinfo, true)
+	simple, _ := tp.subs.ReceiveChannel(subinfo)
+
+	msgs := tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", []byte(edgexEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "edgex" {
+		t.Fatalf("Expected one edgex event for the default format, got %v", msgs)
+	}
+	var msg submgr.ChannelMessage
+	select {
+	case msg = <-simple:
+	default:
+		t.Fatal("Nothing delivered to the simple format subscription")
+	}
+	if msg.EventType != "simple" {
+		t.Fatalf("Expected a simple event, got %v", msg)
+	}
+	var readings []map[string]any
+	if err := json.Unmarshal([]byte(msg.Payload), &readings); err != nil {
+		t.Fatalf("Simple event is not a JSON list: %s", msg.Payload)
+	}
+	if len(readings) != 1 || readings[0]["device"] != "Virtual-Bacon-Cape-04" || readings[0]["resource"] != "mPercentLoad" || readings[0]["value"] != "74" || readings[0]["valueType"] != "Uint32" || readings[0]["origin"] != float64(1661535695202033126) {
+		t.Fatalf("Wrong simple readings: %s", msg.Payload)
+	}
+	if _, ok := readings[0]["id"]; ok {
+		t.Fatalf("Simple reading has an id: %s", msg.Payload)
+	}
+}
@@ -0,0 +1,42 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/tracing"
+	"context"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Key of the W3C trace context of a message's pipeline span, among the function context's values
+const traceParentValue = "sse-traceparent"
+
+/*
+startPipelineSpan (an internal API) starts the root span of a message's trace, which takes
+its ID from the message's correlation ID, and leaves its trace context with the message.
+*/
+func startPipelineSpan(ctx interfaces.AppFunctionContext) trace.Span {
+	attrs := []attribute.KeyValue{attribute.String("edgex.correlation_id", ctx.CorrelationID())}
+	if topic, ok := ctx.GetValue(interfaces.RECEIVEDTOPIC); ok {
+		attrs = append(attrs, attribute.String("messaging.destination.name", topic))
+	}
+	spanCtx, span := tracing.Tracer().Start(tracing.WithCorrelationID(context.Background(), ctx.CorrelationID()),
+		"sse.pipeline", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attrs...))
+	if traceParent := tracing.TraceParent(spanCtx); traceParent != "" {
+		ctx.AddValue(traceParentValue, traceParent)
+	}
+	return span
+}
+
+// startSpan (an internal API) starts a span that is a child of the message's pipeline span.
+func startSpan(ctx interfaces.AppFunctionContext, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	traceParent, _ := ctx.GetValue(traceParentValue)
+	return tracing.Tracer().Start(tracing.WithTraceParent(context.Background(), traceParent), name, trace.WithAttributes(attrs...))
+}
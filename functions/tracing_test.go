@@ -0,0 +1,67 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/tracing"
+	"context"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	ctx.AddValue(interfaces.RECEIVEDTOPIC, "a/b")
+	tp.proc.Pipeline(ctx, []byte("{\"x\":1}"))
+	msg := <-tp.rxchan
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	root, ok := spans["sse.pipeline"]
+	if !ok || root.Parent().IsValid() || root.SpanKind() != trace.SpanKindConsumer {
+		t.Fatalf("No root pipeline span in %v", spans)
+	}
+	for _, name := range []string{"sse.publish", "sse.match", "sse.deliver"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("No %s span", name)
+		}
+		if span.Parent().SpanID() != root.SpanContext().SpanID() || span.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Fatalf("Span %s not a child of the pipeline span", name)
+		}
+	}
+	// The message carries its delivery span to the event stream
+	delivered := trace.SpanContextFromContext(tracing.WithTraceParent(context.Background(), msg.TraceParent))
+	if delivered.SpanID() != spans["sse.deliver"].SpanContext().SpanID() {
+		t.Fatalf("Wrong trace context %q on the delivered message", msg.TraceParent)
+	}
+
+	// Not traced at all without a provider
+	otel.SetTracerProvider(noop.NewTracerProvider())
+	ctx = pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	ctx.AddValue(interfaces.RECEIVEDTOPIC, "a/b")
+	tp.proc.Pipeline(ctx, []byte("{\"x\":1}"))
+	if msg := <-tp.rxchan; msg.TraceParent != "" {
+		t.Fatalf("Trace context %q with tracing disabled", msg.TraceParent)
+	}
+}
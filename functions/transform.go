@@ -0,0 +1,177 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+)
+
+// transformsReadings reports whether the options change the readings of Events: units or rounding.
+func transformsReadings(options submgr.SubscriptionOptions) bool {
+	return len(options.Units) > 0 || options.Rounding != nil
+}
+
+// transformKey returns what subscriptions whose readings are transformed the same have in common.
+func transformKey(options submgr.SubscriptionOptions) string {
+	key := strings.Join(options.Units, "\x00")
+	if options.Rounding != nil {
+		key += fmt.Sprintf("\x01%d/%v", options.Rounding.Digits, options.Rounding.Significant)
+	}
+	return key
+}
+
+/*
+transformReading returns a numeric reading converted to the units of the options, and then
+rounded as they say, and whether that changed it.
+*/
+func (p *Processor) transformReading(reading dtos.BaseReading, options submgr.SubscriptionOptions) (dtos.BaseReading, bool) {
+	if !isNumericValueType(reading.ValueType) {
+		return reading, false
+	}
+	changed := false
+	if len(options.Units) > 0 {
+		reading, changed = p.conversions.convert(reading, options.Units)
+	}
+	if options.Rounding != nil {
+		rounded, ok := roundReading(reading, *options.Rounding)
+		reading = rounded
+		changed = changed || ok
+	}
+	return reading, changed
+}
+
+/*
+roundReading returns a numeric reading rounded to the decimal places, or significant digits,
+and false if that didn't change it. Floats are written in the shortest form that reads back
+the same, which is what makes rounded streams compress well; integers stay integers.
+*/
+func roundReading(reading dtos.BaseReading, rounding submgr.Rounding) (dtos.BaseReading, bool) {
+	value, err := strconv.ParseFloat(reading.Value, 64)
+	if err != nil || value == 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return reading, false
+	}
+	isFloat := reading.ValueType == common.ValueTypeFloat32 || reading.ValueType == common.ValueTypeFloat64
+	if !isFloat && !rounding.Significant {
+		// Already no decimal places
+		return reading, false
+	}
+	places := rounding.Digits
+	if rounding.Significant {
+		places = rounding.Digits - 1 - int(math.Floor(math.Log10(math.Abs(value))))
+	}
+	var rounded float64
+	if places >= 0 {
+		scale := math.Pow10(places)
+		rounded = math.Round(value*scale) / scale
+	} else {
+		scale := math.Pow10(-places)
+		rounded = math.Round(value/scale) * scale
+	}
+	var text string
+	switch {
+	case !isFloat:
+		text = strconv.FormatFloat(rounded, 'f', 0, 64)
+	case reading.ValueType == common.ValueTypeFloat32:
+		text = strconv.FormatFloat(rounded, 'e', -1, 32)
+	default:
+		text = strconv.FormatFloat(rounded, 'e', -1, 64)
+	}
+	if text == reading.Value {
+		return reading, false
+	}
+	reading.Value = text
+	return reading, true
+}
+
+/*
+transformEvent returns the Event with its readings transformed as the options say, and
+its payload, the JSON of msg, the same way. Returns false, and nothing, if no reading
+changed, or the payload isn't an Event.
+*/
+func (p *Processor) transformEvent(event dtos.Event, payload string, options submgr.SubscriptionOptions) (dtos.Event, string, bool) {
+	var transformed map[int]dtos.BaseReading
+	for i, reading := range event.Readings {
+		if reading, ok := p.transformReading(reading, options); ok {
+			if transformed == nil {
+				transformed = make(map[int]dtos.BaseReading)
+			}
+			transformed[i] = reading
+		}
+	}
+	if transformed == nil {
+		return event, payload, false
+	}
+
+	// The payload has the Event's readings in the same order, and may have more than the Event, e.g. deviceMetadata
+	var data map[string]any
+	if err := json.Unmarshal([]byte(payload), &data); err != nil {
+		return event, payload, false
+	}
+	readings, _ := data["readings"].([]any)
+	if len(readings) != len(event.Readings) {
+		return event, payload, false
+	}
+	event.Readings = slices.Clone(event.Readings)
+	for i, reading := range transformed {
+		element, ok := readings[i].(map[string]any)
+		if !ok {
+			return event, payload, false
+		}
+		element["value"] = reading.Value
+		element["valueType"] = reading.ValueType
+		if reading.Units != "" {
+			element["units"] = reading.Units
+		}
+		event.Readings[i] = reading
+	}
+	transformedPayload, err := json.Marshal(data)
+	if err != nil {
+		return event, payload, false
+	}
+	return event, string(transformedPayload), true
+}
+
+/*
+deliverTransformed sends an EdgeX Event to the subscriptions in chanlist, grouped by how
+their units and rounding options transform its readings, transformed for each group. Those
+without the options, or whose options change no reading, get the Event as it came.
+*/
+func (p *Processor) deliverTransformed(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic any, event dtos.Event, msg submgr.ChannelMessage) {
+	unchanged := make([]submgr.SendHandle, 0, len(chanlist))
+	groups := make(map[string][]submgr.SendHandle)
+	for _, ch := range chanlist {
+		if !transformsReadings(ch.Options()) {
+			unchanged = append(unchanged, ch)
+			continue
+		}
+		key := transformKey(ch.Options())
+		groups[key] = append(groups[key], ch)
+	}
+	for _, group := range groups {
+		transformedEvent, payload, ok := p.transformEvent(event, msg.Payload, group[0].Options())
+		if !ok {
+			unchanged = append(unchanged, group...)
+			continue
+		}
+		transformedMsg := msg
+		transformedMsg.Payload = payload
+		p.deliverFormats(ctx, group, topic, transformedEvent, transformedMsg)
+	}
+	if len(unchanged) > 0 {
+		p.deliverFormats(ctx, unchanged, topic, event, msg)
+	}
+}
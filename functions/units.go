@@ -0,0 +1,58 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"strconv"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+// Struct unitConversions holds the service's UnitConversions, keyed by the units converted from, then to.
+type unitConversions map[string]map[string]configuration.UnitConversionConfig
+
+func newUnitConversions(configs map[string]configuration.UnitConversionConfig) unitConversions {
+	rv := make(unitConversions)
+	for _, conversion := range configs {
+		if rv[conversion.From] == nil {
+			rv[conversion.From] = make(map[string]configuration.UnitConversionConfig)
+		}
+		rv[conversion.From][conversion.To] = conversion
+	}
+	return rv
+}
+
+// find returns the conversion from units to the first of wanted there is one to, and false if there is none.
+func (c unitConversions) find(units string, wanted []string) (configuration.UnitConversionConfig, bool) {
+	for _, to := range wanted {
+		if conversion, ok := c[units][to]; ok {
+			return conversion, true
+		}
+	}
+	return configuration.UnitConversionConfig{}, false
+}
+
+/*
+convert returns a numeric reading converted to the first of units there is a conversion to,
+as a Float64 reading, and false if there is none.
+*/
+func (c unitConversions) convert(reading dtos.BaseReading, units []string) (dtos.BaseReading, bool) {
+	conversion, ok := c.find(reading.Units, units)
+	if !ok {
+		return reading, false
+	}
+	value, err := strconv.ParseFloat(reading.Value, 64)
+	if err != nil {
+		return reading, false
+	}
+	reading.Value = strconv.FormatFloat(value*conversion.Scale+conversion.Offset, 'e', -1, 64)
+	reading.ValueType = common.ValueTypeFloat64
+	reading.Units = conversion.To
+	return reading, true
+}
@@ -0,0 +1,138 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// An Event with a reading in percent, and one in degrees Fahrenheit
+const unitsEvent = "{\"apiVersion\":\"v3\",\"id\":\"7d3d60c0-5279-436b-b99d-6ab1de0eb600\",\"deviceName\":\"Virtual-Bacon-Cape-04\",\"profileName\":\"Bacon-Cape\",\"sourceName\":\"status\",\"origin\":1661535695202033126,\"readings\":[{\"id\":\"b4f7b655-5dac-4f34-8dc7-caa2f8c1a34d\",\"origin\":1661535695202033126,\"deviceName\":\"Virtual-Bacon-Cape-04\",\"resourceName\":\"mPercentLoad\",\"profileName\":\"Bacon-Cape\",\"valueType\":\"Uint32\",\"value\":\"74\",\"units\":\"%\"},{\"id\":\"c4f7b655-5dac-4f34-8dc7-caa2f8c1a34d\",\"origin\":1661535695202033126,\"deviceName\":\"Virtual-Bacon-Cape-04\",\"resourceName\":\"temperature\",\"profileName\":\"Bacon-Cape\",\"valueType\":\"Int16\",\"value\":\"212\",\"units\":\"degF\"}]}"
+
+func TestUnitConversion(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.UnitConversions = map[string]configuration.UnitConversionConfig{
+		"fahrenheit-celsius": {From: "degF", To: "degC", Scale: 5.0 / 9, Offset: -160.0 / 9},
+		"fahrenheit-kelvin":  {From: "degF", To: "K", Scale: 5.0 / 9, Offset: 255.37222222222223},
+	}
+	tp.proc = NewProcessor(tp.proc.lc, &tp.subs, &tp.cfg)
+	receivers := make(map[string]<-chan submgr.ChannelMessage)
+	options := map[string]submgr.SubscriptionOptions{
+		"celsius": {Units: []string{"degC"}},
+		"kelvin":  {Units: []string{"K", "degC"}, Format: submgr.FormatSimple},
+		"none":    {Units: []string{"kPa"}},
+	}
+	for name, option := range options {
+		subid, _ := tp.subs.NewSubscription()
+		subinfo := tp.subs.Subscription(subid)
+		if err := tp.subs.Include(subinfo, "edgex/"); err != nil {
+			t.Fatalf("Could not add include: %v", err)
+		}
+		if err := tp.subs.SetOptions(subinfo, option); err != nil {
+			t.Fatalf("Could not set options: %v", err)
+		}
+		tp.subs.SetActive(subinfo, true)
+		receivers[name], _ = tp.subs.ReceiveChannel(subinfo)
+	}
+
+	msgs := tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/status", []byte(unitsEvent))
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Payload, "\"value\":\"212\"") {
+		t.Fatalf("Subscription without units got %v", msgs)
+	}
+	none := <-receivers["none"]
+	if none.Payload != msgs[0].Payload {
+		t.Fatalf("Subscription with units nothing converts to got %s", none.Payload)
+	}
+
+	var event struct {
+		Readings []map[string]any `json:"readings"`
+	}
+	celsius := <-receivers["celsius"]
+	if err := json.Unmarshal([]byte(celsius.Payload), &event); err != nil || len(event.Readings) != 2 {
+		t.Fatalf("Converted Event is %s", celsius.Payload)
+	}
+	if event.Readings[0]["value"] != "74" || event.Readings[0]["units"] != "%" {
+		t.Fatalf("Reading without a conversion changed: %v", event.Readings[0])
+	}
+	value, _ := strconv.ParseFloat(event.Readings[1]["value"].(string), 64)
+	if value < 99.999 || value > 100.001 || event.Readings[1]["units"] != "degC" || event.Readings[1]["valueType"] != "Float64" {
+		t.Fatalf("Reading not converted to degC: %v", event.Readings[1])
+	}
+
+	kelvin := <-receivers["kelvin"]
+	var simple []simpleReading
+	if err := json.Unmarshal([]byte(kelvin.Payload), &simple); err != nil || len(simple) != 2 || kelvin.EventType != "simple" {
+		t.Fatalf("Converted simple Event is %s", kelvin.Payload)
+	}
+	if simple[1].Units != "K" {
+		t.Fatalf("Reading not converted to the first units with a conversion: %+v", simple[1])
+	}
+}
+
+func TestRounding(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.UnitConversions = map[string]configuration.UnitConversionConfig{
+		"fahrenheit-celsius": {From: "degF", To: "degC", Scale: 5.0 / 9, Offset: -160.0 / 9},
+	}
+	tp.proc = NewProcessor(tp.proc.lc, &tp.subs, &tp.cfg)
+	receivers := make(map[string]<-chan submgr.ChannelMessage)
+	options := map[string]submgr.SubscriptionOptions{
+		"decimals":    {Units: []string{"degC"}, Rounding: &submgr.Rounding{Digits: 1}},
+		"significant": {Rounding: &submgr.Rounding{Digits: 1, Significant: true}},
+		"integers":    {Rounding: &submgr.Rounding{Digits: 0}},
+	}
+	for name, option := range options {
+		subid, _ := tp.subs.NewSubscription()
+		subinfo := tp.subs.Subscription(subid)
+		if err := tp.subs.Include(subinfo, "edgex/"); err != nil {
+			t.Fatalf("Could not add include: %v", err)
+		}
+		if err := tp.subs.SetOptions(subinfo, option); err != nil {
+			t.Fatalf("Could not set options: %v", err)
+		}
+		tp.subs.SetActive(subinfo, true)
+		receivers[name], _ = tp.subs.ReceiveChannel(subinfo)
+	}
+
+	// 213 degF is 100.5555... degC
+	event := strings.Replace(unitsEvent, "\"212\"", "\"213\"", 1)
+	msgs := tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/status", []byte(event))
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Payload, "\"value\":\"213\"") {
+		t.Fatalf("Subscription without rounding got %v", msgs)
+	}
+	var got struct {
+		Readings []map[string]any `json:"readings"`
+	}
+	decimals := <-receivers["decimals"]
+	if err := json.Unmarshal([]byte(decimals.Payload), &got); err != nil || len(got.Readings) != 2 {
+		t.Fatalf("Rounded Event is %s", decimals.Payload)
+	}
+	if got.Readings[1]["value"] != "1.006e+02" || got.Readings[1]["units"] != "degC" {
+		t.Fatalf("Converted reading not rounded to 1 decimal place: %v", got.Readings[1])
+	}
+	if got.Readings[0]["value"] != "74" {
+		t.Fatalf("Integer reading rounded to decimal places: %v", got.Readings[0])
+	}
+
+	significant := <-receivers["significant"]
+	if err := json.Unmarshal([]byte(significant.Payload), &got); err != nil || got.Readings[0]["value"] != "70" || got.Readings[1]["value"] != "200" || got.Readings[1]["valueType"] != "Int16" {
+		t.Fatalf("Readings not rounded to 1 significant digit: %s", significant.Payload)
+	}
+
+	// Nothing to round
+	integers := <-receivers["integers"]
+	if integers.Payload != msgs[0].Payload {
+		t.Fatalf("Integer readings rounded to 0 decimal places got %s", integers.Payload)
+	}
+}
@@ -0,0 +1,102 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+)
+
+// Struct ValidationCounts counts what happened to messages that looked like Events (had readings).
+type ValidationCounts struct {
+	// Passed validation, delivered as "edgex" events
+	Valid uint64
+	// Failed validation, delivered as whatever else they could be classified as
+	Generic uint64
+	// Failed validation, not delivered
+	Dropped uint64
+	// Failed validation, delivered as "invalid" events
+	Annotated uint64
+	// Valid, but with readings their device profiles don't allow, for ProfileValidation
+	ProfileViolations uint64
+}
+
+// Struct validationCounters holds the live counts behind ValidationCounts.
+type validationCounters struct {
+	valid             atomic.Uint64
+	generic           atomic.Uint64
+	dropped           atomic.Uint64
+	annotated         atomic.Uint64
+	profileViolations atomic.Uint64
+}
+
+/*
+ValidationCounts returns the counts of Event validation outcomes since the service started.
+Messages are only validated, and counted, when somebody is subscribed to them
+(for batches, to any topic).
+*/
+func (p *Processor) ValidationCounts() ValidationCounts {
+	return ValidationCounts{
+		Valid:             p.validation.valid.Load(),
+		Generic:           p.validation.generic.Load(),
+		Dropped:           p.validation.dropped.Load(),
+		Annotated:         p.validation.annotated.Load(),
+		ProfileViolations: p.validation.profileViolations.Load(),
+	}
+}
+
+// looksLikeEvent reports whether generic data is meant to be an Event - an object with readings.
+func looksLikeEvent(data any) bool {
+	m, ok := data.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = m["readings"]
+	return ok
+}
+
+/*
+handleInvalidEvent deals with a message that looked like an Event but failed validation,
+according to the InvalidEvents setting:
+
+  generic: Returns true, so the caller goes on to deliver it as something else.
+  drop: Logs a warning and returns false.
+  annotate: Delivers it as an "invalid" event, with the validation error added as
+  member "validationError", and returns false.
+*/
+func (p *Processor) handleInvalidEvent(ctx interfaces.AppFunctionContext, chanlist []submgr.SendHandle, topic string, data map[string]any, invalid error) bool {
+	switch p.config.SSE.InvalidEvents {
+	case configuration.InvalidEventsDrop:
+		p.validation.dropped.Add(1)
+		p.lc.Warnf("Dropped invalid Event on topic %s: %s", topic, invalid.Error())
+		if event_bytes, err := json.Marshal(data); err == nil && p.config.SSE.DeadLetterTopic != "" {
+			p.deadLetter(DeadLetterInvalid, topic, submgr.ChannelMessage{Payload: string(event_bytes)}, len(chanlist), invalid)
+		}
+		return false
+	case configuration.InvalidEventsAnnotate:
+		p.validation.annotated.Add(1)
+		annotated := make(map[string]any, len(data)+1)
+		for key, value := range data {
+			annotated[key] = value
+		}
+		annotated["validationError"] = invalid.Error()
+		event_bytes, err := json.Marshal(annotated)
+		if err != nil {
+			return false
+		}
+		p.deliver(ctx, chanlist, topic, submgr.ChannelMessage{EventType: "invalid", Payload: string(event_bytes)})
+		return false
+	default:
+		p.validation.generic.Add(1)
+		p.lc.Debugf("Invalid Event on topic %s delivered as generic: %s", topic, invalid.Error())
+		return true
+	}
+}
@@ -0,0 +1,76 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// An Event missing its id, so it fails validation
+var invalidEvent = strings.Replace(edgexEvent, "\"id\":\"7d3d60c0-5279-436b-b99d-6ab1de0eb600\",", "", 1)
+
+const invalidTopic = "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad"
+
+func TestInvalidEventGeneric(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	msgs := tp.publish(t, invalidTopic, []byte(invalidEvent))
+	if len(msgs) != 1 || msgs[0].EventType != "" {
+		t.Fatalf("Expected one generic event, got %v", msgs)
+	}
+	_ = tp.publish(t, invalidTopic, []byte(edgexEvent))
+	counts := tp.proc.ValidationCounts()
+	if counts != (ValidationCounts{Valid: 1, Generic: 1}) {
+		t.Fatalf("Wrong counts: %v", counts)
+	}
+}
+
+func TestInvalidEventDrop(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.InvalidEvents = configuration.InvalidEventsDrop
+	msgs := tp.publish(t, invalidTopic, []byte(invalidEvent))
+	if len(msgs) != 0 {
+		t.Fatalf("Expected nothing delivered, got %v", msgs)
+	}
+	// Not Event-like, so not dropped
+	msgs = tp.publish(t, "ble/events/alarms", []byte("{\"event\":\"door opened\"}"))
+	if len(msgs) != 1 {
+		t.Fatalf("Expected one generic event, got %v", msgs)
+	}
+	if counts := tp.proc.ValidationCounts(); counts != (ValidationCounts{Dropped: 1}) {
+		t.Fatalf("Wrong counts: %v", counts)
+	}
+}
+
+func TestInvalidEventAnnotate(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.InvalidEvents = configuration.InvalidEventsAnnotate
+	msgs := tp.publish(t, invalidTopic, []byte("{\"apiVersion\":\"v3\",\"event\":"+invalidEvent+"}"))
+	if len(msgs) != 1 || msgs[0].EventType != "invalid" {
+		t.Fatalf("Expected one invalid event, got %v", msgs)
+	}
+	var annotated map[string]any
+	if err := json.Unmarshal([]byte(msgs[0].Payload), &annotated); err != nil {
+		t.Fatalf("Invalid event is not JSON: %s", msgs[0].Payload)
+	}
+	if errText, _ := annotated["validationError"].(string); errText == "" || annotated["event"] == nil {
+		t.Fatalf("Invalid event not annotated: %s", msgs[0].Payload)
+	}
+	// Batch elements too, on the batch topic
+	msgs = tp.publish(t, "edgex/events/batch", []byte("["+edgexEvent+","+invalidEvent+"]"))
+	if len(msgs) != 2 || msgs[0].EventType != "edgex" || msgs[1].EventType != "invalid" {
+		t.Fatalf("Expected an edgex and an invalid event, got %v", msgs)
+	}
+	if counts := tp.proc.ValidationCounts(); counts != (ValidationCounts{Valid: 1, Annotated: 2}) {
+		t.Fatalf("Wrong counts: %v", counts)
+	}
+}
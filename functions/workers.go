@@ -0,0 +1,188 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"sync"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+/*
+Struct deliveryWorkers runs deliveries for the DeliveryWorkers setting: marshaling and
+transforming messages for their subscriptions, and sending them, off the pipeline
+goroutine, so a slow one doesn't hold up the message bus.
+
+Each worker has a queue of its own, and a message goes to the worker its topic hashes to,
+so messages on one topic - for EdgeX events, from one device's source - reach each
+subscription in the order they were published, as they do without workers. Out of order
+readings would make a mess of trend displays.
+*/
+type deliveryWorkers struct {
+	// Guards closing queues against dispatch()
+	lock   sync.RWMutex
+	closed bool
+	// Deliveries waiting for each worker, up to DeliveryQueue
+	queues []chan func()
+	done   sync.WaitGroup
+}
+
+// newDeliveryWorkers (an internal API) starts count workers, each taking deliveries from a queue of size.
+func newDeliveryWorkers(count uint, size uint) *deliveryWorkers {
+	w := &deliveryWorkers{queues: make([]chan func(), count)}
+	w.done.Add(int(count))
+	for i := range w.queues {
+		queue := make(chan func(), size)
+		w.queues[i] = queue
+		go func() {
+			defer w.done.Done()
+			for job := range queue {
+				job()
+			}
+		}()
+	}
+	return w
+}
+
+// queue (an internal API) returns the queue of the worker messages on topic go to.
+func (w *deliveryWorkers) queue(topic any) chan func() {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(fmt.Sprint(topic)))
+	return w.queues[hash.Sum32()%uint32(len(w.queues))]
+}
+
+/*
+Struct detachedContext is the pipeline's function context as a delivery worker sees it. The
+SDK reuses the context for the next message once Publish returns, so what deliveries read
+from it - the correlation ID, logger, content type, pipeline ID and values such as the
+matched subscriptions - is copied first. The rest, e.g. the metadata clients, is the
+service's, the same for every message, and comes from the context.
+*/
+type detachedContext struct {
+	interfaces.AppFunctionContext
+	correlationID string
+	lc            logger.LoggingClient
+	contentType   string
+	pipelineId    string
+	values        map[string]string
+}
+
+// detach (an internal API) returns a copy of ctx for a delivery worker.
+func detach(ctx interfaces.AppFunctionContext) *detachedContext {
+	return &detachedContext{
+		AppFunctionContext: ctx,
+		correlationID:      ctx.CorrelationID(),
+		lc:                 ctx.LoggingClient(),
+		contentType:        ctx.InputContentType(),
+		pipelineId:         ctx.PipelineId(),
+		values:             ctx.GetAllValues(),
+	}
+}
+
+// Clone returns a copy with its own values.
+func (c *detachedContext) Clone() interfaces.AppFunctionContext {
+	clone := *c
+	clone.values = maps.Clone(c.values)
+	return &clone
+}
+
+// CorrelationID returns the correlation ID of the message, as it was when copied.
+func (c *detachedContext) CorrelationID() string {
+	return c.correlationID
+}
+
+// LoggingClient returns the logger, as it was when copied.
+func (c *detachedContext) LoggingClient() logger.LoggingClient {
+	return c.lc
+}
+
+// InputContentType returns the content type of the message, as it was when copied.
+func (c *detachedContext) InputContentType() string {
+	return c.contentType
+}
+
+// PipelineId returns the ID of the pipeline the message went through.
+func (c *detachedContext) PipelineId() string {
+	return c.pipelineId
+}
+
+// AddValue stores a value in the copy, not the pipeline's context.
+func (c *detachedContext) AddValue(key string, value string) {
+	c.values[key] = value
+}
+
+// RemoveValue deletes a value from the copy.
+func (c *detachedContext) RemoveValue(key string) {
+	delete(c.values, key)
+}
+
+// GetValue returns a value from the copy, and true, or false if there is none.
+func (c *detachedContext) GetValue(key string) (string, bool) {
+	value, ok := c.values[key]
+	return value, ok
+}
+
+// GetAllValues returns a copy of the values.
+func (c *detachedContext) GetAllValues() map[string]string {
+	return maps.Clone(c.values)
+}
+
+/*
+dispatch (an internal API) runs job, delivering a message on topic to the subscriptions
+in chanlist, on the delivery worker for topic, or right away without DeliveryWorkers or
+once they are stopped. With its queue full the message is dropped for all of them, the
+same as for full subscription buffers, rather than hold up the message bus.
+
+job is given ctx, or on a worker, a copy of it made before Publish returns.
+*/
+func (p *Processor) dispatch(ctx interfaces.AppFunctionContext, topic any, chanlist []submgr.SendHandle, job func(ctx interfaces.AppFunctionContext)) {
+	if p.workers == nil {
+		job(ctx)
+		return
+	}
+	p.workers.lock.RLock()
+	if p.workers.closed {
+		p.workers.lock.RUnlock()
+		job(ctx)
+		return
+	}
+	detached := detach(ctx)
+	select {
+	case p.workers.queue(topic) <- func() { job(detached) }:
+		p.workers.lock.RUnlock()
+		return
+	default:
+	}
+	p.workers.lock.RUnlock()
+	subIds := make([]string, 0, len(chanlist))
+	for _, ch := range chanlist {
+		subIds = append(subIds, ch.SubId())
+	}
+	p.delivery.dropped.Add(uint64(len(subIds)))
+	p.warnDropped(topic, subIds)
+}
+
+// Close stops the delivery workers, once they have delivered what is queued.
+func (p *Processor) Close() {
+	if p.workers == nil {
+		return
+	}
+	p.workers.lock.Lock()
+	if !p.workers.closed {
+		p.workers.closed = true
+		for _, queue := range p.workers.queues {
+			close(queue)
+		}
+	}
+	p.workers.lock.Unlock()
+	p.workers.done.Wait()
+}
@@ -0,0 +1,137 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package functions
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+func TestDeliveryWorkers(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.DeliveryWorkers = 1
+	tp.cfg.SSE.DeliveryQueue = 1
+	tp.proc = NewProcessor(logger.NewMockClient(), &tp.subs, &tp.cfg)
+	defer tp.proc.Close()
+
+	// Delivered by a worker, after Publish returns
+	_ = tp.publish(t, "edgex/events/device/Bacon-Cape/Virtual-Bacon-Cape-04/mPercentLoad", jsonData(t, edgexEvent))
+	select {
+	case msg := <-tp.rxchan:
+		if msg.EventType != "edgex" {
+			t.Fatalf("Expected an edgex event, got %v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Event not delivered by the workers")
+	}
+
+	// With the worker busy and its queue full, messages are dropped
+	chanlist := tp.subs.SubscribedChannels("alarms")
+	release := make(chan struct{})
+	started := make(chan struct{})
+	ctx := pkg.NewAppFuncContextForTest("", logger.NewMockClient())
+	tp.proc.dispatch(ctx, "alarms", chanlist, func(interfaces.AppFunctionContext) {
+		close(started)
+		<-release
+	})
+	<-started
+	queued := false
+	tp.proc.dispatch(ctx, "alarms", chanlist, func(interfaces.AppFunctionContext) { queued = true })
+	tp.proc.dispatch(ctx, "alarms", chanlist, func(interfaces.AppFunctionContext) { t.Error("Delivered past a full queue") })
+	if counts := tp.proc.DeliveryCounts(); counts.Dropped != uint64(len(chanlist)) {
+		t.Fatalf("Expected %d dropped, got %+v", len(chanlist), counts)
+	}
+	close(release)
+	// Close waits for what is queued
+	tp.proc.Close()
+	if !queued {
+		t.Fatal("Queued delivery lost when the workers stopped")
+	}
+	ran := false
+	tp.proc.dispatch(ctx, "alarms", []submgr.SendHandle{}, func(interfaces.AppFunctionContext) { ran = true })
+	if !ran {
+		t.Fatal("Delivery after Close was not run right away")
+	}
+}
+
+func TestDetachedContext(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	tp.cfg.SSE.DeliveryWorkers = 1
+	tp.cfg.SSE.DeliveryQueue = 1
+	tp.proc = NewProcessor(logger.NewMockClient(), &tp.subs, &tp.cfg)
+	defer tp.proc.Close()
+
+	// The worker sees the context as it was when dispatched, though the SDK reuses it
+	ctx := pkg.NewAppFuncContextForTest("first", logger.NewMockClient())
+	ctx.AddValue(MatchedSubscriptionsKey, "a,b")
+	release := make(chan struct{})
+	seen := make(chan []string)
+	tp.proc.dispatch(ctx, "alarms", tp.subs.SubscribedChannels("alarms"), func(ctx interfaces.AppFunctionContext) {
+		<-release
+		seen <- append(MatchedSubscriptions(ctx), ctx.CorrelationID())
+	})
+	ctx.(interface{ SetCorrelationID(id string) }).SetCorrelationID("second")
+	ctx.AddValue(MatchedSubscriptionsKey, "c")
+	close(release)
+	if got := <-seen; strings.Join(got, ",") != "a,b,first" {
+		t.Fatalf("Worker saw %v", got)
+	}
+}
+
+func TestDeliveryOrder(t *testing.T) {
+	tp := newTestProcessor(t)
+	defer tp.subs.Close()
+	if err := tp.subs.SetLimits(10, 10, 1000); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+	rxchan := tp.subscribe(t, "edgex/events/device")
+	tp.cfg.SSE.DeliveryWorkers = 4
+	tp.cfg.SSE.DeliveryQueue = 1000
+	tp.proc = NewProcessor(logger.NewMockClient(), &tp.subs, &tp.cfg)
+
+	// Events from several devices, interleaved, each device's numbered in order
+	const devices, events = 8, 50
+	for i := 0; i < events; i++ {
+		for d := 0; d < devices; d++ {
+			device := fmt.Sprintf("Virtual-Bacon-Cape-%02d", d)
+			event := strings.Replace(edgexEvent, "Virtual-Bacon-Cape-04", device, -1)
+			event = strings.Replace(event, "\"value\":\"74\"", fmt.Sprintf("\"value\":\"%d\"", i), 1)
+			_ = tp.publish(t, "edgex/events/device/Bacon-Cape/"+device+"/mPercentLoad", jsonData(t, event))
+		}
+	}
+	tp.proc.Close()
+
+	next := make(map[string]int)
+	for received := 0; received < devices*events; received++ {
+		var msg submgr.ChannelMessage
+		select {
+		case msg = <-rxchan:
+		default:
+			t.Fatalf("Only %d of %d events delivered", received, devices*events)
+		}
+		var event dtos.Event
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			t.Fatalf("Bad event delivered: %v", err)
+		}
+		if value := event.Readings[0].Value; value != strconv.Itoa(next[event.DeviceName]) {
+			t.Fatalf("Event %s from %s delivered out of order, expected %d", value, event.DeviceName, next[event.DeviceName])
+		}
+		next[event.DeviceName]++
+	}
+}
@@ -11,6 +11,7 @@ package interfaces
 
 import (
 	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/functions"
 	"github.com/edgexfoundry-holding/edgex-sse/submgr"
 	appint "github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
@@ -26,6 +27,16 @@ type MyApp struct {
 	Logger logger.LoggingClient
 	// Subscription manager
 	Subs *submgr.SubscriptionManager
+	// EdgeX security is enabled, so the SDK has verified callers' JWTs before our handlers run
+	SecurityEnabled bool
+	// Pipeline functions, for messages that don't come from the SDK's trigger
+	Processor *functions.Processor
+	// Random ID of this replica, event IDs start with, as each replica numbers events by itself. Empty without Replication.
+	ReplicaId string
+	// Creates a subscription made on another replica, if there is one with the ID. nil without Replication.
+	FetchSubscription func(subid string) bool
+	// Alerts operators through support-notifications, see notify.Notifier.Alert(). nil without Alerts.
+	Alert func(condition string, severity string, description string)
 }
 
 // Global instance of this structure
@@ -12,13 +12,29 @@ import (
 	"github.com/edgexfoundry-holding/edgex-sse/submgr"
 	"github.com/edgexfoundry-holding/edgex-sse/web"
 	"github.com/edgexfoundry-holding/edgex-sse/functions"
+	"github.com/edgexfoundry-holding/edgex-sse/external"
+	"github.com/edgexfoundry-holding/edgex-sse/telemetry"
+	"github.com/edgexfoundry-holding/edgex-sse/tracing"
+	"github.com/edgexfoundry-holding/edgex-sse/notify"
+	"github.com/edgexfoundry-holding/edgex-sse/persist"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/extension"
+	"github.com/edgexfoundry-holding/edgex-sse/token"
+	_ "github.com/edgexfoundry-holding/edgex-sse/extensions"
+	"context"
+	"crypto/tls"
+	"errors"
+	"maps"
 	"net/http"
 	"os"
-	"strconv"
-	"time"
+	"slices"
+	"strings"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
 	appint "github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	bootstrapint "github.com/edgexfoundry/go-mod-bootstrap/v4/bootstrap/interfaces"
+	"github.com/edgexfoundry/go-mod-bootstrap/v4/bootstrap/secret"
+	clientint "github.com/edgexfoundry/go-mod-core-contracts/v4/clients/interfaces"
+	"github.com/google/uuid"
 )
 
 const (
@@ -36,8 +52,9 @@ func main() {
 // CreateAndRunAppService wraps what would normally be in main() so that it can be unit tested
 func CreateAndRunAppService(serviceKey string, newServiceFactory func(string, any) (appint.ApplicationService, bool)) int {
 	var ok bool
-	// Asking the messaging client for an "any" will give us the generic un-marshaling of map[string]any
-	var desiredBuffer any
+	// Asking the messaging client for raw bytes lets us handle payloads that are not JSON,
+	// and only un-marshal messages somebody is subscribed to
+	var desiredBuffer []byte
 	interfaces.App.Service, ok = newServiceFactory(serviceKey, &desiredBuffer)
 	if !ok {
 		return -1
@@ -47,6 +64,7 @@ func CreateAndRunAppService(serviceKey string, newServiceFactory func(string, an
 	interfaces.App.Config = &configuration.Config{}
 	interfaces.App.Config.SetDefaults()
 	interfaces.App.Subs = &submgr.SubscriptionManager{}
+	interfaces.App.SecurityEnabled = secret.IsSecurityEnabled()
 
 	// Aliases for shorter lines below
 	cfg := interfaces.App.Config
@@ -55,43 +73,342 @@ func CreateAndRunAppService(serviceKey string, newServiceFactory func(string, an
 	subs := interfaces.App.Subs
 
 	// Load our custom config object from the "SSE" config-file/Consul section
-	// We are not yet set up to listen for run-time config changes
+	// Only its Writable part is watched for run-time changes, below
 	if err := svc.LoadCustomConfig(cfg, "SSE"); err != nil {
 		lc.Errorf("failed loading SSE configuration section: %s", err.Error())
 		return -1
 	}
 	if err := cfg.Validate(); err != nil {
-		lc.Errorf("SSE configuration section failed validation: %s", err.Error())
+		// One line per problem, so they can all be fixed before the next start
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			lc.Errorf("SSE configuration section failed validation: %s", problem)
+		}
 		return -1
 	}
 
-	ageout, err := time.ParseDuration(cfg.SSE.SubscriptionIdleExpiration)
-	ageoutInterval, err2 := time.ParseDuration(cfg.SSE.SubscriptionExpirationCheckInterval)
-	if (err != nil) || (err2 != nil) {  // probably cannot happen, checked in Validate()
-		lc.Error("Could not parse SubscriptionIdleExpiration and/or SubscriptionExpirationCheckInterval")
+	// Parsed by Validate()
+	var err error
+	durations := cfg.SSE.Durations()
+	ageout := durations.SubscriptionIdleExpiration
+	ageoutInterval := durations.SubscriptionExpirationCheckInterval
+	topicAgeout := durations.TopicIdleExpiration
+	lc.Tracef("Starting subscription manager, limits: %d subs, %d entries/sub, event buffer %d, ageout %v check every %v", cfg.SSE.SubscriptionLimit, cfg.SSE.PrefixesLimit, cfg.SSE.EventBuffer, ageout, ageoutInterval)
+	if err := subs.Init(cfg.SSE.SubscriptionLimit, cfg.SSE.PrefixesLimit, cfg.SSE.EventBuffer, ageout, ageoutInterval); err != nil {
+		lc.Errorf("Could not start subscription manager: %s", err.Error())
 		return -1
 	}
-	lc.Tracef("Starting subscription manager, limits: %d subs, %d entries/sub, event buffer %d, ageout %v check every %v", cfg.SSE.SubscriptionLimit, cfg.SSE.PrefixesLimit, cfg.SSE.EventBuffer, ageout, ageoutInterval)
-	subs.Init(cfg.SSE.SubscriptionLimit, cfg.SSE.PrefixesLimit, cfg.SSE.EventBuffer, ageout, ageoutInterval)
+	if cfg.SSE.Replay.Count > 0 {
+		subs.SetRetention(cfg.SSE.Retention())
+		lc.Infof("Keeping up to %d messages, %d bytes, for %v per subscription for replay", cfg.SSE.Replay.Count, cfg.SSE.Replay.Bytes, durations.ReplayMaxAge)
+	}
+	for name, role := range cfg.SSE.Roles {
+		if err := subs.SetQuota(name, submgr.Quota{Subscriptions: role.SubscriptionLimit, Prefixes: role.PrefixesLimit}); err != nil {
+			lc.Errorf("Could not set limits of role %s: %s", name, err.Error())
+			return -1
+		}
+	}
+	subs.SetDeleteHook(web.ForgetSubscription)
+	subs.SetTopicIndex(cfg.SSE.TopicIndexLimit, topicAgeout, func(topic string) {
+		lc.Debugf("No messages on topic %s for %v, removed from topic index", topic, topicAgeout)
+	})
+	if names := extension.Names(); len(names) > 0 {
+		lc.Infof("Extensions available to subscriptions: %s", strings.Join(names, ", "))
+	}
+	notifier := notify.NewNotifier(lc, svc.NotificationClient(), cfg.SSE.NotificationCategory, serviceKey, durations.AlertInterval)
+	if cfg.SSE.ExpiryNotifications {
+		subs.SetExpiryNotice(durations.ExpiryNotice, notifier.Expiry)
+		lc.Infof("Notifying subscriptions that ask for it %v before they are aged out", durations.ExpiryNotice)
+	}
+	if durations.AgeOutGrace > 0 {
+		subs.SetAgeOutGrace(durations.AgeOutGrace)
+		lc.Infof("Keeping subscriptions %v past their idle expiration for their streams to reconnect", durations.AgeOutGrace)
+	}
+	if cfg.SSE.Alerts {
+		if svc.NotificationClient() == nil {
+			lc.Warn("Alerts is on, but support-notifications is not in Clients: alerts will only be logged")
+		}
+		interfaces.App.Alert = notifier.Alert
+		lc.Infof("Alerting through support-notifications under category %s", cfg.SSE.NotificationCategory)
+	}
+
+	// Subscriptions from the configuration, so fixed dashboards don't have to create theirs
+	for _, name := range slices.Sorted(maps.Keys(cfg.SSE.StaticSubscriptions)) {
+		static := cfg.SSE.StaticSubscriptions[name]
+		if err := web.AddStaticSubscription(name, static.IncludeList(), static.ExcludeList(), static.Options()); err != nil {
+			lc.Errorf("Could not create static subscription %s: %s", name, err.Error())
+			return -1
+		}
+		lc.Infof("Created static subscription %s", name)
+	}
 
-	// Create function pipeline - all events we see are ran through these
-	// functions, in order.
-	processor := functions.NewProcessor(lc, subs)
-	err = svc.SetDefaultFunctionsPipeline(processor.Publish)
+	// Subscriptions created through the API before the service stopped, under the same IDs,
+	// and changes to them persisted from now on
+	stopPersistence := func() {}
+	if cfg.SSE.PersistenceBackend != configuration.PersistenceNone {
+		store, err := openStore(cfg, svc)
+		if err != nil {
+			lc.Errorf("Could not open %s subscription persistence: %s", cfg.SSE.PersistenceBackend, err.Error())
+			return -1
+		}
+		restored, err := persist.Restore(lc, store, web.RestoreSubscription)
+		if err != nil {
+			lc.Errorf("Could not load persisted subscriptions from %s: %s", cfg.SSE.PersistenceBackend, err.Error())
+			store.Close()
+			return -1
+		}
+		lc.Infof("Restored %d persisted subscriptions from %s", restored, cfg.SSE.PersistenceBackend)
+		persister := persist.NewPersister(lc, subs, store, durations.PersistenceWriteDelay)
+		persister.Start()
+		// Subscriptions made on the other replicas are made here too, and the other way round
+		stopReplication := func() {}
+		if cfg.SSE.Replication {
+			if interfaces.App.ReplicaId, err = token.GenerateToken(); err != nil {
+				lc.Errorf("Could not generate replica ID: %s", err.Error())
+				persister.Stop()
+				store.Close()
+				return -1
+			}
+			// PersistenceBackend redis, as checked by Validate()
+			replicator := persist.NewReplicator(lc, subs, store.(persist.SharedStore), web.RestoreSubscription, web.ReplaceSubscription, persister.Pending, durations.ReplicationSyncInterval, ageout)
+			replicator.Start()
+			interfaces.App.FetchSubscription = replicator.Fetch
+			stopReplication = replicator.Stop
+			lc.Infof("Replicating subscriptions with other replicas every %v", durations.ReplicationSyncInterval)
+		}
+		stopPersistence = func() {
+			stopReplication()
+			persister.Stop()
+			if err := store.Close(); err != nil {
+				lc.Errorf("Could not close %s subscription persistence: %s", cfg.SSE.PersistenceBackend, err.Error())
+			}
+		}
+	}
+	// Also stopped before the subscription manager is closed, after which the changes not yet
+	// persisted would look like deletions
+	defer func() {
+		stopPersistence()
+	}()
+
+	// Stream tokens signed with a key from the secret provider work on every replica
+	if name := cfg.SSE.StreamTokenSecretName; name != "" {
+		setStreamTokenKey := func(name string) error {
+			secret, err := svc.SecretProvider().GetSecret(name, "key")
+			if err != nil {
+				return err
+			}
+			return web.SetStreamTokenKey([]byte(secret["key"]))
+		}
+		if err := setStreamTokenKey(name); err != nil {
+			lc.Errorf("Could not get stream token key from secret %s: %s", name, err.Error())
+			return -1
+		}
+		err = svc.SecretProvider().RegisterSecretUpdatedCallback(name, func(name string) {
+			if err := setStreamTokenKey(name); err != nil {
+				lc.Errorf("Could not get rotated stream token key from secret %s: %s", name, err.Error())
+			}
+		})
+		if err != nil {
+			lc.Errorf("Could not watch secret %s: %s", name, err.Error())
+			return -1
+		}
+	}
+
+	// Create function pipeline - all events we see are ran through the
+	// functions in Writable PipelineFunctions, in order. With per-topic
+	// pipelines configured, each gets the same functions, which look up the
+	// pipeline's options by its ID.
+	processor := functions.NewProcessor(lc, subs, cfg)
+	processor.SetDeadLetterPublisher(svc.PublishWithTopic)
+	interfaces.App.Processor = &processor
+	defer processor.Close()
+	if len(cfg.SSE.Pipelines) == 0 {
+		err = svc.SetDefaultFunctionsPipeline(processor.Pipeline)
+		if err != nil {
+			lc.Errorf("SetDefaultFunctionsPipeline returned error: %s", err.Error())
+			return -1
+		}
+	}
+	for id, pipeline := range cfg.SSE.Pipelines {
+		err = svc.AddFunctionsPipelineForTopics(id, pipeline.TopicList(), processor.Pipeline)
+		if err != nil {
+			lc.Errorf("Could not add pipeline %s: %s", id, err.Error())
+			return -1
+		}
+	}
+
+	// Spans of messages on their way to the event streams, if enabled
+	stopTracing := tracing.Start(cfg.SSE.Tracing, serviceKey)
+	defer func() {
+		if err := stopTracing(context.Background()); err != nil {
+			lc.Errorf("Could not flush traces: %s", err.Error())
+		}
+	}()
+
+	// Reported on the telemetry topic, if enabled in Writable.Telemetry.Metrics
+	if err := telemetry.Register(svc.MetricsManager(), subs, &processor); err != nil {
+		lc.Errorf("Could not register metrics: %s", err.Error())
+		return -1
+	}
+
+	// The logs tell how the service keeps up, without a line for every message
+	stopSummary := telemetry.StartSummary(lc, durations.ThroughputSummaryInterval, subs, &processor)
+	defer stopSummary()
+	if cfg.SSE.Alerts {
+		stopOverflowAlerts := notifier.WatchOverflow(func() uint64 {
+			return processor.DeliveryCounts().Dropped
+		}, durations.OverflowAlertWindow)
+		defer stopOverflowAlerts()
+	}
+
+	// Why topics do or don't match, for subscriptions with the debug option and sampled messages
+	logMatch := func(decision submgr.MatchDecision) {
+		lc.Info(decision.String())
+	}
+	subs.SetMatchDebug(logMatch, cfg.SSE.Writable.MatchDebugSamples)
+
+	// The functions can be changed in the Configuration Provider without a restart.
+	// The watcher decodes into its own copy, so a bad update never reaches the processor.
+	writable := cfg.SSE.Writable
+	err = svc.ListenForCustomConfigChanges(&writable, "SSE/Writable", func(rawWritable interface{}) {
+		updated, ok := rawWritable.(*configuration.WritableConfig)
+		if !ok {
+			lc.Error("Unexpected type of SSE Writable configuration update, ignoring it")
+			return
+		}
+		if err := processor.SetPipelineFunctions(*updated); err != nil {
+			lc.Errorf("Ignoring invalid SSE Writable configuration update: %s", err.Error())
+			return
+		}
+		lc.Infof("Pipeline functions now %s", updated.PipelineFunctions)
+		subs.SetMatchDebug(logMatch, updated.MatchDebugSamples)
+	})
 	if err != nil {
-		lc.Errorf("SetDefaultFunctionsPipeline returned error: %s", err.Error())
+		lc.Errorf("Could not watch SSE Writable configuration: %s", err.Error())
 		return -1
 	}
 
+	// Messages from a second, non-EdgeX broker go through the same functions
+	if cfg.SSE.ExternalMQTT.Enabled {
+		source := external.NewMQTTSource(lc, cfg.SSE.ExternalMQTT, func(topic string, payload []byte) {
+			processor.Process(svc.BuildContext(uuid.NewString(), ""), topic, payload)
+		})
+		if name := cfg.SSE.ExternalMQTT.SecretName; name != "" {
+			// Credentials from the secret provider, and again each time they are rotated
+			setCredentials := func(name string) error {
+				secret, err := svc.SecretProvider().GetSecret(name, "username", "password")
+				if err != nil {
+					return err
+				}
+				source.SetCredentials(secret["username"], secret["password"])
+				return nil
+			}
+			if err := setCredentials(name); err != nil {
+				lc.Errorf("Could not get external MQTT credentials from secret %s: %s", name, err.Error())
+				return -1
+			}
+			err = svc.SecretProvider().RegisterSecretUpdatedCallback(name, func(name string) {
+				if err := setCredentials(name); err != nil {
+					lc.Errorf("Could not get rotated external MQTT credentials from secret %s: %s", name, err.Error())
+				}
+			})
+			if err != nil {
+				lc.Errorf("Could not watch secret %s: %s", name, err.Error())
+				return -1
+			}
+		}
+		if err := source.Start(); err != nil {
+			lc.Errorf("Could not start external MQTT source: %s", err.Error())
+			return -1
+		}
+		defer source.Stop()
+	}
+
 	// Register our custom REST endpoints
-	err = svc.AddCustomRoute("/api/v3/subscription", appint.Authenticated, web.ProcessSubscriptionRequest, http.MethodPost)
+	// The base paths are configurable, for ingress controllers that rewrite paths
+	subscriptionPath := cfg.SSE.SubscriptionRoute()
+	err = svc.AddCustomRoute(subscriptionPath, appint.Authenticated, web.RateLimited(web.ProcessSubscriptionRequest), http.MethodPost)
+	if err != nil {
+		lc.Errorf("Could not register %s endpoint: %s", subscriptionPath, err.Error())
+		return -1
+	}
+	err = svc.AddCustomRoute(subscriptionPath+"/service/:servicename", appint.Authenticated, web.RateLimited(web.ProcessServiceSubscriptionRequest), http.MethodPost)
+	if err != nil {
+		lc.Errorf("Could not register %s/service/{servicename} endpoint: %s", subscriptionPath, err.Error())
+		return -1
+	}
+	err = svc.AddCustomRoute(subscriptionPath+"/count", appint.Authenticated, web.RateLimited(web.ProcessCountRequest), http.MethodGet)
+	if err != nil {
+		lc.Errorf("Could not register %s/count endpoint: %s", subscriptionPath, err.Error())
+		return -1
+	}
+	err = svc.AddCustomRoute(subscriptionPath+"/id/:subscriptionid", appint.Authenticated, web.RateLimited(web.ProcessSubscriptionRequest), http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodPatch)
+	if err != nil {
+		lc.Errorf("Could not register %s/id/{subscriptionid} endpoint: %s", subscriptionPath, err.Error())
+		return -1
+	}
+
+	err = svc.AddCustomRoute(subscriptionPath+"/id/:subscriptionid/token", appint.Authenticated, web.RateLimited(web.ProcessStreamTokenRequest), http.MethodPost)
+	if err != nil {
+		lc.Errorf("Could not register %s/id/{subscriptionid}/token endpoint: %s", subscriptionPath, err.Error())
+		return -1
+	}
+
+	err = svc.AddCustomRoute(subscriptionPath+"/id/:subscriptionid/rotate", appint.Authenticated, web.RateLimited(web.ProcessRotateRequest), http.MethodPost)
+	if err != nil {
+		lc.Errorf("Could not register %s/id/{subscriptionid}/rotate endpoint: %s", subscriptionPath, err.Error())
+		return -1
+	}
+
+	err = svc.AddCustomRoute(subscriptionPath+"/id/:subscriptionid/ack", appint.Authenticated, web.RateLimited(web.ProcessAcknowledgeRequest), http.MethodPost)
+	if err != nil {
+		lc.Errorf("Could not register %s/id/{subscriptionid}/ack endpoint: %s", subscriptionPath, err.Error())
+		return -1
+	}
+
+	err = svc.AddCustomRoute(subscriptionPath+"/id/:subscriptionid/stats", appint.Authenticated, web.RateLimited(web.ProcessStatsRequest), http.MethodGet, http.MethodDelete)
+	if err != nil {
+		lc.Errorf("Could not register %s/id/{subscriptionid}/stats endpoint: %s", subscriptionPath, err.Error())
+		return -1
+	}
+
+	// Unauthenticated, for orchestrator probes
+	err = svc.AddCustomRoute(cfg.SSE.HealthRoute(), appint.Unauthenticated, web.ProcessHealthRequest, http.MethodGet)
 	if err != nil {
-		lc.Errorf("Could not register /subscription endpoint: %s", err.Error())
+		lc.Errorf("Could not register %s endpoint: %s", cfg.SSE.HealthRoute(), err.Error())
 		return -1
 	}
-	err = svc.AddCustomRoute("/api/v3/subscription/id/:subscriptionid", appint.Authenticated, web.ProcessSubscriptionRequest, http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodPatch)
+
+	// Unauthenticated, so code generators and API gateways can fetch it
+	err = svc.AddCustomRoute(cfg.SSE.OpenAPIRoute(), appint.Unauthenticated, web.ProcessOpenAPIRequest, http.MethodGet)
 	if err != nil {
-		lc.Errorf("Could not register /subscription/id/{subscriptionid} endpoint: %s", err.Error())
+		lc.Errorf("Could not register %s endpoint: %s", cfg.SSE.OpenAPIRoute(), err.Error())
+		return -1
+	}
+
+	if cfg.SSE.DebugUI {
+		err = svc.AddCustomRoute(cfg.SSE.DebugUIRoute(), appint.Authenticated, web.ProcessDebugUIRequest, http.MethodGet)
+		if err != nil {
+			lc.Errorf("Could not register %s endpoint: %s", cfg.SSE.DebugUIRoute(), err.Error())
+			return -1
+		}
+		lc.Infof("Debug page served at %s", cfg.SSE.DebugUIRoute())
+	}
+
+	err = svc.AddCustomRoute(cfg.SSE.ConnectionsRoute(), appint.Authenticated, web.ProcessConnectionsRequest, http.MethodGet)
+	if err != nil {
+		lc.Errorf("Could not register %s endpoint: %s", cfg.SSE.ConnectionsRoute(), err.Error())
+		return -1
+	}
+
+	err = svc.AddCustomRoute(cfg.SSE.LimitsRoute(), appint.Authenticated, web.ProcessLimitsRequest, http.MethodGet, http.MethodPut)
+	if err != nil {
+		lc.Errorf("Could not register %s endpoint: %s", cfg.SSE.LimitsRoute(), err.Error())
+		return -1
+	}
+
+	err = svc.AddCustomRoute(cfg.SSE.TriggerRoute()+"/*", appint.Authenticated, web.ProcessTriggerRequest, http.MethodPost)
+	if err != nil {
+		lc.Errorf("Could not register %s/{topic} endpoint: %s", cfg.SSE.TriggerRoute(), err.Error())
 		return -1
 	}
 
@@ -101,11 +418,49 @@ func CreateAndRunAppService(serviceKey string, newServiceFactory func(string, an
 	// Our solution: serve /events on another port using the regular handler
 	// so the SSE GETs don't time out.
 	eventmux := http.NewServeMux()
-	eventmux.HandleFunc("/api/v3/events/", web.ProcessEventsRequest)
-	listenaddr := cfg.SSE.EventsAddr + ":" + strconv.FormatUint(uint64(cfg.SSE.EventsPort), 10)
-	// Run in the background
-	go http.ListenAndServe(listenaddr, eventmux)
-	lc.Infof("Listening for EventSource GETs at %s", listenaddr)
+	eventmux.HandleFunc(cfg.SSE.EventsRoute(), web.ProcessEventsRequest)
+	eventmux.HandleFunc(cfg.SSE.ConfigRoute(), web.ProcessConfigRequest)
+	// Validated with the rest of the configuration
+	listenaddrs, _ := cfg.SSE.EventsListenAddrs()
+	var tlsConfig *tls.Config
+	if name := cfg.SSE.EventsTLSSecretName; name != "" {
+		// The certificate is swapped in place when rotated, so open streams are not cut off
+		var certs web.CertificateStore
+		setCertificate := func(name string) error {
+			secret, err := svc.SecretProvider().GetSecret(name, web.TLSCertSecretKey, web.TLSKeySecretKey)
+			if err != nil {
+				return err
+			}
+			return certs.Set([]byte(secret[web.TLSCertSecretKey]), []byte(secret[web.TLSKeySecretKey]))
+		}
+		if err := setCertificate(name); err != nil {
+			lc.Errorf("Could not get events TLS certificate from secret %s: %s", name, err.Error())
+			return -1
+		}
+		err = svc.SecretProvider().RegisterSecretUpdatedCallback(name, func(name string) {
+			if err := setCertificate(name); err != nil {
+				lc.Errorf("Could not use rotated events TLS certificate from secret %s, keeping the old one: %s", name, err.Error())
+				return
+			}
+			lc.Infof("Events TLS certificate rotated")
+			// Open streams would keep the old one until they reconnect
+			web.RestartStreams(errors.New("events TLS certificate rotated"))
+		})
+		if err != nil {
+			lc.Errorf("Could not watch secret %s: %s", name, err.Error())
+			return -1
+		}
+		tlsConfig = certs.TLSConfig()
+	}
+	/*
+	Bound now, so a port in use stops the service rather than leaving it without event streams.
+	The streams end, and the servers shut down, when svc.Run() does, before subs are closed.
+	*/
+	err = web.ServeEvents(svc.AppContext(), listenaddrs, web.EventsMiddleware(eventmux), tlsConfig, cfg.SSE.EventsBindRetries, durations.EventsBindRetryInterval)
+	if err != nil {
+		lc.Errorf("%s", err.Error())
+		return -1
+	}
 
 	// This doesn't return until program catches a signal to exit
 	if err := svc.Run(); err != nil {
@@ -113,8 +468,39 @@ func CreateAndRunAppService(serviceKey string, newServiceFactory func(string, an
 		return -1
 	}
 
+	stopPersistence()
+	stopPersistence = func() {}
 	subs.Close()
 	lc.Info("Service exiting")
 
 	return 0
 }
+
+// openStore returns the store of the configured PersistenceBackend.
+func openStore(cfg *configuration.Config, svc appint.ApplicationService) (persist.Store, error) {
+	switch cfg.SSE.PersistenceBackend {
+	case configuration.PersistenceRedis:
+		// EdgeX's Redis has no authentication in non-secure mode, and no secret to go with it
+		var username, password string
+		if name := cfg.SSE.Redis.SecretName; name != "" {
+			secret, err := svc.SecretProvider().GetSecret(name, "username", "password")
+			if err != nil {
+				svc.LoggingClient().Infof("Using Redis without authentication, could not get secret %s: %s", name, err.Error())
+			} else {
+				username, password = secret["username"], secret["password"]
+			}
+		}
+		return persist.NewRedisStore(svc.LoggingClient(), cfg.SSE.Redis, username, password), nil
+	case configuration.PersistenceKeeper:
+		// Core Keeper wants the service's JWT in secure mode, which the secret provider has
+		var auth clientint.AuthenticationInjector
+		if provider, ok := svc.SecretProvider().(bootstrapint.SecretProviderExt); ok {
+			auth = secret.NewJWTSecretProvider(provider)
+		}
+		return persist.NewKeeperStore(svc.LoggingClient(), cfg.SSE.Keeper, auth), nil
+	case configuration.PersistenceFile:
+		return persist.NewFileStore(svc.LoggingClient(), cfg.SSE.File.Path, cfg.SSE.Durations().FileSnapshotInterval), nil
+	default:
+		return nil, errors.New("unknown PersistenceBackend")
+	}
+}
@@ -0,0 +1,99 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+)
+
+// Conditions operators are alerted to, also the label of their notifications
+const (
+	// A subscription could not be created, the limit on subscriptions, or those of a role, was reached
+	AlertSubscriptionLimit = "subscription-limit"
+	// Events were dropped for full subscription buffers throughout OverflowAlertWindow
+	AlertOverflow = "overflow"
+	// The events port's server stopped, e.g. as it could not bind its address
+	AlertEventsListener = "events-listener"
+)
+
+// How many times within the window WatchOverflow() checks for dropped events
+const overflowSamples = 6
+
+/*
+Alert sends a notification through support-notifications that the condition occurred, with
+its severity (models.Minor, models.Normal or models.Critical) and a description of what
+happened, in the background, unless it was sent for the same condition within the alert interval.
+*/
+func (n *Notifier) Alert(condition string, severity string, description string) {
+	now := time.Now()
+	n.lock.Lock()
+	if last, ok := n.alerted[condition]; ok && now.Sub(last) < n.alertInterval {
+		n.lock.Unlock()
+		n.lc.Debugf("Not alerting %s again yet: %s", condition, description)
+		return
+	}
+	n.alerted[condition] = now
+	n.lock.Unlock()
+	go func() {
+		n.sending <- struct{}{}
+		defer func() { <-n.sending }()
+		if err := n.sendNotification([]string{condition}, description, common.ContentTypeText, severity); err != nil {
+			n.lc.Warnf("Could not send %s alert: %s", condition, err.Error())
+			return
+		}
+		n.lc.Debugf("Sent %s alert: %s", condition, description)
+	}()
+}
+
+/*
+WatchOverflow alerts when dropped, the count of events dropped for full subscription
+buffers, grows in every sixth of window, for a whole window, until the returned function
+is called. Short bursts, like a client catching up, don't alert.
+*/
+func (n *Notifier) WatchOverflow(dropped func() uint64, window time.Duration) func() {
+	ticker := time.NewTicker(window / overflowSamples)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer ticker.Stop()
+		last := dropped()
+		// Dropped in each sample of the streak so far, oldest first
+		streak := make([]uint64, 0, overflowSamples)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			current := dropped()
+			if current == last {
+				streak = streak[:0]
+				continue
+			}
+			streak = append(streak, current-last)
+			last = current
+			if len(streak) < overflowSamples {
+				continue
+			}
+			var total uint64
+			for _, count := range streak {
+				total += count
+			}
+			streak = streak[1:]
+			n.Alert(AlertOverflow, models.Normal, fmt.Sprintf("%d events dropped for full subscription buffers in the last %v: clients can't keep up", total, window))
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
@@ -0,0 +1,91 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package notify
+
+import (
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/requests"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+)
+
+func TestAlert(t *testing.T) {
+	client := &fakeNotifications{sent: make(chan requests.AddNotificationRequest, 4)}
+	dut := NewNotifier(logger.NewMockClient(), client, "edgex-sse", "edgex-sse", time.Hour)
+
+	dut.Alert(AlertEventsListener, models.Critical, "bind: address already in use")
+	select {
+	case req := <-client.sent:
+		n := req.Notification
+		if n.Category != "edgex-sse" || !slices.Equal(n.Labels, []string{AlertEventsListener}) || n.Severity != models.Critical || n.Content != "bind: address already in use" {
+			t.Fatalf("Sent notification %+v", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Alert not sent")
+	}
+
+	// Not again within the interval, but other conditions are
+	dut.Alert(AlertEventsListener, models.Critical, "again")
+	dut.Alert(AlertSubscriptionLimit, models.Minor, "limit")
+	select {
+	case req := <-client.sent:
+		if req.Notification.Content != "limit" {
+			t.Fatalf("Sent %s, rather than the subscription limit alert", req.Notification.Content)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Alert not sent")
+	}
+	select {
+	case req := <-client.sent:
+		t.Fatalf("Sent %s within the alert interval", req.Notification.Content)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWatchOverflow(t *testing.T) {
+	client := &fakeNotifications{sent: make(chan requests.AddNotificationRequest, 4)}
+	dut := NewNotifier(logger.NewMockClient(), client, "edgex-sse", "edgex-sse", time.Hour)
+	var dropped atomic.Uint64
+	var dropping atomic.Bool
+	stopDropping := make(chan struct{})
+	defer close(stopDropping)
+	go func() {
+		for {
+			select {
+			case <-stopDropping:
+				return
+			case <-time.After(2 * time.Millisecond):
+				if dropping.Load() {
+					dropped.Add(1)
+				}
+			}
+		}
+	}()
+
+	// Nothing dropped, no alert
+	stop := dut.WatchOverflow(dropped.Load, 60*time.Millisecond)
+	select {
+	case req := <-client.sent:
+		t.Fatalf("Sent %s without drops", req.Notification.Content)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	dropping.Store(true)
+	select {
+	case req := <-client.sent:
+		if !slices.Equal(req.Notification.Labels, []string{AlertOverflow}) {
+			t.Fatalf("Sent notification %+v", req.Notification)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Overflow alert not sent")
+	}
+	stop()
+}
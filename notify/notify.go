@@ -0,0 +1,150 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package for telling others about the service's subscriptions, through webhooks and EdgeX support-notifications.
+package notify
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	edgexDtos "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/requests"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+)
+
+// How long to wait for a webhook or support-notifications to take a notification
+const sendTimeout = 10 * time.Second
+
+// How many notifications are sent at once, more wait
+const maxSending = 8
+
+/*
+Struct Notifier sends notifications in the background, so whatever has something to tell,
+e.g. the idle subscription check, doesn't wait. Those that can't be sent are logged.
+*/
+type Notifier struct {
+	lc logger.LoggingClient
+	// support-notifications, nil if it isn't in the service's Clients
+	client   interfaces.NotificationClient
+	category string
+	sender   string
+	http     *http.Client
+	sending  chan struct{}
+	// How long an alert isn't sent again for, and when each was last sent - access under lock
+	alertInterval time.Duration
+	alerted       map[string]time.Time
+	lock          sync.Mutex
+}
+
+/*
+Factory function. Notifications to support-notifications go to client, nil if the service
+doesn't have one, under the category, from sender, e.g. the service key. The same alert is
+sent at most once every alertInterval.
+*/
+func NewNotifier(lc logger.LoggingClient, client interfaces.NotificationClient, category string, sender string, alertInterval time.Duration) *Notifier {
+	n := &Notifier{}
+	n.lc = lc
+	n.client = client
+	n.category = category
+	n.sender = sender
+	n.http = &http.Client{Timeout: sendTimeout}
+	n.sending = make(chan struct{}, maxSending)
+	n.alertInterval = alertInterval
+	n.alerted = make(map[string]time.Time)
+	return n
+}
+
+/*
+Expiry sends an ExpiryNotification where the subscription's ExpiryNotify option says, if
+anywhere. It is the subscription manager's expiry notice hook.
+*/
+func (n *Notifier) Expiry(notice submgr.ExpiryNotice) {
+	target := notice.Options.ExpiryNotify
+	if target == "" {
+		return
+	}
+	event := dtos.ExpiryEventExpiring
+	switch {
+	case notice.Expired:
+		event = dtos.ExpiryEventExpired
+	case notice.Grace:
+		event = dtos.ExpiryEventGrace
+	case notice.Resumed:
+		event = dtos.ExpiryEventResumed
+	}
+	expiresAt := ""
+	if !notice.Expires.IsZero() {
+		expiresAt = notice.Expires.UTC().Format(time.RFC3339)
+	}
+	content, err := json.Marshal(dtos.ExpiryNotification{
+		SubscriptionId: notice.SubId,
+		Event:          event,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		n.lc.Errorf("Could not marshal expiry notification of subscription %s: %s", notice.SubId, err.Error())
+		return
+	}
+	go func() {
+		n.sending <- struct{}{}
+		defer func() { <-n.sending }()
+		if target == dtos.ExpiryNotifySupport {
+			err = n.sendNotification(nil, string(content), common.ContentTypeJSON, models.Minor)
+		} else {
+			err = n.post(target, content)
+		}
+		if err != nil {
+			n.lc.Warnf("Could not send %s notification of subscription %s: %s", event, notice.SubId, err.Error())
+			return
+		}
+		n.lc.Debugf("Sent %s notification of subscription %s", event, notice.SubId)
+	}()
+}
+
+// post (an internal API) POSTs JSON content to a webhook.
+func (n *Notifier) post(target string, content []byte) error {
+	resp, err := n.http.Post(target, common.ContentTypeJSON, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendNotification (an internal API) sends content to support-notifications, with the labels.
+func (n *Notifier) sendNotification(labels []string, content string, contentType string, severity string) error {
+	if n.client == nil {
+		return fmt.Errorf("support-notifications is not in the service's Clients")
+	}
+	notification := edgexDtos.NewNotification(labels, n.category, content, n.sender, severity)
+	notification.ContentType = contentType
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	responses, err := n.client.SendNotification(ctx, []requests.AddNotificationRequest{requests.NewAddNotificationRequest(notification)})
+	if err != nil {
+		return err
+	}
+	for _, response := range responses {
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return fmt.Errorf("support-notifications returned status %d: %s", response.StatusCode, response.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package notify
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/requests"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+)
+
+// Struct fakeNotifications (an internal API) is a support-notifications client that passes on what it is sent.
+type fakeNotifications struct {
+	interfaces.NotificationClient
+	sent chan requests.AddNotificationRequest
+}
+
+func (f *fakeNotifications) SendNotification(_ context.Context, reqs []requests.AddNotificationRequest) ([]common.BaseWithIdResponse, errors.EdgeX) {
+	rv := make([]common.BaseWithIdResponse, 0, len(reqs))
+	for _, req := range reqs {
+		f.sent <- req
+		rv = append(rv, common.NewBaseWithIdResponse("", "", http.StatusCreated, req.Notification.Id))
+	}
+	return rv, nil
+}
+
+func TestExpiry(t *testing.T) {
+	posted := make(chan []byte, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posted <- body
+	}))
+	defer server.Close()
+	client := &fakeNotifications{sent: make(chan requests.AddNotificationRequest, 4)}
+	dut := NewNotifier(logger.NewMockClient(), client, "edgex-sse", "edgex-sse", time.Hour)
+	expires := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Nowhere to send it
+	dut.Expiry(submgr.ExpiryNotice{SubId: "quiet", Expires: expires})
+	dut.Expiry(submgr.ExpiryNotice{SubId: "hooked", Options: submgr.SubscriptionOptions{ExpiryNotify: server.URL + "/expiry"}, Expires: expires})
+	var got dtos.ExpiryNotification
+	select {
+	case body := <-posted:
+		if err := json.Unmarshal(body, &got); err != nil || got != (dtos.ExpiryNotification{SubscriptionId: "hooked", Event: dtos.ExpiryEventExpiring, ExpiresAt: "2025-03-01T12:00:00Z"}) {
+			t.Fatalf("Webhook got %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook not called")
+	}
+
+	dut.Expiry(submgr.ExpiryNotice{SubId: "notified", Options: submgr.SubscriptionOptions{ExpiryNotify: dtos.ExpiryNotifySupport}, Expires: expires, Expired: true})
+	select {
+	case req := <-client.sent:
+		if req.Notification.Category != "edgex-sse" || req.Notification.Sender != "edgex-sse" || json.Unmarshal([]byte(req.Notification.Content), &got) != nil || got.SubscriptionId != "notified" || got.Event != dtos.ExpiryEventExpired {
+			t.Fatalf("Sent notification %+v", req.Notification)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Notification not sent")
+	}
+	select {
+	case body := <-posted:
+		t.Fatalf("Webhook got %s for a subscription without expiryNotify", body)
+	default:
+	}
+
+	// Saved in its grace window, with no expiration
+	dut.Expiry(submgr.ExpiryNotice{SubId: "notified", Options: submgr.SubscriptionOptions{ExpiryNotify: dtos.ExpiryNotifySupport}, Resumed: true})
+	select {
+	case req := <-client.sent:
+		if strings.Contains(req.Notification.Content, "expiresAt") || json.Unmarshal([]byte(req.Notification.Content), &got) != nil || got.Event != dtos.ExpiryEventResumed {
+			t.Fatalf("Sent notification %+v", req.Notification)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Notification not sent")
+	}
+}
@@ -0,0 +1,220 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+Package openapi serves the API's OpenAPI 3 document, v3/edgex-sse.yaml, made whole: the
+references to other EdgeX services' documents are replaced with schemas generated from the
+Go types, the paths are those the service is configured with, and the schemas of types the
+service defines follow their Go types, so the document can't drift from the code.
+*/
+package openapi
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed v3/edgex-sse.yaml
+var document []byte
+
+// External references of the document, and what replaces them. Paths replaced with nil are left out.
+var external = map[string]any{
+	"core-data.yaml#/components/schemas/Event":                 SchemaOf(reflect.TypeOf(dtos.Event{})),
+	"app-functions-sdk.yaml#/components/schemas/BaseRequest":   SchemaOf(reflect.TypeOf(commonDTO.BaseRequest{})),
+	"app-functions-sdk.yaml#/components/schemas/BaseResponse":  SchemaOf(reflect.TypeOf(commonDTO.BaseResponse{})),
+	"app-functions-sdk.yaml#/components/schemas/ErrorResponse": SchemaOf(reflect.TypeOf(commonDTO.BaseResponse{})),
+	"core-data.yaml#/components/headers/correlatedResponseHeader": map[string]any{
+		"description": "A response header that returns the unique correlation ID used to initiate the request.",
+		"schema":      map[string]any{"type": "string", "format": "uuid"},
+	},
+	"core-data.yaml#/components/parameters/correlatedRequestHeader": map[string]any{
+		"name":        common.CorrelationHeader,
+		"in":          "header",
+		"description": "A unique identifier correlating a request to its associated response, generated if not given.",
+		"schema":      map[string]any{"type": "string", "format": "uuid"},
+	},
+	"app-functions-sdk.yaml#/paths/~1ping":    nil,
+	"app-functions-sdk.yaml#/paths/~1secret":  nil,
+	"app-functions-sdk.yaml#/paths/~1trigger": nil,
+	"app-functions-sdk.yaml#/paths/~1version": nil,
+}
+
+// Component schemas whose properties follow Go types
+var generated = map[string]reflect.Type{
+	"SubscriptionOptions": reflect.TypeOf(submgr.SubscriptionOptions{}),
+}
+
+/*
+Document returns the OpenAPI document as JSON, for a service with that configuration.
+eventsServer is the base URL of the events port, e.g. "http://gateway:59748"; the other
+paths are relative to the server the document is fetched from.
+*/
+func Document(sse *configuration.SseConfig, eventsServer string) ([]byte, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(document, &doc); err != nil {
+		return nil, err
+	}
+	resolved, ok := resolve(doc).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("OpenAPI document is not an object")
+	}
+	doc = resolved
+	doc["servers"] = []any{map[string]any{"url": "/"}}
+
+	components, _ := doc["components"].(map[string]any)
+	schemas, _ := components["schemas"].(map[string]any)
+	for name, t := range generated {
+		schema, ok := schemas[name].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("OpenAPI document has no %s schema", name)
+		}
+		follow(schema, SchemaOf(t))
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	routes := []struct {
+		path, route string
+		// Served on the events port
+		events      bool
+	}{
+		// EventsRoute() ends with a slash, for the events port's ServeMux
+		{"/events", strings.TrimSuffix(sse.EventsRoute(), "/"), true},
+		{"/config", sse.ConfigRoute(), true},
+		{"/subscription", sse.SubscriptionRoute(), false},
+		{"/trigger", sse.TriggerRoute(), false},
+		{"/connections", sse.ConnectionsRoute(), false},
+		{"/health", sse.HealthRoute(), false},
+		{"/openapi", sse.OpenAPIRoute(), false},
+		{"/debug/ui", sse.DebugUIRoute(), false},
+		{"/limits", sse.LimitsRoute(), false},
+	}
+	if !sse.DebugUI {
+		delete(paths, "/debug/ui")
+	}
+	rewritten := make(map[string]any, len(paths))
+	for path, item := range paths {
+		if item == nil {
+			continue
+		}
+		for _, r := range routes {
+			if rest, ok := strings.CutPrefix(path, r.path); ok && (rest == "" || rest[0] == '/') {
+				path = r.route + rest
+				if r.events {
+					// Event streams, and what goes with them, are served on their own port
+					if item, ok := item.(map[string]any); ok {
+						item["servers"] = []any{map[string]any{"url": eventsServer}}
+					}
+				}
+				break
+			}
+		}
+		rewritten[path] = item
+	}
+	doc["paths"] = rewritten
+	return json.Marshal(doc)
+}
+
+/*
+resolve (an internal API) returns a decoded YAML value with the external references replaced,
+and the maps with non-string keys YAML may decode into turned into ones JSON can encode.
+*/
+func resolve(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok && !strings.HasPrefix(ref, "#") {
+			replacement, known := external[ref]
+			if !known {
+				return v
+			}
+			if replacement == nil {
+				return nil
+			}
+			// Keep what is given alongside the reference, e.g. required
+			rv := clone(replacement).(map[string]any)
+			for key, sibling := range v {
+				if key != "$ref" {
+					rv[key] = resolve(sibling)
+				}
+			}
+			return rv
+		}
+		for key, child := range v {
+			v[key] = resolve(child)
+		}
+		return v
+	case map[any]any:
+		rv := make(map[string]any, len(v))
+		for key, child := range v {
+			rv[fmt.Sprint(key)] = child
+		}
+		return resolve(rv)
+	case []any:
+		for i, child := range v {
+			v[i] = resolve(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// clone (an internal API) deep-copies a schema, so one generated schema can be used in several places.
+func clone(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		rv := make(map[string]any, len(v))
+		for key, child := range v {
+			rv[key] = clone(child)
+		}
+		return rv
+	case []any:
+		rv := make([]any, len(v))
+		for i, child := range v {
+			rv[i] = clone(child)
+		}
+		return rv
+	case []string:
+		return append([]string(nil), v...)
+	default:
+		return v
+	}
+}
+
+/*
+follow (an internal API) makes a documented object schema's properties those of the generated
+one: properties the Go type doesn't have are dropped, new ones are added, and the types are
+the Go types'. What is only documented, like descriptions, enums and defaults, is kept.
+*/
+func follow(documented map[string]any, generated map[string]any) {
+	properties, _ := documented["properties"].(map[string]any)
+	rv := make(map[string]any)
+	for name, schema := range generated["properties"].(map[string]any) {
+		merged := schema.(map[string]any)
+		if old, ok := properties[name].(map[string]any); ok {
+			for key, value := range old {
+				if _, set := merged[key]; !set {
+					merged[key] = value
+				}
+			}
+		}
+		rv[name] = merged
+	}
+	documented["properties"] = rv
+	documented["type"] = "object"
+	if required, ok := generated["required"]; ok {
+		documented["required"] = required
+	}
+}
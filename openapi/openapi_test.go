@@ -0,0 +1,143 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package openapi
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// refs (an internal API) collects the $ref values of a decoded JSON document.
+func refs(value any, found *[]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				*found = append(*found, ref)
+			}
+			refs(child, found)
+		}
+	case []any:
+		for _, child := range v {
+			refs(child, found)
+		}
+	}
+}
+
+// lookup (an internal API) finds the value a local JSON pointer reference points at.
+func lookup(doc map[string]any, ref string) any {
+	var value any = doc
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[part]
+	}
+	return value
+}
+
+func TestDocument(t *testing.T) {
+	var cfg configuration.Config
+	cfg.SetDefaults()
+	cfg.SSE.SubscriptionPath = "/sse/subs/"
+	data, err := Document(&cfg.SSE, "https://gateway:59748")
+	if err != nil {
+		t.Fatalf("Document failed: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Document is not JSON: %v", err)
+	}
+
+	var found []string
+	refs(doc, &found)
+	for _, ref := range found {
+		if !strings.HasPrefix(ref, "#/") {
+			t.Errorf("External reference %s left", ref)
+		} else if lookup(doc, ref) == nil {
+			t.Errorf("Reference %s doesn't resolve", ref)
+		}
+	}
+
+	paths := doc["paths"].(map[string]any)
+	for _, path := range []string{"/sse/subs", "/sse/subs/id/{subscription_id}", "/api/v3/events/{subscription_id}", "/api/v3/trigger/{topic}", "/api/v3/openapi", "/api/v3/config"} {
+		if paths[path] == nil {
+			t.Errorf("Path %s missing", path)
+		}
+	}
+	for _, path := range []string{"/subscription", "/config", "/trigger", "/version", "/debug/ui", "/api/v3/debug/ui"} {
+		if _, ok := paths[path]; ok {
+			t.Errorf("Path %s not dropped", path)
+		}
+	}
+	events := paths["/api/v3/events/{subscription_id}"].(map[string]any)
+	if !reflect.DeepEqual(events["servers"], []any{map[string]any{"url": "https://gateway:59748"}}) {
+		t.Errorf("Wrong events servers %v", events["servers"])
+	}
+	config := paths["/api/v3/config"].(map[string]any)
+	if !reflect.DeepEqual(config["servers"], events["servers"]) {
+		t.Errorf("Wrong config servers %v", config["servers"])
+	}
+
+	// The options follow the Go type, keeping their descriptions
+	options := lookup(doc, "#/components/schemas/SubscriptionOptions").(map[string]any)["properties"].(map[string]any)
+	expected := SchemaOf(reflect.TypeOf(submgr.SubscriptionOptions{}))["properties"].(map[string]any)
+	if len(options) != len(expected) {
+		t.Fatalf("Wrong options %v", options)
+	}
+	for name, schema := range expected {
+		option, _ := options[name].(map[string]any)
+		if option["type"] != schema.(map[string]any)["type"] || option["description"] == nil {
+			t.Errorf("Wrong option %s: %v", name, option)
+		}
+	}
+	// The debug page is only there if served
+	cfg.SSE.DebugUI = true
+	data, _ = Document(&cfg.SSE, "")
+	if !strings.Contains(string(data), `"/api/v3/debug/ui":`) {
+		t.Error("No debug page path")
+	}
+	response := lookup(doc, "#/components/schemas/BaseResponse").(map[string]any)["properties"].(map[string]any)
+	if response["statusCode"].(map[string]any)["type"] != "integer" || response["apiVersion"] == nil {
+		t.Errorf("Wrong BaseResponse %v", response)
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	type node struct {
+		Name     string            `json:"name" validate:"required"`
+		Count    uint              `json:"count,omitempty"`
+		Data     []byte            `json:"data"`
+		When     time.Time         `json:"when"`
+		Tags     map[string]string `json:"tags"`
+		Children []*node           `json:"children"`
+		Ignored  string            `json:"-"`
+		hidden   string
+	}
+	schema := SchemaOf(reflect.TypeOf(node{}))
+	properties := schema["properties"].(map[string]any)
+	if len(properties) != 6 || !reflect.DeepEqual(schema["required"], []string{"name"}) {
+		t.Fatalf("Wrong schema %v", schema)
+	}
+	if !reflect.DeepEqual(properties["data"], map[string]any{"type": "string", "format": "byte"}) ||
+		!reflect.DeepEqual(properties["when"], map[string]any{"type": "string", "format": "date-time"}) ||
+		!reflect.DeepEqual(properties["count"], map[string]any{"type": "integer", "minimum": 0}) {
+		t.Fatalf("Wrong properties %v", properties)
+	}
+	// Cut short where it refers to itself
+	children := properties["children"].(map[string]any)
+	if !reflect.DeepEqual(children["items"], map[string]any{"type": "object"}) {
+		t.Fatalf("Wrong children %v", children)
+	}
+}
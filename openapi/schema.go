@@ -0,0 +1,99 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+/*
+SchemaOf returns the OpenAPI schema of the JSON encoding of a Go type: structs become objects
+with a property for each field, by its json tag, and the fields the EdgeX DTOs validate as
+required are listed as such. Types that refer to themselves are cut short at the second level.
+*/
+func SchemaOf(t reflect.Type) map[string]any {
+	return schemaOf(t, make(map[reflect.Type]bool))
+}
+
+// schemaOf (an internal API) is SchemaOf, for a type inside those being visited.
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is base64 in JSON
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), visiting)}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		if visiting[t] {
+			return map[string]any{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		properties := make(map[string]any)
+		var required []string
+		addProperties(t, properties, &required, visiting)
+		rv := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			rv["required"] = required
+		}
+		return rv
+	default:
+		// Interfaces can be anything
+		return map[string]any{}
+	}
+}
+
+// addProperties (an internal API) adds the properties of a struct's fields, and those of embedded structs.
+func addProperties(t reflect.Type, properties map[string]any, required *[]string, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		// Like encoding/json, untagged embedded structs' fields are promoted
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			addProperties(fieldType, properties, required, visiting)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaOf(field.Type, visiting)
+		if validate := field.Tag.Get("validate"); validate == "required" || strings.HasPrefix(validate, "required,") {
+			*required = append(*required, name)
+		}
+	}
+}
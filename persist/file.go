@@ -0,0 +1,187 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package persist
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// Struct snapshot is the contents of the file a FileStore writes.
+type snapshot struct {
+	Subscriptions []submgr.Definition `json:"subscriptions"`
+}
+
+/*
+Struct FileStore keeps subscription definitions in memory, and writes them all to a JSON
+file every so often when they have changed, for running the service on its own, without
+EdgeX's Redis or Core Keeper. The file is replaced whole, so it is never half written, and
+written a last time on Close(). Changes since the last write are lost if the service crashes.
+*/
+type FileStore struct {
+	lc   logger.LoggingClient
+	path string
+	// Guards defs and changed
+	lock    sync.Mutex
+	defs    map[string]submgr.Definition
+	changed bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Factory function. The file is written every interval, while the store is open.
+func NewFileStore(lc logger.LoggingClient, path string, interval time.Duration) *FileStore {
+	s := &FileStore{}
+	s.lc = lc
+	s.path = path
+	s.defs = make(map[string]submgr.Definition)
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run(interval)
+	return s
+}
+
+// run (an internal API) is the goroutine writing the file.
+func (s *FileStore) run(interval time.Duration) {
+	defer close(s.done)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-tick.C:
+			if err := s.write(); err != nil {
+				s.lc.Warnf("Could not write persisted subscriptions to %s, will retry: %s", s.path, err.Error())
+			}
+		}
+	}
+}
+
+/*
+write (an internal API) writes the definitions to the file, if they have changed, through
+a temporary file renamed over it.
+*/
+func (s *FileStore) write() error {
+	s.lock.Lock()
+	if !s.changed {
+		s.lock.Unlock()
+		return nil
+	}
+	contents := snapshot{Subscriptions: make([]submgr.Definition, 0, len(s.defs))}
+	for _, id := range slices.Sorted(maps.Keys(s.defs)) {
+		contents.Subscriptions = append(contents.Subscriptions, s.defs[id])
+	}
+	s.changed = false
+	s.lock.Unlock()
+
+	err := s.writeFile(contents)
+	if err != nil {
+		// Tried again next time
+		s.lock.Lock()
+		s.changed = true
+		s.lock.Unlock()
+	}
+	return err
+}
+
+// writeFile (an internal API) writes the file through a temporary file renamed over it.
+func (s *FileStore) writeFile(contents snapshot) error {
+	data, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Load reads the file, returning its definitions in ID order, or none if there is no file yet.
+func (s *FileStore) Load() ([]submgr.Definition, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []submgr.Definition{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var contents snapshot
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return nil, err
+	}
+	rv := make([]submgr.Definition, 0, len(contents.Subscriptions))
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, def := range contents.Subscriptions {
+		if def.Id == "" {
+			s.lc.Warnf("Skipping persisted subscription without an ID in %s", s.path)
+			continue
+		}
+		s.defs[def.Id] = def
+		rv = append(rv, def)
+	}
+	slices.SortFunc(rv, func(a, b submgr.Definition) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+	return rv, nil
+}
+
+// Save keeps the definition, to be written with the next snapshot.
+func (s *FileStore) Save(def submgr.Definition) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.defs[def.Id] = def
+	s.changed = true
+	return nil
+}
+
+// Delete removes the definition, from the next snapshot.
+func (s *FileStore) Delete(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.defs[id]; ok {
+		delete(s.defs, id)
+		s.changed = true
+	}
+	return nil
+}
+
+// Close stops the snapshots, and writes the file if the definitions have changed since the last.
+func (s *FileStore) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.write()
+}
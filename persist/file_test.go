@@ -0,0 +1,69 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package persist
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "subscriptions.json")
+	lc := logger.NewMockClient()
+	dut := NewFileStore(lc, path, 200*time.Millisecond)
+	if defs, err := dut.Load(); err != nil || len(defs) != 0 {
+		t.Fatalf("Load returned %+v, %v without a file", defs, err)
+	}
+	a := submgr.Definition{Id: "a", Include: []string{"x/"}, Exclude: []string{}, Owner: "alice"}
+	b := submgr.Definition{Id: "b", Include: []string{"y/"}, Exclude: []string{"y/z/"}, Options: submgr.SubscriptionOptions{Format: submgr.FormatSimple}}
+	_ = dut.Save(b)
+	_ = dut.Save(a)
+	// Written by the next snapshot, not at once
+	if _, err := os.Stat(path); err == nil {
+		t.Fatal("File written before the snapshot interval")
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("File not written by a snapshot")
+		}
+	}
+	_ = dut.Delete("a")
+	if err := dut.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Only what was there on close
+	reopened := NewFileStore(lc, path, time.Hour)
+	defs, err := reopened.Load()
+	if err != nil || !reflect.DeepEqual(defs, []submgr.Definition{b}) {
+		t.Fatalf("Load returned %+v, %v", defs, err)
+	}
+	if err := reopened.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Fatalf("Temporary files left behind: %v", entries)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	broken := NewFileStore(lc, path, time.Hour)
+	defer broken.Close()
+	if _, err := broken.Load(); err == nil {
+		t.Fatal("Load succeeded with a file that isn't JSON")
+	}
+}
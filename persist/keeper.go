@@ -0,0 +1,128 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package persist
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	clients "github.com/edgexfoundry/go-mod-core-contracts/v4/clients/http"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/requests"
+)
+
+// How long to wait for Core Keeper to answer
+const keeperTimeout = 10 * time.Second
+
+/*
+Struct KeeperStore keeps subscription definitions in Core Keeper, EdgeX's configuration
+provider, for deployments without Redis: as JSON in a key per subscription ID, below a base path.
+*/
+type KeeperStore struct {
+	lc       logger.LoggingClient
+	basePath string
+	client   interfaces.KVSClient
+}
+
+// Struct noAuthentication (an internal API) is the authentication injector of requests without any.
+type noAuthentication struct{}
+
+func (noAuthentication) AddAuthenticationData(_ *http.Request) error {
+	return nil
+}
+
+func (noAuthentication) RoundTripper() http.RoundTripper {
+	return http.DefaultTransport
+}
+
+// Factory function. The authentication injector adds the service's JWT in secure mode, nil for none.
+func NewKeeperStore(lc logger.LoggingClient, config configuration.KeeperConfig, auth interfaces.AuthenticationInjector) *KeeperStore {
+	if auth == nil {
+		auth = noAuthentication{}
+	}
+	s := &KeeperStore{}
+	s.lc = lc
+	s.basePath = strings.Trim(config.BasePath, "/")
+	baseUrl := "http://" + net.JoinHostPort(config.Host, strconv.FormatUint(uint64(config.Port), 10))
+	s.client = clients.NewKVSClient(baseUrl, auth)
+	return s
+}
+
+// keyOf (an internal API) returns the key of a subscription ID.
+func (s *KeeperStore) keyOf(id string) string {
+	return s.basePath + "/" + id
+}
+
+// Load returns the definitions below the base path, in ID order. Those that don't decode are logged and skipped.
+func (s *KeeperStore) Load() ([]submgr.Definition, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keeperTimeout)
+	defer cancel()
+	resp, err := s.client.ValuesByKey(ctx, s.basePath)
+	if err != nil {
+		// Nothing persisted yet
+		if err.Code() == http.StatusNotFound {
+			return []submgr.Definition{}, nil
+		}
+		return nil, err
+	}
+	rv := make([]submgr.Definition, 0, len(resp.Response))
+	for _, kv := range resp.Response {
+		id, ok := strings.CutPrefix(kv.Key, s.basePath+"/")
+		if !ok || strings.Contains(id, "/") {
+			continue
+		}
+		var def submgr.Definition
+		value, _ := kv.Value.(string)
+		if err := json.Unmarshal([]byte(value), &def); err != nil || def.Id != id {
+			s.lc.Warnf("Skipping persisted subscription %s, not a subscription definition", id)
+			continue
+		}
+		rv = append(rv, def)
+	}
+	slices.SortFunc(rv, func(a, b submgr.Definition) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+	return rv, nil
+}
+
+// Save sets the definition's key.
+func (s *KeeperStore) Save(def submgr.Definition) error {
+	value, err := json.Marshal(def)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), keeperTimeout)
+	defer cancel()
+	if _, err := s.client.UpdateValuesByKey(ctx, s.keyOf(def.Id), false, requests.UpdateKeysRequest{Value: string(value)}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Delete removes the ID's key.
+func (s *KeeperStore) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), keeperTimeout)
+	defer cancel()
+	if _, err := s.client.DeleteKey(ctx, s.keyOf(id)); err != nil && err.Code() != http.StatusNotFound {
+		return err
+	}
+	return nil
+}
+
+// Close does nothing, requests to Core Keeper don't keep connections of their own.
+func (s *KeeperStore) Close() error {
+	return nil
+}
@@ -0,0 +1,124 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package persist
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/requests"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/responses"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+)
+
+// fakeKeeper (an internal API) is just enough of Core Keeper's key-value API for KeeperStore.
+func fakeKeeper(t *testing.T) (*httptest.Server, map[string]string, *sync.Mutex) {
+	kvs := make(map[string]string)
+	var lock sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.URL.Path, "/api/v3/kvs/key/")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			rv := responses.MultiKeyValueResponse{BaseResponse: commonDTO.NewBaseResponse("", "", http.StatusOK)}
+			for k, v := range kvs {
+				if strings.HasPrefix(k, key) {
+					rv.Response = append(rv.Response, models.KVS{Key: k, StoredData: models.StoredData{Value: v}})
+				}
+			}
+			if len(rv.Response) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(commonDTO.NewBaseResponse("", "not found", http.StatusNotFound))
+				return
+			}
+			json.NewEncoder(w).Encode(rv)
+		case http.MethodPut:
+			var req requests.UpdateKeysRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			kvs[key], _ = req.Value.(string)
+			json.NewEncoder(w).Encode(responses.KeysResponse{BaseResponse: commonDTO.NewBaseResponse("", "", http.StatusOK)})
+		case http.MethodDelete:
+			if _, ok := kvs[key]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(commonDTO.NewBaseResponse("", "not found", http.StatusNotFound))
+				return
+			}
+			delete(kvs, key)
+			json.NewEncoder(w).Encode(responses.KeysResponse{BaseResponse: commonDTO.NewBaseResponse("", "", http.StatusOK)})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, kvs, &lock
+}
+
+func TestKeeperStore(t *testing.T) {
+	server, kvs, lock := fakeKeeper(t)
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	portNumber, _ := strconv.Atoi(port)
+	config := configuration.KeeperConfig{Host: host, Port: uint(portNumber), BasePath: "/edgex/v4/subs/"}
+	dut := NewKeeperStore(logger.NewMockClient(), config, nil)
+	defer dut.Close()
+	if defs, err := dut.Load(); err != nil || len(defs) != 0 {
+		t.Fatalf("Load returned %+v, %v with nothing persisted", defs, err)
+	}
+	a := submgr.Definition{Id: "a", Include: []string{"x/"}, Exclude: []string{}, Owner: "alice"}
+	b := submgr.Definition{Id: "b", Include: []string{"y/"}, Exclude: []string{"y/z/"}, Options: submgr.SubscriptionOptions{Format: submgr.FormatSimple}}
+	for _, def := range []submgr.Definition{b, a} {
+		if err := dut.Save(def); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	lock.Lock()
+	if _, ok := kvs["edgex/v4/subs/a"]; !ok {
+		t.Fatalf("Wrong keys %v", kvs)
+	}
+	// Not definitions, skipped
+	kvs["edgex/v4/subs/c"] = "{"
+	kvs["edgex/v4/subs/d/e"] = "{}"
+	lock.Unlock()
+	defs, err := dut.Load()
+	if err != nil || !reflect.DeepEqual(defs, []submgr.Definition{a, b}) {
+		t.Fatalf("Load returned %+v, %v", defs, err)
+	}
+	if err := dut.Delete("a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := dut.Delete("a"); err != nil {
+		t.Fatalf("Delete failed for a key already deleted: %v", err)
+	}
+	if defs, _ := dut.Load(); len(defs) != 1 || defs[0].Id != "b" {
+		t.Fatalf("Load returned %+v after delete", defs)
+	}
+
+	server.Close()
+	if _, err := dut.Load(); err == nil {
+		t.Fatal("Load succeeded without a server")
+	}
+	if err := dut.Save(a); err == nil {
+		t.Fatal("Save succeeded without a server")
+	}
+}
@@ -0,0 +1,211 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package to keep subscriptions created through the API across service restarts
+package persist
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// How long to wait before writing again changes that could not be written
+const retryInterval = 5 * time.Second
+
+// Interface Store is where subscription definitions are kept, keyed by subscription ID.
+type Store interface {
+	// Load returns all the definitions kept
+	Load() ([]submgr.Definition, error)
+	// Save keeps a definition, replacing any with the same ID
+	Save(def submgr.Definition) error
+	// Delete removes the definition with the ID, if there is one
+	Delete(id string) error
+	Close() error
+}
+
+/*
+Interface SharedStore is a Store several replicas of the service use at once, which also
+keeps when each subscription was last listened to, on any of them.
+*/
+type SharedStore interface {
+	Store
+	// Get returns the definition with the ID, and true, or false if there is none
+	Get(id string) (submgr.Definition, bool, error)
+	// Touch records the subscriptions as listened to now
+	Touch(ids []string) error
+	// Seen returns when each subscription was last listened to, of those that have been
+	Seen() (map[string]time.Time, error)
+}
+
+/*
+Restore loads the definitions in the store and recreates their subscriptions with restore,
+e.g. web.RestoreSubscription, returning how many were. Those that can't be recreated, e.g.
+as their quota is gone from the configuration or the limits were lowered, are logged and
+left in the store, so they come back at a later start once the cause is fixed.
+
+Error is returned if the store can't be read.
+*/
+func Restore(lc logger.LoggingClient, store Store, restore func(def submgr.Definition) error) (int, error) {
+	defs, err := store.Load()
+	if err != nil {
+		return 0, err
+	}
+	restored := 0
+	for _, def := range defs {
+		if err := restore(def); err != nil {
+			lc.Warnf("Could not restore persisted subscription %s, keeping it for the next start: %s", def.Id, err.Error())
+			continue
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+/*
+Struct Persister writes the changes to subscriptions to a Store, in the background. Changes
+are gathered for a delay, so a burst of them, e.g. a PATCH, is one write per subscription,
+and written again later if the store can't be reached.
+*/
+type Persister struct {
+	lc    logger.LoggingClient
+	subs  *submgr.SubscriptionManager
+	store Store
+	delay time.Duration
+	// Guards dirty, the IDs of the subscriptions changed since the last write, and writing
+	lock    sync.Mutex
+	dirty   map[string]struct{}
+	writing map[string]struct{}
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Factory function
+func NewPersister(lc logger.LoggingClient, subs *submgr.SubscriptionManager, store Store, delay time.Duration) *Persister {
+	p := &Persister{}
+	p.lc = lc
+	p.subs = subs
+	p.store = store
+	p.delay = delay
+	p.dirty = make(map[string]struct{})
+	p.writing = make(map[string]struct{})
+	p.wake = make(chan struct{}, 1)
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	return p
+}
+
+// Start sets the subscription manager's change hook, and writes the changes until Stop() is called.
+func (p *Persister) Start() {
+	p.subs.SetChangeHook(p.changed)
+	go p.run()
+}
+
+// Stop unsets the change hook and writes the changes not yet written. The store is not closed.
+func (p *Persister) Stop() {
+	p.subs.SetChangeHook(nil)
+	close(p.stop)
+	<-p.done
+}
+
+// changed (an internal API) is the change hook, marking the subscription to be written.
+func (p *Persister) changed(subid string) {
+	p.lock.Lock()
+	p.dirty[subid] = struct{}{}
+	p.lock.Unlock()
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pending returns true if the subscription was changed and the change is not yet written.
+func (p *Persister) Pending(subid string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	_, dirty := p.dirty[subid]
+	_, writing := p.writing[subid]
+	return dirty || writing
+}
+
+// run (an internal API) is the goroutine writing the changes.
+func (p *Persister) run() {
+	defer close(p.done)
+	for {
+		select {
+		case <-p.stop:
+			p.flush()
+			return
+		case <-p.wake:
+		}
+		// Changes made while waiting are written with this one
+		if !p.sleep(p.delay) || (!p.flush() && !p.sleep(retryInterval)) {
+			p.flush()
+			return
+		}
+	}
+}
+
+// sleep (an internal API) waits for the duration, returning false if stopped meanwhile.
+func (p *Persister) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-p.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+/*
+flush (an internal API) writes the subscriptions changed: saves those there are, and deletes
+those there no longer are. Those that fail are marked to be written again.
+
+Returns false if any failed.
+*/
+func (p *Persister) flush() bool {
+	p.lock.Lock()
+	dirty := p.dirty
+	p.dirty = make(map[string]struct{})
+	p.writing = dirty
+	p.lock.Unlock()
+	var failed []string
+	var lastErr error
+	for subid := range dirty {
+		var err error
+		if def, ok := p.subs.Definition(subid); ok {
+			err = p.store.Save(def)
+		} else {
+			err = p.store.Delete(subid)
+		}
+		if err != nil {
+			failed = append(failed, subid)
+			lastErr = err
+		}
+	}
+	p.lock.Lock()
+	for _, subid := range failed {
+		p.dirty[subid] = struct{}{}
+	}
+	p.writing = make(map[string]struct{})
+	p.lock.Unlock()
+	if len(failed) == 0 {
+		return true
+	}
+	p.lc.Warnf("Could not persist %d subscription changes, will retry: %s", len(failed), lastErr.Error())
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package persist
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// Struct memoryStore (an internal API) is a SharedStore in memory, which can be made to fail.
+type memoryStore struct {
+	lock   sync.Mutex
+	defs   map[string]submgr.Definition
+	seen   map[string]time.Time
+	writes int
+	fail   bool
+}
+
+func (m *memoryStore) Load() ([]submgr.Definition, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return slices.Collect(maps.Values(m.defs)), nil
+}
+
+func (m *memoryStore) Save(def submgr.Definition) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.fail {
+		return errors.New("unavailable")
+	}
+	m.writes++
+	m.defs[def.Id] = def
+	return nil
+}
+
+func (m *memoryStore) Delete(id string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.fail {
+		return errors.New("unavailable")
+	}
+	m.writes++
+	delete(m.defs, id)
+	return nil
+}
+
+func (m *memoryStore) Get(id string) (submgr.Definition, bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	def, ok := m.defs[id]
+	return def, ok, nil
+}
+
+func (m *memoryStore) Touch(ids []string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.seen == nil {
+		m.seen = make(map[string]time.Time)
+	}
+	for _, id := range ids {
+		m.seen[id] = time.Now()
+	}
+	return nil
+}
+
+func (m *memoryStore) Seen() (map[string]time.Time, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return maps.Clone(m.seen), nil
+}
+
+func (m *memoryStore) Close() error {
+	return nil
+}
+
+// has (an internal API) returns whether the store has the ID, and how many writes it had.
+func (m *memoryStore) has(id string) (bool, int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	_, ok := m.defs[id]
+	return ok, m.writes
+}
+
+func TestRestore(t *testing.T) {
+	var subs submgr.SubscriptionManager
+	if err := subs.Init(10, 10, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer subs.Close()
+	store := &memoryStore{defs: map[string]submgr.Definition{
+		"good": {Id: "good", Include: []string{"a/"}},
+		"bad":  {Id: "bad", Quota: "gone"},
+	}}
+	restored, err := Restore(logger.NewMockClient(), store, subs.Restore)
+	if err != nil || restored != 1 {
+		t.Fatalf("Restore returned %d, %v", restored, err)
+	}
+	if subs.Subscription("good") == nil || subs.Subscription("bad") != nil {
+		t.Fatal("Wrong subscriptions restored")
+	}
+	if ok, writes := store.has("bad"); !ok || writes != 0 {
+		t.Fatal("Definition that could not be restored not kept")
+	}
+}
+
+func TestPersister(t *testing.T) {
+	var subs submgr.SubscriptionManager
+	if err := subs.Init(10, 10, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer subs.Close()
+	store := &memoryStore{defs: make(map[string]submgr.Definition)}
+	dut := NewPersister(logger.NewMockClient(), &subs, store, 100*time.Millisecond)
+	dut.Start()
+	stopped := false
+	defer func() {
+		if !stopped {
+			dut.Stop()
+		}
+	}()
+	waitFor := func(id string, want bool) int {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if ok, writes := store.has(id); ok == want {
+				return writes
+			}
+		}
+		t.Fatalf("Store still has %s %t", id, !want)
+		return 0
+	}
+
+	// A burst of changes is one write
+	subid, _ := subs.NewSubscription()
+	subinfo := subs.Subscription(subid)
+	_ = subs.Include(subinfo, "a")
+	_ = subs.Include(subinfo, "b")
+	_ = subs.SetOptions(subinfo, submgr.SubscriptionOptions{Format: submgr.FormatSimple})
+	if writes := waitFor(subid, true); writes != 1 {
+		t.Fatalf("%d writes for a burst of changes", writes)
+	}
+	if def := store.defs[subid]; len(def.Include) != 2 || def.Options.Format != submgr.FormatSimple {
+		t.Fatalf("Persisted %+v", def)
+	}
+
+	// Rotation moves it, deletion removes it
+	newid, _ := subs.RotateSubscription(subid)
+	waitFor(newid, true)
+	waitFor(subid, false)
+	subs.DeleteSubscription(newid)
+	waitFor(newid, false)
+
+	// Written once the store is back
+	store.lock.Lock()
+	store.fail = true
+	store.lock.Unlock()
+	subid, _ = subs.NewSubscription()
+	time.Sleep(300 * time.Millisecond)
+	store.lock.Lock()
+	store.fail = false
+	store.lock.Unlock()
+	dut.Stop()
+	stopped = true
+	if ok, _ := store.has(subid); !ok {
+		t.Fatal("Change not written on stop")
+	}
+	// Not written once stopped
+	_ = subs.Include(subs.Subscription(subid), "c")
+	time.Sleep(200 * time.Millisecond)
+	if def := store.defs[subid]; len(def.Include) != 0 {
+		t.Fatalf("Change written after stop: %+v", def)
+	}
+}
@@ -0,0 +1,164 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package persist
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"errors"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/gomodule/redigo/redis"
+)
+
+// How long to wait for Redis when connecting, reading and writing
+const redisTimeout = 10 * time.Second
+
+/*
+Struct RedisStore keeps subscription definitions in a Redis hash, e.g. in the EdgeX Redis
+instance, as JSON in a field per subscription ID. It is a SharedStore: when subscriptions
+were last listened to is kept in a second hash, with ":seen" added to the key.
+*/
+type RedisStore struct {
+	lc   logger.LoggingClient
+	key  string
+	pool *redis.Pool
+}
+
+// Factory function. Username and password are empty for a Redis without authentication.
+func NewRedisStore(lc logger.LoggingClient, config configuration.RedisConfig, username string, password string) *RedisStore {
+	s := &RedisStore{}
+	s.lc = lc
+	s.key = config.Key
+	address := net.JoinHostPort(config.Host, strconv.FormatUint(uint64(config.Port), 10))
+	s.pool = &redis.Pool{
+		MaxIdle:     2,
+		IdleTimeout: time.Minute,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", address,
+				redis.DialUsername(username),
+				redis.DialPassword(password),
+				redis.DialConnectTimeout(redisTimeout),
+				redis.DialReadTimeout(redisTimeout),
+				redis.DialWriteTimeout(redisTimeout))
+		},
+	}
+	return s
+}
+
+// Load returns the definitions in the hash, in ID order. Those that don't decode are logged and skipped.
+func (s *RedisStore) Load() ([]submgr.Definition, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	fields, err := redis.StringMap(conn.Do("HGETALL", s.key))
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]submgr.Definition, 0, len(fields))
+	for id, value := range fields {
+		var def submgr.Definition
+		if err := json.Unmarshal([]byte(value), &def); err != nil || def.Id != id {
+			s.lc.Warnf("Skipping persisted subscription %s, not a subscription definition", id)
+			continue
+		}
+		rv = append(rv, def)
+	}
+	slices.SortFunc(rv, func(a, b submgr.Definition) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+	return rv, nil
+}
+
+// Save sets the definition's field in the hash.
+func (s *RedisStore) Save(def submgr.Definition) error {
+	value, err := json.Marshal(def)
+	if err != nil {
+		return err
+	}
+	conn := s.pool.Get()
+	defer conn.Close()
+	_, err = conn.Do("HSET", s.key, def.Id, value)
+	return err
+}
+
+// Delete removes the ID's field from the hash, and when it was seen.
+func (s *RedisStore) Delete(id string) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("HDEL", s.key, id); err != nil {
+		return err
+	}
+	_, err := conn.Do("HDEL", s.seenKey(), id)
+	return err
+}
+
+// seenKey (an internal API) returns the hash of when subscriptions were last listened to.
+func (s *RedisStore) seenKey() string {
+	return s.key + ":seen"
+}
+
+// Get returns the definition with the ID, and true, or false if there is none.
+func (s *RedisStore) Get(id string) (submgr.Definition, bool, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	value, err := redis.Bytes(conn.Do("HGET", s.key, id))
+	if errors.Is(err, redis.ErrNil) {
+		return submgr.Definition{}, false, nil
+	}
+	if err != nil {
+		return submgr.Definition{}, false, err
+	}
+	var def submgr.Definition
+	if err := json.Unmarshal(value, &def); err != nil || def.Id != id {
+		return submgr.Definition{}, false, nil
+	}
+	return def, true, nil
+}
+
+// Touch records the subscriptions as listened to now.
+func (s *RedisStore) Touch(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	args := redis.Args{}.Add(s.seenKey())
+	for _, id := range ids {
+		args = args.Add(id, now)
+	}
+	conn := s.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("HSET", args...)
+	return err
+}
+
+// Seen returns when each subscription was last listened to, of those that have been.
+func (s *RedisStore) Seen() (map[string]time.Time, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	fields, err := redis.StringMap(conn.Do("HGETALL", s.seenKey()))
+	if err != nil {
+		return nil, err
+	}
+	rv := make(map[string]time.Time, len(fields))
+	for id, value := range fields {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			rv[id] = time.Unix(seconds, 0)
+		}
+	}
+	return rv, nil
+}
+
+// Close closes the connections to Redis.
+func (s *RedisStore) Close() error {
+	return s.pool.Close()
+}
@@ -0,0 +1,209 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package persist
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+/*
+fakeRedis (an internal API) is just enough of a Redis server, with hashes, for RedisStore:
+AUTH, HSET, HGET, HDEL and HGETALL.
+*/
+type fakeRedis struct {
+	listener net.Listener
+	password string
+	lock     sync.Mutex
+	hashes   map[string]map[string]string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	f := &fakeRedis{listener: listener, password: password, hashes: make(map[string]map[string]string)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return f
+}
+
+func (f *fakeRedis) config() configuration.RedisConfig {
+	address := f.listener.Addr().(*net.TCPAddr)
+	return configuration.RedisConfig{Host: address.IP.String(), Port: uint(address.Port), Key: "subs"}
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.lock.Lock()
+		reply := "-ERR unknown command\r\n"
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			authenticated = args[len(args)-1] == f.password
+			reply = "+OK\r\n"
+			if !authenticated {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required\r\n"
+		case cmd == "HSET" && len(args) >= 4 && len(args)%2 == 0:
+			for i := 2; i < len(args); i += 2 {
+				f.hash(args[1])[args[i]] = args[i+1]
+			}
+			reply = fmt.Sprintf(":%d\r\n", len(args)/2-1)
+		case cmd == "HGET" && len(args) == 3:
+			reply = "$-1\r\n"
+			if value, ok := f.hash(args[1])[args[2]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			}
+		case cmd == "HDEL" && len(args) == 3:
+			delete(f.hash(args[1]), args[2])
+			reply = ":1\r\n"
+		case cmd == "HGETALL" && len(args) == 2:
+			hash := f.hash(args[1])
+			reply = fmt.Sprintf("*%d\r\n", 2*len(hash))
+			for field, value := range hash {
+				reply += fmt.Sprintf("$%d\r\n%s\r\n$%d\r\n%s\r\n", len(field), field, len(value), value)
+			}
+		}
+		f.lock.Unlock()
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+// hash (an internal API) returns the hash with the key, created if there is none. Called with the lock held.
+func (f *fakeRedis) hash(key string) map[string]string {
+	if f.hashes[key] == nil {
+		f.hashes[key] = make(map[string]string)
+	}
+	return f.hashes[key]
+}
+
+// readCommand (an internal API) reads a command, an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("bad command %q", line)
+	}
+	args := make([]string, count)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, length+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:length])
+	}
+	return args, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	lc := logger.NewMockClient()
+	dut := NewRedisStore(lc, server.config(), "", "secret")
+	defer dut.Close()
+	a := submgr.Definition{Id: "a", Include: []string{"x/"}, Exclude: []string{}, Owner: "alice"}
+	b := submgr.Definition{Id: "b", Include: []string{"y/"}, Exclude: []string{"y/z/"}, Options: submgr.SubscriptionOptions{Format: submgr.FormatSimple}}
+	for _, def := range []submgr.Definition{b, a} {
+		if err := dut.Save(def); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	// Not a definition, skipped
+	server.lock.Lock()
+	server.hash("subs")["c"] = "{"
+	server.lock.Unlock()
+	defs, err := dut.Load()
+	if err != nil || !reflect.DeepEqual(defs, []submgr.Definition{a, b}) {
+		t.Fatalf("Load returned %+v, %v", defs, err)
+	}
+	if err := dut.Delete("a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if defs, _ := dut.Load(); len(defs) != 1 || defs[0].Id != "b" {
+		t.Fatalf("Load returned %+v after delete", defs)
+	}
+
+	// Shared between replicas
+	if def, ok, err := dut.Get("b"); err != nil || !ok || !reflect.DeepEqual(def, b) {
+		t.Fatalf("Get returned %+v, %t, %v", def, ok, err)
+	}
+	for _, id := range []string{"a", "c"} {
+		if def, ok, err := dut.Get(id); err != nil || ok {
+			t.Fatalf("Get returned %+v, %t, %v for %s", def, ok, err, id)
+		}
+	}
+	if seen, err := dut.Seen(); err != nil || len(seen) != 0 {
+		t.Fatalf("Seen returned %v, %v before any touch", seen, err)
+	}
+	before := time.Now().Add(-time.Second)
+	if err := dut.Touch([]string{"b", "x"}); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	seen, err := dut.Seen()
+	if err != nil || len(seen) != 2 || seen["b"].Before(before) || seen["x"].After(time.Now()) {
+		t.Fatalf("Seen returned %v, %v", seen, err)
+	}
+	if err := dut.Delete("x"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if seen, _ := dut.Seen(); len(seen) != 1 {
+		t.Fatalf("Seen returned %v after delete", seen)
+	}
+
+	wrong := NewRedisStore(lc, server.config(), "", "wrong")
+	defer wrong.Close()
+	if _, err := wrong.Load(); err == nil {
+		t.Fatal("Load succeeded with the wrong password")
+	}
+	server.listener.Close()
+	gone := NewRedisStore(lc, server.config(), "", "secret")
+	defer gone.Close()
+	if err := gone.Save(a); err == nil {
+		t.Fatal("Save succeeded without a server")
+	}
+}
@@ -0,0 +1,197 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package persist
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+/*
+Struct Replicator keeps the subscriptions of replicas of the service, behind a load
+balancer, the same through a SharedStore they all persist to, so any replica can serve any
+subscription's event stream. Every so often, it creates, changes and deletes here what was
+on other replicas, and records which subscriptions are listened to here. Subscriptions
+listened to on another replica aren't aged out here, where nobody listens to them.
+
+Changes made here are written to the store by a Persister, as without replicas.
+*/
+type Replicator struct {
+	lc       logger.LoggingClient
+	subs     *submgr.SubscriptionManager
+	store    SharedStore
+	restore  func(def submgr.Definition) error
+	replace  func(def submgr.Definition) error
+	pending  func(subid string) bool
+	interval time.Duration
+	maxIdle  time.Duration
+	// Guards known, the definitions in the store as of the last sync, and seen
+	lock  sync.Mutex
+	known map[string]submgr.Definition
+	seen  map[string]time.Time
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+/*
+Factory function. restore, e.g. web.RestoreSubscription, creates the subscriptions of other
+replicas here, and replace, e.g. web.ReplaceSubscription, changes them. pending, e.g. Persister.Pending, tells the subscriptions changed here whose
+change is not yet in the store, which are left as they are here until it is. The store is synced with every interval, and subscriptions not listened to
+on any replica for maxIdle may be aged out.
+*/
+func NewReplicator(lc logger.LoggingClient, subs *submgr.SubscriptionManager, store SharedStore, restore func(def submgr.Definition) error, replace func(def submgr.Definition) error, pending func(subid string) bool, interval time.Duration, maxIdle time.Duration) *Replicator {
+	r := &Replicator{}
+	r.lc = lc
+	r.subs = subs
+	r.store = store
+	r.restore = restore
+	r.replace = replace
+	r.pending = pending
+	r.interval = interval
+	r.maxIdle = maxIdle
+	r.known = make(map[string]submgr.Definition)
+	r.seen = make(map[string]time.Time)
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	return r
+}
+
+/*
+Start takes the subscriptions there are, e.g. restored from the store, as those the store
+had at the last sync, sets the subscription manager's expiry hook, and syncs with the store
+until Stop() is called.
+*/
+func (r *Replicator) Start() {
+	r.lock.Lock()
+	for _, def := range r.subs.Definitions() {
+		r.known[def.Id] = def
+	}
+	r.lock.Unlock()
+	r.subs.SetExpiryHook(r.mayExpire)
+	go r.run()
+}
+
+// Stop unsets the expiry hook and stops syncing.
+func (r *Replicator) Stop() {
+	r.subs.SetExpiryHook(nil)
+	close(r.stop)
+	<-r.done
+}
+
+// run (an internal API) is the goroutine syncing with the store.
+func (r *Replicator) run() {
+	defer close(r.done)
+	tick := time.NewTicker(r.interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-tick.C:
+			if err := r.sync(); err != nil {
+				r.lc.Warnf("Could not sync subscriptions with other replicas: %s", err.Error())
+			}
+		}
+	}
+}
+
+/*
+sync (an internal API) records the subscriptions listened to here, and applies the changes
+made in the store since the last sync. Definitions that are the same as then were not changed
+elsewhere, so changes made here and not yet persisted are kept. Neither are subscriptions
+with changes still being persisted changed, e.g. one created and deleted here before a sync
+recorded it is not created again from the store.
+*/
+func (r *Replicator) sync() error {
+	listened := make([]string, 0)
+	for _, sub := range r.subs.AllSubscriptions() {
+		if r.subs.IsActive(sub) {
+			listened = append(listened, r.subs.SubscriptionId(sub))
+		}
+	}
+	if err := r.store.Touch(listened); err != nil {
+		return err
+	}
+	seen, err := r.store.Seen()
+	if err != nil {
+		return err
+	}
+	defs, err := r.store.Load()
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.seen = seen
+	current := make(map[string]submgr.Definition, len(defs))
+	for _, def := range defs {
+		current[def.Id] = def
+		if known, ok := r.known[def.Id]; ok && reflect.DeepEqual(known, def) {
+			continue
+		}
+		if r.pending(def.Id) {
+			continue
+		}
+		if r.subs.Subscription(def.Id) == nil {
+			err = r.restore(def)
+		} else {
+			err = r.replace(def)
+		}
+		if err != nil {
+			r.lc.Warnf("Could not replicate subscription %s: %s", def.Id, err.Error())
+			continue
+		}
+		r.lc.Debugf("Replicated subscription %s", def.Id)
+	}
+	// Deleted, or given a new ID, on another replica
+	for id := range r.known {
+		if _, ok := current[id]; !ok && r.subs.Subscription(id) != nil && !r.pending(id) {
+			r.lc.Debugf("Subscription %s deleted on another replica", id)
+			r.subs.DeleteSubscription(id)
+		}
+	}
+	r.known = current
+	return nil
+}
+
+/*
+Fetch creates here the subscription with the ID from the store, if it is there, e.g. when
+its client connects here right after creating it on another replica. Returns true if it was.
+*/
+func (r *Replicator) Fetch(subid string) bool {
+	def, ok, err := r.store.Get(subid)
+	if err != nil {
+		r.lc.Warnf("Could not look up subscription %s from other replicas: %s", subid, err.Error())
+		return false
+	}
+	if !ok {
+		return false
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.subs.Subscription(subid) == nil {
+		if err := r.restore(def); err != nil {
+			r.lc.Warnf("Could not replicate subscription %s: %s", subid, err.Error())
+			return false
+		}
+	}
+	r.known[subid] = def
+	return true
+}
+
+// mayExpire (an internal API) is the expiry hook: true unless listened to within maxIdle on any replica.
+func (r *Replicator) mayExpire(subid string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	seen, ok := r.seen[subid]
+	return !ok || time.Since(seen) > r.maxIdle
+}
@@ -0,0 +1,141 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package persist
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+func TestReplicator(t *testing.T) {
+	store := &memoryStore{defs: make(map[string]submgr.Definition)}
+	lc := logger.NewMockClient()
+	// Two replicas, each persisting its changes at once
+	var subsA, subsB submgr.SubscriptionManager
+	persisters := make([]*Persister, 0, 2)
+	for _, subs := range []*submgr.SubscriptionManager{&subsA, &subsB} {
+		if err := subs.Init(10, 10, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		defer subs.Close()
+		persister := NewPersister(lc, subs, store, time.Millisecond)
+		persister.Start()
+		defer persister.Stop()
+		persisters = append(persisters, persister)
+	}
+	dutA := NewReplicator(lc, &subsA, store, subsA.Restore, subsA.Replace, persisters[0].Pending, time.Hour, 3*time.Second)
+	dutA.Start()
+	defer dutA.Stop()
+	dutB := NewReplicator(lc, &subsB, store, subsB.Restore, subsB.Replace, persisters[1].Pending, time.Hour, 3*time.Second)
+	dutB.Start()
+	defer dutB.Stop()
+	waitFor := func(id string) submgr.Definition {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if def, ok, _ := store.Get(id); ok {
+				return def
+			}
+		}
+		t.Fatalf("%s not persisted", id)
+		return submgr.Definition{}
+	}
+
+	// Created on A, fetched by B when its client connects there
+	subid, _ := subsA.NewSubscription()
+	_ = subsA.Include(subsA.Subscription(subid), "a")
+	waitFor(subid)
+	if dutB.Fetch("unknown") {
+		t.Fatal("Fetched a subscription not in the store")
+	}
+	if !dutB.Fetch(subid) || subsB.Subscription(subid) == nil {
+		t.Fatal("Subscription not fetched")
+	}
+
+	// Changed and created on A, synced to B
+	_ = subsA.Include(subsA.Subscription(subid), "b")
+	otherid, _ := subsA.NewSubscription()
+	want := waitFor(otherid)
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if def, _, _ := store.Get(subid); len(def.Include) == 2 {
+			break
+		}
+	}
+	if err := dutB.sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if def, ok := subsB.Definition(otherid); !ok || !reflect.DeepEqual(def, want) {
+		t.Fatalf("Created as %+v, %t", def, ok)
+	}
+	if def, _ := subsB.Definition(subid); len(def.Include) != 2 {
+		t.Fatalf("Changed to %+v", def)
+	}
+
+	// A change made on B and not yet persisted is kept
+	store.lock.Lock()
+	store.fail = true
+	store.lock.Unlock()
+	_ = subsB.SetOptions(subsB.Subscription(otherid), submgr.SubscriptionOptions{Format: submgr.FormatSimple})
+	if err := dutB.sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if options := subsB.Options(subsB.Subscription(otherid)); options.Format != submgr.FormatSimple {
+		t.Fatalf("Change made here lost: %+v", options)
+	}
+	store.lock.Lock()
+	store.fail = false
+	store.lock.Unlock()
+
+	// Created and deleted on B before a sync, and the delete not yet persisted: not created again
+	localid, _ := subsB.NewSubscription()
+	waitFor(localid)
+	store.lock.Lock()
+	store.fail = true
+	store.lock.Unlock()
+	subsB.DeleteSubscription(localid)
+	if err := dutB.sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if subsB.Subscription(localid) != nil {
+		t.Fatal("Subscription deleted here created again")
+	}
+	store.lock.Lock()
+	store.fail = false
+	store.lock.Unlock()
+
+	// Deleted on A, and on B at the next sync
+	subsA.DeleteSubscription(subid)
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if _, ok, _ := store.Get(subid); !ok {
+			break
+		}
+	}
+	if err := dutB.sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if subsB.Subscription(subid) != nil {
+		t.Fatal("Subscription deleted on another replica not deleted")
+	}
+
+	// Listened to on B, so not aged out on A
+	if !dutA.mayExpire(otherid) {
+		t.Fatal("Subscription never listened to may not expire")
+	}
+	subsB.SetActive(subsB.Subscription(otherid), true)
+	if err := dutB.sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if err := dutA.sync(); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if dutA.mayExpire(otherid) {
+		t.Fatal("Subscription listened to on another replica may expire")
+	}
+}
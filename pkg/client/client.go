@@ -0,0 +1,260 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+Package client is a Go client for the SSE application service, for other EdgeX services and
+tests: it manages subscriptions, and streams their events, reconnecting as EventSource would.
+Request and response bodies are those of package dtos, shared with the service.
+*/
+package client
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Default base paths of the service's endpoints, as in its SSE configuration
+const (
+	DefaultSubscriptionPath = "/api/v3/subscription"
+	DefaultEventsPath       = "/api/v3/events"
+)
+
+// Struct Error is a response from the service other than success.
+type Error struct {
+	StatusCode int
+	// The message in the response, or its status text if it has none
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("edgex-sse returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Options holds a subscription's delivery options, see the service's API documentation.
+type Options = dtos.SubscriptionOptions
+
+// DeviceSelector picks the devices whose EdgeX events a subscription receives, for Options.Devices.
+type DeviceSelector = dtos.DeviceSelector
+
+// Rounding rounds the numeric readings of the EdgeX events a subscription receives, for Options.Rounding.
+type Rounding = dtos.Rounding
+
+/*
+Subscription is what a subscription gets: topic prefixes to include and exclude, and options.
+Nil Options leave the options as they are when updating, and at their defaults otherwise.
+*/
+type Subscription = dtos.SubscriptionRequest
+
+// Struct Client talks to one SSE application service.
+type Client struct {
+	// Base URL of the service's API port, e.g. "http://localhost:59747"
+	APIURL string
+	// Base URL of its events port, e.g. "http://localhost:59748"
+	EventsURL string
+	// Base paths of the endpoints, if the service doesn't use the defaults
+	SubscriptionPath string
+	EventsPath       string
+	// Sent as a bearer token with every request, "" for none
+	Token string
+	// For all requests. Event streams never end on their own, so it should have no Timeout.
+	HTTPClient *http.Client
+}
+
+// New returns a client of the service at those base URLs, with the default paths.
+func New(apiURL string, eventsURL string) *Client {
+	return &Client{
+		APIURL:           strings.TrimSuffix(apiURL, "/"),
+		EventsURL:        strings.TrimSuffix(eventsURL, "/"),
+		SubscriptionPath: DefaultSubscriptionPath,
+		EventsPath:       DefaultEventsPath,
+		HTTPClient:       http.DefaultClient,
+	}
+}
+
+// subscriptionURL (an internal API) returns the URL of a subscription, or of the collection for "".
+func (c *Client) subscriptionURL(id string) string {
+	if id == "" {
+		return c.APIURL + c.SubscriptionPath
+	}
+	return c.APIURL + c.SubscriptionPath + "/id/" + url.PathEscape(id)
+}
+
+/*
+do (an internal API) sends a request with a JSON body, if body isn't nil, and decodes the
+JSON response into result, if it isn't nil. Responses other than 2xx are returned as *Error.
+*/
+func (c *Client) do(ctx context.Context, method string, url string, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(req)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return responseError(resp.StatusCode, data)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+// authorize (an internal API) adds the token to a request.
+func (c *Client) authorize(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+}
+
+// responseError (an internal API) makes an *Error of a response, with the message of an EdgeX BaseResponse if it is one.
+func responseError(statusCode int, body []byte) error {
+	var base struct {
+		Message string `json:"message"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &base) == nil && base.Message != "" {
+		message = base.Message
+	}
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	return &Error{StatusCode: statusCode, Message: message}
+}
+
+// IsNotFound returns whether err says the subscription does not exist (any more).
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+/*
+CreateSubscription creates a subscription with those lists and options, and returns its ID.
+If they can't all be set, the subscription is deleted again.
+*/
+func (c *Client) CreateSubscription(ctx context.Context, sub Subscription) (string, error) {
+	var created dtos.SubscriptionIdResponse
+	if err := c.do(ctx, http.MethodPost, c.subscriptionURL(""), nil, &created); err != nil {
+		return "", err
+	}
+	if created.SubscriptionId == "" {
+		return "", errors.New("edgex-sse returned no subscription ID")
+	}
+	if len(sub.Include) == 0 && len(sub.Exclude) == 0 && sub.Options == nil {
+		return created.SubscriptionId, nil
+	}
+	if err := c.UpdateSubscription(ctx, created.SubscriptionId, sub); err != nil {
+		_ = c.DeleteSubscription(context.WithoutCancel(ctx), created.SubscriptionId)
+		return "", err
+	}
+	return created.SubscriptionId, nil
+}
+
+/*
+CreateServiceSubscription creates a subscription to the Events of a device service's
+devices, as core-metadata has them, including those it adds later, and returns its ID and
+the service's devices now.
+*/
+func (c *Client) CreateServiceSubscription(ctx context.Context, service string) (string, []string, error) {
+	var created dtos.ServiceSubscriptionResponse
+	if err := c.do(ctx, http.MethodPost, c.subscriptionURL("")+"/service/"+url.PathEscape(service), nil, &created); err != nil {
+		return "", nil, err
+	}
+	if created.SubscriptionId == "" {
+		return "", nil, errors.New("edgex-sse returned no subscription ID")
+	}
+	return created.SubscriptionId, created.Devices, nil
+}
+
+// GetSubscription returns a subscription's lists and options.
+func (c *Client) GetSubscription(ctx context.Context, id string) (Subscription, error) {
+	var response dtos.SubscriptionResponse
+	if err := c.do(ctx, http.MethodGet, c.subscriptionURL(id), nil, &response); err != nil {
+		return Subscription{}, err
+	}
+	return Subscription{Include: response.Include, Exclude: response.Exclude, Options: &response.Options}, nil
+}
+
+/*
+UpdateSubscription adds to a subscription's lists: including a prefix that is excluded takes
+it off the exclude list, and the other way around, unless sub is Strict, which has that
+refused instead. Options, if given, replace the old ones.
+*/
+func (c *Client) UpdateSubscription(ctx context.Context, id string, sub Subscription) error {
+	return c.do(ctx, http.MethodPatch, c.subscriptionURL(id), sub, nil)
+}
+
+// ReplaceSubscription replaces a subscription's lists and options.
+func (c *Client) ReplaceSubscription(ctx context.Context, id string, sub Subscription) error {
+	return c.do(ctx, http.MethodPut, c.subscriptionURL(id), sub, nil)
+}
+
+/*
+Acknowledge acknowledges the events of a subscription with the acknowledge option up to and
+including the one with that ID, so they aren't sent again when the stream reconnects. With
+replication, the ID starts with the replica's, up to a ".", before the sequence number.
+*/
+func (c *Client) Acknowledge(ctx context.Context, id string, eventID string) error {
+	if dot := strings.LastIndexByte(eventID, '.'); dot >= 0 {
+		eventID = eventID[dot+1:]
+	}
+	seq, err := strconv.ParseUint(eventID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("event ID %q is not a sequence number", eventID)
+	}
+	return c.do(ctx, http.MethodPost, c.subscriptionURL(id)+"/ack", dtos.AcknowledgeRequest{Seq: seq}, nil)
+}
+
+// Stats returns what happened to a subscription's events since it was created, or its stats were reset.
+func (c *Client) Stats(ctx context.Context, id string) (dtos.SubscriptionStatsResponse, error) {
+	var response dtos.SubscriptionStatsResponse
+	err := c.do(ctx, http.MethodGet, c.subscriptionURL(id)+"/stats", nil, &response)
+	return response, err
+}
+
+// ResetStats starts a subscription's stats counting from zero again.
+func (c *Client) ResetStats(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, c.subscriptionURL(id)+"/stats", nil, nil)
+}
+
+// Count returns how many subscriptions the caller may have, and how many it has.
+func (c *Client) Count(ctx context.Context) (dtos.SubscriptionCountResponse, error) {
+	var response dtos.SubscriptionCountResponse
+	err := c.do(ctx, http.MethodGet, c.subscriptionURL("")+"/count", nil, &response)
+	return response, err
+}
+
+// DeleteSubscription deletes a subscription, ending its event streams.
+func (c *Client) DeleteSubscription(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, c.subscriptionURL(id), nil, nil)
+}
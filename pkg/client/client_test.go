@@ -0,0 +1,229 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSubscriptions(t *testing.T) {
+	var patched Subscription
+	deleted := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/subscription", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Wrong Authorization %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"apiVersion":"v3","statusCode":201,"subscriptionId":"sub/1"}`)
+	})
+	mux.HandleFunc("POST /api/v3/subscription/service/{service}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("service") != "device-modbus" {
+			t.Errorf("Wrong service %q", r.PathValue("service"))
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"apiVersion":"v3","statusCode":201,"subscriptionId":"sub/2","service":"device-modbus","devices":["dev1","dev2"]}`)
+	})
+	mux.HandleFunc("PATCH /api/v3/subscription/id/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "sub/1" {
+			t.Errorf("Wrong subscription ID %q", r.PathValue("id"))
+		}
+		patched = Subscription{}
+		json.NewDecoder(r.Body).Decode(&patched)
+		if len(patched.Include) > 0 && patched.Include[0] == "forbidden" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"apiVersion":"v3","statusCode":403,"message":"Topic forbidden not allowed"}`)
+		}
+	})
+	mux.HandleFunc("GET /api/v3/subscription/id/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"apiVersion":"v3","statusCode":200,"include":["a/"],"exclude":[],"options":{"passThrough":false,"format":"simple"}}`)
+	})
+	mux.HandleFunc("PUT /api/v3/subscription/id/{id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("DELETE /api/v3/subscription/id/{id}", func(w http.ResponseWriter, r *http.Request) {
+		deleted = true
+	})
+	var acked []uint64
+	mux.HandleFunc("POST /api/v3/subscription/id/{id}/ack", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Seq uint64 `json:"seq"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		acked = append(acked, request.Seq)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	dut := New(server.URL+"/", server.URL)
+	dut.Token = "secret"
+	ctx := context.Background()
+
+	sub := Subscription{Include: []string{"a"}, Exclude: []string{"a/b"}, Options: &Options{Format: "simple"}}
+	id, err := dut.CreateSubscription(ctx, sub)
+	if err != nil || id != "sub/1" {
+		t.Fatalf("CreateSubscription returned %q, %v", id, err)
+	}
+	if !reflect.DeepEqual(patched, sub) {
+		t.Fatalf("Wrong update %+v", patched)
+	}
+	serviceID, devices, err := dut.CreateServiceSubscription(ctx, "device-modbus")
+	if err != nil || serviceID != "sub/2" || !reflect.DeepEqual(devices, []string{"dev1", "dev2"}) {
+		t.Fatalf("CreateServiceSubscription returned %q, %v, %v", serviceID, devices, err)
+	}
+	got, err := dut.GetSubscription(ctx, id)
+	if err != nil || !reflect.DeepEqual(got.Include, []string{"a/"}) || got.Options == nil || got.Options.Format != "simple" {
+		t.Fatalf("GetSubscription returned %+v, %v", got, err)
+	}
+
+	// A subscription that can't be set up is deleted
+	_, err = dut.CreateSubscription(ctx, Subscription{Include: []string{"forbidden"}})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || apiErr.Message != "Topic forbidden not allowed" {
+		t.Fatalf("CreateSubscription returned %v", err)
+	}
+	if !deleted {
+		t.Fatal("Subscription not deleted after a failed update")
+	}
+	if err := dut.DeleteSubscription(ctx, "other"); err != nil {
+		t.Fatalf("DeleteSubscription failed: %v", err)
+	}
+	err = dut.ReplaceSubscription(ctx, id, sub)
+	if !IsNotFound(err) || err.(*Error).Message != "Subscription not found" {
+		t.Fatalf("ReplaceSubscription returned %v", err)
+	}
+	// Event IDs with and without a replica's ID
+	for _, eventID := range []string{"7", "replica-a.8"} {
+		if err := dut.Acknowledge(ctx, id, eventID); err != nil {
+			t.Fatalf("Acknowledge(%s) failed: %v", eventID, err)
+		}
+	}
+	if !reflect.DeepEqual(acked, []uint64{7, 8}) || dut.Acknowledge(ctx, id, "replica-a.") == nil {
+		t.Fatalf("Wrong acknowledgements %v", acked)
+	}
+}
+
+func TestStream(t *testing.T) {
+	connections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connections++
+		if r.URL.Path != "/api/v3/events/sub1" || r.URL.Query().Get("token") != "tok" {
+			t.Errorf("Wrong request %s", r.URL)
+		}
+		switch connections {
+		case 1:
+			fmt.Fprint(w, "retry: 10\n\n: heartbeat\n\nid: 1\nevent: simple\n: correlation-id c0ffee\ndata: {\"a\":\ndata: 1}\n\n")
+		case 2:
+			if r.Header.Get("Last-Event-ID") != "1" {
+				t.Errorf("Wrong Last-Event-ID %q", r.Header.Get("Last-Event-ID"))
+			}
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 3:
+			fmt.Fprint(w, "id: 2\r\ndata: x\r\n\r\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	dut := New(server.URL, server.URL)
+
+	var events []Event
+	var errs []error
+	for event, err := range dut.Stream(context.Background(), "sub1", StreamOptions{StreamToken: "tok", ReconnectDelay: time.Hour}) {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			events = append(events, event)
+		}
+	}
+	expected := []Event{{ID: "1", Type: "simple", Data: "{\"a\":\n1}", CorrelationID: "c0ffee"}, {ID: "2", Data: "x"}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Wrong events %+v", events)
+	}
+	var value map[string]int
+	if err := events[0].Decode(&value); err != nil || value["a"] != 1 {
+		t.Fatalf("Decode returned %v, %v", value, err)
+	}
+	// Dropped, too many connections, dropped, gone
+	if len(errs) != 4 || !errors.Is(errs[3], ErrSubscriptionGone) {
+		t.Fatalf("Wrong errors %v", errs)
+	}
+}
+
+func TestStreamChunks(t *testing.T) {
+	connections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connections++
+		switch connections {
+		case 1:
+			fmt.Fprint(w, "event: chunk\n: correlation-id c0ffee\ndata: {\"part\":0,\"eventType\":\"edgex\",\"data\":\"{\\\"a\\\":\"}\n\n")
+			fmt.Fprint(w, ": heartbeat\n\n")
+			fmt.Fprint(w, "id: 7\nevent: chunk\ndata: {\"part\":1,\"last\":true,\"eventType\":\"edgex\",\"data\":\"1}\"}\n\n")
+			// The first part of the next is lost
+			fmt.Fprint(w, "event: chunk\ndata: {\"part\":1,\"last\":true,\"data\":\"x\"}\n\n")
+		default:
+			if r.Header.Get("Last-Event-ID") != "7" {
+				t.Errorf("Wrong Last-Event-ID %q", r.Header.Get("Last-Event-ID"))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	dut := New(server.URL, server.URL)
+
+	var events []Event
+	var errs []error
+	for event, err := range dut.Stream(context.Background(), "sub1", StreamOptions{ReconnectDelay: time.Millisecond}) {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			events = append(events, event)
+		}
+	}
+	expected := []Event{{ID: "7", Type: "edgex", Data: "{\"a\":1}", CorrelationID: "c0ffee"}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Wrong events %+v", events)
+	}
+	if len(errs) != 2 || errors.Is(errs[0], ErrSubscriptionGone) || !errors.Is(errs[1], ErrSubscriptionGone) {
+		t.Fatalf("Wrong errors %v", errs)
+	}
+}
+
+func TestStreamHeartbeatTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ": heartbeat\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	dut := New(server.URL, server.URL)
+	start := time.Now()
+	for _, err := range dut.Stream(context.Background(), "sub1", StreamOptions{HeartbeatTimeout: 50 * time.Millisecond}) {
+		if !errors.Is(err, ErrHeartbeatTimeout) {
+			t.Fatalf("Stream returned %v", err)
+		}
+		break
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("Heartbeat timeout took too long")
+	}
+
+	// Ends when the context does
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	for _, err := range dut.Stream(ctx, "sub1", StreamOptions{}) {
+		t.Fatalf("Stream returned %v", err)
+	}
+}
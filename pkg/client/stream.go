@@ -0,0 +1,266 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package client
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// How long Stream() waits before reconnecting, unless StreamOptions or the service say otherwise
+const DefaultReconnectDelay = 3 * time.Second
+
+// Errors yielded by Stream()
+var (
+	// The subscription was deleted, or never existed. The stream ends.
+	ErrSubscriptionGone = errors.New("subscription gone")
+	// Nothing, not even a heartbeat, came for StreamOptions.HeartbeatTimeout. The stream reconnects.
+	ErrHeartbeatTimeout = errors.New("no heartbeat from event stream")
+)
+
+/*
+Type of the event that comes first when Stream() reconnects, and events after the last one
+received are lost, e.g. as the service restarted: resynchronize whatever the events keep up to date.
+*/
+const ResetEvent = "reset"
+
+/*
+Type of the heartbeat event of services with HeartbeatEvents set, its data a dtos.PingEvent:
+how many events wait for the stream, and were dropped, and the service's time.
+*/
+const PingEvent = "ping"
+
+/*
+Type of the event sent when the stream's buffer in the service fills past its BackpressureMark,
+its data a dtos.BackpressureEvent: the events aren't read fast enough, and will be dropped
+once the buffer is full, e.g. render less, or subscribe to fewer.
+*/
+const BackpressureEvent = "backpressure"
+
+/*
+Type of the events a payload longer than the subscription's ChunkSize option comes in.
+Stream() joins them back together, and yields the whole event, so they aren't seen.
+*/
+const ChunkEvent = "chunk"
+
+// Type of the events binary readings come in with the Media option, their data a dtos.MediaEvent
+const MediaEvent = "media"
+
+// Struct Event is one event from a subscription's event stream.
+type Event struct {
+	// The event's number, with Replay configured in the service, for resuming after it
+	ID string
+	// The event type, e.g. "edgex", "simple", "system", ResetEvent, PingEvent, BackpressureEvent or MediaEvent, "" for generic events
+	Type string
+	// The payload, usually JSON
+	Data string
+	// EdgeX correlation ID of the message bus message the event came from, if known
+	CorrelationID string
+}
+
+// Decode un-marshals the event's JSON payload into v.
+func (e Event) Decode(v any) error {
+	return json.Unmarshal([]byte(e.Data), v)
+}
+
+// Struct StreamOptions says how Stream() connects, and reconnects.
+type StreamOptions struct {
+	// Resume after the event with this ID, as if reconnecting
+	LastEventID string
+	// Stream token, for services with RequireStreamToken set
+	StreamToken string
+	// How long to wait before reconnecting, DefaultReconnectDelay if zero. A retry field
+	// from the service overrides it, like in EventSource.
+	ReconnectDelay time.Duration
+	// Reconnect when nothing comes for this long, e.g. twice the service's HeartbeatInterval.
+	// Zero waits forever.
+	HeartbeatTimeout time.Duration
+}
+
+// Struct streamState is what Stream() keeps from one connection to the next.
+type streamState struct {
+	lastEventID string
+	delay       time.Duration
+	// Wait before the next connection asked for by the service (Retry-After), instead of delay
+	retryAfter time.Duration
+}
+
+// Error returned by stream() when the consumer stopped iterating
+var errStopped = errors.New("stopped")
+
+/*
+Stream returns an iterator over the events of a subscription. When the stream drops, the
+error is yielded and Stream reconnects, sending the ID of the last event received so events
+the service still keeps are replayed, or a ResetEvent comes if some are lost. It ends when ctx is done, the loop over it breaks,
+the subscription is gone (ErrSubscriptionGone is yielded), or the service refuses the
+stream (its *Error is yielded) for anything but too many requests or connections.
+*/
+func (c *Client) Stream(ctx context.Context, id string, opts StreamOptions) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		state := streamState{lastEventID: opts.LastEventID, delay: opts.ReconnectDelay}
+		if state.delay <= 0 {
+			state.delay = DefaultReconnectDelay
+		}
+		for {
+			err := c.stream(ctx, id, opts, &state, yield)
+			if err == errStopped || ctx.Err() != nil {
+				return
+			}
+			var apiErr *Error
+			if errors.Is(err, ErrSubscriptionGone) || (errors.As(err, &apiErr) && apiErr.StatusCode != http.StatusTooManyRequests) {
+				yield(Event{}, err)
+				return
+			}
+			if !yield(Event{}, err) {
+				return
+			}
+			wait := state.delay
+			if state.retryAfter > 0 {
+				wait = state.retryAfter
+				state.retryAfter = 0
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+	}
+}
+
+// stream (an internal API) makes one connection of Stream(), and returns why it ended.
+func (c *Client) stream(ctx context.Context, id string, opts StreamOptions, state *streamState, yield func(Event, error) bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	streamURL := c.EventsURL + c.EventsPath + "/" + url.PathEscape(id)
+	if opts.StreamToken != "" {
+		streamURL += "?token=" + url.QueryEscape(opts.StreamToken)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if state.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", state.lastEventID)
+	}
+	c.authorize(req)
+
+	// The watchdog cancels the request when nothing comes in time
+	var timedOut atomic.Bool
+	alive := func() {}
+	if opts.HeartbeatTimeout > 0 {
+		watchdog := time.AfterFunc(opts.HeartbeatTimeout, func() {
+			timedOut.Store(true)
+			cancel()
+		})
+		defer watchdog.Stop()
+		alive = func() { watchdog.Reset(opts.HeartbeatTimeout) }
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if timedOut.Load() {
+			return ErrHeartbeatTimeout
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return ErrSubscriptionGone
+	default:
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			state.retryAfter = time.Duration(seconds) * time.Second
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return responseError(resp.StatusCode, body)
+	}
+
+	var event Event
+	var data []string
+	// The parts of a chunked event so far, and the correlation ID the first came with
+	var parts []string
+	var partsCorrelationID string
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if timedOut.Load() {
+				return ErrHeartbeatTimeout
+			}
+			if err == io.EOF {
+				return fmt.Errorf("event stream ended: %w", io.ErrUnexpectedEOF)
+			}
+			return err
+		}
+		alive()
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line == "" {
+			// A blank line ends an event, those without data are not dispatched
+			if data != nil {
+				event.Data = strings.Join(data, "\n")
+				if event.Type == ChunkEvent {
+					var chunk dtos.ChunkEvent
+					if err := event.Decode(&chunk); err != nil || chunk.Part != len(parts) {
+						// Reconnecting resumes before the event, the ID is only on its last part
+						return errors.New("chunk of an event malformed or out of order")
+					}
+					if chunk.Part == 0 {
+						partsCorrelationID = event.CorrelationID
+					}
+					parts = append(parts, chunk.Data)
+					if !chunk.Last {
+						event = Event{}
+						data = nil
+						continue
+					}
+					event = Event{ID: event.ID, Type: chunk.EventType, Data: strings.Join(parts, ""), CorrelationID: partsCorrelationID}
+					parts = nil
+				}
+				if !yield(event, nil) {
+					return errStopped
+				}
+			}
+			event = Event{}
+			data = nil
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			// A comment, only the correlation ID ones mean something
+			if correlationID, ok := strings.CutPrefix(value, "correlation-id "); ok {
+				event.CorrelationID = correlationID
+			}
+		case "event":
+			event.Type = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			event.ID = value
+			state.lastEventID = value
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				state.delay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
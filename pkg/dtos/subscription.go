@@ -0,0 +1,476 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+Package dtos holds the request and response bodies of the SSE application service's
+subscription API, for the service itself, its Go client, and other consumers. Like the
+EdgeX ones, they embed the common BaseRequest or BaseResponse.
+*/
+package dtos
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/extension"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/filter"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+)
+
+// Values for SubscriptionOptions.Format
+const (
+	// EdgeX Events are delivered as they are (also when Format is empty)
+	FormatEdgex = "edgex"
+	// EdgeX Events are flattened into a list of readings
+	FormatSimple = "simple"
+	// Numeric readings of EdgeX Events are delivered as SenML (RFC 8428) records
+	FormatSenML = "senml"
+)
+
+// Values for SubscriptionOptions.Envelope
+const (
+	// Payloads are delivered as they are (also when Envelope is empty)
+	EnvelopeNone = "none"
+	// Payloads are wrapped in CloudEvents 1.0 structured JSON
+	EnvelopeCloudEvents = "cloudevents"
+	// Payloads are wrapped in a DeliveryEnvelope
+	EnvelopeDelivery = "delivery"
+)
+
+// Smallest SubscriptionOptions.ChunkSize, so payloads aren't sent in absurdly many chunks
+const MinChunkSize = 1024
+
+// Value of SubscriptionOptions.ExpiryNotify for notices through EdgeX support-notifications
+const ExpiryNotifySupport = "support-notifications"
+
+// Values of ExpiryNotification.Event
+const (
+	// The subscription is about to be aged out, as nobody listens to it
+	ExpiryEventExpiring = "expiring"
+	// The subscription has been aged out
+	ExpiryEventExpired = "expired"
+	// The subscription would have been aged out, and is kept until expiresAt in case its stream reconnects
+	ExpiryEventGrace = "grace"
+	// The subscription's stream reconnected in its grace window, so it isn't aged out
+	ExpiryEventResumed = "resumed"
+)
+
+/*
+Struct DeviceSelector picks the devices whose Events a subscription with the Devices option
+receives, by their metadata in core-metadata. A device must match everything given.
+*/
+type DeviceSelector struct {
+	// Labels the device must all have, e.g. ["line-3"]
+	Labels []string `json:"labels,omitempty"`
+	// Device profile the device must have
+	Profile string `json:"profile,omitempty"`
+	// Device service the device must belong to
+	Service string `json:"service,omitempty"`
+}
+
+/*
+Struct Rounding rounds the numeric readings of Events for a subscription with the Rounding
+option, so high-rate streams compress better.
+*/
+type Rounding struct {
+	// Decimal places to round to, e.g. 2 for 21.46, or with Significant, significant digits
+	Digits int `json:"digits"`
+	// Digits are significant digits, e.g. 3 for 21.5 and 12300
+	Significant bool `json:"significant,omitempty"`
+}
+
+// Struct SubscriptionOptions holds the per-subscription delivery options set by clients.
+type SubscriptionOptions struct {
+	// Deliver message payloads as received on the bus, without classifying them
+	PassThrough bool `json:"passThrough"`
+	// How to deliver EdgeX Events, FormatEdgex, FormatSimple or FormatSenML
+	Format string `json:"format,omitempty"`
+	// What to wrap payloads in, EnvelopeNone, EnvelopeCloudEvents or EnvelopeDelivery
+	Envelope string `json:"envelope,omitempty"`
+	// Report why each topic was or wasn't matched, see the service's MatchDebugSamples
+	Debug bool `json:"debug,omitempty"`
+	// Where to send an ExpiryNotification when the subscription is about to be aged out, and
+	// when it has been: an http or https URL to POST it to, or ExpiryNotifySupport. Needs the
+	// service's ExpiryNotifications.
+	ExpiryNotify string `json:"expiryNotify,omitempty"`
+	// Only deliver Events from the devices this picks, as they are added, changed and
+	// removed in core-metadata. Other messages are delivered as without it.
+	Devices *DeviceSelector `json:"devices,omitempty"`
+	// Only deliver Events that pass this SQL-like filter, e.g. "SELECT * WHERE temperature > 30",
+	// see package filter. Other messages are delivered as without it.
+	Filter string `json:"filter,omitempty"`
+	// Units to convert numeric readings of EdgeX Events to, e.g. ["degC", "kPa"], by the
+	// service's UnitConversions. Readings in other units are delivered as they are.
+	Units []string `json:"units,omitempty"`
+	// Round numeric readings of EdgeX Events, after converting their units
+	Rounding *Rounding `json:"rounding,omitempty"`
+	// Discard messages that waited longer than this to be sent, e.g. "5m", rather than send
+	// them late, when the client reconnects or falls behind
+	MaxAge string `json:"maxAge,omitempty"`
+	// Keep messages for replay until the client acknowledges them, and send those it hasn't
+	// again whenever it reconnects: at-least-once delivery. Needs the service's Replay.
+	Acknowledge bool `json:"acknowledge,omitempty"`
+	// Send payloads longer than this many bytes as a series of chunk events, each with up
+	// to this much of it, so one large event, e.g. with a binary reading, doesn't hold up
+	// the stream in one giant write. 0 to send them whole.
+	ChunkSize int `json:"chunkSize,omitempty"`
+	// Deliver the binary readings of EdgeX Events, e.g. camera frames, in a MediaEvent each,
+	// after the Event, which has their size instead of their value
+	Media bool `json:"media,omitempty"`
+	// Add the labels their device profiles' value mappings give the values of readings of
+	// EdgeX Events, e.g. "Running" for 1, as member "label" of those readings, so UIs don't
+	// need enumeration tables of their own. Other readings are delivered as they are.
+	EnumLabels bool `json:"enumLabels,omitempty"`
+	// Filters and transforms of EdgeX Events the service was built with, by name, see
+	// package extension. Other messages are delivered as without them.
+	Extensions []extension.Ref `json:"extensions,omitempty"`
+	// How long to keep the subscription with nobody listening, e.g. "2h", rather than the
+	// service's SubscriptionIdleExpiration, within the service's limits
+	IdleExpiration string `json:"idleExpiration,omitempty"`
+}
+
+/*
+Clone returns a copy of the options that shares nothing with them, so a copy kept by the
+subscription manager can't be changed through, or race with, the caller's.
+*/
+func (o SubscriptionOptions) Clone() SubscriptionOptions {
+	if o.Devices != nil {
+		devices := *o.Devices
+		devices.Labels = slices.Clone(devices.Labels)
+		o.Devices = &devices
+	}
+	o.Units = slices.Clone(o.Units)
+	if o.Rounding != nil {
+		rounding := *o.Rounding
+		o.Rounding = &rounding
+	}
+	if o.Extensions != nil {
+		extensions := make([]extension.Ref, len(o.Extensions))
+		for i, ref := range o.Extensions {
+			extensions[i] = extension.Ref{Name: ref.Name, Args: maps.Clone(ref.Args)}
+		}
+		o.Extensions = extensions
+	}
+	return o
+}
+
+// Validate returns an error if the options have values we don't know.
+func (o SubscriptionOptions) Validate() error {
+	switch o.Format {
+	case "", FormatEdgex, FormatSimple, FormatSenML:
+	default:
+		return errors.New("format must be 'edgex', 'simple' or 'senml'")
+	}
+	switch o.Envelope {
+	case "", EnvelopeNone, EnvelopeCloudEvents, EnvelopeDelivery:
+	default:
+		return errors.New("envelope must be 'none', 'cloudevents' or 'delivery'")
+	}
+	if o.ExpiryNotify != "" && o.ExpiryNotify != ExpiryNotifySupport {
+		target, err := url.Parse(o.ExpiryNotify)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return errors.New("expiryNotify must be an http or https URL, or 'support-notifications'")
+		}
+	}
+	if o.Devices != nil {
+		if len(o.Devices.Labels) == 0 && o.Devices.Profile == "" && o.Devices.Service == "" {
+			return errors.New("devices must have labels, a profile or a service")
+		}
+		for _, label := range o.Devices.Labels {
+			if label == "" {
+				return errors.New("devices labels must not be empty")
+			}
+		}
+	}
+	for _, units := range o.Units {
+		if units == "" {
+			return errors.New("units must not be empty")
+		}
+	}
+	if o.Rounding != nil {
+		if o.Rounding.Significant && (o.Rounding.Digits < 1 || o.Rounding.Digits > 17) {
+			return errors.New("rounding digits must be 1 to 17 significant digits")
+		}
+		if !o.Rounding.Significant && (o.Rounding.Digits < 0 || o.Rounding.Digits > 15) {
+			return errors.New("rounding digits must be 0 to 15 decimal places")
+		}
+	}
+	if o.MaxAge != "" {
+		if maxAge, err := time.ParseDuration(o.MaxAge); err != nil || maxAge <= 0 {
+			return errors.New("maxAge must be a duration longer than zero, e.g. '5m'")
+		}
+	}
+	if o.IdleExpiration != "" {
+		if idle, err := time.ParseDuration(o.IdleExpiration); err != nil || idle <= 0 {
+			return errors.New("idleExpiration must be a duration longer than zero, e.g. '2h'")
+		}
+	}
+	if o.ChunkSize != 0 && o.ChunkSize < MinChunkSize {
+		return errors.New("chunkSize must be 0 or at least 1024 bytes")
+	}
+	if o.Filter != "" {
+		if _, err := filter.Compile(o.Filter); err != nil {
+			return errors.New("filter: " + err.Error())
+		}
+	}
+	if _, err := extension.Build(o.Extensions); err != nil {
+		return errors.New("extensions: " + err.Error())
+	}
+	return nil
+}
+
+/*
+Struct SubscriptionRequest is the body of PUT and PATCH of a subscription: topic prefixes to
+include and exclude, and options. PATCH adds to the lists, and leaves the options as they
+are if there are none; PUT replaces the lists, and the options with their defaults if none.
+*/
+type SubscriptionRequest struct {
+	commonDTO.BaseRequest `json:",inline"`
+	Include               []string             `json:"include"`
+	Exclude               []string             `json:"exclude"`
+	Options               *SubscriptionOptions `json:"options,omitempty"`
+	// Refuse a prefix that is in the other list, or in both of the request's, instead of
+	// taking it off the other list
+	Strict bool `json:"strict,omitempty"`
+}
+
+// Validate returns an error if the request's options are not valid.
+func (r SubscriptionRequest) Validate() error {
+	if r.Options != nil {
+		return r.Options.Validate()
+	}
+	return nil
+}
+
+// Struct SubscriptionResponse is the response to GET of a subscription.
+type SubscriptionResponse struct {
+	commonDTO.BaseResponse `json:",inline"`
+	Include                []string            `json:"include"`
+	Exclude                []string            `json:"exclude"`
+	Options                SubscriptionOptions `json:"options"`
+	// Number of entries allowed in each of the lists
+	PrefixLimit uint `json:"prefixLimit,omitempty"`
+	// How long until the subscription is aged out unless someone listens to it, e.g. "59s",
+	// absent if someone does, or it is from the service's configuration
+	ExpiresIn string `json:"expiresIn,omitempty"`
+}
+
+/*
+NewSubscriptionResponse returns a successful SubscriptionResponse to the request with
+requestId. Its lists are copies of include and exclude in lexicographic order, whatever
+order they are kept in, so responses for the same lists are the same, and can be compared.
+*/
+func NewSubscriptionResponse(requestId string, include []string, exclude []string, options SubscriptionOptions) SubscriptionResponse {
+	include = slices.Clone(include)
+	slices.Sort(include)
+	exclude = slices.Clone(exclude)
+	slices.Sort(exclude)
+	return SubscriptionResponse{
+		BaseResponse: commonDTO.NewBaseResponse(requestId, "", http.StatusOK),
+		Include:      include,
+		Exclude:      exclude,
+		Options:      options,
+	}
+}
+
+/*
+Struct ExpiryNotification is what is sent where a subscription's ExpiryNotify option says,
+when it is about to be aged out as nobody listens to it, and when it has been, or, with the
+service's AgeOutGrace, when its grace window starts, and when it is saved in it.
+*/
+type ExpiryNotification struct {
+	SubscriptionId string `json:"subscriptionId"`
+	// ExpiryEventExpiring, ExpiryEventExpired, ExpiryEventGrace or ExpiryEventResumed
+	Event string `json:"event"`
+	// When it is aged out unless someone listens to it, or when it was, RFC 3339. Absent for ExpiryEventResumed.
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+/*
+Struct SubscriptionStatsResponse is the response to GET of a subscription's stats: what
+happened to its events, across all its event streams, since it was created or they were
+last reset.
+*/
+type SubscriptionStatsResponse struct {
+	commonDTO.BaseResponse `json:",inline"`
+	// Events delivered to the subscription's buffer, for its event stream
+	Delivered uint64 `json:"delivered"`
+	// Events dropped because its buffer was full
+	Dropped uint64 `json:"dropped"`
+	// Events discarded for waiting longer than its maxAge option
+	Expired uint64 `json:"expired"`
+	// Size of the payloads of the events delivered
+	Bytes uint64 `json:"bytes"`
+	// Event streams opened
+	Connections uint64 `json:"connections"`
+	// When the counting started, RFC 3339
+	Since string `json:"since"`
+}
+
+/*
+Struct SubscriptionCountResponse is the response to GET of the subscription count: how many
+subscriptions the caller's limits allow, and how many of them are in use, so clients can
+hold back before creating one is refused.
+*/
+type SubscriptionCountResponse struct {
+	commonDTO.BaseResponse `json:",inline"`
+	// Subscriptions that count against the caller's limit
+	Count uint32 `json:"count"`
+	// Subscriptions that can exist at once under it
+	Limit uint32 `json:"limit"`
+	// Number of entries allowed in each include and exclude list of a new subscription
+	PrefixLimit uint `json:"prefixLimit"`
+}
+
+/*
+Struct LimitsRequest is the body of PUT of the service's limits, applied to subscriptions
+created after. Those not given, or 0, stay as they are.
+*/
+type LimitsRequest struct {
+	commonDTO.BaseRequest `json:",inline"`
+	SubscriptionLimit     uint32 `json:"subscriptionLimit,omitempty"`
+	PrefixesLimit         uint   `json:"prefixesLimit,omitempty"`
+	EventBuffer           uint   `json:"eventBuffer,omitempty"`
+}
+
+// Struct Limits is a set of the service's limits.
+type Limits struct {
+	// Subscriptions that can exist at once, not counting those of callers in a role
+	SubscriptionLimit uint32 `json:"subscriptionLimit"`
+	// Number of entries allowed in each include and exclude list of a new subscription
+	PrefixesLimit uint `json:"prefixesLimit"`
+	// Events a new subscription's buffer holds
+	EventBuffer uint `json:"eventBuffer"`
+}
+
+// Struct LimitsResponse is the response to GET and PUT of the service's limits.
+type LimitsResponse struct {
+	commonDTO.BaseResponse `json:",inline"`
+	// The limits in force
+	Limits Limits `json:"limits"`
+	// The largest each can be set to
+	Maxima Limits `json:"maxima"`
+}
+
+/*
+Struct DeliveryEnvelope is what a payload is wrapped in for a subscription with the delivery
+envelope option: the bookkeeping of its delivery, without parsing SSE fields or comments.
+*/
+type DeliveryEnvelope struct {
+	// Sequence number, as the SSE event ID, with Replay configured in the service
+	Seq uint64 `json:"seq,omitempty"`
+	// Topic the message came on
+	Topic string `json:"topic"`
+	// When the service received it, RFC 3339
+	ReceivedAt string `json:"receivedAt"`
+	// EdgeX correlation ID of the message bus message, if known
+	CorrelationId string `json:"correlationId,omitempty"`
+	// SSE event type, e.g. "edgex", "" for generic events
+	EventType string `json:"eventType"`
+	// The payload, as JSON if it is JSON, else a JSON string
+	Payload json.RawMessage `json:"payload"`
+}
+
+/*
+Struct PingEvent is the data of the ping event sent on idle event streams instead of a
+heartbeat comment, with the service's HeartbeatEvents: how the stream is doing, so clients
+can tell lag and clock skew without asking another endpoint.
+*/
+type PingEvent struct {
+	// The service's time, RFC 3339
+	Time string `json:"time"`
+	// Messages waiting to be sent to the stream
+	BufferDepth int `json:"bufferDepth"`
+	// Messages dropped, for the subscription's buffer being full, since the last ping, or
+	// since the stream was opened
+	Dropped uint64 `json:"dropped"`
+}
+
+/*
+Struct BackpressureEvent is the data of the backpressure event sent to an event stream whose
+buffer filled past the service's BackpressureMark: its client isn't keeping up, and events
+will be dropped once the buffer is full, unless it reads faster or asks for fewer.
+*/
+type BackpressureEvent struct {
+	// Messages waiting to be sent to the stream
+	BufferDepth int `json:"bufferDepth"`
+	// Messages that can wait before more are dropped
+	BufferSize int `json:"bufferSize"`
+}
+
+/*
+Struct ChunkEvent is the data of a chunk event, one part of a payload longer than the
+subscription's ChunkSize option. The parts of a payload come one after the other, numbered
+from 0, and the last one says so; joining their Data gives the payload. Only the last one
+has the SSE event ID, so a client resuming after it doesn't get half a payload.
+*/
+type ChunkEvent struct {
+	// Index of the part, from 0
+	Part int `json:"part"`
+	// This is the last part
+	Last bool `json:"last,omitempty"`
+	// SSE event type of the payload, e.g. "edgex", "" for generic events
+	EventType string `json:"eventType,omitempty"`
+	// This part of the payload
+	Data string `json:"data"`
+}
+
+/*
+Struct MediaEvent is the data of a media event, a binary reading of an EdgeX Event delivered
+to a subscription with the media option, e.g. for a UI to show the image it holds.
+*/
+type MediaEvent struct {
+	// ID of the Event the reading is in
+	EventId string `json:"eventId"`
+	// ID of the reading
+	Id           string `json:"id"`
+	DeviceName   string `json:"deviceName"`
+	ResourceName string `json:"resourceName"`
+	Origin       int64  `json:"origin"`
+	// MIME type of the value, e.g. "image/jpeg"
+	MediaType string `json:"mediaType"`
+	// The value, base64
+	Data string `json:"data"`
+}
+
+/*
+Struct AcknowledgeRequest is the body of POST to a subscription's ack endpoint: the sequence
+number, the event ID, of the last message received, acknowledging it and all before it.
+*/
+type AcknowledgeRequest struct {
+	commonDTO.BaseRequest `json:",inline"`
+	Seq                   uint64 `json:"seq"`
+}
+
+// Struct SubscriptionIdResponse is the response to POST of a subscription, and to rotating its ID.
+type SubscriptionIdResponse struct {
+	commonDTO.BaseResponse `json:",inline"`
+	SubscriptionId         string `json:"subscriptionId"`
+}
+
+// NewSubscriptionIdResponse returns a SubscriptionIdResponse to the request with requestId.
+func NewSubscriptionIdResponse(requestId string, subscriptionId string, message string, statusCode int) SubscriptionIdResponse {
+	return SubscriptionIdResponse{
+		BaseResponse:   commonDTO.NewBaseResponse(requestId, message, statusCode),
+		SubscriptionId: subscriptionId,
+	}
+}
+
+/*
+Struct ServiceSubscriptionResponse is the response to POST of a subscription to a device
+service: the subscription's ID, and the devices core-metadata has for the service now.
+*/
+type ServiceSubscriptionResponse struct {
+	SubscriptionIdResponse `json:",inline"`
+	Service                string   `json:"service"`
+	// Names of the service's devices, in lexicographic order
+	Devices []string `json:"devices"`
+}
@@ -0,0 +1,75 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package dtos
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSubscriptionRequestValidate(t *testing.T) {
+	valid := []SubscriptionRequest{
+		{},
+		{Include: []string{""}, Options: &SubscriptionOptions{}},
+		{Options: &SubscriptionOptions{Format: FormatSenML, Envelope: EnvelopeCloudEvents}},
+		{Options: &SubscriptionOptions{Envelope: EnvelopeDelivery}},
+		{Options: &SubscriptionOptions{MaxAge: "90s"}},
+		{Options: &SubscriptionOptions{ExpiryNotify: "https://backend.example.com/expiry"}},
+		{Options: &SubscriptionOptions{ExpiryNotify: ExpiryNotifySupport}},
+		{Options: &SubscriptionOptions{Devices: &DeviceSelector{Labels: []string{"line-3"}}}},
+		{Options: &SubscriptionOptions{Devices: &DeviceSelector{Profile: "Random-Integer-Device"}}},
+		{Options: &SubscriptionOptions{Filter: "SELECT * FROM demo WHERE temperature > 30"}},
+		{Options: &SubscriptionOptions{Units: []string{"degC", "kPa"}}},
+		{Options: &SubscriptionOptions{Rounding: &Rounding{}}},
+		{Options: &SubscriptionOptions{Rounding: &Rounding{Digits: 3, Significant: true}}},
+		{Options: &SubscriptionOptions{ChunkSize: MinChunkSize}},
+	}
+	for _, request := range valid {
+		if err := request.Validate(); err != nil {
+			t.Errorf("Validate(%+v) failed: %v", request, err)
+		}
+	}
+	invalid := []SubscriptionRequest{
+		{Options: &SubscriptionOptions{Format: "xml"}},
+		{Options: &SubscriptionOptions{Envelope: "soap"}},
+		{Options: &SubscriptionOptions{MaxAge: "0s"}},
+		{Options: &SubscriptionOptions{MaxAge: "an hour"}},
+		{Options: &SubscriptionOptions{ExpiryNotify: "ftp://backend.example.com/expiry"}},
+		{Options: &SubscriptionOptions{ExpiryNotify: "/expiry"}},
+		{Options: &SubscriptionOptions{Devices: &DeviceSelector{}}},
+		{Options: &SubscriptionOptions{Devices: &DeviceSelector{Labels: []string{"line-3", ""}}}},
+		{Options: &SubscriptionOptions{Filter: "SELECT temperature WHERE temperature > 30"}},
+		{Options: &SubscriptionOptions{Units: []string{"degC", ""}}},
+		{Options: &SubscriptionOptions{Rounding: &Rounding{Digits: -1}}},
+		{Options: &SubscriptionOptions{Rounding: &Rounding{Significant: true}}},
+		{Options: &SubscriptionOptions{ChunkSize: 100}},
+		{Options: &SubscriptionOptions{ChunkSize: -1}},
+	}
+	for _, request := range invalid {
+		if err := request.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", request)
+		}
+	}
+}
+
+func TestResponses(t *testing.T) {
+	data, _ := json.Marshal(NewSubscriptionIdResponse("", "sub1", "Subscription created", http.StatusCreated))
+	var created map[string]any
+	if err := json.Unmarshal(data, &created); err != nil || created["subscriptionId"] != "sub1" || created["statusCode"] != float64(http.StatusCreated) || created["apiVersion"] != "v3" {
+		t.Fatalf("Wrong SubscriptionIdResponse %s", data)
+	}
+	// Empty lists and default options are still there
+	data, _ = json.Marshal(NewSubscriptionResponse("", nil, []string{"a/"}, SubscriptionOptions{}))
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil || got["include"] != nil || got["options"] == nil {
+		t.Fatalf("Wrong SubscriptionResponse %s", data)
+	}
+	if _, ok := got["include"]; !ok {
+		t.Fatalf("No include in %s", data)
+	}
+}
@@ -0,0 +1,206 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+Package extension lets a build of the service add its own per-subscription filters and
+transforms of EdgeX Events, e.g. a downstream fork's, without changing the service's code.
+A file in package extensions, at the top of the repository, registers them from its init()
+function, under names subscriptions then give in their extensions option:
+
+	func init() {
+		extension.RegisterFilter("alarm-only", func(args map[string]string) (extension.Filter, error) {
+			return alarmFilter{resource: args["resource"]}, nil
+		})
+	}
+
+and a subscription with options {"extensions": [{"name": "alarm-only", "args": {"resource": "alarm"}}]}
+only gets Events that filter matches. Filters see Events as published; transforms are applied
+after them, in the order given, before the service's own units, rounding and format options.
+Other messages are delivered as without them.
+*/
+package extension
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+// Type Filter decides which Events go to a subscription.
+type Filter interface {
+	// Match returns true if the Event goes to the subscription.
+	Match(event dtos.Event) bool
+}
+
+// Type Transform changes the Events that go to a subscription.
+type Transform interface {
+	// Transform returns the Event as the subscription gets it. It must not change the
+	// Event's readings in place, they are shared with other subscriptions. Error is
+	// returned if it can't be transformed; the subscription doesn't get it then.
+	Transform(event dtos.Event) (dtos.Event, error)
+}
+
+/*
+Types FilterFactory and TransformFactory make a subscription's Filter or Transform from the
+args of its extensions option, when the options are set, so bad ones are refused then.
+*/
+type (
+	FilterFactory    func(args map[string]string) (Filter, error)
+	TransformFactory func(args map[string]string) (Transform, error)
+)
+
+// Struct Ref is an entry of a subscription's extensions option: a registered name, and its args.
+type Ref struct {
+	Name string            `json:"name"`
+	Args map[string]string `json:"args,omitempty"`
+}
+
+var (
+	lock       sync.RWMutex
+	filters    = make(map[string]FilterFactory)
+	transforms = make(map[string]TransformFactory)
+)
+
+/*
+RegisterFilter makes a Filter available to subscriptions under name. Filters and transforms
+share names. Like the standard library's registries, it panics if name is empty or taken,
+as that can only be a mistake in the build.
+*/
+func RegisterFilter(name string, factory FilterFactory) {
+	lock.Lock()
+	defer lock.Unlock()
+	checkName(name)
+	filters[name] = factory
+}
+
+// RegisterTransform makes a Transform available to subscriptions under name, as RegisterFilter() does a Filter.
+func RegisterTransform(name string, factory TransformFactory) {
+	lock.Lock()
+	defer lock.Unlock()
+	checkName(name)
+	transforms[name] = factory
+}
+
+// checkName (an internal API) panics if name can't be registered. Call under lock.
+func checkName(name string) {
+	if name == "" {
+		panic("extension registered without a name")
+	}
+	_, isFilter := filters[name]
+	_, isTransform := transforms[name]
+	if isFilter || isTransform {
+		panic("extension " + name + " registered twice")
+	}
+}
+
+// Names returns the names of the registered filters and transforms, in lexicographic order.
+func Names() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	rv := make([]string, 0, len(filters)+len(transforms))
+	for name := range filters {
+		rv = append(rv, name)
+	}
+	for name := range transforms {
+		rv = append(rv, name)
+	}
+	slices.Sort(rv)
+	return rv
+}
+
+// Struct Chain is a subscription's extensions, made from its extensions option.
+type Chain struct {
+	// JSON of the option's transforms, the same for subscriptions whose Events are transformed the same
+	key        string
+	filters    []Filter
+	transforms []Transform
+}
+
+/*
+Build makes the filters and transforms of an extensions option, nil if there are none.
+
+Error is returned if a name isn't registered, or its factory refuses its args.
+*/
+func Build(refs []Ref) (*Chain, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	rv := &Chain{}
+	var transformRefs []Ref
+	lock.RLock()
+	defer lock.RUnlock()
+	for _, ref := range refs {
+		if factory, ok := filters[ref.Name]; ok {
+			filter, err := factory(ref.Args)
+			if err != nil {
+				return nil, fmt.Errorf("extension %s: %w", ref.Name, err)
+			}
+			rv.filters = append(rv.filters, filter)
+		} else if factory, ok := transforms[ref.Name]; ok {
+			transform, err := factory(ref.Args)
+			if err != nil {
+				return nil, fmt.Errorf("extension %s: %w", ref.Name, err)
+			}
+			rv.transforms = append(rv.transforms, transform)
+			transformRefs = append(transformRefs, ref)
+		} else {
+			return nil, fmt.Errorf("no extension %s", ref.Name)
+		}
+	}
+	key, err := json.Marshal(transformRefs)
+	if err != nil {
+		return nil, err
+	}
+	rv.key = string(key)
+	return rv, nil
+}
+
+// Match returns true if all the filters match the Event, or there are none.
+func (c *Chain) Match(event dtos.Event) bool {
+	if c == nil {
+		return true
+	}
+	for _, filter := range c.filters {
+		if !filter.Match(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// Transforms returns true if there are transforms.
+func (c *Chain) Transforms() bool {
+	return c != nil && len(c.transforms) > 0
+}
+
+// Key returns what subscriptions whose Events are transformed the same have in common.
+func (c *Chain) Key() string {
+	if !c.Transforms() {
+		return ""
+	}
+	return c.key
+}
+
+/*
+Transform returns the Event as transformed by each transform in turn.
+
+Error is returned if a transform fails.
+*/
+func (c *Chain) Transform(event dtos.Event) (dtos.Event, error) {
+	if c == nil {
+		return event, nil
+	}
+	for _, transform := range c.transforms {
+		var err error
+		if event, err = transform.Transform(event); err != nil {
+			return event, err
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,120 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package extension
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+// Struct deviceFilter matches Events from one device.
+type deviceFilter struct{ device string }
+
+func (f deviceFilter) Match(event dtos.Event) bool {
+	return event.DeviceName == f.device
+}
+
+// Type tagTransform tags Events with a value.
+type tagTransform string
+
+func (t tagTransform) Transform(event dtos.Event) (dtos.Event, error) {
+	if t == "" {
+		return event, errors.New("no tag")
+	}
+	tags := map[string]any{"test": string(t)}
+	for name, value := range event.Tags {
+		if name != "test" {
+			tags[name] = value
+		}
+	}
+	event.Tags = tags
+	return event, nil
+}
+
+func init() {
+	RegisterFilter("test-device", func(args map[string]string) (Filter, error) {
+		if args["device"] == "" {
+			return nil, errors.New("device is required")
+		}
+		return deviceFilter{device: args["device"]}, nil
+	})
+	RegisterTransform("test-tag", func(args map[string]string) (Transform, error) {
+		return tagTransform(args["tag"]), nil
+	})
+}
+
+func TestRegister(t *testing.T) {
+	if names := Names(); !slices.Contains(names, "test-device") || !slices.Contains(names, "test-tag") || !slices.IsSorted(names) {
+		t.Fatalf("Registered names are %v", names)
+	}
+	for _, name := range []string{"test-device", "test-tag", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Registering %q did not panic", name)
+				}
+			}()
+			RegisterTransform(name, func(map[string]string) (Transform, error) { return tagTransform("x"), nil })
+		}()
+	}
+}
+
+func TestBuild(t *testing.T) {
+	if chain, err := Build(nil); chain != nil || err != nil {
+		t.Fatalf("Build of no extensions returned %v, %v", chain, err)
+	}
+	for _, refs := range [][]Ref{
+		{{Name: "test-missing"}},
+		{{Name: "test-device"}},
+		{{Name: "test-tag", Args: map[string]string{"tag": "a"}}, {Name: "test-device", Args: map[string]string{}}},
+	} {
+		if _, err := Build(refs); err == nil {
+			t.Errorf("Build of %v succeeded", refs)
+		}
+	}
+
+	// A nil chain matches and leaves Events alone
+	var none *Chain
+	event := dtos.NewEvent("Pump", "pump-1", "status")
+	if transformed, err := none.Transform(event); !none.Match(event) || none.Transforms() || none.Key() != "" || err != nil || transformed.DeviceName != "pump-1" {
+		t.Fatal("Nil chain does something")
+	}
+
+	filterOnly, err := Build([]Ref{{Name: "test-device", Args: map[string]string{"device": "pump-1"}}})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !filterOnly.Match(event) || filterOnly.Transforms() || filterOnly.Key() != "" {
+		t.Fatal("Filter without transforms does not just filter")
+	}
+	event.DeviceName = "pump-2"
+	if filterOnly.Match(event) {
+		t.Fatal("Filter matched another device")
+	}
+
+	// Transforms apply in order; the same ones have the same key, whatever the filters
+	chain, err := Build([]Ref{{Name: "test-tag", Args: map[string]string{"tag": "a"}}, {Name: "test-device", Args: map[string]string{"device": "pump-2"}}, {Name: "test-tag", Args: map[string]string{"tag": "b"}}})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	same, _ := Build([]Ref{{Name: "test-tag", Args: map[string]string{"tag": "a"}}, {Name: "test-tag", Args: map[string]string{"tag": "b"}}})
+	other, _ := Build([]Ref{{Name: "test-tag", Args: map[string]string{"tag": "b"}}, {Name: "test-tag", Args: map[string]string{"tag": "a"}}})
+	if !chain.Transforms() || chain.Key() != same.Key() || chain.Key() == other.Key() {
+		t.Fatalf("Keys %s, %s, %s", chain.Key(), same.Key(), other.Key())
+	}
+	transformed, err := chain.Transform(event)
+	if err != nil || transformed.Tags["test"] != "b" || event.Tags != nil {
+		t.Fatalf("Transformed to %+v, %v", transformed, err)
+	}
+	failing, _ := Build([]Ref{{Name: "test-tag"}})
+	if _, err := failing.Transform(event); err == nil {
+		t.Fatal("Failing transform did not fail")
+	}
+}
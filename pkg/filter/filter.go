@@ -0,0 +1,683 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+Package filter compiles the SQL-like filters of subscriptions, a subset of the eKuiper
+syntax, and matches EdgeX Events against them. A filter is a condition, optionally after
+SELECT * (and a FROM, which is ignored) and WHERE:
+
+	SELECT * FROM demo WHERE temperature > 30 AND meta(deviceName) LIKE 'pump-%'
+	humidity BETWEEN 20 AND 80 OR alarm = true
+
+Like in eKuiper, the readings of an Event are its columns, by resource name, with numeric
+readings as numbers and Bool readings as booleans. Names that aren't readings are the
+Event's deviceName, profileName, sourceName, origin and id; meta(name) is always one of
+those, or one of the Event's tags. Names that aren't plain identifiers go in backquotes.
+
+Conditions are =, != (or <>), <, <=, >, >=, [NOT] IN (...), [NOT] LIKE with % and _,
+[NOT] BETWEEN ... AND ..., IS [NOT] NULL, AND, OR, NOT and parentheses. Strings go in
+single or double quotes. A comparison with a column the Event doesn't have, or of values
+of different types, is false.
+*/
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+// Longest filter Compile() takes, and deepest nesting of parentheses and NOTs
+const (
+	MaxLength = 4096
+	maxDepth  = 32
+)
+
+// Struct Expression is a compiled filter.
+type Expression struct {
+	text  string
+	where condition
+}
+
+/*
+Compile parses a filter, so matching Events against it is quick.
+
+Error is returned if the filter is longer than MaxLength, or isn't in the syntax above.
+*/
+func Compile(text string) (*Expression, error) {
+	if len(text) > MaxLength {
+		return nil, fmt.Errorf("filter longer than %d characters", MaxLength)
+	}
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	where, err := p.filter()
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{text: text, where: where}, nil
+}
+
+// String returns the filter as it was compiled.
+func (e *Expression) String() string {
+	return e.text
+}
+
+// Match reports whether the Event passes the filter.
+func (e *Expression) Match(event dtos.Event) bool {
+	return e.where.eval(&event)
+}
+
+// Interface condition (an internal API) is a compiled condition.
+type condition interface {
+	eval(event *dtos.Event) bool
+}
+
+// Interface operand (an internal API) is a compiled value: a float64, string or bool, and whether there is one.
+type operand interface {
+	value(event *dtos.Event) (any, bool)
+}
+
+// Struct literal (an internal API) is a constant operand.
+type literal struct {
+	v any
+}
+
+func (l literal) value(_ *dtos.Event) (any, bool) {
+	return l.v, true
+}
+
+// Struct column (an internal API) is a reading, or Event field, by name; meta() only looks at the Event.
+type column struct {
+	name string
+	meta bool
+}
+
+func (c column) value(event *dtos.Event) (any, bool) {
+	if !c.meta {
+		for _, reading := range event.Readings {
+			if reading.ResourceName == c.name {
+				return readingValue(reading)
+			}
+		}
+	}
+	switch c.name {
+	case "deviceName":
+		return event.DeviceName, true
+	case "profileName":
+		return event.ProfileName, true
+	case "sourceName":
+		return event.SourceName, true
+	case "origin":
+		return float64(event.Origin), true
+	case "id":
+		return event.Id, true
+	}
+	if c.meta {
+		if tag, ok := event.Tags[c.name]; ok {
+			switch v := tag.(type) {
+			case string, bool, float64:
+				return v, true
+			default:
+				return fmt.Sprint(v), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// readingValue (an internal API) returns a reading's value as a number, boolean or string, by its type.
+func readingValue(reading dtos.BaseReading) (any, bool) {
+	switch reading.ValueType {
+	case common.ValueTypeBool:
+		v, err := strconv.ParseBool(reading.Value)
+		return v, err == nil
+	case common.ValueTypeUint8, common.ValueTypeUint16, common.ValueTypeUint32, common.ValueTypeUint64,
+		common.ValueTypeInt8, common.ValueTypeInt16, common.ValueTypeInt32, common.ValueTypeInt64,
+		common.ValueTypeFloat32, common.ValueTypeFloat64:
+		v, err := strconv.ParseFloat(reading.Value, 64)
+		return v, err == nil
+	case common.ValueTypeString:
+		return reading.Value, true
+	}
+	// Arrays, binary and objects can only be checked for being there
+	return nil, true
+}
+
+// Struct truthy (an internal API) is an operand used as a condition, true if it is boolean true.
+type truthy struct {
+	operand operand
+}
+
+func (t truthy) eval(event *dtos.Event) bool {
+	v, ok := t.operand.value(event)
+	b, isBool := v.(bool)
+	return ok && isBool && b
+}
+
+// Struct logical (an internal API) is AND, or OR, of two conditions.
+type logical struct {
+	and         bool
+	left, right condition
+}
+
+func (l logical) eval(event *dtos.Event) bool {
+	if l.and {
+		return l.left.eval(event) && l.right.eval(event)
+	}
+	return l.left.eval(event) || l.right.eval(event)
+}
+
+// Struct not (an internal API) is NOT of a condition.
+type not struct {
+	inner condition
+}
+
+func (n not) eval(event *dtos.Event) bool {
+	return !n.inner.eval(event)
+}
+
+// Struct comparison (an internal API) is one of =, !=, <, <=, > and >=.
+type comparison struct {
+	op          string
+	left, right operand
+}
+
+func (c comparison) eval(event *dtos.Event) bool {
+	left, ok := c.left.value(event)
+	if !ok {
+		return false
+	}
+	right, ok := c.right.value(event)
+	if !ok {
+		return false
+	}
+	order, ok := compare(left, right)
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "=":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	default:
+		return order >= 0
+	}
+}
+
+// compare (an internal API) orders two values of the same type; false if they can't be.
+func compare(a any, b any) (int, bool) {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1, true
+			case a > b:
+				return 1, true
+			}
+			return 0, true
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			if a == b {
+				return 0, true
+			}
+			// Only = and != make sense, false sorts first
+			if b {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+// Struct in (an internal API) is [NOT] IN a list of literals.
+type in struct {
+	operand operand
+	list    []any
+	negated bool
+}
+
+func (i in) eval(event *dtos.Event) bool {
+	v, ok := i.operand.value(event)
+	if !ok {
+		return false
+	}
+	for _, item := range i.list {
+		if order, ok := compare(v, item); ok && order == 0 {
+			return !i.negated
+		}
+	}
+	return i.negated
+}
+
+// Struct like (an internal API) is [NOT] LIKE a pattern, compiled into a regular expression.
+type like struct {
+	operand operand
+	pattern *regexp.Regexp
+	negated bool
+}
+
+func (l like) eval(event *dtos.Event) bool {
+	v, ok := l.operand.value(event)
+	s, isString := v.(string)
+	if !ok || !isString {
+		return false
+	}
+	return l.pattern.MatchString(s) != l.negated
+}
+
+// Struct isNull (an internal API) is IS [NOT] NULL: whether the Event has the column.
+type isNull struct {
+	operand operand
+	negated bool
+}
+
+func (n isNull) eval(event *dtos.Event) bool {
+	_, ok := n.operand.value(event)
+	return ok == n.negated
+}
+
+// likePattern (an internal API) turns a LIKE pattern into a regular expression matching the whole string.
+func likePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^(?s:")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(")$")
+	return regexp.MustCompile(b.String())
+}
+
+// Kinds of tokens
+const (
+	tokenEnd = iota
+	tokenIdent
+	tokenQuoted
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+// Struct token (an internal API) is a word, literal or symbol of a filter.
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// keyword (an internal API) reports whether the token is the keyword, in any case.
+func (t token) keyword(word string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, word)
+}
+
+// lex (an internal API) splits a filter into tokens, ending with a tokenEnd.
+func lex(text string) ([]token, error) {
+	rv := make([]token, 0)
+	i := 0
+	for i < len(text) {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z'):
+			start := i
+			for i < len(text) && (text[i] == '_' || (text[i] >= 'A' && text[i] <= 'Z') || (text[i] >= 'a' && text[i] <= 'z') || (text[i] >= '0' && text[i] <= '9')) {
+				i++
+			}
+			rv = append(rv, token{kind: tokenIdent, text: text[start:i], pos: start})
+		case c >= '0' && c <= '9' || (c == '.' && i+1 < len(text) && text[i+1] >= '0' && text[i+1] <= '9'):
+			start := i
+			for i < len(text) && (text[i] >= '0' && text[i] <= '9' || text[i] == '.' || text[i] == 'e' || text[i] == 'E' ||
+				((text[i] == '+' || text[i] == '-') && (text[i-1] == 'e' || text[i-1] == 'E'))) {
+				i++
+			}
+			rv = append(rv, token{kind: tokenNumber, text: text[start:i], pos: start})
+		case c == '\'' || c == '"' || c == '`':
+			start := i
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(text) {
+					return nil, fmt.Errorf("unterminated %c at %d", c, start)
+				}
+				if text[i] == c {
+					// Doubled quotes are one quote
+					if i+1 < len(text) && text[i+1] == c {
+						b.WriteByte(c)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteByte(text[i])
+				i++
+			}
+			kind := tokenString
+			if c == '`' {
+				kind = tokenQuoted
+			}
+			rv = append(rv, token{kind: kind, text: b.String(), pos: start})
+		default:
+			start := i
+			symbol := string(c)
+			if i+1 < len(text) {
+				switch two := text[i : i+2]; two {
+				case "!=", "<>", "<=", ">=":
+					symbol = two
+				}
+			}
+			switch symbol {
+			case "=", "!=", "<>", "<", "<=", ">", ">=", "(", ")", ",", "*", "-":
+			default:
+				return nil, fmt.Errorf("unexpected %q at %d", symbol, start)
+			}
+			if symbol == "<>" {
+				symbol = "!="
+			}
+			i += len(symbol)
+			rv = append(rv, token{kind: tokenSymbol, text: symbol, pos: start})
+		}
+	}
+	return append(rv, token{kind: tokenEnd, pos: len(text)}), nil
+}
+
+// Struct parser (an internal API) is a recursive descent parser over the tokens of a filter.
+type parser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+// peek (an internal API) returns the next token, without taking it.
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+// take (an internal API) returns the next token, and moves past it unless it is the end.
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEnd {
+		p.next++
+	}
+	return t
+}
+
+// symbol (an internal API) takes the next token if it is the symbol.
+func (p *parser) symbol(s string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == s {
+		p.next++
+		return true
+	}
+	return false
+}
+
+// keyword (an internal API) takes the next token if it is the keyword.
+func (p *parser) keyword(word string) bool {
+	if p.peek().keyword(word) {
+		p.next++
+		return true
+	}
+	return false
+}
+
+// unexpected (an internal API) returns the error for the next token not being what it should.
+func (p *parser) unexpected(wanted string) error {
+	t := p.peek()
+	if t.kind == tokenEnd {
+		return fmt.Errorf("expected %s at end of filter", wanted)
+	}
+	return fmt.Errorf("expected %s at %d, not %q", wanted, t.pos, t.text)
+}
+
+// Struct always (an internal API) matches every Event, for SELECT * without WHERE.
+type always struct{}
+
+func (always) eval(_ *dtos.Event) bool {
+	return true
+}
+
+// filter (an internal API) parses a whole filter: [SELECT * [FROM name] [WHERE]] condition.
+func (p *parser) filter() (condition, error) {
+	if p.keyword("SELECT") {
+		if !p.symbol("*") {
+			return nil, errors.New("only SELECT * is supported, Events are delivered whole")
+		}
+		if p.keyword("FROM") {
+			if t := p.take(); t.kind != tokenIdent && t.kind != tokenQuoted {
+				p.next--
+				return nil, p.unexpected("a stream name")
+			}
+		}
+		if p.peek().kind == tokenEnd {
+			return always{}, nil
+		}
+		if !p.keyword("WHERE") {
+			return nil, p.unexpected("WHERE")
+		}
+	} else {
+		p.keyword("WHERE")
+	}
+	if p.peek().kind == tokenEnd {
+		return nil, errors.New("filter has no condition")
+	}
+	rv, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEnd {
+		return nil, p.unexpected("AND, OR or end of filter")
+	}
+	return rv, nil
+}
+
+// or (an internal API) parses conditions joined by OR.
+func (p *parser) or() (condition, error) {
+	left, err := p.and()
+	for err == nil && p.keyword("OR") {
+		var right condition
+		right, err = p.and()
+		left = logical{left: left, right: right}
+	}
+	return left, err
+}
+
+// and (an internal API) parses conditions joined by AND.
+func (p *parser) and() (condition, error) {
+	left, err := p.not()
+	for err == nil && p.keyword("AND") {
+		var right condition
+		right, err = p.not()
+		left = logical{and: true, left: left, right: right}
+	}
+	return left, err
+}
+
+// not (an internal API) parses a condition, possibly negated by NOT.
+func (p *parser) not() (condition, error) {
+	if p.depth++; p.depth > maxDepth {
+		return nil, errors.New("filter nested too deeply")
+	}
+	defer func() { p.depth-- }()
+	if p.keyword("NOT") {
+		inner, err := p.not()
+		return not{inner: inner}, err
+	}
+	return p.predicate()
+}
+
+// predicate (an internal API) parses a parenthesized condition, a comparison or other test, or an operand used as a condition.
+func (p *parser) predicate() (condition, error) {
+	// Parentheses only go around conditions
+	if p.peek().kind == tokenSymbol && p.peek().text == "(" {
+		p.next++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.symbol(")") {
+			return nil, p.unexpected("')'")
+		}
+		return inner, nil
+	}
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind == tokenSymbol {
+		switch t.text {
+		case "=", "!=", "<", "<=", ">", ">=":
+			p.next++
+			right, err := p.operand()
+			return comparison{op: t.text, left: left, right: right}, err
+		}
+	}
+	if p.keyword("IS") {
+		negated := p.keyword("NOT")
+		if !p.keyword("NULL") {
+			return nil, p.unexpected("NULL")
+		}
+		return isNull{operand: left, negated: negated}, nil
+	}
+	negated := p.keyword("NOT")
+	switch {
+	case p.keyword("IN"):
+		list, err := p.list()
+		return in{operand: left, list: list, negated: negated}, err
+	case p.keyword("LIKE"):
+		t := p.take()
+		if t.kind != tokenString {
+			p.next--
+			return nil, p.unexpected("a quoted pattern")
+		}
+		return like{operand: left, pattern: likePattern(t.text), negated: negated}, nil
+	case p.keyword("BETWEEN"):
+		low, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword("AND") {
+			return nil, p.unexpected("AND")
+		}
+		high, err := p.operand()
+		var rv condition = logical{and: true, left: comparison{op: ">=", left: left, right: low}, right: comparison{op: "<=", left: left, right: high}}
+		if negated {
+			rv = not{inner: rv}
+		}
+		return rv, err
+	}
+	if negated {
+		return nil, p.unexpected("IN, LIKE or BETWEEN")
+	}
+	return truthy{operand: left}, nil
+}
+
+// list (an internal API) parses the parenthesized list of literals of IN.
+func (p *parser) list() ([]any, error) {
+	if !p.symbol("(") {
+		return nil, p.unexpected("'('")
+	}
+	rv := make([]any, 0)
+	for {
+		item, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		l, ok := item.(literal)
+		if !ok {
+			return nil, errors.New("IN lists must only have strings, numbers and booleans")
+		}
+		rv = append(rv, l.v)
+		if p.symbol(")") {
+			return rv, nil
+		}
+		if !p.symbol(",") {
+			return nil, p.unexpected("',' or ')'")
+		}
+	}
+}
+
+// operand (an internal API) parses a literal, a column or meta(name).
+func (p *parser) operand() (operand, error) {
+	t := p.take()
+	switch t.kind {
+	case tokenString:
+		return literal{v: t.text}, nil
+	case tokenNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q at %d", t.text, t.pos)
+		}
+		return literal{v: v}, nil
+	case tokenQuoted:
+		return column{name: t.text}, nil
+	case tokenSymbol:
+		if t.text == "-" && p.peek().kind == tokenNumber {
+			number, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return literal{v: -number.(literal).v.(float64)}, nil
+		}
+	case tokenIdent:
+		switch {
+		case t.keyword("TRUE"):
+			return literal{v: true}, nil
+		case t.keyword("FALSE"):
+			return literal{v: false}, nil
+		case t.keyword("META") && p.symbol("("):
+			name := p.take()
+			if name.kind != tokenIdent && name.kind != tokenQuoted {
+				p.next--
+				return nil, p.unexpected("a name")
+			}
+			if !p.symbol(")") {
+				return nil, p.unexpected("')'")
+			}
+			return column{name: name.text, meta: true}, nil
+		}
+		for _, reserved := range []string{"AND", "OR", "NOT", "IN", "LIKE", "IS", "NULL", "BETWEEN", "SELECT", "FROM", "WHERE"} {
+			if t.keyword(reserved) {
+				p.next--
+				return nil, p.unexpected("a name or value")
+			}
+		}
+		return column{name: t.text}, nil
+	}
+	if t.kind != tokenEnd {
+		p.next--
+	}
+	return nil, p.unexpected("a name or value")
+}
@@ -0,0 +1,118 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+)
+
+// testEvent (an internal API) returns an Event from pump-1 with a few kinds of readings.
+func testEvent(t *testing.T) dtos.Event {
+	event := dtos.NewEvent("Pump", "pump-1", "status")
+	event.Origin = 1700000000
+	event.Tags = map[string]any{"site": "plant-3", "floor": float64(2)}
+	readings := []struct {
+		name      string
+		valueType string
+		value     any
+	}{
+		{"temperature", common.ValueTypeFloat64, 31.5},
+		{"pressure", common.ValueTypeInt32, int32(-4)},
+		{"running", common.ValueTypeBool, true},
+		{"mode", common.ValueTypeString, "auto"},
+		{"Flow-Rate", common.ValueTypeUint16, uint16(12)},
+	}
+	for _, r := range readings {
+		reading, err := dtos.NewSimpleReading("Pump", "pump-1", r.name, r.valueType, r.value)
+		if err != nil {
+			t.Fatalf("Bad test reading %s: %v", r.name, err)
+		}
+		event.Readings = append(event.Readings, reading)
+	}
+	return event
+}
+
+func TestMatch(t *testing.T) {
+	event := testEvent(t)
+	cases := map[string]bool{
+		"temperature > 30":                                 true,
+		"temperature > 31.5":                               false,
+		"SELECT * FROM demo WHERE temperature >= 31.5":     true,
+		"select * where temperature < 3.2e1":               true,
+		"SELECT *":                                         true,
+		"WHERE pressure = -4":                              true,
+		"pressure <> -4":                                   false,
+		"running":                                          true,
+		"running = false":                                  false,
+		"NOT running":                                      false,
+		"mode = 'auto' AND deviceName = \"pump-1\"":        true,
+		"mode IN ('manual', 'off')":                        false,
+		"mode NOT IN ('manual', 'off')":                    true,
+		"meta(deviceName) LIKE 'pump-%'":                   true,
+		"deviceName LIKE 'pump-_'":                         true,
+		"deviceName NOT LIKE 'PUMP%'":                      true,
+		"`Flow-Rate` BETWEEN 10 AND 20":                    true,
+		"`Flow-Rate` NOT BETWEEN 10 AND 20":                false,
+		"meta(site) = 'plant-3' AND meta(floor) = 2":       true,
+		"profileName = 'Pump' AND sourceName = 'status'":   true,
+		"origin > 1000":                                    true,
+		"humidity > 10":                                    false,
+		"NOT humidity > 10":                                true,
+		"humidity IS NULL AND temperature IS NOT NULL":     true,
+		"mode > 3":                                         false,
+		"(temperature > 40 OR pressure < 0) AND running":   true,
+		"temperature > 40 OR pressure < 0 AND NOT running": false,
+		"meta(mode) = 'auto'":                              false,
+		"mode = 'it''s'":                                   false,
+	}
+	for text, expected := range cases {
+		expression, err := Compile(text)
+		if err != nil {
+			t.Errorf("Compile(%q) failed: %v", text, err)
+			continue
+		}
+		if got := expression.Match(event); got != expected {
+			t.Errorf("%q matched %v", text, got)
+		}
+		if expression.String() != text {
+			t.Errorf("String() returned %q, not %q", expression.String(), text)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := map[string]string{
+		"":                            "no condition",
+		"SELECT temperature":          "SELECT *",
+		"SELECT * FROM":               "stream name",
+		"SELECT * demo":               "WHERE",
+		"temperature >":               "at end of filter",
+		"temperature > 30 AND":        "at end of filter",
+		"temperature > 30 30":         "end of filter",
+		"(temperature > 30":           "')'",
+		"mode = 'auto":                "unterminated",
+		"temperature ; 3":             "unexpected",
+		"mode IN ('a', mode)":         "IN lists",
+		"mode LIKE 3":                 "quoted pattern",
+		"mode NOT 3":                  "IN, LIKE or BETWEEN",
+		"mode IS 3":                   "NULL",
+		"temperature BETWEEN 1 OR 2":  "AND",
+		"AND = 3":                     "a name or value",
+		"meta(3) = 1":                 "a name",
+		strings.Repeat("NOT ", 40) + "running":      "nested",
+		"x = '" + strings.Repeat("a", MaxLength) + "'": "longer",
+	}
+	for text, expected := range cases {
+		if _, err := Compile(text); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Compile(%.40q) returned %v, expected %q", text, err, expected)
+		}
+	}
+}
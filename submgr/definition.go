@@ -0,0 +1,191 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package submgr
+
+import (
+	"errors"
+	"slices"
+)
+
+/*
+Struct Definition is what it takes to recreate a subscription, under the same ID, after the
+service restarts: its lists and options, and who it belongs to and may receive.
+*/
+type Definition struct {
+	Id      string              `json:"id"`
+	Include []string            `json:"include"`
+	Exclude []string            `json:"exclude"`
+	Options SubscriptionOptions `json:"options"`
+	// Identity of whoever created the subscription, "" if anonymous
+	Owner string `json:"owner,omitempty"`
+	// Quota the subscription counts against, "" for the default limits
+	Quota string `json:"quota,omitempty"`
+	// Topic prefixes the subscription may receive, nil for any
+	Allowed []string `json:"allowed,omitempty"`
+	// Sequence number its messages may have been numbered up to, with Replay: after a
+	// restart, they are numbered after it, and event IDs up to it are from before
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+/*
+SetChangeHook sets a function called, outside of locks, with the ID of a subscription
+whenever one is created, changed or deleted, or gets a new ID (with the old one and the
+new one), e.g. to persist it. Subscriptions from the configuration don't count. nil for none.
+*/
+func (s *SubscriptionManager) SetChangeHook(hook func(subid string)) {
+	if hook == nil {
+		s.changeHook.Store(nil)
+		return
+	}
+	s.changeHook.Store(&hook)
+}
+
+// changedId (an internal API) calls the change hook for a subscription ID. Call outside of locks.
+func (s *SubscriptionManager) changedId(subid string) {
+	if hook := s.changeHook.Load(); hook != nil && subid != "" {
+		(*hook)(subid)
+	}
+}
+
+// changed (an internal API) calls the change hook for a subscription, unless deleted or static. Call outside of locks.
+func (s *SubscriptionManager) changed(subInfo *SubscriptionInfo) {
+	if s.changeHook.Load() == nil || subInfo == nil {
+		return
+	}
+	subInfo.lock.RLock()
+	subid, static := subInfo.SubId, subInfo.static
+	subInfo.lock.RUnlock()
+	if !static {
+		s.changedId(subid)
+	}
+}
+
+/*
+Definition returns the definition of the subscription with that ID, and true, or false if
+there is no such subscription, or it comes from the configuration.
+*/
+func (s *SubscriptionManager) Definition(subid string) (Definition, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	sub, ok := s.subscriptions[subid]
+	if !ok {
+		return Definition{}, false
+	}
+	sub.lock.RLock()
+	defer sub.lock.RUnlock()
+	if sub.static {
+		return Definition{}, false
+	}
+	sub.retainLock.Lock()
+	seq := sub.reservedSeq
+	sub.retainLock.Unlock()
+	return Definition{
+		Id:      sub.SubId,
+		Include: slices.Clone(sub.includes),
+		Exclude: slices.Clone(sub.excludes),
+		Options: sub.options.Clone(),
+		Owner:   sub.owner,
+		Quota:   sub.quota,
+		Allowed: slices.Clone(sub.allowed),
+		Seq:     seq,
+	}, true
+}
+
+// Definitions returns the definitions of all the subscriptions, but those from the configuration.
+func (s *SubscriptionManager) Definitions() []Definition {
+	sublist := s.AllSubscriptions()
+	rv := make([]Definition, 0, len(sublist))
+	for _, sub := range sublist {
+		if def, ok := s.Definition(s.SubscriptionId(sub)); ok {
+			rv = append(rv, def)
+		}
+	}
+	return rv
+}
+
+/*
+Restore recreates a subscription from its definition, as it was: the lists are taken as
+they are, not coalesced again, and its messages are numbered after the definition's Seq.
+Like a new one, nobody is listening on it, so it is auto-deleted unless someone does within
+the idle expiration. The change hook isn't called.
+
+Error is returned if the ID is in use, its quota is unknown or full, the lists are longer
+than the quota allows, or the options are not valid.
+*/
+func (s *SubscriptionManager) Restore(def Definition) error {
+	if def.Id == "" {
+		return errors.New("subscription ID must not be empty")
+	}
+	compiled, err := compileOptions(def.Options)
+	if err != nil {
+		return err
+	}
+	if err := s.addSubscription(def.Id, false, def.Quota); err != nil {
+		return err
+	}
+	s.lock.RLock()
+	sub := s.subscriptions[def.Id]
+	s.lock.RUnlock()
+	// Set by addSubscription(), never changed
+	if uint(len(def.Include)) > sub.prefixLimit || uint(len(def.Exclude)) > sub.prefixLimit {
+		s.DeleteSubscription(def.Id)
+		return errors.New("include or exclude limit reached")
+	}
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	sub.includes = append(make([]string, 0, len(def.Include)), def.Include...)
+	sub.excludes = append(make([]string, 0, len(def.Exclude)), def.Exclude...)
+	sub.setOptions(def.Options, compiled)
+	s.restartIdle(sub)
+	sub.owner = def.Owner
+	if def.Allowed != nil {
+		sub.allowed = slices.Clone(def.Allowed)
+	}
+	sub.retainLock.Lock()
+	defer sub.retainLock.Unlock()
+	sub.seq = def.Seq
+	sub.reservedSeq = def.Seq
+	sub.restoredSeq = def.Seq
+	return nil
+}
+
+/*
+Replace sets the lists, options, owner and allowed topics of the subscription with the
+definition's ID to the definition's, e.g. as changed on another replica of the service.
+Like Restore(), the lists are taken as they are, and the change hook isn't called. The
+numbering of its messages stays this replica's own.
+
+Error is returned if there is no such subscription, it comes from the configuration, the
+lists are longer than its quota allows, or the options are not valid.
+*/
+func (s *SubscriptionManager) Replace(def Definition) error {
+	compiled, err := compileOptions(def.Options)
+	if err != nil {
+		return err
+	}
+	s.lock.RLock()
+	sub, ok := s.subscriptions[def.Id]
+	s.lock.RUnlock()
+	if !ok {
+		return errors.New("subscription not found")
+	}
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	if sub.static {
+		return errors.New("subscription comes from the configuration")
+	}
+	if uint(len(def.Include)) > sub.prefixLimit || uint(len(def.Exclude)) > sub.prefixLimit {
+		return errors.New("include or exclude limit reached")
+	}
+	sub.includes = append(make([]string, 0, len(def.Include)), def.Include...)
+	sub.excludes = append(make([]string, 0, len(def.Exclude)), def.Exclude...)
+	sub.setOptions(def.Options, compiled)
+	s.restartIdle(sub)
+	sub.owner = def.Owner
+	sub.allowed = slices.Clone(def.Allowed)
+	return nil
+}
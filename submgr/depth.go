@@ -0,0 +1,126 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package submgr
+
+import (
+	"errors"
+	"strings"
+)
+
+/*
+Topic levels an include or exclude entry can end with, to limit how many levels a topic
+may have beneath its prefix: each LevelWildcard is exactly one more (non-empty) level, and
+a final DepthWildcard allows any number after those. So "edgex/events/device/Bacon-Cape/dev1/+"
+is every resource of device dev1, but not topics below them, and ".../dev1/+/#" is
+everything below dev1 but dev1 itself. A prefix ending in DepthWildcard alone is the same as
+the prefix without it.
+*/
+const (
+	LevelWildcard = "+"
+	DepthWildcard = "#"
+)
+
+// Error returned by Include() and Exclude() for an entry with a wildcard anywhere but its last levels
+var ErrWildcardPosition = errors.New("topic wildcards are only allowed as the last levels of an entry, + before #")
+
+/*
+normalizeEntry (an internal API) ends an include or exclude entry with a slash, and drops
+a DepthWildcard directly after its prefix, which changes nothing.
+
+Error is returned if a wildcard is anywhere but at the end, as entryDepth() expects.
+*/
+func normalizeEntry(entry string) (string, error) {
+	endWithSlash(&entry)
+	if entry == DepthWildcard+"/" {
+		return "", nil
+	}
+	prefix, levels, _ := entryDepth(entry)
+	for _, level := range strings.Split(prefix, "/") {
+		if level == LevelWildcard || level == DepthWildcard {
+			return entry, ErrWildcardPosition
+		}
+	}
+	if levels == 0 {
+		return prefix, nil
+	}
+	return entry, nil
+}
+
+// ValidateEntry returns ErrWildcardPosition if an include or exclude entry has a wildcard other than at the end, so Include() and Exclude() would refuse it.
+func ValidateEntry(entry string) error {
+	_, err := normalizeEntry(entry)
+	return err
+}
+
+/*
+entryDepth (an internal API) splits an include or exclude entry, ending with a slash, into
+its prefix and the number of levels a topic must have beneath it, exactly, or at least if
+atLeast. A plain prefix is 0 levels, at least.
+*/
+func entryDepth(entry string) (prefix string, levels int, atLeast bool) {
+	prefix = entry
+	if prefix == DepthWildcard+"/" || strings.HasSuffix(prefix, "/"+DepthWildcard+"/") {
+		prefix = prefix[:len(prefix)-len(DepthWildcard)-1]
+		atLeast = true
+	}
+	for prefix == LevelWildcard+"/" || strings.HasSuffix(prefix, "/"+LevelWildcard+"/") {
+		prefix = prefix[:len(prefix)-len(LevelWildcard)-1]
+		levels++
+	}
+	if levels == 0 {
+		atLeast = true
+	}
+	return prefix, levels, atLeast
+}
+
+/*
+minTopicLength (an internal API) is the length of the shortest topic, ending with a slash,
+an entry can match: the entry's own, as each LevelWildcard stands for at least one
+character, less a final DepthWildcard. Lists are sorted by it, so matching can stop at the
+first entry longer than the topic.
+*/
+func minTopicLength(entry string) int {
+	if entry == DepthWildcard+"/" || strings.HasSuffix(entry, "/"+DepthWildcard+"/") {
+		return len(entry) - len(DepthWildcard) - 1
+	}
+	return len(entry)
+}
+
+// entryMatches (an internal API) returns true if the topic, ending with a slash, is matched by the include or exclude entry.
+func entryMatches(entry string, topic string) bool {
+	prefix, levels, atLeast := entryDepth(entry)
+	if !strings.HasPrefix(topic, prefix) {
+		return false
+	}
+	if levels == 0 {
+		return true
+	}
+	rest := topic[len(prefix):]
+	for i := 0; i < levels; i++ {
+		end := strings.IndexByte(rest, '/')
+		if end <= 0 {
+			return false
+		}
+		rest = rest[end+1:]
+	}
+	return atLeast || rest == ""
+}
+
+/*
+entryCovers (an internal API) returns true if every topic entry other matches is also
+matched by entry, so coalescing can drop other. Only plain prefixes are known to cover
+entries other than themselves.
+*/
+func entryCovers(entry string, other string) bool {
+	if entry == other {
+		return true
+	}
+	if _, levels, _ := entryDepth(entry); levels > 0 {
+		return false
+	}
+	return strings.HasPrefix(other, entry)
+}
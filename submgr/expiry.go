@@ -0,0 +1,125 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package submgr
+
+import (
+	"time"
+)
+
+// Struct ExpiryNotice tells that an idle subscription is about to be aged out, or has been.
+type ExpiryNotice struct {
+	SubId string
+	// The subscription's options, e.g. where to send the notice
+	Options SubscriptionOptions
+	// When it is aged out unless someone listens to it, or when it was; zero if Resumed
+	Expires time.Time
+	// The subscription has been aged out
+	Expired bool
+	// The subscription would have been aged out, and is in its grace window until Expires
+	Grace bool
+	// The subscription was in its grace window, and isn't aged out, as someone uses it again
+	Resumed bool
+}
+
+// Struct expiryNotice is what SetExpiryNotice() set.
+type expiryNotice struct {
+	lead time.Duration
+	hook func(ExpiryNotice)
+}
+
+/*
+SetExpiryNotice has the idle subscription check call hook, outside of locks, when a
+subscription nobody listens to is within lead of being aged out (once per idle period),
+and when it has been aged out, e.g. so its client can recreate it in time. The check runs
+every check interval passed to Init(), so lead should be longer. A nil hook calls nothing.
+*/
+func (s *SubscriptionManager) SetExpiryNotice(lead time.Duration, hook func(ExpiryNotice)) {
+	if hook == nil {
+		s.expiryNotice.Store(nil)
+		return
+	}
+	s.expiryNotice.Store(&expiryNotice{lead: lead, hook: hook})
+}
+
+/*
+getExpiringList (an internal API) returns notices for the idle subscriptions within lead of
+being aged out, that haven't had one for that expiration yet, and marks them as having had it.
+*/
+func (s *SubscriptionManager) getExpiringList(lead time.Duration) []ExpiryNotice {
+	rv := make([]ExpiryNotice, 0)
+	checkTime := time.Now()
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for subid, sub := range s.subscriptions {
+		sub.lock.Lock()
+		if !sub.active && !sub.process && !sub.inGrace && !sub.expiration.IsZero() && !sub.expiration.Equal(sub.noticeFor) && !checkTime.Add(lead).Before(sub.expiration) {
+			sub.noticeFor = sub.expiration
+			rv = append(rv, ExpiryNotice{SubId: subid, Options: sub.options.Clone(), Expires: sub.expiration})
+		}
+		sub.lock.Unlock()
+	}
+	return rv
+}
+
+/*
+SetAgeOutGrace has the idle subscription check keep a subscription nobody listens to for
+grace longer, once its idle expiration passes, before it ages it out: the expiry notice
+hook is called with a Grace notice then, and with a Resumed one if the subscription's
+stream reconnects (or it is otherwise used) in the window, which cancels its deletion. So a
+client that is slow to reconnect doesn't lose its subscription by a few seconds. The check
+runs every check interval passed to Init(), so grace should be at least that. 0 to age out
+subscriptions right away.
+*/
+func (s *SubscriptionManager) SetAgeOutGrace(grace time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ageOutGrace = grace
+}
+
+// endGrace (an internal API) takes a subscription out of its grace window, returning the notice of that, true if it was in one. Call under lock.
+func (sub *SubscriptionInfo) endGrace(subid string) (ExpiryNotice, bool) {
+	if !sub.inGrace {
+		return ExpiryNotice{}, false
+	}
+	sub.inGrace = false
+	return ExpiryNotice{SubId: subid, Options: sub.options.Clone(), Resumed: true}, true
+}
+
+// idleAge (an internal API) returns how long a subscription is kept with nobody listening: its IdleExpiration option, or the manager's. Call under lock.
+func (s *SubscriptionManager) idleAge(sub *SubscriptionInfo) time.Duration {
+	if sub.idleAge > 0 {
+		return sub.idleAge
+	}
+	return s.maxIdleSubscriptionAge
+}
+
+// restartIdle (an internal API) restarts the idle expiration of a subscription nobody listens to, e.g. for a changed IdleExpiration option. Call under lock.
+func (s *SubscriptionManager) restartIdle(sub *SubscriptionInfo) {
+	if !sub.expiration.IsZero() && !sub.inGrace {
+		sub.expiration = time.Now().Add(s.idleAge(sub))
+	}
+}
+
+/*
+ExpiresIn returns how long until a subscription is aged out, unless someone listens to it,
+as of when requests being processed on it are done, as those restart the idle expiration:
+false if someone listens to it, it is from the configuration, or it doesn't exist.
+*/
+func (s *SubscriptionManager) ExpiresIn(subInfo *SubscriptionInfo) (time.Duration, bool) {
+	if subInfo == nil {
+		return 0, false
+	}
+	subInfo.lock.RLock()
+	defer subInfo.lock.RUnlock()
+	switch {
+	case subInfo.active || subInfo.static || subInfo.SubId == "":
+		return 0, false
+	case subInfo.process:
+		return s.idleAge(subInfo), true
+	}
+	return max(time.Until(subInfo.expiration), 0), true
+}
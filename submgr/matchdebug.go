@@ -0,0 +1,134 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package submgr
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Values for MatchDecision.Reason
+const (
+	// An include entry accepted the topic, and no exclude entry rejected it
+	MatchIncluded = "included"
+	// An include entry accepted the topic, but an exclude entry rejected it
+	MatchExcluded = "excluded"
+	// No include entry is a prefix of the topic
+	MatchNoInclude = "no include"
+	// Nobody is receiving on the subscription
+	MatchInactive = "inactive"
+	// The topic is outside the subscription's allowed topics
+	MatchNotAllowed = "not allowed"
+)
+
+/*
+Struct MatchDecision says why SubscribedChannels() did or didn't match one subscription
+to a topic, for troubleshooting include and exclude lists.
+*/
+type MatchDecision struct {
+	SubId string
+	// The topic, as received
+	Topic string
+	// One of the Match... values
+	Reason string
+	// The include entry that accepted the topic, with MatchIncluded and MatchExcluded
+	Include string
+	// The exclude entry that rejected the topic, with MatchExcluded
+	Exclude string
+	// The subscription's include list, with MatchNoInclude
+	Includes []string
+}
+
+// Matched returns whether the subscription gets messages on the topic.
+func (d MatchDecision) Matched() bool {
+	return d.Reason == MatchIncluded
+}
+
+// String describes the decision in a log message.
+func (d MatchDecision) String() string {
+	switch d.Reason {
+	case MatchIncluded:
+		return fmt.Sprintf("Subscription %s matched topic %s by include %q", d.SubId, d.Topic, d.Include)
+	case MatchExcluded:
+		return fmt.Sprintf("Subscription %s did not match topic %s, included by %q but excluded by %q", d.SubId, d.Topic, d.Include, d.Exclude)
+	case MatchNoInclude:
+		return fmt.Sprintf("Subscription %s did not match topic %s, no include is a prefix of it (includes %q)", d.SubId, d.Topic, d.Includes)
+	case MatchInactive:
+		return fmt.Sprintf("Subscription %s did not match topic %s, nobody is receiving on it", d.SubId, d.Topic)
+	default:
+		return fmt.Sprintf("Subscription %s did not match topic %s, it is not allowed", d.SubId, d.Topic)
+	}
+}
+
+// Struct matchDebug is what SetMatchDebug() set.
+type matchDebug struct {
+	hook func(MatchDecision)
+	// Lets through the messages whose decisions are all reported, nil for none
+	sampler *rate.Limiter
+}
+
+/*
+SetMatchDebug has SubscribedChannels() report its match decisions to hook, which is called
+outside of locks, e.g. to log them.
+
+Decisions are reported for every topic checked against subscriptions with the Debug option,
+and for all subscriptions for up to samplesPerMinute messages a minute, spread out over
+the minute. Zero samplesPerMinute reports only for subscriptions with the Debug option,
+and a nil hook reports nothing.
+*/
+func (s *SubscriptionManager) SetMatchDebug(hook func(MatchDecision), samplesPerMinute uint) {
+	if hook == nil {
+		s.matchDebug.Store(nil)
+		return
+	}
+	debug := &matchDebug{hook: hook}
+	if samplesPerMinute > 0 {
+		debug.sampler = rate.NewLimiter(rate.Every(time.Minute/time.Duration(samplesPerMinute)), 1)
+	}
+	s.matchDebug.Store(debug)
+}
+
+// match (an internal API) decides whether the subscription gets messages on topic. Call under sub.lock.
+func (sub *SubscriptionInfo) match(topic string) (reason string, include string, exclude string) {
+	if !sub.active {
+		return MatchInactive, "", ""
+	}
+	if !sub.isAllowed(topic) {
+		return MatchNotAllowed, "", ""
+	}
+	for _, i := range sub.includes {
+		if minTopicLength(i) > len(topic) {
+			// List is sorted by that, once we get here it can't match
+			break
+		}
+		if entryMatches(i, topic) {
+			// Found an include, verify we are not excluded
+			for _, e := range sub.excludes {
+				if minTopicLength(e) > len(topic) {
+					break
+				}
+				if entryMatches(e, topic) {
+					return MatchExcluded, i, e
+				}
+			}
+			return MatchIncluded, i, ""
+		}
+	}
+	return MatchNoInclude, "", ""
+}
+
+// decision (an internal API) is the MatchDecision for match's results. Call under sub.lock.
+func (sub *SubscriptionInfo) decision(topic string, reason string, include string, exclude string) MatchDecision {
+	rv := MatchDecision{SubId: sub.SubId, Topic: topic, Reason: reason, Include: include, Exclude: exclude}
+	if reason == MatchNoInclude {
+		rv.Includes = slices.Clone(sub.includes)
+	}
+	return rv
+}
@@ -0,0 +1,201 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package submgr
+
+import (
+	"errors"
+	"time"
+)
+
+// Errors returned by Acknowledge()
+var (
+	ErrNotAcknowledging = errors.New("subscription does not have the acknowledge option")
+	ErrNotSent          = errors.New("no message with that sequence number was sent yet")
+)
+
+/*
+Struct Retention limits how many of the messages sent to each subscription are kept for
+Replay(), so a client that reconnects can get what it missed. Whichever limit is hit
+first applies. A zero Count disables retention.
+*/
+type Retention struct {
+	// Number of messages kept
+	Count uint
+	// Total size of the messages kept, in bytes
+	Bytes uint
+	// How long a message is kept
+	MaxAge time.Duration
+}
+
+/*
+How many sequence numbers a subscription reserves at a time. More are reserved when half
+are used, so they are persisted before they run out, and the last reserved is never used.
+*/
+const seqReservation = 1000
+
+// Struct retainedMessage is a message kept for Replay(), with when it was sent.
+type retainedMessage struct {
+	msg  ChannelMessage
+	sent time.Time
+}
+
+// size (an internal API) is what a message counts against Retention.Bytes.
+func (m ChannelMessage) size() uint {
+	return uint(len(m.EventType) + len(m.Payload) + len(m.CorrelationID) + len(m.Topic))
+}
+
+/*
+SetRetention sets how many messages subscriptions keep for Replay(). It applies to
+subscriptions created after it is called, so call it right after Init().
+*/
+func (s *SubscriptionManager) SetRetention(retention Retention) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.retention = retention
+}
+
+// retain (an internal API) keeps a sent message, then drops the oldest ones over the limits. Call under retainLock.
+func (sub *SubscriptionInfo) retain(msg ChannelMessage) {
+	now := time.Now()
+	sub.retained = append(sub.retained, retainedMessage{msg: msg, sent: now})
+	sub.retainedBytes += msg.size()
+	sub.pruneRetained(now)
+}
+
+/*
+pruneRetained (an internal API) drops retained messages over the limits, oldest first. With
+the acknowledge option, those acknowledged are dropped, and those that aren't are kept past
+MaxAge, only dropped over the Count and Bytes limits. Call under retainLock.
+*/
+func (sub *SubscriptionInfo) pruneRetained(now time.Time) {
+	drop := 0
+	bytes := sub.retainedBytes
+	for drop < len(sub.retained) {
+		oldest := sub.retained[drop]
+		acknowledged := sub.acking && oldest.msg.Seq <= sub.acked
+		expired := !sub.acking && now.Sub(oldest.sent) > sub.retention.MaxAge
+		if uint(len(sub.retained)-drop) <= sub.retention.Count && bytes <= sub.retention.Bytes && !acknowledged && !expired {
+			break
+		}
+		bytes -= oldest.msg.size()
+		drop++
+	}
+	if drop > 0 {
+		// Copy, so the dropped messages' memory is not held on to by the slice
+		sub.retained = append([]retainedMessage(nil), sub.retained[drop:]...)
+		sub.retainedBytes = bytes
+	}
+}
+
+/*
+reserveSeq (an internal API) reserves more sequence numbers when half of those reserved are
+used. Returns true if it did, for the definition with them to be persisted. Call under retainLock.
+*/
+func (sub *SubscriptionInfo) reserveSeq() bool {
+	if sub.seq+seqReservation/2 <= sub.reservedSeq {
+		return false
+	}
+	sub.reservedSeq = sub.seq + seqReservation
+	return true
+}
+
+/*
+Replay returns the messages kept for a subscription that came after the one with
+sequence number after (the Seq of the last message a client received), oldest first.
+
+They include messages still waiting in the subscription's channel; whoever receives
+from it should skip messages it has already replayed.
+*/
+func (s *SubscriptionManager) Replay(subInfo *SubscriptionInfo, after uint64) []ChannelMessage {
+	if subInfo == nil {
+		return nil
+	}
+	subInfo.retainLock.Lock()
+	defer subInfo.retainLock.Unlock()
+	subInfo.pruneRetained(time.Now())
+	rv := make([]ChannelMessage, 0)
+	for _, retained := range subInfo.retained {
+		if retained.msg.Seq > after {
+			rv = append(rv, retained.msg)
+		}
+	}
+	return rv
+}
+
+/*
+Resume is Replay() for a client reconnecting after the message with sequence number after,
+also returning whether nothing it missed is lost: after was numbered since the service
+started, and no message after it was dropped from those kept. If so, it returns the messages
+after it and after. If not, it returns all the messages kept and the sequence number before
+the first, for the client to start over from after resynchronizing.
+*/
+func (s *SubscriptionManager) Resume(subInfo *SubscriptionInfo, after uint64) ([]ChannelMessage, uint64, bool) {
+	if subInfo == nil {
+		return nil, 0, false
+	}
+	subInfo.retainLock.Lock()
+	defer subInfo.retainLock.Unlock()
+	subInfo.pruneRetained(time.Now())
+	oldest := subInfo.seq + 1
+	if len(subInfo.retained) > 0 {
+		oldest = subInfo.retained[0].msg.Seq
+	}
+	// Numbers below restoredSeq may have been used before the restart, restoredSeq itself
+	// never was, and numbers above seq aren't used yet
+	lost := after < subInfo.restoredSeq || after > subInfo.seq || after+1 < oldest
+	if lost {
+		after = oldest - 1
+	}
+	rv := make([]ChannelMessage, 0)
+	for _, retained := range subInfo.retained {
+		if retained.msg.Seq > after {
+			rv = append(rv, retained.msg)
+		}
+	}
+	return rv, after, !lost
+}
+
+/*
+Acknowledge records that a client of a subscription with the acknowledge option received
+its messages up to and including the one with sequence number seq, so they needn't be sent
+again. Acknowledging fewer than before changes nothing.
+
+Error is returned if the subscription doesn't exist or have the option (ErrNotAcknowledging),
+or seq wasn't sent yet (ErrNotSent).
+*/
+func (s *SubscriptionManager) Acknowledge(subInfo *SubscriptionInfo, seq uint64) error {
+	if subInfo == nil {
+		return errors.New("subscription not found")
+	}
+	subInfo.retainLock.Lock()
+	defer subInfo.retainLock.Unlock()
+	if !subInfo.acking {
+		return ErrNotAcknowledging
+	}
+	if seq > subInfo.seq {
+		return ErrNotSent
+	}
+	if seq > subInfo.acked {
+		subInfo.acked = seq
+		subInfo.pruneRetained(time.Now())
+	}
+	return nil
+}
+
+/*
+Acknowledged returns the sequence number a subscription's messages were acknowledged up to,
+and true, if it has the acknowledge option. A client reconnecting to it is sent those after,
+again if they were sent before, see Resume().
+*/
+func (s *SubscriptionManager) Acknowledged(subInfo *SubscriptionInfo) (uint64, bool) {
+	if subInfo == nil {
+		return 0, false
+	}
+	subInfo.retainLock.Lock()
+	defer subInfo.retainLock.Unlock()
+	return subInfo.acked, subInfo.acking
+}
@@ -0,0 +1,95 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package submgr
+
+import (
+	"errors"
+	"time"
+)
+
+/*
+Struct SubscriptionStats counts what happened to a subscription's messages, across all its
+event streams, since it was created or its stats were last reset.
+*/
+type SubscriptionStats struct {
+	// Put in the subscription's channel, for its event stream
+	Delivered uint64
+	// Not put in the channel, because it was full
+	Dropped uint64
+	// Discarded by its event stream, for waiting longer than its MaxAge option
+	Expired uint64
+	// Size of the payloads delivered
+	Bytes uint64
+	// Event streams opened
+	Connections uint64
+	// When the counting started
+	Since time.Time
+}
+
+// stats (an internal API) returns the subscription's counts since it was created. Call under retainLock.
+func (sub *SubscriptionInfo) stats() SubscriptionStats {
+	return SubscriptionStats{
+		Delivered:   sub.delivered,
+		Dropped:     sub.dropped,
+		Expired:     sub.expired,
+		Bytes:       sub.bytes,
+		Connections: sub.connections,
+	}
+}
+
+/*
+Dropped returns how many of a subscription's messages have been dropped for its channel
+being full since it was created, not reset by ResetStats(), e.g. for an event stream to
+tell its client how many it lost since it last told.
+*/
+func (s *SubscriptionManager) Dropped(subInfo *SubscriptionInfo) uint64 {
+	if subInfo == nil {
+		return 0
+	}
+	subInfo.retainLock.Lock()
+	defer subInfo.retainLock.Unlock()
+	return subInfo.dropped
+}
+
+/*
+Stats returns a subscription's counts since it was created, or since ResetStats().
+
+Error is returned if the subscription does not exist.
+*/
+func (s *SubscriptionManager) Stats(subInfo *SubscriptionInfo) (SubscriptionStats, error) {
+	if subInfo == nil {
+		return SubscriptionStats{}, errors.New("subscription not found")
+	}
+	subInfo.retainLock.Lock()
+	defer subInfo.retainLock.Unlock()
+	rv := subInfo.stats()
+	base := subInfo.statsBase
+	rv.Delivered -= base.Delivered
+	rv.Dropped -= base.Dropped
+	rv.Expired -= base.Expired
+	rv.Bytes -= base.Bytes
+	rv.Connections -= base.Connections
+	rv.Since = base.Since
+	return rv, nil
+}
+
+/*
+ResetStats starts a subscription's Stats() counting from zero again. Throughput() is not
+reset, so summaries of it are not thrown off.
+
+Error is returned if the subscription does not exist.
+*/
+func (s *SubscriptionManager) ResetStats(subInfo *SubscriptionInfo) error {
+	if subInfo == nil {
+		return errors.New("subscription not found")
+	}
+	subInfo.retainLock.Lock()
+	defer subInfo.retainLock.Unlock()
+	subInfo.statsBase = subInfo.stats()
+	subInfo.statsBase.Since = time.Now()
+	return nil
+}
@@ -25,8 +25,12 @@ to all channels returned from the match list above.
 package submgr
 
 import (
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/extension"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/filter"
 	"github.com/edgexfoundry-holding/edgex-sse/token"
 	"errors"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -36,12 +40,56 @@ import (
 
 // Struct ChannelMessage defines the messages to be sent through the managed channels.
 type ChannelMessage struct {
-	// EventType is either "edgex" for EdgeX Events, or "" for anything else.
+	// EventType is "edgex" for EdgeX Events, "simple" for EdgeX Events in the simple format,
+	// "senml" for EdgeX Events as SenML records, "system" for core-metadata system events, "metric" for service telemetry metrics,
+	// "response" for command responses,
+	// "cbor" for base64-wrapped CBOR, "raw" for wrapped payloads that were not JSON or CBOR,
+	// "invalid" for annotated Events that failed validation, "media" for binary readings of Events
+	// delivered on their own, or "" for anything else.
 	EventType string
 	// Payload is the text of the event.
 	Payload string
+	// Seq numbers the messages sent to a subscription, from 1, when it retains them
+	// for Replay(). Zero otherwise. Set by SendHandle.TrySend().
+	Seq uint64
+	// Queued is when the message was put in the subscription's channel. Set by SendHandle.TrySend().
+	Queued time.Time
+	// TraceParent is the W3C trace context of the message's delivery span, "" if not traced.
+	TraceParent string
+	// CorrelationID is that of the message bus message the event came from, "" if unknown.
+	CorrelationID string
+	// Wrap the payload in a DeliveryEnvelope when it is written, once Seq is known, with
+	// the topic the message came on, and when it was received.
+	Delivery   bool
+	Topic      string
+	ReceivedAt time.Time
+	// Write payloads longer than this in chunk events, as the subscription's ChunkSize option says, 0 whole.
+	ChunkSize int
 }
 
+// Values for SubscriptionOptions.Format
+const (
+	FormatEdgex  = dtos.FormatEdgex
+	FormatSimple = dtos.FormatSimple
+	FormatSenML  = dtos.FormatSenML
+)
+
+// Values for SubscriptionOptions.Envelope
+const (
+	EnvelopeNone        = dtos.EnvelopeNone
+	EnvelopeCloudEvents = dtos.EnvelopeCloudEvents
+	EnvelopeDelivery    = dtos.EnvelopeDelivery
+)
+
+// SubscriptionOptions holds the per-subscription delivery options set by clients.
+type SubscriptionOptions = dtos.SubscriptionOptions
+
+// Rounding rounds the numeric readings of Events for a subscription, see SubscriptionOptions.Rounding.
+type Rounding = dtos.Rounding
+
+// DeviceSelector picks the devices whose Events a subscription receives, see SubscriptionOptions.Devices.
+type DeviceSelector = dtos.DeviceSelector
+
 // Struct SubscriptionInfo collects the information we track for each subscription.
 type SubscriptionInfo struct {
 	// Included topic list - access under lock
@@ -56,11 +104,145 @@ type SubscriptionInfo struct {
 	process bool
 	// If active is false, when to auto-delete this subscription? Access under lock
 	expiration time.Time
+	// The expiration an ExpiryNotice was sent for - access under lock
+	noticeFor time.Time
+	// In the grace window after its idle expiration, see SetAgeOutGrace() - access under lock
+	inGrace bool
+	// The IdleExpiration option, zero for the manager's - access under lock
+	idleAge time.Duration
 	lock   *sync.RWMutex
 	// The channel to send events for this subscription
 	channel chan ChannelMessage
 	// if channel is closed, make the flag true
 	IsClosedChan bool
+	// Bumped whenever the subscription is deleted, so stale SendHandles can tell - access under lock
+	generation uint64
+	// Delivery options, and their filter compiled and extensions built, nil if none - access under lock
+	options    SubscriptionOptions
+	filter     *filter.Expression
+	extensions *extension.Chain
+	// Created from configuration, never auto-deleted
+	static bool
+	// Identity of whoever created the subscription, "" if anonymous - access under lock
+	owner string
+	// Topic prefixes the subscription may receive, nil for any - access under lock
+	allowed []string
+	// Name of the quota the subscription counts against, "" for the default limits
+	quota string
+	// Limit on number of items in each of the include and exclude lists
+	prefixLimit uint
+	// Messages kept for Replay(), and their numbering - access under retainLock
+	retainLock    sync.Mutex
+	retention     Retention
+	seq           uint64
+	retained      []retainedMessage
+	retainedBytes uint
+	// Numbering persisted up to, and restored from; lower numbers are from before a restart - access under retainLock
+	reservedSeq   uint64
+	restoredSeq   uint64
+	// The Acknowledge option, and the sequence number acknowledged up to - access under retainLock
+	acking        bool
+	acked         uint64
+	// The MaxAge option, zero for none, and how many messages were discarded for it - access under retainLock
+	maxAge        time.Duration
+	expired       uint64
+	// Most messages ever waiting in the channel - access under retainLock
+	highWater     int
+	// Messages put in the channel, and not for it being full, and when one last was - access under retainLock
+	delivered     uint64
+	dropped       uint64
+	lastDelivered time.Time
+	// Size of the payloads put in the channel, and event streams opened - access under retainLock
+	bytes         uint64
+	connections   uint64
+	// What Stats() counts from, as of its last reset - access under retainLock
+	statsBase     SubscriptionStats
+}
+
+/*
+Struct SendHandle is the send-side of a subscription's channel, as returned by SubscribedChannels().
+
+It remembers the subscription's generation at lookup time. Send() re-checks it under
+the subscription lock, so a subscription deleted between the lookup and the send
+is never written to (and its closed channel never panics the sender).
+*/
+type SendHandle struct {
+	sub        *SubscriptionInfo
+	generation uint64
+	options    SubscriptionOptions
+	filter     *filter.Expression
+	extensions *extension.Chain
+	// For the change hook, when more sequence numbers are reserved
+	manager    *SubscriptionManager
+}
+
+/*
+Options returns the subscription's delivery options as of when the handle was looked up.
+Unlike SubscriptionManager.Options(), for every message, they aren't copied: their lists
+are shared with the subscription, and must not be changed.
+*/
+func (h SendHandle) Options() SubscriptionOptions {
+	return h.options
+}
+
+// Filter returns the subscription's compiled filter option as of when the handle was looked up, nil if none.
+func (h SendHandle) Filter() *filter.Expression {
+	return h.filter
+}
+
+// Extensions returns the subscription's extensions option built as of when the handle was looked up, nil if none.
+func (h SendHandle) Extensions() *extension.Chain {
+	return h.extensions
+}
+
+// SubId returns the ID of the handle's subscription.
+func (h SendHandle) SubId() string {
+	if h.sub == nil {
+		return ""
+	}
+	h.sub.lock.RLock()
+	defer h.sub.lock.RUnlock()
+	return h.sub.SubId
+}
+
+// Struct SubscriptionThroughput counts the messages sent to a subscription since it was created.
+type SubscriptionThroughput struct {
+	SubId string
+	// Put in the subscription's channel, for its event stream
+	Delivered uint64
+	// Not put in the channel, because it was full
+	Dropped uint64
+	// Discarded by its event stream, for waiting longer than its MaxAge option
+	Expired uint64
+}
+
+// Struct Quota limits the subscriptions created under one name, e.g. by the callers in one role.
+type Quota struct {
+	// Number of subscriptions that can exist under the quota at once
+	Subscriptions uint32
+	// Number of entries allowed in each of their include and exclude lists
+	Prefixes uint
+}
+
+// Struct QuotaUsage is how much of a quota is in use.
+type QuotaUsage struct {
+	// Subscriptions that exist under the quota, and how many can at once
+	Subscriptions     uint32
+	SubscriptionLimit uint32
+	// Number of entries allowed in each include and exclude list of its new subscriptions
+	PrefixLimit uint
+}
+
+// Struct TopicActivity is what the topic index records about each topic seen by SubscribedChannels().
+type TopicActivity struct {
+	// The topic, as received
+	Topic string
+	// When a message was last seen on the topic
+	LastSeen time.Time
+	// Number of messages seen on the topic
+	Messages uint64
+	// Total number of subscriptions matched, summed over all messages
+	Matches uint64
 }
 
 /*
@@ -76,18 +258,48 @@ type SubscriptionManager struct {
 	lock             sync.RWMutex
 	// Number of subscriptions - access with atomic functions
 	numSubscriptions uint32
-	// Limit on number of simultaneous subscriptions.
+	// Limit on number of simultaneous subscriptions, not counting those under a quota - access under lock
 	subscriptionLimit uint32
-	// Limit on number of items in a single subscription's include and exclude lists.
+	// Quotas by name, for subscriptions that get other limits - access under lock
+	quotas map[string]Quota
+	// What new subscriptions keep for Replay() - access under lock
+	retention Retention
+	// Limit on number of items in a single subscription's include and exclude lists - access under lock
 	includeExcludeLimit uint
-	// Buffer size of created channels
+	// Buffer size of created channels - access under lock
 	chanBufferSize uint
 	// How long to keep subscriptions around when nobody is listening
 	maxIdleSubscriptionAge time.Duration
+	// How much longer to keep them once that has passed, see SetAgeOutGrace() - access under lock
+	ageOutGrace time.Duration
 	// How often to check for idle subscriptions
 	idleSubscriptionCheckInterval time.Duration
-	// Channel to tell age-out task when to stop
-	stopIdleCheck chan bool
+	// Channel to tell age-out task when to stop, and the one it closes when it has - access under lock
+	stopIdleCheck    chan bool
+	idleCheckStopped chan struct{}
+	// Set by Init(), cleared by Close() - access under lock
+	initialized bool
+	// Topic index, keyed by topic - access under topicLock
+	topics    map[string]*TopicActivity
+	topicLock sync.Mutex
+	// Limit on number of topics in the index, 0 disables the index
+	topicLimit uint
+	// topicLimit isn't 0, checked without topicLock so messages don't contend for it when disabled
+	topicIndexOn atomic.Bool
+	// How long a topic can go without messages before it leaves the index
+	topicExpiration time.Duration
+	// Called (outside of locks) with each topic that leaves the index
+	topicExpiredHook func(topic string)
+	// Where match decisions are reported, nil for nowhere
+	matchDebug atomic.Pointer[matchDebug]
+	// Called with the IDs of subscriptions created, changed or deleted, nil for nothing
+	changeHook atomic.Pointer[func(subid string)]
+	// Asked whether idle subscriptions may be aged out, nil for always
+	expiryHook atomic.Pointer[func(subid string) bool]
+	// Called with the IDs of subscriptions deleted, however they were, nil for nothing
+	deleteHook atomic.Pointer[func(subid string)]
+	// Told about subscriptions about to be aged out, and aged out, nil for nothing
+	expiryNotice atomic.Pointer[expiryNotice]
 }
 
 // Utility functions
@@ -110,7 +322,7 @@ func stringSliceRemove(in *[]string, toRemove string) []string {
 	return rv
 }
 
-// Type byLength contains the methods to sort string slices by string length using Sort().
+// Type byLength contains the methods to sort include and exclude lists by the length of the shortest topic each entry matches using Sort().
 type byLength []string
 
 func (s byLength) Len() int {
@@ -120,7 +332,7 @@ func (s byLength) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
 func (s byLength) Less(i, j int) bool {
-	return len(s[i]) < len(s[j])
+	return minTopicLength(s[i]) < minTopicLength(s[j])
 }
 
 // SubscriptionManager methods
@@ -128,39 +340,168 @@ func (s byLength) Less(i, j int) bool {
 // getAgeOutList (an internal API) returns a list of subscription IDs that
 // have been inactive too long. Is its own function so it can lock then defer unlock - 
 // we cannot delete subscriptions while holding that lock.
-func (s *SubscriptionManager) getAgeOutList() ([]string) {
+// With an age-out grace, those not yet in their grace window are put in it instead,
+// and returned as notices of that.
+func (s *SubscriptionManager) getAgeOutList() ([]string, []ExpiryNotice) {
 	rv := make([]string, 0, atomic.LoadUint32(&s.numSubscriptions))
+	grace := make([]ExpiryNotice, 0)
 	checkTime := time.Now() // gets both wall-clock and monotonic, uses the appropriate one
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 	for subid, sub := range s.subscriptions {
-		sub.lock.RLock()
+		sub.lock.Lock()
 		if (!sub.active) && (!sub.process) && (!sub.expiration.IsZero()) && (checkTime.After(sub.expiration)) {
-			rv = append(rv, subid)
+			if s.ageOutGrace > 0 && !sub.inGrace {
+				sub.inGrace = true
+				sub.expiration = checkTime.Add(s.ageOutGrace)
+				grace = append(grace, ExpiryNotice{SubId: subid, Options: sub.options.Clone(), Expires: sub.expiration, Grace: true})
+			} else {
+				rv = append(rv, subid)
+			}
 		}
-		sub.lock.RUnlock()
+		sub.lock.Unlock()
 	}
-	return rv
+	return rv, grace
 }
 
 // ageOutCheck (an internal API) deletes any subscriptions that have had nobody
-// listening for a while.
+// listening for a while, past their grace window if any, unless the expiry hook says
+// they are still in use, and sends expiry notices.
 func (s *SubscriptionManager) ageOutCheck() {
-	idList := s.getAgeOutList()
+	idList, grace := s.getAgeOutList()
+	hook := s.expiryHook.Load()
+	notice := s.expiryNotice.Load()
+	if notice != nil {
+		for _, inGrace := range grace {
+			notice.hook(inGrace)
+		}
+	}
 	for _, subid := range idList {
+		if hook != nil && !(*hook)(subid) {
+			if resumed, ok := s.extendExpiration(subid); ok && notice != nil {
+				notice.hook(resumed)
+			}
+			continue
+		}
+		options := s.Options(s.Subscription(subid))
 		s.DeleteSubscription(subid)
+		if notice != nil {
+			notice.hook(ExpiryNotice{SubId: subid, Options: options, Expires: time.Now(), Expired: true})
+		}
+	}
+	if notice != nil {
+		for _, expiring := range s.getExpiringList(notice.lead) {
+			notice.hook(expiring)
+		}
+	}
+	s.topicAgeOut()
+}
+
+/*
+extendExpiration (an internal API) gives an idle subscription another idle expiration period.
+Returns the notice that it is out of its grace window, true if it was in one.
+*/
+func (s *SubscriptionManager) extendExpiration(subid string) (ExpiryNotice, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	sub, ok := s.subscriptions[subid]
+	if !ok {
+		return ExpiryNotice{}, false
+	}
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	if !sub.active && !sub.process && !sub.expiration.IsZero() {
+		sub.expiration = time.Now().Add(s.idleAge(sub))
+	}
+	return sub.endGrace(subid)
+}
+
+/*
+SetExpiryHook sets a function asked, outside of locks, whether an idle subscription may be
+aged out, e.g. as it isn't listened to on another replica of the service either. If it
+returns false, the subscription gets another idle expiration period. nil to always age out.
+*/
+func (s *SubscriptionManager) SetExpiryHook(hook func(subid string) bool) {
+	if hook == nil {
+		s.expiryHook.Store(nil)
+		return
+	}
+	s.expiryHook.Store(&hook)
+}
+
+/*
+SetDeleteHook sets a function called, outside of locks, with the ID of each subscription
+deleted, whether through DeleteSubscription() or aged out, e.g. to forget what is kept
+about it elsewhere. It isn't called for the old ID of a rotated subscription, or when the
+manager is closed. nil for none.
+*/
+func (s *SubscriptionManager) SetDeleteHook(hook func(subid string)) {
+	if hook == nil {
+		s.deleteHook.Store(nil)
+		return
+	}
+	s.deleteHook.Store(&hook)
+}
+
+// recordTopic (an internal API) updates the topic index for one message matching numMatches subscriptions.
+func (s *SubscriptionManager) recordTopic(topic string, numMatches int) {
+	if !s.topicIndexOn.Load() {
+		return
+	}
+	s.topicLock.Lock()
+	defer s.topicLock.Unlock()
+	if s.topicLimit == 0 {
+		return
+	}
+	entry, ok := s.topics[topic]
+	if !ok {
+		if uint(len(s.topics)) >= s.topicLimit {
+			return
+		}
+		entry = &TopicActivity{Topic: topic}
+		s.topics[topic] = entry
+	}
+	entry.LastSeen = time.Now()
+	entry.Messages++
+	entry.Matches += uint64(numMatches)
+}
+
+// topicAgeOut (an internal API) removes topics that have been quiet too long, then calls the hook for each.
+func (s *SubscriptionManager) topicAgeOut() {
+	expired := make([]string, 0)
+	s.topicLock.Lock()
+	hook := s.topicExpiredHook
+	if s.topicExpiration > 0 {
+		checkTime := time.Now()
+		for topic, entry := range s.topics {
+			if checkTime.Sub(entry.LastSeen) > s.topicExpiration {
+				expired = append(expired, topic)
+				delete(s.topics, topic)
+			}
+		}
+	}
+	s.topicLock.Unlock()
+	if hook != nil {
+		for _, topic := range expired {
+			hook(topic)
+		}
 	}
 }
 
-// ageOutTask (an internal API) runs in the background to periodically ageOutCheck().
-func (s *SubscriptionManager) ageOutTask() {
-	ticker := time.NewTicker(s.idleSubscriptionCheckInterval)
+/*
+ageOutTask (an internal API) runs in the background to ageOutCheck() every interval, until
+told to stop on stop, then closes stopped. They are passed in, as a later Init() replaces
+the manager's.
+*/
+func (s *SubscriptionManager) ageOutTask(interval time.Duration, stop <-chan bool, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
 			s.ageOutCheck()
-		case <-s.stopIdleCheck:
-			ticker.Stop()
+		case <-stop:
 			return
 		}
 	}
@@ -176,31 +517,60 @@ and starts a background task to prune inactive subscriptions.
   inexclimit: Number of simultaneous entries allowed in each subscription's include
   and exclude topic lists (the limit applies separately to each list).
   bufsize: Number of messages buffered on each channel. This is a balance between memory
-  usage and blocking at high event volumes.
+  usage and dropping messages at high event volumes: sends don't block, so messages for a
+  subscription whose buffer is full are dropped (see SendHandle.Send()).
   maxage: How long a subscription can have nobody listening before it is auto-deleted.
   checkinterval: How often to check for auto-deletion.
+
+Error is returned, and nothing is started, if any limit or interval is zero,
+or if the manager is already initialized (Close() it first).
 */
-func (s *SubscriptionManager) Init(sublimit uint32, incexclimit uint, bufsize uint, maxage time.Duration, checkinterval time.Duration) {
+func (s *SubscriptionManager) Init(sublimit uint32, incexclimit uint, bufsize uint, maxage time.Duration, checkinterval time.Duration) error {
+	if sublimit == 0 || incexclimit == 0 || bufsize == 0 {
+		return errors.New("subscription, include/exclude and buffer limits must be greater than zero")
+	}
+	if maxage <= 0 || checkinterval <= 0 {
+		return errors.New("idle expiration and check interval must be greater than zero")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.initialized {
+		return errors.New("subscription manager already initialized")
+	}
 	s.subscriptions = make(map[string]*SubscriptionInfo)
 	s.subscriptionList = make([]*SubscriptionInfo, 0)
 	s.subscriptionLimit = sublimit
+	s.quotas = make(map[string]Quota)
 	s.includeExcludeLimit = incexclimit
 	s.chanBufferSize = bufsize
 	s.maxIdleSubscriptionAge = maxage
 	s.idleSubscriptionCheckInterval = checkinterval
 	s.stopIdleCheck = make(chan bool, 2)
-	go s.ageOutTask()
+	s.idleCheckStopped = make(chan struct{})
+	s.topicLock.Lock()
+	s.topics = make(map[string]*TopicActivity)
+	s.topicLock.Unlock()
+	s.initialized = true
+	go s.ageOutTask(checkinterval, s.stopIdleCheck, s.idleCheckStopped)
+	return nil
 }
 
 /*
 Close stops SubscriptionManager.
 
-The age-out task is stopped, and all subscriptions are deleted.
+The age-out task is stopped, and waited for, and all subscriptions are deleted.
 */
 func (s *SubscriptionManager) Close() {
-	s.stopIdleCheck <- true
 	s.lock.Lock()
+	if !s.initialized {
+		s.lock.Unlock()
+		return
+	}
+	// Waited for once the lock, which an age-out check in progress needs, is released
+	defer func(stopped <-chan struct{}) { <-stopped }(s.idleCheckStopped)
 	defer s.lock.Unlock()
+	s.stopIdleCheck <- true
+	s.initialized = false
 	for _, sub := range s.subscriptionList {
 		sub.lock.Lock()
 		defer sub.lock.Unlock()
@@ -209,17 +579,81 @@ func (s *SubscriptionManager) Close() {
 		close(sub.channel)
 		sub.IsClosedChan = true
 		sub.SubId = ""
+		sub.generation++
 	}
 	s.subscriptionList = make([]*SubscriptionInfo, 0)
 	s.subscriptions = make(map[string]*SubscriptionInfo)
 	atomic.StoreUint32(&s.numSubscriptions, 0)
 }
 
+/*
+SetTopicIndex configures the topic activity index.
+
+  limit: Maximum number of topics tracked; topics seen once the index is full are not tracked.
+  Zero disables the index.
+  expiration: How long a topic can go without messages before it is removed from the index.
+  Zero means topics are never removed.
+  hook: If not nil, called with each topic removed from the index, e.g. so data kept
+  per topic elsewhere can be cleaned up when a device disappears.
+
+The index starts out disabled.
+*/
+func (s *SubscriptionManager) SetTopicIndex(limit uint, expiration time.Duration, hook func(topic string)) {
+	s.topicLock.Lock()
+	defer s.topicLock.Unlock()
+	s.topicLimit = limit
+	s.topicIndexOn.Store(limit != 0)
+	s.topicExpiration = expiration
+	s.topicExpiredHook = hook
+	if s.topics == nil {
+		s.topics = make(map[string]*TopicActivity)
+	}
+}
+
+// Topics returns a copy of the topic index, sorted by topic.
+func (s *SubscriptionManager) Topics() []TopicActivity {
+	s.topicLock.Lock()
+	rv := make([]TopicActivity, 0, len(s.topics))
+	for _, entry := range s.topics {
+		rv = append(rv, *entry)
+	}
+	s.topicLock.Unlock()
+	sort.Slice(rv, func(i, j int) bool { return rv[i].Topic < rv[j].Topic })
+	return rv
+}
+
 // NumSubscriptions returns the current number of subscriptions (with proper locking).
 func (s *SubscriptionManager) NumSubscriptions() uint32 {
 	return atomic.LoadUint32(&s.numSubscriptions)
 }
 
+// ChannelHighWater returns the most messages any current subscription has had waiting in its channel.
+func (s *SubscriptionManager) ChannelHighWater() int {
+	rv := 0
+	for _, sub := range s.AllSubscriptions() {
+		sub.retainLock.Lock()
+		rv = max(rv, sub.highWater)
+		sub.retainLock.Unlock()
+	}
+	return rv
+}
+
+// Throughput returns the message counts of all the current subscriptions.
+func (s *SubscriptionManager) Throughput() []SubscriptionThroughput {
+	sublist := s.AllSubscriptions()
+	rv := make([]SubscriptionThroughput, 0, len(sublist))
+	for _, sub := range sublist {
+		sub.lock.RLock()
+		entry := SubscriptionThroughput{SubId: sub.SubId}
+		sub.retainLock.Lock()
+		entry.Delivered, entry.Dropped, entry.Expired = sub.delivered, sub.dropped, sub.expired
+		sub.retainLock.Unlock()
+		sub.lock.RUnlock()
+		rv = append(rv, entry)
+	}
+	return rv
+}
+
 /*
 NewSubscription creates a new subscription and associated channel, subscribed to nothing.
 
@@ -228,30 +662,161 @@ that subscription. Error is returned instead if the limit is reached,
 or if there is a problem generating the ID.
 */
 func (s *SubscriptionManager) NewSubscription() (string, error) {
-	current_num := atomic.LoadUint32(&s.numSubscriptions)
-	if current_num >= s.subscriptionLimit {
-		return "", errors.New("subscription limit reached")
-	}
+	return s.NewSubscriptionWithQuota("")
+}
+
+/*
+NewSubscriptionWithQuota creates a subscription like NewSubscription(), but counting against
+the named quota, and with its limit on include and exclude list entries, rather than the
+limits passed to Init(). An empty name means those limits.
+
+Error is returned if the quota is unknown, or its limit is reached.
+*/
+func (s *SubscriptionManager) NewSubscriptionWithQuota(quota string) (string, error) {
 	newid, err := token.GenerateToken()
 	if err != nil {
 		return "", err
 	}
+	if err := s.addSubscription(newid, false, quota); err != nil {
+		return "", err
+	}
+	s.changedId(newid)
+	return newid, nil
+}
+
+/*
+SetLimits replaces the limits passed to Init(), e.g. as the load changes. Subscriptions
+created after get the new include and exclude limit and buffer size, the ones that exist
+keep theirs; those already past a lowered subscription limit are not deleted.
+
+Error is returned if any limit is zero.
+*/
+func (s *SubscriptionManager) SetLimits(sublimit uint32, incexclimit uint, bufsize uint) error {
+	if sublimit == 0 || incexclimit == 0 || bufsize == 0 {
+		return errors.New("subscription, include/exclude and buffer limits must be greater than zero")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.subscriptionLimit = sublimit
+	s.includeExcludeLimit = incexclimit
+	s.chanBufferSize = bufsize
+	return nil
+}
+
+// Limits returns the subscription, include/exclude and buffer limits, as passed to Init() or SetLimits().
+func (s *SubscriptionManager) Limits() (uint32, uint, uint) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.subscriptionLimit, s.includeExcludeLimit, s.chanBufferSize
+}
+
+/*
+SetQuota adds or replaces a named quota for NewSubscriptionWithQuota(). Subscriptions
+already created under it keep their include and exclude limit.
+
+Error is returned if the name is empty, or a limit is zero.
+*/
+func (s *SubscriptionManager) SetQuota(name string, quota Quota) error {
+	if name == "" {
+		return errors.New("quota name must not be empty")
+	}
+	if quota.Subscriptions == 0 || quota.Prefixes == 0 {
+		return errors.New("quota limits must be greater than zero")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.quotas[name] = quota
+	return nil
+}
+
+/*
+NewStaticSubscription creates a subscription like NewSubscription(), but with the given ID,
+so clients can rely on it being the same every time the service starts. Static subscriptions
+are never auto-deleted for having nobody listening.
+
+Error is returned if the limit is reached, or the ID is already in use.
+*/
+func (s *SubscriptionManager) NewStaticSubscription(subid string) error {
+	if subid == "" {
+		return errors.New("subscription ID must not be empty")
+	}
+	return s.addSubscription(subid, true, "")
+}
+
+/*
+Usage returns how much of the named quota is in use, as counted against by
+NewSubscriptionWithQuota(). An empty name means the limits passed to Init().
+
+Error is returned if the quota is unknown.
+*/
+func (s *SubscriptionManager) Usage(quota string) (QuotaUsage, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	limit, err := s.quotaLimit(quota)
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+	return QuotaUsage{Subscriptions: s.quotaCount(quota), SubscriptionLimit: limit.Subscriptions, PrefixLimit: limit.Prefixes}, nil
+}
+
+// quotaLimit (an internal API) returns the limits of the named quota. Call under lock.
+func (s *SubscriptionManager) quotaLimit(quota string) (Quota, error) {
+	if quota == "" {
+		return Quota{Subscriptions: s.subscriptionLimit, Prefixes: s.includeExcludeLimit}, nil
+	}
+	limit, ok := s.quotas[quota]
+	if !ok {
+		return limit, errors.New("unknown quota " + quota)
+	}
+	return limit, nil
+}
+
+// quotaCount (an internal API) returns the number of subscriptions under the named quota. Call under lock.
+func (s *SubscriptionManager) quotaCount(quota string) uint32 {
+	var count uint32
+	for _, sub := range s.subscriptionList {
+		if sub.quota == quota {
+			count++
+		}
+	}
+	return count
+}
+
+// addSubscription (an internal API) creates a subscription with the given ID, counting against the named quota.
+func (s *SubscriptionManager) addSubscription(subid string, static bool, quota string) error {
 	newsub := new(SubscriptionInfo)
-	newsub.SubId = newid
+	newsub.SubId = subid
 	newsub.includes = make([]string, 0)
 	newsub.excludes = make([]string, 0)
 	newsub.active = false
 	newsub.process = false
-	newsub.channel = make(chan ChannelMessage, s.chanBufferSize)
+	newsub.static = static
+	newsub.quota = quota
 	newsub.IsClosedChan = false
-	newsub.expiration = time.Now().Add(s.maxIdleSubscriptionAge)
+	if !static {
+		newsub.expiration = time.Now().Add(s.maxIdleSubscriptionAge)
+	}
 	newsub.lock = new(sync.RWMutex)
+	newsub.statsBase.Since = time.Now()
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	s.subscriptions[newid] = newsub
+	limit, err := s.quotaLimit(quota)
+	if err != nil {
+		return err
+	}
+	if s.quotaCount(quota) >= limit.Subscriptions {
+		return ErrSubscriptionLimit
+	}
+	newsub.prefixLimit = limit.Prefixes
+	newsub.channel = make(chan ChannelMessage, s.chanBufferSize)
+	newsub.retention = s.retention
+	if _, ok := s.subscriptions[subid]; ok {
+		return errors.New("subscription ID already in use")
+	}
+	s.subscriptions[subid] = newsub
 	s.subscriptionList = append(s.subscriptionList, newsub)
 	atomic.AddUint32(&s.numSubscriptions, 1)
-	return newid, nil
+	return nil
 }
 
 /*
@@ -262,11 +827,21 @@ The associated channel is closed.
 No status is returned. If the subscription does not exist, no action is taken.
 */
 func (s *SubscriptionManager) DeleteSubscription(subid string) {
+	defer s.changedId(subid)
+	if s.deleteSubscription(subid) {
+		if hook := s.deleteHook.Load(); hook != nil {
+			(*hook)(subid)
+		}
+	}
+}
+
+// deleteSubscription (an internal API) is DeleteSubscription(), returning false if there was no such subscription.
+func (s *SubscriptionManager) deleteSubscription(subid string) bool {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	sub, ok := s.subscriptions[subid]
 	if !ok {
-		return
+		return false
 	}
 	sub.lock.Lock()
 	defer sub.lock.Unlock()
@@ -275,6 +850,11 @@ func (s *SubscriptionManager) DeleteSubscription(subid string) {
 	sub.SubId = ""
 	close(sub.channel)
 	sub.IsClosedChan = true
+	sub.generation++
+	sub.retainLock.Lock()
+	sub.retained = nil
+	sub.retainedBytes = 0
+	sub.retainLock.Unlock()
 	delete(s.subscriptions, subid)
 	newsublist := make([]*SubscriptionInfo, 0, len(s.subscriptionList))
 	for _, s := range s.subscriptionList {
@@ -284,6 +864,54 @@ func (s *SubscriptionManager) DeleteSubscription(subid string) {
 	}
 	s.subscriptionList = newsublist
 	atomic.StoreUint32(&s.numSubscriptions, uint32(len(s.subscriptions)))
+	return true
+}
+
+/*
+RotateSubscription gives a subscription a new randomly-generated ID, keeping its lists and
+options, and returns it. The old ID stops working. The subscription's channel is closed and
+replaced, ending any stream reading from it, so the client has to reconnect with the new ID.
+
+Error is returned if the subscription does not exist, or there is a problem generating the ID.
+*/
+func (s *SubscriptionManager) RotateSubscription(subid string) (string, error) {
+	newid, err := token.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.rotateSubscription(subid, newid); err != nil {
+		return "", err
+	}
+	s.changedId(subid)
+	s.changedId(newid)
+	return newid, nil
+}
+
+// rotateSubscription (an internal API) is RotateSubscription(), to the given new ID.
+func (s *SubscriptionManager) rotateSubscription(subid string, newid string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	sub, ok := s.subscriptions[subid]
+	if !ok {
+		return errors.New("subscription not found")
+	}
+	if sub.static {
+		return errors.New("subscriptions from the configuration keep their ID")
+	}
+	if _, ok := s.subscriptions[newid]; ok {
+		return errors.New("subscription ID already in use")
+	}
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	close(sub.channel)
+	// The buffer it was created with, even if the EventBuffer limit changed since
+	sub.channel = make(chan ChannelMessage, cap(sub.channel))
+	// Handles looked up under the old ID must not send to the new channel
+	sub.generation++
+	sub.SubId = newid
+	delete(s.subscriptions, subid)
+	s.subscriptions[newid] = sub
+	return nil
 }
 
 // subscription (an internal API) returns a pointer to that subscription's information structure.
@@ -297,11 +925,14 @@ func (s *SubscriptionManager) Subscription(subid string) *SubscriptionInfo {
 	return rv
 }
 
-// allSubscriptions (an internal API) returns pointers to all the subscriptions' information structures.
+/*
+AllSubscriptions returns pointers to all the subscriptions' information structures, in a
+list of the caller's own: the manager's changes as subscriptions come and go.
+*/
 func (s *SubscriptionManager) AllSubscriptions() []*SubscriptionInfo {
-	s.lock.Lock()
-	defer s.lock.Unlock()
-	return s.subscriptionList
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return slices.Clone(s.subscriptionList)
 }
 
 // Whenever subscription is deleted, subscription string of subscription info is set to empty.
@@ -328,6 +959,13 @@ func (s *SubscriptionManager) IsChannelClosed(subInfo *SubscriptionInfo) bool {
 	return false
 }
 
+// IsActive returns true if someone is listening on the subscription's event stream.
+func (s *SubscriptionManager) IsActive(subInfo *SubscriptionInfo) bool {
+	subInfo.lock.RLock()
+	defer subInfo.lock.RUnlock()
+	return subInfo.active
+}
+
 /*
 SubscriptionInfo returns a subscription's include/exclude lists.
 
@@ -341,17 +979,189 @@ func (s *SubscriptionManager) SubscriptionInfo(subInfo *SubscriptionInfo) ([]str
 	}
 	subInfo.lock.Lock()
 	defer subInfo.lock.Unlock()
-	includes = subInfo.includes
-	excludes = subInfo.excludes
+	// Copies, the subscription's are changed in place
+	includes = append(includes, subInfo.includes...)
+	excludes = append(excludes, subInfo.excludes...)
 	return includes, excludes, true
 }
 
+// PrefixLimit returns the number of entries allowed in each of a subscription's include and exclude lists, 0 for a nil subscription.
+func (s *SubscriptionManager) PrefixLimit(subInfo *SubscriptionInfo) uint {
+	if subInfo == nil {
+		return 0
+	}
+	return subInfo.prefixLimit
+}
+
+// Options returns a copy of a subscription's delivery options. A nil subscription has the default options.
+func (s *SubscriptionManager) Options(subInfo *SubscriptionInfo) SubscriptionOptions {
+	if subInfo == nil {
+		return SubscriptionOptions{}
+	}
+	subInfo.lock.RLock()
+	defer subInfo.lock.RUnlock()
+	return subInfo.options.Clone()
+}
+
+// Owner returns the identity of whoever created the subscription, or "" if not known.
+func (s *SubscriptionManager) Owner(subInfo *SubscriptionInfo) string {
+	if subInfo == nil {
+		return ""
+	}
+	subInfo.lock.RLock()
+	defer subInfo.lock.RUnlock()
+	return subInfo.owner
+}
+
+// SubscriptionId returns the subscription's current ID, "" once deleted.
+func (s *SubscriptionManager) SubscriptionId(subInfo *SubscriptionInfo) string {
+	if subInfo == nil {
+		return ""
+	}
+	subInfo.lock.RLock()
+	defer subInfo.lock.RUnlock()
+	return subInfo.SubId
+}
+
+// ChannelDepth returns the number of messages waiting in the subscription's channel to be sent.
+func (s *SubscriptionManager) ChannelDepth(subInfo *SubscriptionInfo) int {
+	if subInfo == nil {
+		return 0
+	}
+	subInfo.lock.RLock()
+	defer subInfo.lock.RUnlock()
+	return len(subInfo.channel)
+}
+
+// ChannelSize returns the number of messages that can wait in the subscription's channel before more are dropped.
+func (s *SubscriptionManager) ChannelSize(subInfo *SubscriptionInfo) int {
+	if subInfo == nil {
+		return 0
+	}
+	subInfo.lock.RLock()
+	defer subInfo.lock.RUnlock()
+	return cap(subInfo.channel)
+}
+
+/*
+Expired reports whether a message waited longer to be sent than the subscription's MaxAge
+option allows, counting it if so, for its event stream to discard it instead of sending it.
+*/
+func (s *SubscriptionManager) Expired(subInfo *SubscriptionInfo, msg ChannelMessage) bool {
+	if subInfo == nil || msg.Queued.IsZero() {
+		return false
+	}
+	subInfo.retainLock.Lock()
+	defer subInfo.retainLock.Unlock()
+	if subInfo.maxAge <= 0 || time.Since(msg.Queued) <= subInfo.maxAge {
+		return false
+	}
+	subInfo.expired++
+	return true
+}
+
+// LastDelivered returns when a message was last put in the subscription's channel, zero if never.
+func (s *SubscriptionManager) LastDelivered(subInfo *SubscriptionInfo) time.Time {
+	if subInfo == nil {
+		return time.Time{}
+	}
+	subInfo.retainLock.Lock()
+	defer subInfo.retainLock.Unlock()
+	return subInfo.lastDelivered
+}
+
+/*
+BufferSaturation returns how many subscriptions have an event stream reading them, and
+how many of those have a full channel buffer, so new messages for them are dropped.
+*/
+func (s *SubscriptionManager) BufferSaturation() (streaming int, full int) {
+	for _, sub := range s.AllSubscriptions() {
+		sub.lock.RLock()
+		if sub.active && !sub.IsClosedChan {
+			streaming++
+			if len(sub.channel) == cap(sub.channel) {
+				full++
+			}
+		}
+		sub.lock.RUnlock()
+	}
+	return streaming, full
+}
+
+// SetOwner records the identity of whoever created the subscription.
+func (s *SubscriptionManager) SetOwner(subInfo *SubscriptionInfo, owner string) {
+	if subInfo == nil {
+		return
+	}
+	defer s.changed(subInfo)
+	subInfo.lock.Lock()
+	defer subInfo.lock.Unlock()
+	subInfo.owner = owner
+}
+
+/*
+SetOptions replaces a subscription's delivery options.
+
+Error is returned if the subscription does not exist, or the options are not valid.
+*/
+func (s *SubscriptionManager) SetOptions(subInfo *SubscriptionInfo, options SubscriptionOptions) error {
+	if subInfo == nil {
+		return errors.New("subscription not found")
+	}
+	compiled, err := compileOptions(options)
+	if err != nil {
+		return err
+	}
+	defer s.changed(subInfo)
+	subInfo.lock.Lock()
+	defer subInfo.lock.Unlock()
+	subInfo.setOptions(options, compiled)
+	s.restartIdle(subInfo)
+	return nil
+}
+
+// setOptions (an internal API) sets a subscription's options, and their compiled filter and extensions. Call under lock.
+func (sub *SubscriptionInfo) setOptions(options SubscriptionOptions, compiled compiledOptions) {
+	sub.options = options.Clone()
+	sub.filter = compiled.filter
+	sub.extensions = compiled.extensions
+	sub.retainLock.Lock()
+	defer sub.retainLock.Unlock()
+	sub.acking = options.Acknowledge
+	// Validated already
+	sub.maxAge, _ = time.ParseDuration(options.MaxAge)
+	sub.idleAge, _ = time.ParseDuration(options.IdleExpiration)
+	sub.pruneRetained(time.Now())
+}
+
+// Struct compiledOptions is what compileOptions() makes of options.
+type compiledOptions struct {
+	filter     *filter.Expression
+	extensions *extension.Chain
+}
+
+// compileOptions (an internal API) validates options, and returns their filter compiled and extensions built, nil if none.
+func compileOptions(options SubscriptionOptions) (compiledOptions, error) {
+	var rv compiledOptions
+	if err := options.Validate(); err != nil {
+		return rv, err
+	}
+	var err error
+	if options.Filter != "" {
+		if rv.filter, err = filter.Compile(options.Filter); err != nil {
+			return rv, err
+		}
+	}
+	rv.extensions, err = extension.Build(options.Extensions)
+	return rv, err
+}
+
 /*
 ReceiveChannel returns the receive-end of a subscription's channel.
 
 Error is returned if the subscription does not exist.
 
-The send-end of the channel is returned by GetSubscribedChannels() for
+The send-end of the channel is returned by SubscribedChannels() for
 topics that meet the subscription's include/exclude criteria.
 */
 func (s *SubscriptionManager) ReceiveChannel(subInfo *SubscriptionInfo) (<-chan ChannelMessage, error) {
@@ -366,12 +1176,16 @@ func (s *SubscriptionManager) ReceiveChannel(subInfo *SubscriptionInfo) (<-chan
 /*
 Include adds a topic prefix to a subscription's include list.
 
-Error is returned if the subscription ID does not exist, or if the
-limit on number of include/exclude list entries is reached.
+Error is returned if the subscription ID does not exist, if the
+limit on number of include/exclude list entries is reached,
+ErrTopicNotAllowed if the prefix is outside the subscription's allowed topics,
+or ErrWildcardPosition if it has a wildcard other than at the end.
 
 Entries are coalesced - a prefix replaces all other include-list entries
 that it "covers" (entries that begin with the new prefix). If a prefix
 is given that is in the exclude list, that exclude-list entry is removed.
+Entries ending in LevelWildcard or DepthWildcard levels only match topics
+with that many levels after their prefix, and only cover themselves.
 
 An include-list entry of "" (empty string) covers everything.
 */
@@ -379,7 +1193,11 @@ func (s *SubscriptionManager) Include(subInfo *SubscriptionInfo, topicPrefix str
 	if subInfo == nil {
 		return errors.New("subscription not found")
 	}
-	endWithSlash(&topicPrefix)
+	topicPrefix, err := normalizeEntry(topicPrefix)
+	if err != nil {
+		return err
+	}
+	defer s.changed(subInfo)
 	// Coalescence: If this exact prefix is in the exclude list, just remove it
 	subInfo.lock.Lock()
 	defer subInfo.lock.Unlock()
@@ -390,13 +1208,16 @@ func (s *SubscriptionManager) Include(subInfo *SubscriptionInfo, topicPrefix str
 			return nil
 		}
 	}
+	if !subInfo.isAllowed(topicPrefix) {
+		return ErrTopicNotAllowed
+	}
 	// If this "covers" entries in the include list, remove them and replace with this
 	includesToRemove := make([]string, 0)
 	for _, i := range subInfo.includes {
 		if i == topicPrefix {
 			return nil // already present
 		}
-		if strings.HasPrefix(i, topicPrefix) {
+		if entryCovers(topicPrefix, i) {
 			includesToRemove = append(includesToRemove, i)
 		}
 	}
@@ -404,7 +1225,7 @@ func (s *SubscriptionManager) Include(subInfo *SubscriptionInfo, topicPrefix str
 	for _, i := range includesToRemove {
 		subInfo.includes = stringSliceRemove(&subInfo.includes, i)
 	}
-	if len(subInfo.includes) >= int(s.includeExcludeLimit) {
+	if len(subInfo.includes) >= int(subInfo.prefixLimit) {
 		return errors.New("include limit reached")
 	}
 	subInfo.includes = append(subInfo.includes, topicPrefix)
@@ -412,21 +1233,92 @@ func (s *SubscriptionManager) Include(subInfo *SubscriptionInfo, topicPrefix str
 	return nil
 }
 
+/*
+RemoveExclude takes a topic prefix off a subscription's exclude list, if it is there, without
+the checks of Include(), e.g. so a PUT can drop excludes outside the allowed topics.
+
+Error is returned if the subscription ID does not exist, or ErrWildcardPosition
+if the prefix has a wildcard other than at the end.
+*/
+func (s *SubscriptionManager) RemoveExclude(subInfo *SubscriptionInfo, topicPrefix string) error {
+	if subInfo == nil {
+		return errors.New("subscription not found")
+	}
+	topicPrefix, err := normalizeEntry(topicPrefix)
+	if err != nil {
+		return err
+	}
+	defer s.changed(subInfo)
+	subInfo.lock.Lock()
+	defer subInfo.lock.Unlock()
+	if slices.Contains(subInfo.excludes, topicPrefix) {
+		subInfo.excludes = stringSliceRemove(&subInfo.excludes, topicPrefix)
+	}
+	return nil
+}
+
+/*
+Conflict returns the first topic prefix that is in both includes and excludes, or that is
+in the subscription's other list, so that Include() or Exclude() would take it off that list
+rather than add it, and "" if there is none: what coalescing would change silently.
+*/
+func (s *SubscriptionManager) Conflict(subInfo *SubscriptionInfo, includes []string, excludes []string) string {
+	opposite := func(list []string) map[string]bool {
+		rv := make(map[string]bool, len(list))
+		for _, prefix := range list {
+			prefix, _ = normalizeEntry(prefix)
+			rv[prefix] = true
+		}
+		return rv
+	}
+	excluding := opposite(excludes)
+	including := opposite(includes)
+	if subInfo != nil {
+		subInfo.lock.RLock()
+		for _, e := range subInfo.excludes {
+			excluding[e] = true
+		}
+		for _, i := range subInfo.includes {
+			including[i] = true
+		}
+		subInfo.lock.RUnlock()
+	}
+	for _, prefix := range includes {
+		prefix, _ = normalizeEntry(prefix)
+		if excluding[prefix] {
+			return prefix
+		}
+	}
+	for _, prefix := range excludes {
+		prefix, _ = normalizeEntry(prefix)
+		if including[prefix] {
+			return prefix
+		}
+	}
+	return ""
+}
+
 /*
 Exclude adds a topic prefix to a subscription's exclude list.
 
-Error is returned if the subscription ID does not exist, or if the
-limit on number of include/exclude list entries is reached.
+Error is returned if the subscription ID does not exist, if the
+limit on number of include/exclude list entries is reached, or
+ErrWildcardPosition if the prefix has a wildcard other than at the end.
 
 Entries are coalesced - a prefix replaces all other exclude-list entries
 that it "covers" (entries that begin with the new prefix). If a prefix
 is given that is in the include list, that include-list entry is removed.
+Wildcards are as for Include().
 */
 func (s *SubscriptionManager) Exclude(subInfo *SubscriptionInfo, topicPrefix string) error {
 	if subInfo == nil {
 		return errors.New("subscription not found")
 	}
-	endWithSlash(&topicPrefix)
+	topicPrefix, err := normalizeEntry(topicPrefix)
+	if err != nil {
+		return err
+	}
+	defer s.changed(subInfo)
 	// Coalescence: If this exact prefix is in the include list, just remove it
 	subInfo.lock.Lock()
 	defer subInfo.lock.Unlock()
@@ -442,14 +1334,14 @@ func (s *SubscriptionManager) Exclude(subInfo *SubscriptionInfo, topicPrefix str
 		if e == topicPrefix {
 			return nil // already present
 		}
-		if strings.HasPrefix(e, topicPrefix) {
+		if entryCovers(topicPrefix, e) {
 			excludesToRemove = append(excludesToRemove, e)
 		}
 	}
 	for _, e := range excludesToRemove {
 		subInfo.excludes = stringSliceRemove(&subInfo.excludes, e)
 	}
-	if len(subInfo.excludes) >= int(s.includeExcludeLimit) {
+	if len(subInfo.excludes) >= int(subInfo.prefixLimit) {
 		return errors.New("exclude limit reached")
 	}
 	subInfo.excludes = append(subInfo.excludes, topicPrefix)
@@ -463,19 +1355,29 @@ receive end of that subscription's channel.
 
 New subscriptions default to false. If false, the subscription will not
 show up in SubscribedChannels() - we don't want the event pipeline sending
-events to it if nobody is listening.
+events to it if nobody is listening. Each call with true counts as a
+connection in Stats().
 */
 func (s *SubscriptionManager) SetActive(subInfo *SubscriptionInfo, isActive bool) {
 	if subInfo == nil {
 		return
 	}
 	subInfo.lock.Lock()
-	defer subInfo.lock.Unlock()
 	subInfo.active = isActive
-	if subInfo.active {
+	if isActive {
+		subInfo.retainLock.Lock()
+		subInfo.connections++
+		subInfo.retainLock.Unlock()
+	}
+	if subInfo.active || subInfo.static {
 		subInfo.expiration = time.Time{}
 	} else {
-		subInfo.expiration = time.Now().Add(s.maxIdleSubscriptionAge)
+		subInfo.expiration = time.Now().Add(s.idleAge(subInfo))
+	}
+	resumed, ok := subInfo.endGrace(subInfo.SubId)
+	subInfo.lock.Unlock()
+	if notice := s.expiryNotice.Load(); ok && notice != nil {
+		notice.hook(resumed)
 	}
 }
 
@@ -490,63 +1392,166 @@ func (s *SubscriptionManager) SetProcess(subInfo *SubscriptionInfo, isProcess bo
 		return
 	}
 	subInfo.lock.Lock()
-	defer subInfo.lock.Unlock()
 	subInfo.process = isProcess
-	if subInfo.process {
+	if subInfo.process || subInfo.static {
 		subInfo.expiration = time.Time{}
 	} else {
-		subInfo.expiration = time.Now().Add(s.maxIdleSubscriptionAge)
+		subInfo.expiration = time.Now().Add(s.idleAge(subInfo))
+	}
+	resumed, ok := subInfo.endGrace(subInfo.SubId)
+	subInfo.lock.Unlock()
+	if notice := s.expiryNotice.Load(); ok && notice != nil {
+		notice.hook(resumed)
+	}
+}
+
+// Error returned by NewSubscription() and the like when the subscription limit, or the quota's, is reached
+var ErrSubscriptionLimit = errors.New("subscription limit reached")
+
+// Error returned by Include() for a prefix outside the subscription's allowed topics
+var ErrTopicNotAllowed = errors.New("topic not allowed")
+
+/*
+SetAllowedTopics limits the topics a subscription can receive to those beginning with one
+of the prefixes, e.g. its owner's tenant namespace. Include() refuses prefixes that would
+reach beyond them, and nothing else is matched either. Includes already there that reach
+beyond them, e.g. as they were narrowed, are taken off. nil allows any topic.
+*/
+func (s *SubscriptionManager) SetAllowedTopics(subInfo *SubscriptionInfo, prefixes []string) {
+	if subInfo == nil {
+		return
+	}
+	var allowed []string
+	if prefixes != nil {
+		allowed = make([]string, 0, len(prefixes))
+		for _, prefix := range prefixes {
+			endWithSlash(&prefix)
+			allowed = append(allowed, prefix)
+		}
+	}
+	defer s.changed(subInfo)
+	subInfo.lock.Lock()
+	defer subInfo.lock.Unlock()
+	subInfo.allowed = allowed
+	subInfo.includes = slices.DeleteFunc(subInfo.includes, func(include string) bool {
+		return !subInfo.isAllowed(include)
+	})
+}
+
+// isAllowed (an internal API) returns true if the topic (or prefix), ending with a slash, is within the allowed topics. Call under lock.
+func (sub *SubscriptionInfo) isAllowed(topic string) bool {
+	if sub.allowed == nil {
+		return true
+	}
+	for _, a := range sub.allowed {
+		if strings.HasPrefix(topic, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors returned by SendHandle.TrySend()
+var (
+	ErrSubscriptionGone = errors.New("subscription deleted")
+	ErrBufferFull       = errors.New("subscription buffer full")
+)
+
+/*
+Send delivers a message to the subscription this handle was looked up for.
+
+Returns false if the message was not delivered: either the subscription has been
+deleted since SubscribedChannels() returned the handle, or its channel buffer is full.
+The send never blocks - blocking while holding the subscription lock would stall
+DeleteSubscription() and SetActive() behind a reader that may be gone.
+*/
+func (h SendHandle) Send(msg ChannelMessage) bool {
+	return h.TrySend(msg) == nil
+}
+
+// TrySend is Send, returning ErrSubscriptionGone or ErrBufferFull to say why a message was not delivered.
+func (h SendHandle) TrySend(msg ChannelMessage) error {
+	if h.sub == nil {
+		return ErrSubscriptionGone
+	}
+	reserved, err := h.send(msg)
+	if reserved && h.manager != nil {
+		// Persisted before the numbers are used up
+		h.manager.changed(h.sub)
+	}
+	return err
+}
+
+// send (an internal API) is TrySend, also returning true if more sequence numbers were reserved.
+func (h SendHandle) send(msg ChannelMessage) (bool, error) {
+	h.sub.lock.RLock()
+	defer h.sub.lock.RUnlock()
+	if h.sub.IsClosedChan || h.sub.generation != h.generation {
+		return false, ErrSubscriptionGone
+	}
+	// Numbered and kept in the order they go into the channel
+	h.sub.retainLock.Lock()
+	defer h.sub.retainLock.Unlock()
+	retaining := h.sub.retention.Count > 0
+	if retaining {
+		msg.Seq = h.sub.seq + 1
+	}
+	msg.Queued = time.Now()
+	select {
+	case h.sub.channel <- msg:
+		h.sub.highWater = max(h.sub.highWater, len(h.sub.channel))
+		h.sub.delivered++
+		h.sub.lastDelivered = msg.Queued
+		h.sub.bytes += uint64(len(msg.Payload))
+		reserved := false
+		if retaining {
+			h.sub.seq = msg.Seq
+			h.sub.retain(msg)
+			reserved = h.sub.reserveSeq()
+		}
+		return reserved, nil
+	default:
+		h.sub.dropped++
+		return false, ErrBufferFull
 	}
 }
 
 /*
-SubscribedChannels, given a topic string, returns the send-side of the
+SubscribedChannels, given a topic string, returns send handles for the
 channels of all subscriptions that match that topic.
 
 This is used in the event pipeline - the service will check the topic
-of every event with this function, sending the event to the returned
-channels if any.
+of every event with this function, sending the event through the returned
+handles if any.
 */
-func (s *SubscriptionManager) SubscribedChannels(topic string) []chan<- ChannelMessage {
+func (s *SubscriptionManager) SubscribedChannels(topic string) []SendHandle {
 	currentNumSubscriptions := s.NumSubscriptions()
 	// First easy, common case: nobody is subscribed to anything
 	if currentNumSubscriptions == 0 {
+		s.recordTopic(topic, 0)
 		return nil
 	}
-	rv := make([]chan<- ChannelMessage, 0, currentNumSubscriptions)
+	receivedTopic := topic
+	rv := make([]SendHandle, 0, currentNumSubscriptions)
 	sublist := s.AllSubscriptions()
 	endWithSlash(&topic)
+	debug := s.matchDebug.Load()
+	sampled := debug != nil && debug.sampler != nil && debug.sampler.Allow()
+	var decisions []MatchDecision
 	for _, sub := range sublist {
-		useThisSub := false
 		sub.lock.RLock()
-		if !sub.active {
-			sub.lock.RUnlock()
-			continue
-		}
-		for _, i := range sub.includes {
-			if len(i) > len(topic) {
-				// List is sorted by length, once we get here it can't be a prefix
-				break
-			}
-			if strings.HasPrefix(topic, i) {
-				useThisSub = true
-				// Found an include, verify we are not excluded
-				for _, e := range sub.excludes {
-					if len(e) > len(topic) {
-						break
-					}
-					if strings.HasPrefix(topic, e) {
-						useThisSub = false
-						break
-					}
-				}
-				break
-			}
+		reason, include, exclude := sub.match(topic)
+		if reason == MatchIncluded {
+			rv = append(rv, SendHandle{sub: sub, generation: sub.generation, options: sub.options, filter: sub.filter, extensions: sub.extensions, manager: s})
 		}
-		if useThisSub {
-			rv = append(rv, sub.channel)
+		if debug != nil && (sampled || sub.options.Debug) {
+			decisions = append(decisions, sub.decision(receivedTopic, reason, include, exclude))
 		}
 		sub.lock.RUnlock()
 	}
+	for _, decision := range decisions {
+		debug.hook(decision)
+	}
+	s.recordTopic(receivedTopic, len(rv))
 	return rv
 }
@@ -8,14 +8,20 @@ package submgr
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 func TestAddRemove(t *testing.T) {
 	var dut SubscriptionManager
-	dut.Init(2, 3, 4, 300*time.Second, 30*time.Second)
+	if err := dut.Init(2, 3, 4, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
 	defer dut.Close()
 	this_num := dut.NumSubscriptions()
 	if this_num != 0 {
@@ -91,6 +97,38 @@ func TestAddRemove(t *testing.T) {
 	}
 }
 
+func TestInitValidation(t *testing.T) {
+	var dut SubscriptionManager
+	if dut.Init(0, 3, 4, 300*time.Second, 30*time.Second) == nil {
+		t.Fatal("Init succeeded with zero subscription limit")
+	}
+	if dut.Init(2, 0, 4, 300*time.Second, 30*time.Second) == nil {
+		t.Fatal("Init succeeded with zero include/exclude limit")
+	}
+	if dut.Init(2, 3, 0, 300*time.Second, 30*time.Second) == nil {
+		t.Fatal("Init succeeded with zero buffer size")
+	}
+	if dut.Init(2, 3, 4, 0, 30*time.Second) == nil {
+		t.Fatal("Init succeeded with zero idle expiration")
+	}
+	if dut.Init(2, 3, 4, 300*time.Second, 0) == nil {
+		t.Fatal("Init succeeded with zero check interval")
+	}
+	// A failed Init must leave the manager uninitialized, Close() is then a no-op
+	dut.Close()
+	if err := dut.Init(2, 3, 4, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if dut.Init(2, 3, 4, 300*time.Second, 30*time.Second) == nil {
+		t.Fatal("Second Init succeeded without Close")
+	}
+	dut.Close()
+	if err := dut.Init(2, 3, 4, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init after Close failed: %v", err)
+	}
+	dut.Close()
+}
+
 func TestEndWithSlash(t *testing.T) {
 	var s1 string
 	var s2 string
@@ -120,7 +158,9 @@ func TestEndWithSlash(t *testing.T) {
 
 func TestInclude(t *testing.T) {
 	var dut SubscriptionManager
-	dut.Init(2, 3, 4, 300*time.Second, 30*time.Second)
+	if err := dut.Init(2, 3, 4, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
 	subid, err := dut.NewSubscription()
 	if err != nil {
 		t.Fatalf("Error creating subscription: %v", err)
@@ -217,7 +257,9 @@ func TestInclude(t *testing.T) {
 
 func TestExclude(t *testing.T) {
 	var dut SubscriptionManager
-	dut.Init(2, 3, 4, 300*time.Second, 30*time.Second)
+	if err := dut.Init(2, 3, 4, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
 	defer dut.Close()
 	subid, err := dut.NewSubscription()
 	if err != nil {
@@ -299,7 +341,9 @@ func TestExclude(t *testing.T) {
 
 func TestIncludeExclude(t *testing.T) {
 	var dut SubscriptionManager
-	dut.Init(2, 3, 4, 300*time.Second, 30*time.Second)
+	if err := dut.Init(2, 3, 4, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
 	defer dut.Close()
 	subid, err := dut.NewSubscription()
 	if err != nil {
@@ -370,9 +414,41 @@ func TestIncludeExclude(t *testing.T) {
 	}
 }
 
+func TestConflict(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(2, 3, 4, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	subid, _ := dut.NewSubscription()
+	subinfo := dut.Subscription(subid)
+	_ = dut.Include(subinfo, "a/b")
+	_ = dut.Exclude(subinfo, "a/b/c")
+	tests := []struct {
+		includes []string
+		excludes []string
+		expected string
+	}{
+		{[]string{"a/b/c/d", "x"}, []string{"a/b/e", "a"}, ""},
+		{[]string{"x", "a/b/c"}, nil, "a/b/c/"},
+		{nil, []string{"a/b/"}, "a/b/"},
+		{[]string{"y/"}, []string{"y"}, "y/"},
+	}
+	for _, test := range tests {
+		if got := dut.Conflict(subinfo, test.includes, test.excludes); got != test.expected {
+			t.Errorf("Conflict(%v, %v) returned %q, expected %q", test.includes, test.excludes, got, test.expected)
+		}
+	}
+	if got := dut.Conflict(nil, []string{"z"}, []string{"z/"}); got != "z/" {
+		t.Errorf("Conflict of the lists alone returned %q", got)
+	}
+}
+
 func TestSortition(t *testing.T) {
 	var dut SubscriptionManager
-	dut.Init(10, 10, 10, 300*time.Second, 30*time.Second)
+	if err := dut.Init(10, 10, 10, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
 	defer dut.Close()
 	subid, err := dut.NewSubscription()
 	if err != nil {
@@ -441,7 +517,9 @@ func receive(c <-chan ChannelMessage, e chan<- error, expected []ChannelMessage)
 
 func TestChannelSimple(t *testing.T) {
 	var dut SubscriptionManager
-	dut.Init(2, 3, 4, 300*time.Second, 30*time.Second)
+	if err := dut.Init(2, 3, 4, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
 	// Test no-subscription case
 	badchanlist := dut.SubscribedChannels("a/b/c/d")
 	if len(badchanlist) != 0 {
@@ -486,7 +564,9 @@ func TestChannelSimple(t *testing.T) {
 	}
 	// Send to the channel
 	for _, c := range chanlist {
-		c <- testMessage
+		if !c.Send(testMessage) {
+			t.Fatal("Send to active subscription failed")
+		}
 	}
 	dut.SetActive(subinfo, false)
 	chanlist = dut.SubscribedChannels("a/b/c/d")
@@ -519,7 +599,9 @@ var sv = [...]SendVector{
 
 func TestChannelComplex(t *testing.T) {
 	var dut SubscriptionManager
-	dut.Init(10, 10, 10, 300*time.Second, 30*time.Second)
+	if err := dut.Init(10, 10, 10, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
 	defer dut.Close()
 	sub1, err1 := dut.NewSubscription()
 	if err1 != nil {
@@ -588,7 +670,9 @@ func TestChannelComplex(t *testing.T) {
 	for _, s := range sv {
 		chanlist := dut.SubscribedChannels(s.topic)
 		for _, c := range chanlist {
-			c <- s.msg
+			if !c.Send(s.msg) {
+				t.Fatalf("Send of %s failed", s.msg.Payload)
+			}
 		}
 	}
 	dut.DeleteSubscription(sub2) // closes channel
@@ -605,7 +689,9 @@ func TestChannelComplex(t *testing.T) {
 
 func BenchmarkLookups(b *testing.B) {
 	var dut SubscriptionManager
-	dut.Init(10, 10, 10, 300*time.Second, 30*time.Second)
+	if err := dut.Init(10, 10, 10, 300*time.Second, 30*time.Second); err != nil {
+		b.Fatalf("Init failed: %v", err)
+	}
 	defer dut.Close()
 	sub1, _ := dut.NewSubscription()
 	sub2, _ := dut.NewSubscription()
@@ -682,7 +768,9 @@ func frobSubs(s *SubscriptionManager, prefix string, stopme <-chan bool) {
 // Meant to run with go test -race to ensure we locked everything correctly
 func TestConcurrency(t *testing.T) {
 	var dut SubscriptionManager
-	dut.Init(10, 10, 10, 300*time.Second, 30*time.Second)
+	if err := dut.Init(10, 10, 10, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
 	defer dut.Close()
 	subid, err := dut.NewSubscription()
 	if err != nil {
@@ -731,9 +819,77 @@ func TestConcurrency(t *testing.T) {
 	}
 }
 
+/*
+TestSnapshots changes what AllSubscriptions(), Options() and Definition() return, and the
+options passed to SetOptions(), while messages are matched, so the race detector catches
+any of them sharing memory with the manager.
+*/
+func TestSnapshots(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	var subids []string
+	for i := 0; i < 3; i++ {
+		subid, _ := dut.NewSubscription()
+		subinfo := dut.Subscription(subid)
+		dut.Include(subinfo, "a/b")
+		options := SubscriptionOptions{Units: []string{"degC"}, Devices: &DeviceSelector{Labels: []string{"line-3"}}, Rounding: &Rounding{Digits: 2}}
+		if err := dut.SetOptions(subinfo, options); err != nil {
+			t.Fatalf("SetOptions failed: %v", err)
+		}
+		// The caller's options are its own after SetOptions
+		options.Units[0] = "degF"
+		options.Devices.Labels[0] = "line-4"
+		options.Rounding.Digits = 5
+		subids = append(subids, subid)
+	}
+	want := dut.AllSubscriptions()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			for _, ch := range dut.SubscribedChannels("a/b/c") {
+				if options := ch.Options(); options.Units[0] != "degC" || options.Devices.Labels[0] != "line-3" || options.Rounding.Digits != 2 {
+					t.Errorf("Subscription %s options changed to %+v", ch.SubId(), options)
+				}
+			}
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		sublist := dut.AllSubscriptions()
+		slices.Reverse(sublist)
+		sublist[0] = nil
+		subinfo := dut.Subscription(subids[i%len(subids)])
+		options := dut.Options(subinfo)
+		options.Units[0] = "K"
+		options.Devices.Labels[0] = "line-5"
+		options.Rounding.Digits = 7
+		if def, ok := dut.Definition(subids[i%len(subids)]); ok {
+			def.Options.Units[0] = "K"
+		}
+	}
+	close(done)
+	wg.Wait()
+	if got := dut.AllSubscriptions(); !slices.Equal(got, want) {
+		t.Fatal("Subscription list changed through a copy")
+	}
+}
+
 func TestAging(t *testing.T) {
 	var dut SubscriptionManager
-	dut.Init(10, 10, 10, 3*time.Second, 500*time.Millisecond)
+	if err := dut.Init(10, 10, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
 	defer dut.Close()
 	subid1, _ := dut.NewSubscription()
 	subid2, _ := dut.NewSubscription()
@@ -781,3 +937,990 @@ func TestAging(t *testing.T) {
 		t.Fatal("Active subscription 3 aged out")
 	}
 }
+
+// Keep sending to whatever matches the topic until told to stop, counting panics
+func burstSend(s *SubscriptionManager, topic string, stopme <-chan bool, e chan<- error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e <- fmt.Errorf("send panicked: %v", r)
+			return
+		}
+		e <- nil
+	}()
+	msg := ChannelMessage{EventType: "edgex", Payload: "burst"}
+	for {
+		select {
+		case <-stopme:
+			return
+		default:
+		}
+		for _, h := range s.SubscribedChannels(topic) {
+			h.Send(msg)
+		}
+	}
+}
+
+// Deletes subscriptions while senders are mid-burst; stale handles must neither panic nor deliver
+func TestDeleteDuringBurst(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	const numSenders = 4
+	stop := make(chan bool)
+	errs := make(chan error, numSenders)
+	for i := 0; i < numSenders; i++ {
+		go burstSend(&dut, "a/b/c", stop, errs)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		subid, err := dut.NewSubscription()
+		if err != nil {
+			t.Fatalf("Error creating subscription: %v", err)
+		}
+		subinfo := dut.Subscription(subid)
+		dut.Include(subinfo, "a/b")
+		dut.SetActive(subinfo, true)
+		rxchan, _ := dut.ReceiveChannel(subinfo)
+		// Drain a little so sends actually land, then delete mid-burst
+		for i := 0; i < 3; i++ {
+			select {
+			case <-rxchan:
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		handles := dut.SubscribedChannels("a/b/c")
+		dut.DeleteSubscription(subid)
+		for _, h := range handles {
+			if h.sub == subinfo && h.Send(ChannelMessage{Payload: "late"}) {
+				t.Fatal("Stale handle delivered to a deleted subscription")
+			}
+		}
+	}
+	close(stop)
+	for i := 0; i < numSenders; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTopicIndex(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, 300*time.Second, 100*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	// Disabled by default
+	_ = dut.SubscribedChannels("a/b/c")
+	if len(dut.Topics()) != 0 {
+		t.Fatal("Topic recorded with index disabled")
+	}
+	expired := make(chan string, 10)
+	dut.SetTopicIndex(2, 500*time.Millisecond, func(topic string) { expired <- topic })
+	subid, _ := dut.NewSubscription()
+	subinfo := dut.Subscription(subid)
+	dut.Include(subinfo, "a/b")
+	dut.SetActive(subinfo, true)
+	_ = dut.SubscribedChannels("a/b/c")
+	_ = dut.SubscribedChannels("a/b/c")
+	_ = dut.SubscribedChannels("x/y")
+	// Index is full, this one is not tracked
+	_ = dut.SubscribedChannels("x/z")
+	topics := dut.Topics()
+	if len(topics) != 2 {
+		t.Fatalf("Topic index has %d entries, expected 2: %v", len(topics), topics)
+	}
+	if topics[0].Topic != "a/b/c" || topics[0].Messages != 2 || topics[0].Matches != 2 {
+		t.Fatalf("Wrong index entry for a/b/c: %v", topics[0])
+	}
+	if topics[1].Topic != "x/y" || topics[1].Messages != 1 || topics[1].Matches != 0 {
+		t.Fatalf("Wrong index entry for x/y: %v", topics[1])
+	}
+	// Keep a/b/c alive while x/y ages out
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		_ = dut.SubscribedChannels("a/b/c")
+		time.Sleep(50 * time.Millisecond)
+	}
+	select {
+	case topic := <-expired:
+		if topic != "x/y" {
+			t.Fatalf("Wrong topic %s expired, expected x/y", topic)
+		}
+	default:
+		t.Fatal("Idle topic did not expire")
+	}
+	topics = dut.Topics()
+	if len(topics) != 1 || topics[0].Topic != "a/b/c" {
+		t.Fatalf("Wrong topic index after expiration: %v", topics)
+	}
+	// Disabled again, messages no longer count
+	dut.SetTopicIndex(0, 0, nil)
+	messages := topics[0].Messages
+	_ = dut.SubscribedChannels("a/b/c")
+	if topics = dut.Topics(); len(topics) != 1 || topics[0].Messages != messages {
+		t.Fatalf("Topic recorded with index disabled again: %v", topics)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	subid, _ := dut.NewSubscription()
+	subinfo := dut.Subscription(subid)
+	if !reflect.DeepEqual(dut.Options(subinfo), SubscriptionOptions{}) {
+		t.Fatal("New subscription has non-default options")
+	}
+	if dut.SetOptions(nil, SubscriptionOptions{PassThrough: true}) == nil {
+		t.Fatal("SetOptions succeeded on nonexistent subscription")
+	}
+	if err := dut.SetOptions(subinfo, SubscriptionOptions{PassThrough: true}); err != nil {
+		t.Fatalf("SetOptions failed: %v", err)
+	}
+	if !dut.Options(subinfo).PassThrough {
+		t.Fatal("Options not set")
+	}
+	if dut.SetOptions(subinfo, SubscriptionOptions{Format: "xml"}) == nil {
+		t.Fatal("SetOptions succeeded with unknown format")
+	}
+	if dut.SetOptions(subinfo, SubscriptionOptions{Envelope: "soap"}) == nil {
+		t.Fatal("SetOptions succeeded with unknown envelope")
+	}
+	if !dut.Options(subinfo).PassThrough {
+		t.Fatal("Failed SetOptions changed the options")
+	}
+	dut.Include(subinfo, "a/b")
+	dut.SetActive(subinfo, true)
+	handles := dut.SubscribedChannels("a/b/c")
+	if len(handles) != 1 || !handles[0].Options().PassThrough || handles[0].Filter() != nil {
+		t.Fatalf("Send handle does not carry subscription options: %v", handles)
+	}
+	if dut.SetOptions(subinfo, SubscriptionOptions{Filter: "temperature >"}) == nil {
+		t.Fatal("SetOptions succeeded with a bad filter")
+	}
+	if err := dut.SetOptions(subinfo, SubscriptionOptions{Filter: "temperature > 30"}); err != nil {
+		t.Fatalf("SetOptions failed: %v", err)
+	}
+	handles = dut.SubscribedChannels("a/b/c")
+	if len(handles) != 1 || handles[0].Filter() == nil || handles[0].Filter().String() != "temperature > 30" {
+		t.Fatalf("Send handle does not carry the compiled filter: %v", handles)
+	}
+}
+
+func TestTrySend(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 2, 300*time.Second, 30*time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	subid, _ := dut.NewSubscription()
+	subinfo := dut.Subscription(subid)
+	dut.Include(subinfo, "a/b")
+	dut.SetActive(subinfo, true)
+	handles := dut.SubscribedChannels("a/b/c")
+	if len(handles) != 1 {
+		t.Fatalf("Expected one handle, got %d", len(handles))
+	}
+	for i := 0; i < 2; i++ {
+		if err := handles[0].TrySend(ChannelMessage{Payload: "x"}); err != nil {
+			t.Fatalf("TrySend failed with room in the buffer: %v", err)
+		}
+	}
+	if err := handles[0].TrySend(ChannelMessage{Payload: "x"}); err != ErrBufferFull {
+		t.Fatalf("Expected ErrBufferFull, got %v", err)
+	}
+	dut.DeleteSubscription(subid)
+	if err := handles[0].TrySend(ChannelMessage{Payload: "x"}); err != ErrSubscriptionGone {
+		t.Fatalf("Expected ErrSubscriptionGone, got %v", err)
+	}
+}
+
+func TestStaticSubscription(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(2, 10, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	if err := dut.NewStaticSubscription(""); err == nil {
+		t.Fatal("Static subscription created with an empty ID")
+	}
+	if err := dut.NewStaticSubscription("dashboard"); err != nil {
+		t.Fatalf("NewStaticSubscription failed: %v", err)
+	}
+	if err := dut.NewStaticSubscription("dashboard"); err == nil {
+		t.Fatal("Static subscription created with an ID already in use")
+	}
+	subinfo := dut.Subscription("dashboard")
+	if subinfo == nil || subinfo.SubId != "dashboard" {
+		t.Fatal("Static subscription not found by its ID")
+	}
+	if _, err := dut.NewSubscription(); err != nil {
+		t.Fatalf("NewSubscription failed: %v", err)
+	}
+	if err := dut.NewStaticSubscription("other"); err == nil {
+		t.Fatal("Static subscription created past the subscription limit")
+	}
+	// Listening, then not, must not start its idle clock either
+	dut.SetActive(subinfo, true)
+	dut.SetActive(subinfo, false)
+	time.Sleep(4*time.Second)
+	if dut.IsSubscriptionDeleted(subinfo) {
+		t.Fatal("Static subscription aged out")
+	}
+	if dut.NumSubscriptions() != 1 {
+		t.Fatalf("Expected only the static subscription to remain, have %d", dut.NumSubscriptions())
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(1, 1, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	old, err := dut.NewSubscription()
+	if err != nil {
+		t.Fatalf("NewSubscription failed: %v", err)
+	}
+	if dut.SetLimits(2, 0, 20) == nil {
+		t.Fatal("SetLimits accepted a zero limit")
+	}
+	if err := dut.SetLimits(2, 2, 20); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+	if sublimit, prefixes, buffer := dut.Limits(); sublimit != 2 || prefixes != 2 || buffer != 20 {
+		t.Fatalf("Limits %d %d %d", sublimit, prefixes, buffer)
+	}
+	subid, err := dut.NewSubscription()
+	if err != nil {
+		t.Fatalf("NewSubscription failed under the raised limit: %v", err)
+	}
+	if _, err := dut.NewSubscription(); err == nil {
+		t.Fatal("Subscription created past the raised limit")
+	}
+	// Only the new subscription gets the new limits
+	subinfo, oldinfo := dut.Subscription(subid), dut.Subscription(old)
+	if dut.ChannelSize(subinfo) != 20 || dut.PrefixLimit(subinfo) != 2 || dut.ChannelSize(oldinfo) != 10 || dut.PrefixLimit(oldinfo) != 1 {
+		t.Fatal("Limits not applied to the new subscription only")
+	}
+	// Lowering it leaves those past it alone
+	if err := dut.SetLimits(1, 1, 10); err != nil || dut.NumSubscriptions() != 2 {
+		t.Fatalf("Lowering limits gave %v, %d subscriptions", err, dut.NumSubscriptions())
+	}
+}
+
+func TestQuota(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(1, 1, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	if dut.SetQuota("", Quota{Subscriptions: 2, Prefixes: 2}) == nil || dut.SetQuota("admin", Quota{Subscriptions: 2}) == nil {
+		t.Fatal("SetQuota accepted an empty name or zero limit")
+	}
+	if err := dut.SetQuota("admin", Quota{Subscriptions: 2, Prefixes: 2}); err != nil {
+		t.Fatalf("SetQuota failed: %v", err)
+	}
+	if _, err := dut.NewSubscriptionWithQuota("unknown"); err == nil {
+		t.Fatal("Subscription created under an unknown quota")
+	}
+	if _, err := dut.NewSubscription(); err != nil {
+		t.Fatalf("NewSubscription failed: %v", err)
+	}
+	if _, err := dut.NewSubscription(); err == nil {
+		t.Fatal("Subscription created past the default limit")
+	}
+	subid, err := dut.NewSubscriptionWithQuota("admin")
+	if err != nil {
+		t.Fatalf("NewSubscriptionWithQuota failed: %v", err)
+	}
+	if _, err := dut.NewSubscriptionWithQuota("admin"); err != nil {
+		t.Fatalf("NewSubscriptionWithQuota failed under its limit: %v", err)
+	}
+	if _, err := dut.NewSubscriptionWithQuota("admin"); err == nil {
+		t.Fatal("Subscription created past the quota's limit")
+	}
+	if usage, err := dut.Usage("admin"); err != nil || usage != (QuotaUsage{Subscriptions: 2, SubscriptionLimit: 2, PrefixLimit: 2}) {
+		t.Fatalf("Usage of quota %+v, %v", usage, err)
+	}
+	if usage, err := dut.Usage(""); err != nil || usage != (QuotaUsage{Subscriptions: 1, SubscriptionLimit: 1, PrefixLimit: 1}) {
+		t.Fatalf("Usage of default limits %+v, %v", usage, err)
+	}
+	if _, err := dut.Usage("unknown"); err == nil {
+		t.Fatal("Usage of an unknown quota")
+	}
+	subinfo := dut.Subscription(subid)
+	if dut.PrefixLimit(subinfo) != 2 {
+		t.Fatalf("Prefix limit %d under the quota", dut.PrefixLimit(subinfo))
+	}
+	if dut.Include(subinfo, "a") != nil || dut.Include(subinfo, "b") != nil {
+		t.Fatal("Include failed under the quota's prefix limit")
+	}
+	if dut.Include(subinfo, "c") == nil {
+		t.Fatal("Include succeeded past the quota's prefix limit")
+	}
+	// Deleting frees up room under the quota only
+	dut.DeleteSubscription(subid)
+	if _, err := dut.NewSubscription(); err == nil {
+		t.Fatal("Subscription created past the default limit")
+	}
+	if _, err := dut.NewSubscriptionWithQuota("admin"); err != nil {
+		t.Fatalf("NewSubscriptionWithQuota failed after a delete: %v", err)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	plainid, _ := dut.NewSubscription()
+	dut.SetRetention(Retention{Count: 3, Bytes: 18, MaxAge: 300 * time.Millisecond})
+	subid, _ := dut.NewSubscription()
+	plain := dut.Subscription(plainid)
+	subinfo := dut.Subscription(subid)
+	for _, sub := range []*SubscriptionInfo{plain, subinfo} {
+		_ = dut.Include(sub, "a")
+		dut.SetActive(sub, true)
+	}
+	for _, payload := range []string{"one", "two", "three", "four"} {
+		for _, h := range dut.SubscribedChannels("a/b") {
+			if err := h.TrySend(ChannelMessage{Payload: payload}); err != nil {
+				t.Fatalf("TrySend failed: %v", err)
+			}
+		}
+	}
+	// Created before SetRetention, so not numbered or kept
+	if msg := <-plain.channel; msg.Seq != 0 || len(dut.Replay(plain, 0)) != 0 {
+		t.Fatal("Subscription without retention numbered or kept messages")
+	}
+	for i := uint64(1); i <= 4; i++ {
+		if msg := <-subinfo.channel; msg.Seq != i {
+			t.Fatalf("Message numbered %d, expected %d", msg.Seq, i)
+		}
+	}
+	// Count limits to the last three
+	replay := dut.Replay(subinfo, 0)
+	if len(replay) != 3 || replay[0].Payload != "two" || replay[2].Seq != 4 {
+		t.Fatalf("Wrong messages kept: %v", replay)
+	}
+	if replay = dut.Replay(subinfo, 3); len(replay) != 1 || replay[0].Payload != "four" {
+		t.Fatalf("Wrong messages after 3: %v", replay)
+	}
+	// Bytes limits too
+	for _, h := range dut.SubscribedChannels("a/b") {
+		_ = h.TrySend(ChannelMessage{Payload: "a longer message"})
+	}
+	if replay = dut.Replay(subinfo, 0); len(replay) != 1 || replay[0].Seq != 5 {
+		t.Fatalf("Wrong messages kept under the byte limit: %v", replay)
+	}
+	// And MaxAge
+	time.Sleep(400 * time.Millisecond)
+	if replay = dut.Replay(subinfo, 0); len(replay) != 0 {
+		t.Fatalf("Messages kept past MaxAge: %v", replay)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	var changed []string
+	dut.SetChangeHook(func(subid string) { changed = append(changed, subid) })
+	if _, err := dut.RotateSubscription("inexist"); err == nil {
+		t.Fatal("Rotated a subscription that doesn't exist")
+	}
+	_ = dut.NewStaticSubscription("static")
+	if _, err := dut.RotateSubscription("static"); err == nil {
+		t.Fatal("Rotated a static subscription")
+	}
+	oldid, _ := dut.NewSubscription()
+	changed = nil
+	if err := dut.rotateSubscription(oldid, "static"); err == nil {
+		t.Fatal("Rotated a subscription to an ID in use")
+	}
+	if len(changed) != 0 {
+		t.Fatalf("Change hook called for failed rotations: %v", changed)
+	}
+	// A smaller buffer for new subscriptions doesn't shrink this one's
+	if err := dut.SetLimits(10, 10, 5); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+	subinfo := dut.Subscription(oldid)
+	_ = dut.Include(subinfo, "a")
+	dut.SetActive(subinfo, true)
+	oldchan, _ := dut.ReceiveChannel(subinfo)
+	stale := dut.SubscribedChannels("a/b")
+	changed = nil
+	newid, err := dut.RotateSubscription(oldid)
+	if err != nil || newid == "" || newid == oldid {
+		t.Fatalf("RotateSubscription returned %s, %v", newid, err)
+	}
+	if !slices.Equal(changed, []string{oldid, newid}) {
+		t.Fatalf("Change hook called for %v", changed)
+	}
+	if dut.Subscription(oldid) != nil || dut.Subscription(newid) != subinfo || subinfo.SubId != newid {
+		t.Fatal("Subscription not moved to its new ID")
+	}
+	if _, ok := <-oldchan; ok {
+		t.Fatal("Channel read under the old ID not closed")
+	}
+	if len(stale) != 1 || stale[0].TrySend(ChannelMessage{Payload: "stale"}) != ErrSubscriptionGone {
+		t.Fatal("Handle looked up before rotation could still send")
+	}
+	includes, _, ok := dut.SubscriptionInfo(subinfo)
+	if !ok || len(includes) != 1 || dut.IsChannelClosed(subinfo) {
+		t.Fatalf("Rotated subscription lost its state: %v %v", includes, ok)
+	}
+	handles := dut.SubscribedChannels("a/b")
+	if len(handles) != 1 || !handles[0].Send(ChannelMessage{Payload: "new"}) {
+		t.Fatal("Could not send to the rotated subscription")
+	}
+	newchan, _ := dut.ReceiveChannel(subinfo)
+	if cap(newchan) != 10 {
+		t.Fatalf("New channel has a buffer of %d, expected 10", cap(newchan))
+	}
+	if msg := <-newchan; msg.Payload != "new" {
+		t.Fatalf("Wrong message %v on the new channel", msg)
+	}
+	if dut.NumSubscriptions() != 2 {
+		t.Fatalf("Expected 2 subscriptions, have %d", dut.NumSubscriptions())
+	}
+}
+
+func TestAllowedTopics(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	subid, _ := dut.NewSubscription()
+	subinfo := dut.Subscription(subid)
+	dut.SetAllowedTopics(subinfo, []string{"tenant1", "shared/"})
+	if dut.Include(subinfo, "tenant1/dev") != nil || dut.Include(subinfo, "shared") != nil {
+		t.Fatal("Include failed within the allowed topics")
+	}
+	for _, topic := range []string{"tenant2/dev", "tenant", "tenant10", "a"} {
+		if err := dut.Include(subinfo, topic); !errors.Is(err, ErrTopicNotAllowed) {
+			t.Fatalf("Include of %s returned %v", topic, err)
+		}
+	}
+	// Enforced at match time too, if the allowed topics narrow after including
+	dut.SetActive(subinfo, true)
+	if len(dut.SubscribedChannels("shared/x")) != 1 {
+		t.Fatal("No match within the allowed topics")
+	}
+	dut.SetAllowedTopics(subinfo, []string{"tenant1"})
+	if len(dut.SubscribedChannels("shared/x")) != 0 || len(dut.SubscribedChannels("tenant1/dev/x")) != 1 {
+		t.Fatal("Matching ignored the allowed topics")
+	}
+	// Excludes outside the allowed topics can still be removed
+	if dut.Exclude(subinfo, "tenant2/dev") != nil {
+		t.Fatal("Exclude failed")
+	}
+	if dut.RemoveExclude(subinfo, "tenant2/dev") != nil || dut.RemoveExclude(subinfo, "absent") != nil {
+		t.Fatal("RemoveExclude failed")
+	}
+	if _, excludes, _ := dut.SubscriptionInfo(subinfo); len(excludes) != 0 {
+		t.Fatalf("Excludes left: %v", excludes)
+	}
+	dut.SetAllowedTopics(subinfo, nil)
+	if dut.Include(subinfo, "a") != nil {
+		t.Fatal("Include failed with no topic restriction")
+	}
+}
+
+func TestTopicDepth(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	subid, _ := dut.NewSubscription()
+	subinfo := dut.Subscription(subid)
+	dut.SetActive(subinfo, true)
+	device := "edgex/events/device/Bacon-Cape/dev1"
+	matches := func(topic string) bool {
+		return len(dut.SubscribedChannels(topic)) == 1
+	}
+
+	// All resources of a device, but not its sub-topics
+	if err := dut.Include(subinfo, device+"/+"); err != nil {
+		t.Fatalf("Include failed: %v", err)
+	}
+	if !matches(device+"/temp") || matches(device) || matches(device+"/temp/raw") || matches(device+"//") || matches(device+"x/temp") {
+		t.Fatal("Exactly one level beneath the prefix not matched")
+	}
+	// A plain prefix covers it, but it doesn't cover entries below it
+	if err := dut.Include(subinfo, device+"/+/raw"); !errors.Is(err, ErrWildcardPosition) {
+		t.Fatalf("Include with a wildcard before another level returned %v", err)
+	}
+	if err := dut.Include(subinfo, device+"/temp/x"); err != nil {
+		t.Fatalf("Include failed: %v", err)
+	}
+	if includes, _, _ := dut.SubscriptionInfo(subinfo); len(includes) != 2 {
+		t.Fatalf("Wildcard entry coalesced an entry it does not cover: %v", includes)
+	}
+	if err := dut.Include(subinfo, device); err != nil {
+		t.Fatalf("Include failed: %v", err)
+	}
+	if includes, _, _ := dut.SubscriptionInfo(subinfo); !slices.Equal(includes, []string{device + "/"}) {
+		t.Fatalf("Prefix did not coalesce the entries it covers: %v", includes)
+	}
+
+	// Everything beneath the device, excluding two levels down, and # alone changes nothing
+	if err := dut.Exclude(subinfo, device+"/+/+"); err != nil {
+		t.Fatalf("Exclude failed: %v", err)
+	}
+	if !matches(device+"/temp") || matches(device+"/temp/raw") || !matches(device+"/temp/raw/x") {
+		t.Fatal("Excluding exactly two levels beneath the prefix not matched")
+	}
+	if err := dut.Exclude(subinfo, device+"/#"); err != nil {
+		t.Fatalf("Exclude failed: %v", err)
+	}
+	if includes, _, _ := dut.SubscriptionInfo(subinfo); len(includes) != 0 {
+		t.Fatalf("Excluding the include with # did not remove it: %v", includes)
+	}
+	if err := dut.Include(subinfo, device+"/+/#"); err != nil {
+		t.Fatalf("Include failed: %v", err)
+	}
+	if matches(device) || !matches(device+"/temp") || !matches(device+"/temp/raw/x") {
+		t.Fatal("At least one level beneath the prefix not matched")
+	}
+	if prefix := dut.Conflict(nil, []string{"a/#"}, []string{"a"}); prefix != "a/" {
+		t.Fatalf("Conflict of a/# and a returned %q", prefix)
+	}
+}
+
+func TestMatchDebug(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	var decisions []MatchDecision
+	dut.SetMatchDebug(func(d MatchDecision) { decisions = append(decisions, d) }, 0)
+	debugged, _ := dut.NewSubscription()
+	debugInfo := dut.Subscription(debugged)
+	dut.Include(debugInfo, "a")
+	dut.Exclude(debugInfo, "a/b")
+	dut.SetOptions(debugInfo, SubscriptionOptions{Debug: true})
+	dut.SetActive(debugInfo, true)
+	other, _ := dut.NewSubscription()
+	otherInfo := dut.Subscription(other)
+	dut.Include(otherInfo, "c")
+
+	// Only the subscription with the Debug option reports
+	expected := []MatchDecision{
+		{SubId: debugged, Topic: "a/x", Reason: MatchIncluded, Include: "a/"},
+		{SubId: debugged, Topic: "a/b/x", Reason: MatchExcluded, Include: "a/", Exclude: "a/b/"},
+		{SubId: debugged, Topic: "c/x", Reason: MatchNoInclude, Includes: []string{"a/"}},
+	}
+	for _, d := range expected {
+		dut.SubscribedChannels(d.Topic)
+	}
+	if len(decisions) != len(expected) {
+		t.Fatalf("Wrong decisions %+v", decisions)
+	}
+	for i, d := range decisions {
+		if d.String() != expected[i].String() || d.Matched() != (i == 0) {
+			t.Fatalf("Decision %q, expected %q", d, expected[i])
+		}
+	}
+
+	// Sampled messages report for every subscription, but not beyond the sampling rate
+	decisions = nil
+	dut.SetMatchDebug(func(d MatchDecision) { decisions = append(decisions, d) }, 1)
+	dut.SubscribedChannels("c/x")
+	dut.SubscribedChannels("c/x")
+	if len(decisions) != 3 || decisions[1].SubId != other || decisions[1].Reason != MatchInactive {
+		t.Fatalf("Wrong sampled decisions %+v", decisions)
+	}
+
+	dut.SetMatchDebug(nil, 0)
+	decisions = nil
+	dut.SubscribedChannels("a/x")
+	if len(decisions) != 0 {
+		t.Fatalf("Decisions %+v with match debugging off", decisions)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 2, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	var lock sync.Mutex
+	changed := make([]string, 0)
+	dut.SetChangeHook(func(subid string) {
+		lock.Lock()
+		defer lock.Unlock()
+		changed = append(changed, subid)
+	})
+	_ = dut.NewStaticSubscription("static")
+	_ = dut.Include(dut.Subscription("static"), "s")
+	if _, ok := dut.Definition("static"); ok {
+		t.Fatal("Got the definition of a static subscription")
+	}
+	subid, _ := dut.NewSubscriptionWithQuota("")
+	subinfo := dut.Subscription(subid)
+	_ = dut.Include(subinfo, "a/b")
+	_ = dut.Exclude(subinfo, "a/b/c")
+	_ = dut.SetOptions(subinfo, SubscriptionOptions{Format: FormatSimple})
+	dut.SetOwner(subinfo, "alice")
+	dut.SetAllowedTopics(subinfo, []string{"a"})
+	lock.Lock()
+	if len(changed) != 6 || slices.ContainsFunc(changed, func(id string) bool { return id != subid }) {
+		t.Fatalf("Change hook called with %v", changed)
+	}
+	lock.Unlock()
+	def, ok := dut.Definition(subid)
+	want := Definition{Id: subid, Include: []string{"a/b/"}, Exclude: []string{"a/b/c/"}, Options: SubscriptionOptions{Format: FormatSimple}, Owner: "alice", Allowed: []string{"a/"}}
+	if !ok || !reflect.DeepEqual(def, want) {
+		t.Fatalf("Definition returned %+v, %t", def, ok)
+	}
+	if defs := dut.Definitions(); len(defs) != 1 || defs[0].Id != subid {
+		t.Fatalf("Definitions returned %+v", defs)
+	}
+
+	// Restored as it was, without calling the hook
+	dut.DeleteSubscription(subid)
+	if _, ok := dut.Definition(subid); ok {
+		t.Fatal("Got the definition of a deleted subscription")
+	}
+	lock.Lock()
+	changed = changed[:0]
+	lock.Unlock()
+	if err := dut.Restore(def); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored, _ := dut.Definition(subid); !reflect.DeepEqual(restored, want) {
+		t.Fatalf("Restored %+v", restored)
+	}
+	lock.Lock()
+	if len(changed) != 0 {
+		t.Fatalf("Change hook called with %v on restore", changed)
+	}
+	lock.Unlock()
+	if dut.Restore(def) == nil {
+		t.Fatal("Restored a subscription whose ID is in use")
+	}
+	if dut.Restore(Definition{Id: "x", Include: []string{"a", "b", "c"}}) == nil || dut.Subscription("x") != nil {
+		t.Fatal("Restored a subscription with more includes than the limit")
+	}
+	if dut.Restore(Definition{Id: "y", Quota: "unknown"}) == nil {
+		t.Fatal("Restored a subscription with an unknown quota")
+	}
+	if dut.Restore(Definition{Id: "z", Options: SubscriptionOptions{Format: "bogus"}}) == nil {
+		t.Fatal("Restored a subscription with invalid options")
+	}
+}
+
+func TestExpiryHook(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, time.Second, 200*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	var inUse atomic.Bool
+	inUse.Store(true)
+	dut.SetExpiryHook(func(subid string) bool {
+		return !inUse.Load()
+	})
+	subid, _ := dut.NewSubscription()
+	subinfo := dut.Subscription(subid)
+	if dut.IsActive(subinfo) {
+		t.Fatal("New subscription active")
+	}
+	dut.SetActive(subinfo, true)
+	if !dut.IsActive(subinfo) {
+		t.Fatal("Subscription not active")
+	}
+	dut.SetActive(subinfo, false)
+	time.Sleep(2 * time.Second)
+	if dut.IsSubscriptionDeleted(subinfo) {
+		t.Fatal("Subscription the hook said is in use aged out")
+	}
+	inUse.Store(false)
+	time.Sleep(2 * time.Second)
+	if !dut.IsSubscriptionDeleted(subinfo) {
+		t.Fatal("Subscription the hook let expire did not age out")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 2, 10, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	var changes atomic.Int32
+	dut.SetChangeHook(func(subid string) {
+		changes.Add(1)
+	})
+	subid, _ := dut.NewSubscription()
+	_ = dut.Include(dut.Subscription(subid), "a")
+	changes.Store(0)
+	want := Definition{Id: subid, Include: []string{"b/"}, Exclude: []string{"b/c/"}, Options: SubscriptionOptions{Format: FormatSimple}, Owner: "bob", Allowed: []string{"b/"}}
+	if err := dut.Replace(want); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if def, _ := dut.Definition(subid); !reflect.DeepEqual(def, want) {
+		t.Fatalf("Replaced with %+v", def)
+	}
+	if changes.Load() != 0 {
+		t.Fatal("Change hook called on replace")
+	}
+	if dut.Replace(Definition{Id: "unknown"}) == nil {
+		t.Fatal("Replaced a subscription that does not exist")
+	}
+	_ = dut.NewStaticSubscription("static")
+	if dut.Replace(Definition{Id: "static"}) == nil {
+		t.Fatal("Replaced a static subscription")
+	}
+	if dut.Replace(Definition{Id: subid, Include: []string{"a", "b", "c"}}) == nil {
+		t.Fatal("Replaced with more includes than the limit")
+	}
+	if dut.Replace(Definition{Id: subid, Options: SubscriptionOptions{Format: "bogus"}}) == nil {
+		t.Fatal("Replaced with invalid options")
+	}
+	if def, _ := dut.Definition(subid); !reflect.DeepEqual(def, want) {
+		t.Fatalf("Changed by a failed replace: %+v", def)
+	}
+}
+
+func TestResume(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 2000, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	dut.SetRetention(Retention{Count: 3, Bytes: 4096, MaxAge: time.Minute})
+	var changes atomic.Int32
+	dut.SetChangeHook(func(subid string) {
+		changes.Add(1)
+	})
+	// Restored, numbered up to 100 before the restart
+	if err := dut.Restore(Definition{Id: "sub", Include: []string{"a/"}, Seq: 100}); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	subinfo := dut.Subscription("sub")
+	dut.SetActive(subinfo, true)
+	send := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if err := dut.SubscribedChannels("a/b")[0].TrySend(ChannelMessage{Payload: "x"}); err != nil {
+				t.Fatalf("TrySend failed: %v", err)
+			}
+		}
+	}
+	// Nothing sent since the restart
+	if msgs, from, ok := dut.Resume(subinfo, 100); !ok || from != 100 || len(msgs) != 0 {
+		t.Fatalf("Resume after the restored number returned %v, %d, %t", msgs, from, ok)
+	}
+	send(1)
+	if msg := <-subinfo.channel; msg.Seq != 101 {
+		t.Fatalf("Message numbered %d after restoring 100", msg.Seq)
+	}
+	// Reserved ahead, and persisted through the change hook
+	if def, _ := dut.Definition("sub"); def.Seq != 101+seqReservation || changes.Load() != 1 {
+		t.Fatalf("Reserved up to %d, %d changes", def.Seq, changes.Load())
+	}
+	send(2)
+	if msgs, from, ok := dut.Resume(subinfo, 101); !ok || from != 101 || len(msgs) != 2 || msgs[0].Seq != 102 {
+		t.Fatalf("Resume after 101 returned %v, %d, %t", msgs, from, ok)
+	}
+	// From before the restart, not yet numbered, or no longer kept
+	for _, after := range []uint64{99, 104, 1000} {
+		if msgs, from, ok := dut.Resume(subinfo, after); ok || from != 100 || len(msgs) != 3 {
+			t.Fatalf("Resume after %d returned %v, %d, %t", after, msgs, from, ok)
+		}
+	}
+	send(1)
+	if msgs, from, ok := dut.Resume(subinfo, 100); ok || from != 101 || len(msgs) != 3 {
+		t.Fatalf("Resume after a message no longer kept returned %v, %d, %t", msgs, from, ok)
+	}
+	// More reserved when half are used
+	send(seqReservation / 2)
+	if def, _ := dut.Definition("sub"); def.Seq <= 101+seqReservation || changes.Load() != 2 {
+		t.Fatalf("Reserved up to %d, %d changes", def.Seq, changes.Load())
+	}
+}
+
+func TestExpiryNotice(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, 2*time.Second, 200*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	var lock sync.Mutex
+	notices := make([]ExpiryNotice, 0)
+	dut.SetExpiryNotice(time.Second, func(notice ExpiryNotice) {
+		lock.Lock()
+		defer lock.Unlock()
+		notices = append(notices, notice)
+	})
+	_ = dut.NewStaticSubscription("static")
+	listened, _ := dut.NewSubscription()
+	dut.SetActive(dut.Subscription(listened), true)
+	subid, _ := dut.NewSubscription()
+	_ = dut.SetOptions(dut.Subscription(subid), SubscriptionOptions{ExpiryNotify: "https://example.com/expiry"})
+	time.Sleep(1500 * time.Millisecond)
+	lock.Lock()
+	if len(notices) != 1 || notices[0].SubId != subid || notices[0].Expired || notices[0].Options.ExpiryNotify == "" || time.Until(notices[0].Expires) > time.Second {
+		t.Fatalf("Got notices %+v about to expire", notices)
+	}
+	lock.Unlock()
+	time.Sleep(1500 * time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	if len(notices) != 2 || notices[1].SubId != subid || !notices[1].Expired || notices[1].Options.ExpiryNotify == "" {
+		t.Fatalf("Got notices %+v after expiring", notices)
+	}
+}
+
+func TestAgeOutGrace(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, time.Second, 200*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	dut.SetAgeOutGrace(time.Second)
+	var lock sync.Mutex
+	notices := make([]ExpiryNotice, 0)
+	dut.SetExpiryNotice(100*time.Millisecond, func(notice ExpiryNotice) {
+		lock.Lock()
+		defer lock.Unlock()
+		if !notice.Expired && !notice.Grace && !notice.Resumed {
+			return
+		}
+		notices = append(notices, notice)
+	})
+	saved, _ := dut.NewSubscription()
+	lost, _ := dut.NewSubscription()
+
+	// Both are in their grace window after the idle expiration, not aged out
+	time.Sleep(1500 * time.Millisecond)
+	lock.Lock()
+	if len(notices) != 2 || !notices[0].Grace || !notices[1].Grace || time.Until(notices[0].Expires) <= 0 {
+		t.Fatalf("Got notices %+v at the idle expiration", notices)
+	}
+	lock.Unlock()
+	if dut.Subscription(saved) == nil || dut.Subscription(lost) == nil {
+		t.Fatal("Subscription aged out in its grace window")
+	}
+
+	// A stream reconnecting in the window saves its subscription
+	dut.SetActive(dut.Subscription(saved), true)
+	time.Sleep(1500 * time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	if len(notices) != 4 || notices[2].SubId != saved || !notices[2].Resumed || notices[3].SubId != lost || !notices[3].Expired {
+		t.Fatalf("Got notices %+v after the grace window", notices)
+	}
+	if dut.Subscription(saved) == nil || dut.Subscription(lost) != nil {
+		t.Fatal("Wrong subscription aged out after the grace window")
+	}
+}
+
+func TestIdleExpiration(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 10, time.Minute, 200*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	short, _ := dut.NewSubscription()
+	long, _ := dut.NewSubscription()
+	if expiresIn, ok := dut.ExpiresIn(dut.Subscription(long)); !ok || expiresIn <= 59*time.Second || expiresIn > time.Minute {
+		t.Fatalf("New subscription expires in %v, %v", expiresIn, ok)
+	}
+	// Setting the option restarts the idle time
+	if err := dut.SetOptions(dut.Subscription(short), SubscriptionOptions{IdleExpiration: "500ms"}); err != nil {
+		t.Fatalf("SetOptions failed: %v", err)
+	}
+	if expiresIn, ok := dut.ExpiresIn(dut.Subscription(short)); !ok || expiresIn > 500*time.Millisecond {
+		t.Fatalf("Subscription with idleExpiration 500ms expires in %v, %v", expiresIn, ok)
+	}
+	// Being processed, it expires that long after
+	dut.SetProcess(dut.Subscription(long), true)
+	if expiresIn, ok := dut.ExpiresIn(dut.Subscription(long)); !ok || expiresIn != time.Minute {
+		t.Fatalf("Subscription being processed expires in %v, %v", expiresIn, ok)
+	}
+	dut.SetProcess(dut.Subscription(long), false)
+	time.Sleep(time.Second)
+	if dut.Subscription(short) != nil || dut.Subscription(long) == nil {
+		t.Fatal("Subscriptions not aged out by their idle expirations")
+	}
+	_ = dut.NewStaticSubscription("static")
+	if _, ok := dut.ExpiresIn(dut.Subscription("static")); ok {
+		t.Fatal("Static subscription expires")
+	}
+}
+
+func TestAcknowledge(t *testing.T) {
+	var dut SubscriptionManager
+	if err := dut.Init(10, 10, 2000, 3*time.Second, 500*time.Millisecond); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer dut.Close()
+	dut.SetRetention(Retention{Count: 4, Bytes: 4096, MaxAge: 100 * time.Millisecond})
+	subid, _ := dut.NewSubscription()
+	subinfo := dut.Subscription(subid)
+	if err := dut.Include(subinfo, "a/"); err != nil {
+		t.Fatalf("Include failed: %v", err)
+	}
+	dut.SetActive(subinfo, true)
+	if err := dut.Acknowledge(subinfo, 0); !errors.Is(err, ErrNotAcknowledging) {
+		t.Fatalf("Acknowledge without the option returned %v", err)
+	}
+	if err := dut.SetOptions(subinfo, SubscriptionOptions{Acknowledge: true}); err != nil {
+		t.Fatalf("SetOptions failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := dut.SubscribedChannels("a/b")[0].TrySend(ChannelMessage{Payload: "x"}); err != nil {
+			t.Fatalf("TrySend failed: %v", err)
+		}
+	}
+	if err := dut.Acknowledge(subinfo, 4); !errors.Is(err, ErrNotSent) {
+		t.Fatalf("Acknowledge of a message not sent returned %v", err)
+	}
+	if err := dut.Acknowledge(subinfo, 1); err != nil {
+		t.Fatalf("Acknowledge failed: %v", err)
+	}
+	// Going back changes nothing
+	if err := dut.Acknowledge(subinfo, 0); err != nil {
+		t.Fatalf("Acknowledge of fewer failed: %v", err)
+	}
+	if acked, ok := dut.Acknowledged(subinfo); !ok || acked != 1 {
+		t.Fatalf("Acknowledged returned %d, %t", acked, ok)
+	}
+	// Not acknowledged, so kept past MaxAge
+	time.Sleep(200 * time.Millisecond)
+	if msgs, from, ok := dut.Resume(subinfo, 1); !ok || from != 1 || len(msgs) != 2 || msgs[0].Seq != 2 {
+		t.Fatalf("Resume after the acknowledged returned %v, %d, %t", msgs, from, ok)
+	}
+	// But not over Count
+	for i := 0; i < 3; i++ {
+		if err := dut.SubscribedChannels("a/b")[0].TrySend(ChannelMessage{Payload: "x"}); err != nil {
+			t.Fatalf("TrySend failed: %v", err)
+		}
+	}
+	if msgs, from, ok := dut.Resume(subinfo, 1); ok || from != 2 || len(msgs) != 4 {
+		t.Fatalf("Resume after messages over Count returned %v, %d, %t", msgs, from, ok)
+	}
+	if err := dut.Acknowledge(subinfo, 6); err != nil {
+		t.Fatalf("Acknowledge failed: %v", err)
+	}
+	if msgs := dut.Replay(subinfo, 0); len(msgs) != 0 {
+		t.Fatalf("Acknowledged messages still kept: %v", msgs)
+	}
+}
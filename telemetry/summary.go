@@ -0,0 +1,74 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package telemetry
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/functions"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// Struct summary logs the message counts since it last did.
+type summary struct {
+	lc        logger.LoggingClient
+	subs      *submgr.SubscriptionManager
+	processor *functions.Processor
+	interval  time.Duration
+	// The counts as of the last summary
+	last     functions.DeliveryCounts
+	lastSubs map[string]submgr.SubscriptionThroughput
+}
+
+/*
+StartSummary logs, every interval, the messages received, matched, delivered and dropped
+since the last time, and the same for each subscription that got any, until the returned
+function is called. With a zero interval, it does nothing.
+*/
+func StartSummary(lc logger.LoggingClient, interval time.Duration, subs *submgr.SubscriptionManager, processor *functions.Processor) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+	s := &summary{lc: lc, subs: subs, processor: processor, interval: interval}
+	ticker := time.NewTicker(interval)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.log()
+			case <-stop:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
+
+// log (an internal API) logs the counts since the last call, or since the service started.
+func (s *summary) log() {
+	counts := s.processor.DeliveryCounts()
+	subs := make(map[string]submgr.SubscriptionThroughput)
+	for _, entry := range s.subs.Throughput() {
+		subs[entry.SubId] = entry
+		// A subscription new since the last summary, or with a new ID, counts from zero
+		last := s.lastSubs[entry.SubId]
+		out := entry.Delivered - last.Delivered
+		dropped := entry.Dropped - last.Dropped
+		expired := entry.Expired - last.Expired
+		if out+dropped+expired > 0 {
+			s.lc.Info("Subscription throughput", "subscription", entry.SubId, "in", out+dropped, "out", out, "dropped", dropped,
+				"expired", expired, "interval", s.interval.String())
+		}
+	}
+	s.lc.Info("Throughput summary", "received", counts.Received-s.last.Received, "matched", counts.Matched-s.last.Matched,
+		"delivered", counts.Delivered-s.last.Delivered, "dropped", counts.Dropped-s.last.Dropped, "interval", s.interval.String())
+	s.last = counts
+	s.lastSubs = subs
+}
@@ -0,0 +1,81 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package for the service's metrics, reported by the SDK on the EdgeX telemetry topic
+package telemetry
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/functions"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"github.com/edgexfoundry-holding/edgex-sse/web"
+	"errors"
+	"fmt"
+
+	bootstrapInterfaces "github.com/edgexfoundry/go-mod-bootstrap/v4/bootstrap/interfaces"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// Metric names, to enable in Writable.Telemetry.Metrics
+const (
+	ActiveSubscriptions = "SseActiveSubscriptions"
+	ActiveConnections   = "SseActiveConnections"
+	EventsReceived      = "SseEventsReceived"
+	EventsMatched       = "SseEventsMatched"
+	EventsDelivered     = "SseEventsDelivered"
+	EventsDropped       = "SseEventsDropped"
+	EventsSuppressed    = "SseEventsSuppressed"
+	ChannelHighWater    = "SseChannelHighWater"
+)
+
+/*
+Struct functionalCounter is a counter whose count is read from elsewhere when reported,
+so the counting code doesn't depend on the metrics library.
+*/
+type functionalCounter struct {
+	count func() uint64
+}
+
+func (c functionalCounter) Count() int64 {
+	return int64(c.count())
+}
+
+func (c functionalCounter) Snapshot() gometrics.Counter {
+	return gometrics.CounterSnapshot(c.Count())
+}
+
+// Clear, Dec and Inc are not supported, the count belongs to whoever counts
+func (c functionalCounter) Clear() {
+	panic("Clear called on a functionalCounter")
+}
+
+func (c functionalCounter) Dec(int64) {
+	panic("Dec called on a functionalCounter")
+}
+
+func (c functionalCounter) Inc(int64) {
+	panic("Inc called on a functionalCounter")
+}
+
+// Register registers the service's metrics with the SDK's metrics manager.
+func Register(mm bootstrapInterfaces.MetricsManager, subs *submgr.SubscriptionManager, processor *functions.Processor) error {
+	items := map[string]any{
+		ActiveSubscriptions: gometrics.NewFunctionalGauge(func() int64 { return int64(subs.NumSubscriptions()) }),
+		ActiveConnections:   gometrics.NewFunctionalGauge(func() int64 { return int64(web.ActiveConnections()) }),
+		EventsReceived:      functionalCounter{func() uint64 { return processor.DeliveryCounts().Received }},
+		EventsMatched:       functionalCounter{func() uint64 { return processor.DeliveryCounts().Matched }},
+		EventsDelivered:     functionalCounter{func() uint64 { return processor.DeliveryCounts().Delivered }},
+		EventsDropped:       functionalCounter{func() uint64 { return processor.DeliveryCounts().Dropped }},
+		EventsSuppressed:    functionalCounter{func() uint64 { return processor.DeliveryCounts().Suppressed }},
+		ChannelHighWater:    gometrics.NewFunctionalGauge(func() int64 { return int64(subs.ChannelHighWater()) }),
+	}
+	var errs []error
+	for name, item := range items {
+		if err := mm.Register(name, item, nil); err != nil {
+			errs = append(errs, fmt.Errorf("could not register metric %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
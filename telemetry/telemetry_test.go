@@ -0,0 +1,127 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package telemetry
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/functions"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"testing"
+	"time"
+
+	bootstrapInterfaces "github.com/edgexfoundry/go-mod-bootstrap/v4/bootstrap/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// Struct fakeMetricsManager keeps what is registered, the rest of the interface is unused
+type fakeMetricsManager struct {
+	bootstrapInterfaces.MetricsManager
+	registered map[string]any
+}
+
+func (m *fakeMetricsManager) Register(name string, item interface{}, _ map[string]string) error {
+	m.registered[name] = item
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	var subs submgr.SubscriptionManager
+	if err := subs.Init(10, 10, 10, time.Minute, time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer subs.Close()
+	var cfg configuration.Config
+	cfg.SetDefaults()
+	processor := functions.NewProcessor(logger.NewMockClient(), &subs, &cfg)
+	subid, _ := subs.NewSubscription()
+	subinfo := subs.Subscription(subid)
+	_ = subs.Include(subinfo, "a")
+	subs.SetActive(subinfo, true)
+	for _, handle := range subs.SubscribedChannels("a/b") {
+		handle.Send(submgr.ChannelMessage{Payload: "x"})
+		handle.Send(submgr.ChannelMessage{Payload: "y"})
+	}
+
+	mm := &fakeMetricsManager{registered: make(map[string]any)}
+	if err := Register(mm, &subs, &processor); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	for _, name := range []string{ActiveSubscriptions, ActiveConnections, EventsReceived, EventsMatched, EventsDelivered, EventsDropped, EventsSuppressed, ChannelHighWater} {
+		if mm.registered[name] == nil {
+			t.Fatalf("Metric %s not registered", name)
+		}
+	}
+	if n := mm.registered[ActiveSubscriptions].(gometrics.Gauge).Snapshot().Value(); n != 1 {
+		t.Fatalf("Wrong %s: %d", ActiveSubscriptions, n)
+	}
+	if n := mm.registered[ChannelHighWater].(gometrics.Gauge).Snapshot().Value(); n != 2 {
+		t.Fatalf("Wrong %s: %d", ChannelHighWater, n)
+	}
+	if n := mm.registered[EventsReceived].(gometrics.Counter).Snapshot().Count(); n != 0 {
+		t.Fatalf("Wrong %s: %d", EventsReceived, n)
+	}
+}
+
+// Struct infoLogger keeps the messages and key/value pairs of Info calls
+type infoLogger struct {
+	logger.LoggingClient
+	lines map[string][][]any
+}
+
+func (l *infoLogger) Info(msg string, args ...any) {
+	l.lines[msg] = append(l.lines[msg], args)
+}
+
+func TestSummary(t *testing.T) {
+	var subs submgr.SubscriptionManager
+	if err := subs.Init(10, 10, 2, time.Minute, time.Second); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer subs.Close()
+	var cfg configuration.Config
+	cfg.SetDefaults()
+	processor := functions.NewProcessor(logger.NewMockClient(), &subs, &cfg)
+	busy, _ := subs.NewSubscription()
+	subinfo := subs.Subscription(busy)
+	_ = subs.Include(subinfo, "a")
+	subs.SetActive(subinfo, true)
+	idle, _ := subs.NewSubscription()
+	_ = subs.Include(subs.Subscription(idle), "b")
+	send := func(n int) {
+		for i := 0; i < n; i++ {
+			for _, handle := range subs.SubscribedChannels("a/b") {
+				handle.Send(submgr.ChannelMessage{Payload: "x"})
+			}
+		}
+	}
+
+	lc := &infoLogger{LoggingClient: logger.NewMockClient(), lines: make(map[string][][]any)}
+	s := &summary{lc: lc, subs: &subs, processor: &processor, interval: time.Minute}
+	// The buffer holds 2, the third is dropped
+	send(3)
+	s.log()
+	lines := lc.lines["Subscription throughput"]
+	if len(lines) != 1 || lines[0][1] != busy || lines[0][3] != uint64(3) || lines[0][5] != uint64(2) || lines[0][7] != uint64(1) {
+		t.Fatalf("Wrong subscription summaries %v", lines)
+	}
+	if len(lc.lines["Throughput summary"]) != 1 {
+		t.Fatalf("Wrong summaries %v", lc.lines)
+	}
+	// Counts since the last summary, and nothing for subscriptions that got nothing
+	send(1)
+	s.log()
+	s.log()
+	lines = lc.lines["Subscription throughput"]
+	if len(lines) != 2 || lines[1][3] != uint64(1) || lines[1][7] != uint64(1) {
+		t.Fatalf("Wrong subscription summaries %v", lines)
+	}
+	if len(lc.lines["Throughput summary"]) != 3 {
+		t.Fatalf("Wrong summaries %v", lc.lines)
+	}
+	StartSummary(lc, 0, &subs, &processor)()
+}
@@ -33,3 +33,24 @@ func GenerateToken() (string, error) {
 	}
 	return base64.URLEncoding.EncodeToString(bytes), nil
 }
+
+/*
+Valid returns true for a string that could be a token GenerateToken() returned: the length
+of TokenLength bytes base64'ed, all of it the URI-safe base64 alphabet.
+*/
+func Valid(str string) bool {
+	if len(str) != base64.URLEncoding.EncodedLen(TokenLength) {
+		return false
+	}
+	for i := 0; i < len(str); i++ {
+		if !ValidChar(str[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidChar returns true for a character of the URI-safe base64 alphabet tokens are made of.
+func ValidChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
@@ -54,3 +54,19 @@ func TestTokenRandomness(t *testing.T) {
 		}
 	}
 }
+
+// TestTokenValid checks generated tokens are valid, and strings that couldn't be one aren't.
+func TestTokenValid(t *testing.T) {
+	str, err := GenerateToken()
+	if err != nil {
+		t.Fatalf("Error generating token: %v", err)
+	}
+	if !Valid(str) {
+		t.Fatalf("Token generated (%s) is not valid", str)
+	}
+	for _, bad := range []string{"", str[1:], str + "A", str[:23] + "+", str[:23] + "/", str[:23] + "=", "../" + str[3:]} {
+		if Valid(bad) {
+			t.Errorf("%q is valid", bad)
+		}
+	}
+}
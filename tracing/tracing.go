@@ -0,0 +1,129 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+Package for OpenTelemetry tracing of messages from the message bus to the event streams.
+
+Spans are made with the global tracer provider, a no-op until Start() is called, so
+tracing costs next to nothing when disabled. A message's trace ID is its EdgeX correlation
+ID (a UUID is 16 bytes, like a trace ID), so a trace can be found from the service's logs,
+and other services tracing the same way join it.
+*/
+package tracing
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of our tracer, the instrumentation scope of our spans
+const tracerName = "github.com/edgexfoundry-holding/edgex-sse"
+
+// Key of the W3C trace context in carriers
+const traceParentKey = "traceparent"
+
+// Type correlationKey is the context key of the correlation ID a new trace takes its ID from.
+type correlationKey struct{}
+
+// Struct idGenerator makes trace IDs from correlation IDs, and random span IDs.
+type idGenerator struct {
+	lock   sync.Mutex
+	random *rand.ChaCha8
+}
+
+func newIDGenerator() *idGenerator {
+	var seed [32]byte
+	_, _ = crand.Read(seed[:])
+	return &idGenerator{random: rand.NewChaCha8(seed)}
+}
+
+func (g *idGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var traceID trace.TraceID
+	if correlationID, ok := ctx.Value(correlationKey{}).(string); ok {
+		if id, err := uuid.Parse(correlationID); err == nil {
+			traceID = trace.TraceID(id)
+		}
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for !traceID.IsValid() {
+		_, _ = g.random.Read(traceID[:])
+	}
+	return traceID, g.newSpanID()
+}
+
+func (g *idGenerator) NewSpanID(_ context.Context, _ trace.TraceID) trace.SpanID {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.newSpanID()
+}
+
+// newSpanID (an internal API) returns a random span ID. Call under lock.
+func (g *idGenerator) newSpanID() trace.SpanID {
+	var spanID trace.SpanID
+	for !spanID.IsValid() {
+		binary.BigEndian.PutUint64(spanID[:], g.random.Uint64())
+	}
+	return spanID
+}
+
+/*
+Start exports spans as configured, until the returned function is called to flush and stop.
+With tracing disabled, it does nothing.
+*/
+func Start(config configuration.TracingConfig, serviceName string) func(context.Context) error {
+	if !config.Enabled {
+		return func(context.Context) error { return nil }
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(newExporter(config.Endpoint)),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		sdktrace.WithIDGenerator(newIDGenerator()),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
+
+// Tracer returns the tracer for our spans.
+func Tracer() trace.Tracer {
+	return otel.GetTracerProvider().Tracer(tracerName)
+}
+
+// WithCorrelationID returns a context in which a new trace takes its ID from the correlation ID.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, correlationID)
+}
+
+/*
+TraceParent returns the W3C traceparent of the span in the context, to carry it where a
+context can't go (e.g. through a subscription's channel), or "" if there is none.
+*/
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier[traceParentKey]
+}
+
+// WithTraceParent returns a context whose spans are children of the span with that traceparent.
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceParentKey: traceParent})
+}
@@ -0,0 +1,120 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceIDFromCorrelationID(t *testing.T) {
+	provider := sdktrace.NewTracerProvider(sdktrace.WithIDGenerator(newIDGenerator()))
+	tracer := provider.Tracer(tracerName)
+	ctx, span := tracer.Start(WithCorrelationID(context.Background(), "0f2d6a4e-8b1c-4c3d-9e5f-a6b7c8d9e0f1"), "test")
+	defer span.End()
+	if id := span.SpanContext().TraceID().String(); id != "0f2d6a4e8b1c4c3d9e5fa6b7c8d9e0f1" {
+		t.Fatalf("Wrong trace ID %s", id)
+	}
+	// Not a UUID, a random ID
+	_, other := tracer.Start(WithCorrelationID(context.Background(), "not-a-uuid"), "test")
+	defer other.End()
+	if !other.SpanContext().TraceID().IsValid() {
+		t.Fatalf("Invalid trace ID")
+	}
+
+	// The trace context survives a trip through a string
+	traceParent := TraceParent(ctx)
+	if !strings.HasPrefix(traceParent, "00-0f2d6a4e8b1c4c3d9e5fa6b7c8d9e0f1-") {
+		t.Fatalf("Wrong traceparent %q", traceParent)
+	}
+	_, child := tracer.Start(WithTraceParent(context.Background(), traceParent), "child")
+	defer child.End()
+	if child.SpanContext().TraceID() != span.SpanContext().TraceID() {
+		t.Fatalf("Child span in another trace")
+	}
+	if TraceParent(context.Background()) != "" || WithTraceParent(context.Background(), "") != context.Background() {
+		t.Fatalf("Trace context with no span")
+	}
+}
+
+func TestExporter(t *testing.T) {
+	var received otlpTraces
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Wrong request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = otlpTraces{}
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("Could not decode %s: %v", body, err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	exp := newExporter(server.URL + "/")
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "edgex-sse"))),
+	)
+	defer provider.Shutdown(context.Background())
+	tracer := provider.Tracer(tracerName)
+	ctx, parent := tracer.Start(context.Background(), "parent", trace.WithSpanKind(trace.SpanKindConsumer))
+	_, child := tracer.Start(ctx, "child", trace.WithAttributes(attribute.Int("n", 3), attribute.Bool("b", true)))
+	child.SetStatus(codes.Error, "failed")
+	child.End()
+
+	if len(received.ResourceSpans) != 1 || len(received.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("Wrong structure %+v", received)
+	}
+	resourceSpans := received.ResourceSpans[0]
+	if attrs := resourceSpans.Resource.Attributes; len(attrs) != 1 || *attrs[0].Value.StringValue != "edgex-sse" {
+		t.Fatalf("Wrong resource %+v", resourceSpans.Resource)
+	}
+	scopeSpans := resourceSpans.ScopeSpans[0]
+	if scopeSpans.Scope.Name != tracerName || len(scopeSpans.Spans) != 1 {
+		t.Fatalf("Wrong scope spans %+v", scopeSpans)
+	}
+	span := scopeSpans.Spans[0]
+	if span.Name != "child" || span.TraceId != parent.SpanContext().TraceID().String() ||
+		span.ParentSpanId != parent.SpanContext().SpanID().String() || len(span.SpanId) != 16 {
+		t.Fatalf("Wrong span %+v", span)
+	}
+	if span.Status.Code != 2 || span.Status.Message != "failed" || span.Kind != 1 {
+		t.Fatalf("Wrong status or kind %+v", span)
+	}
+	if len(span.Attributes) != 2 || *span.Attributes[0].Value.IntValue != "3" || !*span.Attributes[1].Value.BoolValue {
+		t.Fatalf("Wrong attributes %+v", span.Attributes)
+	}
+
+	parent.End()
+	if span := received.ResourceSpans[0].ScopeSpans[0].Spans[0]; span.Kind != 5 || span.ParentSpanId != "" || span.Status.Code != 0 {
+		t.Fatalf("Wrong root span %+v", span)
+	}
+
+	// Errors from the receiver are reported
+	status = http.StatusBadRequest
+	if err := exp.ExportSpans(context.Background(), tracetest.SpanStubs{{Name: "rejected"}}.Snapshots()); err == nil {
+		t.Fatalf("No error from a rejected export")
+	}
+	if err := exp.ExportSpans(context.Background(), nil); err != nil {
+		t.Fatalf("Error exporting nothing: %v", err)
+	}
+}
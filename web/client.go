@@ -0,0 +1,68 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+/*
+clientAddress returns the IP address of the client that made the request.
+
+That is the address the request came from, unless it came from one of the TrustedProxies.
+Then it is the last address in X-Forwarded-For that is not a trusted proxy (the ones
+before it could have been made up by the client), or X-Real-IP if there is no
+X-Forwarded-For.
+*/
+func clientAddress(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	proxies := interfaces.App.Config.SSE.TrustedProxyList()
+	if len(proxies) == 0 || !isTrusted(proxies, remote) {
+		return remote
+	}
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// Can't tell who sent a malformed entry, stop at the last one we can
+				break
+			}
+			remote = hop
+			if !isTrusted(proxies, hop) {
+				break
+			}
+		}
+		return remote
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return remote
+}
+
+// isTrusted returns true if address is in one of the proxies' ranges.
+func isTrusted(proxies []netip.Prefix, address string) bool {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+	for _, proxy := range proxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
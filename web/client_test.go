@@ -0,0 +1,53 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"net/http"
+	"testing"
+)
+
+func TestClientAddress(t *testing.T) {
+	interfaces.App.Config = &configuration.Config{}
+	interfaces.App.Config.SetDefaults()
+	tests := []struct {
+		name      string
+		trusted   string
+		remote    string
+		forwarded []string
+		realIP    string
+		expected  string
+	}{
+		{"direct", "", "192.0.2.1:5000", nil, "", "192.0.2.1"},
+		{"untrusted proxy headers ignored", "", "192.0.2.1:5000", []string{"198.51.100.7"}, "198.51.100.8", "192.0.2.1"},
+		{"trusted proxy", "127.0.0.1", "127.0.0.1:5000", []string{"198.51.100.7"}, "", "198.51.100.7"},
+		{"spoofed entries before the proxy's", "10.0.0.0/8", "10.0.0.2:5000", []string{"203.0.113.9, 198.51.100.7", "10.0.0.1"}, "", "198.51.100.7"},
+		{"all trusted", "10.0.0.0/8", "10.0.0.2:5000", []string{"10.0.0.3"}, "", "10.0.0.3"},
+		{"malformed entry", "10.0.0.0/8", "10.0.0.2:5000", []string{"198.51.100.7, unknown"}, "", "10.0.0.2"},
+		{"real IP", "::1", "[::1]:5000", nil, "198.51.100.8", "198.51.100.8"},
+		{"forwarded over real IP", "::1", "[::1]:5000", []string{"198.51.100.7"}, "198.51.100.8", "198.51.100.7"},
+	}
+	for _, test := range tests {
+		interfaces.App.Config.SSE.TrustedProxies = test.trusted
+		if err := interfaces.App.Config.Validate(); err != nil {
+			t.Fatalf("Validate failed: %v", err)
+		}
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = test.remote
+		for _, forwarded := range test.forwarded {
+			r.Header.Add("X-Forwarded-For", forwarded)
+		}
+		if test.realIP != "" {
+			r.Header.Set("X-Real-IP", test.realIP)
+		}
+		if got := clientAddress(r); got != test.expected {
+			t.Errorf("%s: client address %s, expected %s", test.name, got, test.expected)
+		}
+	}
+}
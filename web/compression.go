@@ -0,0 +1,125 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Content coding of event streams compressed with CompressEvents
+const gzipEncoding = "gzip"
+
+/*
+streamCompression returns the content coding to compress an event stream with: gzip if
+CompressEvents is set and the request accepts it, else "" for none.
+*/
+func streamCompression(r *http.Request) string {
+	if !interfaces.App.Config.SSE.CompressEvents {
+		return ""
+	}
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), gzipEncoding) {
+			continue
+		}
+		// A zero weight refuses it
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err != nil || q <= 0 {
+					return ""
+				}
+			}
+		}
+		return gzipEncoding
+	}
+	return ""
+}
+
+// Struct countedWriter adds the bytes written through it to count.
+type countedWriter struct {
+	w     io.Writer
+	count *atomic.Uint64
+}
+
+func (c countedWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.count.Add(uint64(n))
+	return n, err
+}
+
+/*
+Struct streamWriter is what an event stream is written through: compressed, if the stream
+is, and counting the bytes before and after in the stream's stats.
+
+A compressed stream is flushed less often while the subscription has more events waiting,
+so those are compressed together, which shrinks them far more than each on its own: it is
+flushed when there are none left, or CompressionBlockSize bytes have been written since it
+last was. Streams that keep up still get each event as it comes; those falling behind, e.g.
+on a slow link, get bigger blocks, until they catch up. Uncompressed streams are flushed
+after each event, as there is nothing to gain by waiting.
+*/
+type streamWriter struct {
+	stream    *eventStream
+	out       io.Writer
+	gz        *gzip.Writer
+	blockSize int
+	// Bytes written since the last flush
+	pending   int
+}
+
+// newStreamWriter sets up the response to an event stream's request, with its compression if it has one.
+func newStreamWriter(w http.ResponseWriter, stream *eventStream) *streamWriter {
+	wire := countedWriter{w: w, count: &stream.bytesSent}
+	rv := &streamWriter{stream: stream, out: wire, blockSize: int(interfaces.App.Config.SSE.CompressionBlockSize)}
+	if interfaces.App.Config.SSE.CompressEvents {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	if stream.compression == gzipEncoding {
+		w.Header().Set("Content-Encoding", gzipEncoding)
+		rv.gz = gzip.NewWriter(wire)
+		rv.out = rv.gz
+	}
+	return rv
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	n, err := s.out.Write(p)
+	s.stream.bytesWritten.Add(uint64(n))
+	s.pending += n
+	return n, err
+}
+
+// hold returns true if a flush can wait, as more events are about to be written to a compressed stream.
+func (s *streamWriter) hold(more bool) bool {
+	return more && s.gz != nil && s.pending < s.blockSize
+}
+
+// flush sends what has been written to the client, compressing it first if the stream is compressed.
+func (s *streamWriter) flush(rc *http.ResponseController) error {
+	if s.gz != nil {
+		if err := s.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	s.pending = 0
+	s.stream.flushes.Add(1)
+	return rc.Flush()
+}
+
+// close ends a compressed stream, so the client can tell it wasn't cut short.
+func (s *streamWriter) close() {
+	if s.gz != nil {
+		s.gz.Close()
+	}
+}
@@ -0,0 +1,90 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"maps"
+	"net/http"
+	"slices"
+
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+)
+
+// Shown instead of a password, or another setting not to be shown, that is set
+const redacted = "<redacted>"
+
+// redact replaces the setting with redacted if it is set.
+func redact(setting *string) {
+	if *setting != "" {
+		*setting = redacted
+	}
+}
+
+/*
+ProcessConfigRequest handles GET of the SSE configuration section in force: as loaded, with
+its defaults and environment overrides, with the limits changed at LimitsPath and the
+Writable configuration last applied in their places, and what is derived from it, so
+operators can check exactly what the service runs with.
+
+It is served on the events port, which doesn't authenticate callers, so passwords and
+usernames, the identities of the Roles, TrustedProxies and the hosts of the Redis and Core
+Keeper subscriptions are persisted to are redacted, not to tell anyone who has which role or
+where to find them. StaticSubscriptions are listed without their names, which are their IDs.
+The AdminRole is shown, as its identities are not.
+*/
+func ProcessConfigRequest(w http.ResponseWriter, r *http.Request) {
+	type derivedConfig struct {
+		// Durations as parsed from their settings
+		Durations         map[string]string `json:"durations"`
+		EventsListenAddrs []string          `json:"eventsListenAddrs"`
+		LimitMaxima       dtos.Limits       `json:"limitMaxima"`
+	}
+	type configReturn struct {
+		commonDTO.BaseResponse `json:",inline"`
+		SSE                    configuration.SseConfig                   `json:"sse"`
+		// In the order of their names
+		StaticSubscriptions    []configuration.StaticSubscriptionConfig `json:"staticSubscriptions"`
+		Derived                derivedConfig                            `json:"derived"`
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sse := interfaces.App.Config.SSE
+	sse.SubscriptionLimit, sse.PrefixesLimit, sse.EventBuffer = interfaces.App.Subs.Limits()
+	if interfaces.App.Processor != nil {
+		sse.Writable = interfaces.App.Processor.Writable()
+	}
+	redact(&sse.ExternalMQTT.Username)
+	redact(&sse.ExternalMQTT.Password)
+	redact(&sse.TrustedProxies)
+	redact(&sse.Redis.Host)
+	redact(&sse.Keeper.Host)
+	// A copy, not to change the configuration's own
+	roles := make(map[string]configuration.RoleConfig, len(sse.Roles))
+	for name, role := range sse.Roles {
+		redact(&role.Identities)
+		roles[name] = role
+	}
+	sse.Roles = roles
+	rv := configReturn{StaticSubscriptions: make([]configuration.StaticSubscriptionConfig, 0, len(sse.StaticSubscriptions))}
+	for _, name := range slices.Sorted(maps.Keys(sse.StaticSubscriptions)) {
+		rv.StaticSubscriptions = append(rv.StaticSubscriptions, sse.StaticSubscriptions[name])
+	}
+	sse.StaticSubscriptions = nil
+	rv.BaseResponse = commonDTO.NewBaseResponse("", "", http.StatusOK)
+	rv.SSE = sse
+	// Validated at startup
+	rv.Derived.EventsListenAddrs, _ = sse.EventsListenAddrs()
+	rv.Derived.Durations = sse.Durations().Strings()
+	maxima := &rv.Derived.LimitMaxima
+	maxima.SubscriptionLimit, maxima.PrefixesLimit, maxima.EventBuffer = interfaces.App.Config.SSE.LimitMaxima()
+	sendResponse(w, r, rv, http.StatusOK)
+}
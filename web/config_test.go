@@ -0,0 +1,86 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	sse := &interfaces.App.Config.SSE
+	sse.ExternalMQTT.Password = "hunter2"
+	sse.TrustedProxies = "10.0.0.1"
+	sse.Roles = map[string]configuration.RoleConfig{"admin": {Identities: "alice", SubscriptionLimit: 5, PrefixesLimit: 5}}
+	sse.AdminRole = "admin"
+	sse.StaticSubscriptions = map[string]configuration.StaticSubscriptionConfig{
+		"secret-b": {Include: "b"},
+		"secret-a": {Include: "a"},
+	}
+	sse.HeartbeatInterval = "45s"
+	if err := interfaces.App.Config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	// Limits changed while running are those shown
+	if err := interfaces.App.Subs.SetLimits(7, 8, 90); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	ProcessConfigRequest(rr, httptest.NewRequest(http.MethodGet, sse.ConfigRoute(), nil))
+	var body struct {
+		SSE                 map[string]any                           `json:"sse"`
+		StaticSubscriptions []configuration.StaticSubscriptionConfig `json:"staticSubscriptions"`
+		Derived             struct {
+			Durations         map[string]string `json:"durations"`
+			EventsListenAddrs []string          `json:"eventsListenAddrs"`
+			LimitMaxima       map[string]uint   `json:"limitMaxima"`
+		} `json:"derived"`
+	}
+	if rr.Code != http.StatusOK || json.Unmarshal(rr.Body.Bytes(), &body) != nil {
+		t.Fatalf("Got %d %s", rr.Code, rr.Body.String())
+	}
+	if body.SSE["SubscriptionLimit"] != 7.0 || body.SSE["PrefixesLimit"] != 8.0 || body.SSE["EventBuffer"] != 90.0 || body.SSE["HeartbeatInterval"] != "45s" {
+		t.Fatalf("Wrong settings %v", body.SSE)
+	}
+	if mqtt := body.SSE["ExternalMQTT"].(map[string]any); mqtt["Password"] != redacted {
+		t.Fatalf("Password not redacted: %v", mqtt)
+	}
+	role := body.SSE["Roles"].(map[string]any)["admin"].(map[string]any)
+	if role["Identities"] != redacted || role["SubscriptionLimit"] != 5.0 || body.SSE["AdminRole"] != "admin" {
+		t.Fatalf("Role identities not redacted: %v", body.SSE["Roles"])
+	}
+	if body.SSE["TrustedProxies"] != redacted || body.SSE["Redis"].(map[string]any)["Host"] != redacted || body.SSE["Keeper"].(map[string]any)["Host"] != redacted {
+		t.Fatalf("Proxies or hosts not redacted: %v", body.SSE)
+	}
+	if body.SSE["StaticSubscriptions"] != nil || !reflect.DeepEqual(body.StaticSubscriptions, []configuration.StaticSubscriptionConfig{{Include: "a"}, {Include: "b"}}) {
+		t.Fatalf("Wrong static subscriptions %v, %v", body.SSE["StaticSubscriptions"], body.StaticSubscriptions)
+	}
+	if body.Derived.Durations["HeartbeatInterval"] != "45s" || body.Derived.Durations["SubscriptionIdleExpiration"] != "1m0s" {
+		t.Fatalf("Wrong durations %v", body.Derived.Durations)
+	}
+	if !reflect.DeepEqual(body.Derived.EventsListenAddrs, []string{"127.0.0.1:59748"}) || body.Derived.LimitMaxima["subscriptionLimit"] != 50 {
+		t.Fatalf("Wrong derived values %+v", body.Derived)
+	}
+	// The configuration itself is left as it is
+	if sse.ExternalMQTT.Password != "hunter2" || len(sse.StaticSubscriptions) != 2 || sse.Roles["admin"].Identities != "alice" || sse.Redis.Host != "localhost" {
+		t.Fatal("Configuration changed by the request")
+	}
+
+	rr = httptest.NewRecorder()
+	ProcessConfigRequest(rr, httptest.NewRequest(http.MethodPost, sse.ConfigRoute(), nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Got %d for POST, expected 405", rr.Code)
+	}
+}
@@ -0,0 +1,55 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"net/http"
+	"sync"
+)
+
+var (
+	// Open event streams per client address
+	connections     = make(map[string]uint)
+	connectionsLock sync.Mutex
+)
+
+/*
+acquireConnection counts an event stream for the client's address, returning false if it
+already has ConnectionsPerClient open, so one host can't use up the service's connections.
+Call the returned function when the stream ends.
+*/
+func acquireConnection(r *http.Request) (func(), bool) {
+	client := clientAddress(r)
+	limit := interfaces.App.Config.SSE.ConnectionsPerClient
+	connectionsLock.Lock()
+	defer connectionsLock.Unlock()
+	if limit > 0 && connections[client] >= limit {
+		return nil, false
+	}
+	connections[client]++
+	return func() {
+		connectionsLock.Lock()
+		defer connectionsLock.Unlock()
+		if connections[client] <= 1 {
+			delete(connections, client)
+			return
+		}
+		connections[client]--
+	}, true
+}
+
+// ActiveConnections returns the number of event streams open.
+func ActiveConnections() uint {
+	connectionsLock.Lock()
+	defer connectionsLock.Unlock()
+	var rv uint
+	for _, n := range connections {
+		rv += n
+	}
+	return rv
+}
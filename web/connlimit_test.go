@@ -0,0 +1,56 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConnectionLimit(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	interfaces.App.Config.SSE.ConnectionsPerClient = 2
+	subid := checkCreateRequest(t, http.StatusCreated)
+	req, _ := http.NewRequest(http.MethodGet, interfaces.App.Config.SSE.EventsRoute()+subid, nil)
+	req.RemoteAddr = "192.0.2.30:40000"
+	other, _ := http.NewRequest(http.MethodGet, interfaces.App.Config.SSE.EventsRoute()+subid, nil)
+	other.RemoteAddr = "192.0.2.31:40000"
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, ok := acquireConnection(req)
+		if !ok {
+			t.Fatalf("Connection %d refused under the limit", i+1)
+		}
+		releases = append(releases, release)
+	}
+	rr := httptest.NewRecorder()
+	ProcessEventsRequest(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Got status %d past the connection limit, expected 429", rr.Code)
+	}
+	// Other clients are not affected
+	release, ok := acquireConnection(other)
+	if !ok {
+		t.Fatal("Connection from another client refused")
+	}
+	release()
+	// Closing a stream makes room
+	releases[0]()
+	if release, ok = acquireConnection(req); !ok {
+		t.Fatal("Connection refused after one was closed")
+	}
+	release()
+	releases[1]()
+	connectionsLock.Lock()
+	defer connectionsLock.Unlock()
+	if len(connections) != 0 {
+		t.Fatalf("Connections left counted: %v", connections)
+	}
+}
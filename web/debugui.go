@@ -0,0 +1,56 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"bytes"
+	_ "embed"
+	"html/template"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed debugui.html
+var debugUIPage string
+
+var debugUITemplate = template.Must(template.New("debugui").Parse(debugUIPage))
+
+/*
+ProcessDebugUIRequest handles GET of the debug page: a form that creates a subscription to
+the topics given, and shows its live events, so field engineers can check data is flowing
+with nothing but a browser. The subscription is deleted when the page is left. Only
+served with DebugUI set.
+*/
+func ProcessDebugUIRequest(c echo.Context) error {
+	w := c.Response()
+	r := c.Request()
+	sse := &interfaces.App.Config.SSE
+
+	scheme := "http"
+	if sse.EventsTLSSecretName != "" {
+		scheme = "https"
+	}
+	var page bytes.Buffer
+	err := debugUITemplate.Execute(&page, map[string]any{
+		"SubscriptionPath": sse.SubscriptionRoute(),
+		"EventsPath":       sse.EventsRoute(),
+		"EventsPort":       sse.EventsPort,
+		"EventsScheme":     scheme,
+	})
+	if err != nil {
+		respondBase(w, r, requestId(r), http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page.Bytes())
+	return nil
+}
@@ -0,0 +1,40 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestDebugUI(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	interfaces.App.Config.SSE.SubscriptionPath = "/sse/subscription/"
+	interfaces.App.Config.SSE.EventsPort = 61000
+	route := interfaces.App.Config.SSE.DebugUIRoute()
+	req, _ := http.NewRequest(http.MethodGet, route, nil)
+	rr := httptest.NewRecorder()
+	router := echo.New()
+	router.GET(route, ProcessDebugUIRequest)
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("Got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	// The configured paths and port are in the script, as JavaScript strings
+	page := rr.Body.String()
+	for _, expected := range []string{`const subscriptionPath = "/sse/subscription";`, `"http" + "://"`, ` 61000 `, `"/api/v3/events/"`} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("No %s in page", expected)
+		}
+	}
+}
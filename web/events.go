@@ -8,43 +8,284 @@ package web
 
 import (
 	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"github.com/edgexfoundry-holding/edgex-sse/tracing"
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Type of the event telling a reconnecting client that it missed events, and should resynchronize
+const ResetEventType = "reset"
+
+// Type of the heartbeat event, with HeartbeatEvents configured
+const PingEventType = "ping"
+
+// Type of the event telling a client its stream's buffer filled past BackpressureMark
+const BackpressureEventType = "backpressure"
+
+// Type of the events a payload longer than the subscription's ChunkSize option is sent in
+const ChunkEventType = "chunk"
+
+/*
+Query parameter of an /events request that, with ExclusiveStreams, takes the subscription's
+event stream over from the connection that has it open: "true" ends that one.
+*/
+const TakeoverParameter = "takeover"
+
+// Headers of the response to HEAD of an event stream
+const (
+	// Number of messages waiting to be sent
+	BufferDepthHeader = "X-Buffer-Depth"
+	// When a message was last delivered to the subscription, RFC 3339, absent if never
+	LastDeliveryHeader = "X-Last-Delivery"
+	// Whether an event stream is reading the subscription, "true" or "false"
+	StreamActiveHeader = "X-Stream-Active"
 )
 
+/*
+deliveryPayload returns the payload of a message wrapped in a DeliveryEnvelope, or as it is
+if it can't be.
+*/
+func deliveryPayload(msg submgr.ChannelMessage) string {
+	envelope := dtos.DeliveryEnvelope{
+		Seq:           msg.Seq,
+		Topic:         msg.Topic,
+		ReceivedAt:    msg.ReceivedAt.UTC().Format(time.RFC3339Nano),
+		CorrelationId: msg.CorrelationID,
+		EventType:     msg.EventType,
+		Payload:       json.RawMessage(msg.Payload),
+	}
+	if !json.Valid([]byte(msg.Payload)) {
+		envelope.Payload, _ = json.Marshal(msg.Payload)
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return msg.Payload
+	}
+	return string(payload)
+}
+
+/*
+chunks returns the messages to write for one: itself, or if its payload (in its delivery
+envelope, if it has one) is longer than its ChunkSize, chunk events with a part of it each.
+Parts are split between UTF-8 characters, so each can be JSON. The correlation ID goes
+with the first part, the event ID with the last.
+*/
+func chunks(msg submgr.ChannelMessage) []submgr.ChannelMessage {
+	if msg.Delivery {
+		msg.Payload = deliveryPayload(msg)
+		msg.Delivery = false
+	}
+	if msg.ChunkSize <= 0 || len(msg.Payload) <= msg.ChunkSize {
+		return []submgr.ChannelMessage{msg}
+	}
+	rv := make([]submgr.ChannelMessage, 0, len(msg.Payload)/msg.ChunkSize+1)
+	for rest := msg.Payload; rest != ""; {
+		end := min(msg.ChunkSize, len(rest))
+		for end < len(rest) && end > 0 && !utf8.RuneStart(rest[end]) {
+			end--
+		}
+		data, _ := json.Marshal(dtos.ChunkEvent{
+			Part:      len(rv),
+			Last:      end == len(rest),
+			EventType: msg.EventType,
+			Data:      rest[:end],
+		})
+		part := submgr.ChannelMessage{EventType: ChunkEventType, Payload: string(data)}
+		if len(rv) == 0 {
+			part.CorrelationID = msg.CorrelationID
+		}
+		if end == len(rest) {
+			part.Seq = msg.Seq
+		}
+		rv = append(rv, part)
+		rest = rest[end:]
+	}
+	return rv
+}
+
+/*
+eventId returns the event ID of the message with sequence number seq. With Replication, it
+starts with the ReplicaId, as each replica numbers events by itself.
+*/
+func eventId(seq uint64) string {
+	if interfaces.App.ReplicaId == "" {
+		return strconv.FormatUint(seq, 10)
+	}
+	return interfaces.App.ReplicaId + "." + strconv.FormatUint(seq, 10)
+}
+
+/*
+parseEventId returns the sequence number of an event ID from eventId().
+
+Error is returned if it isn't one, or it is from another replica (or this one before a
+restart), where the same number is another message.
+*/
+func parseEventId(id string) (uint64, error) {
+	if interfaces.App.ReplicaId != "" {
+		var ok bool
+		if id, ok = strings.CutPrefix(id, interfaces.App.ReplicaId+"."); !ok {
+			return 0, errors.New("event ID from another replica")
+		}
+	}
+	return strconv.ParseUint(id, 10, 64)
+}
+
+/*
+writeEvent writes one message in event stream format. A payload with line breaks
+(e.g. pretty-printed JSON passed through as received) is sent as several data lines,
+which the client joins back together with newlines.
+*/
+func writeEvent(w io.Writer, msg submgr.ChannelMessage) {
+	if msg.Delivery {
+		msg.Payload = deliveryPayload(msg)
+	}
+	// Numbered messages can be replayed, the client sends the last ID back when it reconnects
+	if msg.Seq != 0 {
+		io.WriteString(w, "id: "+eventId(msg.Seq)+"\n")
+	}
+	if msg.EventType != "" {
+		io.WriteString(w, "event: "+msg.EventType+"\n")
+	}
+	// A comment, for tracing a problem event back through the logs of other services
+	if msg.CorrelationID != "" && !strings.ContainsAny(msg.CorrelationID, "\r\n") {
+		io.WriteString(w, ": correlation-id "+msg.CorrelationID+"\n")
+	}
+	for _, line := range strings.Split(msg.Payload, "\n") {
+		io.WriteString(w, "data: "+strings.TrimSuffix(line, "\r")+"\n")
+	}
+	io.WriteString(w, "\n")
+}
+
+/*
+writeReset writes the reset event, telling a reconnecting client that events after the one
+it last received are lost, so it should resynchronize, e.g. read the current state of what
+it follows from core-data or core-command. Its ID is that of the last event before those
+that follow, or empty (so EventSource doesn't send it back) if there is none.
+*/
+func writeReset(w io.Writer, lastEventId string, from uint64) {
+	id := ""
+	if from != 0 {
+		id = eventId(from)
+	}
+	data, _ := json.Marshal(struct {
+		LastEventId string `json:"lastEventId"`
+	}{lastEventId})
+	io.WriteString(w, "id: "+id+"\n")
+	io.WriteString(w, "event: "+ResetEventType+"\n")
+	io.WriteString(w, "data: "+string(data)+"\n\n")
+}
+
+/*
+probeStream answers HEAD of an event stream, so clients and orchestrators can check it
+without opening one: how many messages wait for it, when one last came, and whether
+someone is reading it.
+*/
+func probeStream(w http.ResponseWriter, subInfo *submgr.SubscriptionInfo) {
+	subs := interfaces.App.Subs
+	w.Header().Set(BufferDepthHeader, strconv.Itoa(subs.ChannelDepth(subInfo)))
+	if last := subs.LastDelivered(subInfo); !last.IsZero() {
+		w.Header().Set(LastDeliveryHeader, last.UTC().Format(time.RFC3339Nano))
+	}
+	w.Header().Set(StreamActiveHeader, strconv.FormatBool(subs.IsActive(subInfo)))
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", BufferDepthHeader+", "+LastDeliveryHeader+", "+StreamActiveHeader)
+	w.WriteHeader(http.StatusOK)
+}
+
+// writePing writes the ping event, with the time now, and the stream's buffer depth and drops.
+func writePing(w io.Writer, depth int, dropped uint64) {
+	data, _ := json.Marshal(dtos.PingEvent{
+		Time:        time.Now().UTC().Format(time.RFC3339Nano),
+		BufferDepth: depth,
+		Dropped:     dropped,
+	})
+	io.WriteString(w, "event: "+PingEventType+"\n")
+	io.WriteString(w, "data: "+string(data)+"\n\n")
+}
+
+// writeBackpressure writes the backpressure event, with how full the stream's buffer is.
+func writeBackpressure(w io.Writer, depth int, size int) {
+	data, _ := json.Marshal(dtos.BackpressureEvent{BufferDepth: depth, BufferSize: size})
+	io.WriteString(w, "event: "+BackpressureEventType+"\n")
+	io.WriteString(w, "data: "+string(data)+"\n\n")
+}
+
+// writeRetry writes the retry field, telling the client how long to wait before it reconnects.
+func writeRetry(w io.Writer, delay time.Duration) {
+	io.WriteString(w, "retry: "+strconv.FormatInt(delay.Milliseconds(), 10)+"\n\n")
+}
+
+// traced (an internal API) runs send in a span that is a child of the message's delivery span, if it has one.
+func traced(ctx context.Context, msg submgr.ChannelMessage, send func() bool) bool {
+	if msg.TraceParent == "" {
+		return send()
+	}
+	_, span := tracing.Tracer().Start(tracing.WithTraceParent(ctx, msg.TraceParent), "sse.write", trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+	if !send() {
+		span.SetStatus(codes.Error, "could not write to the event stream")
+		return false
+	}
+	return true
+}
 
 func ProcessEventsRequest(w http.ResponseWriter, r *http.Request) {
 	lc := interfaces.App.Logger
 	subs := interfaces.App.Subs
 
-	if r.Method != http.MethodGet {
-		http.Error(w, "Only GET is allowed", http.StatusMethodNotAllowed)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Only GET and HEAD are allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !strings.HasPrefix(r.URL.Path, "/api/v3/events/") {
+	prefix := interfaces.App.Config.SSE.EventsRoute()
+	if !strings.HasPrefix(r.URL.Path, prefix) {
 		http.Error(w, "Improper request path", http.StatusNotFound)
 		return
 	}
-	subid := strings.TrimPrefix(r.URL.Path, "/api/v3/events/")
+	subid := strings.TrimPrefix(r.URL.Path, prefix)
 	if subid == "" || strings.ContainsRune(subid, '/') {
 		http.Error(w, "Subscription ID required", http.StatusNotFound)
 		return
 	}
-	lc.Debugf("Got /events request for subscription %s", subid)
+	if !validSubscriptionId(subid) {
+		http.Error(w, "Malformed subscription ID", http.StatusBadRequest)
+		return
+	}
+	lc.Debugf("Got /events request for subscription %s from %s", subid, clientAddress(r))
+	if wait, locked := lockedOut(r); locked {
+		respondLockedOut(w, r, wait)
+		return
+	}
+	if err := authorizeStream(r, subid); err != nil {
+		lc.Debugf("Refused /events request for subscription %s from %s: %s", subid, clientAddress(r), err.Error())
+		http.Error(w, "Valid stream token required", http.StatusUnauthorized)
+		return
+	}
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		http.Error(w, "SSE unsupported", http.StatusInternalServerError)
 		return
 	}
-	lockmgt.RLock()
-	subInfo, ok := g_subscriptions[subid]
+	subInfo, ok := lookupSubscription(subid)
 	if !ok {
+		lookupFailed(r)
 		http.Error(w, "Subscription not found", http.StatusNotFound)
-		lockmgt.RUnlock()
 		return
 	}
-	lockmgt.RUnlock()
 	
 	check1 := subs.IsSubscriptionDeleted(subInfo)
 	if check1 {
@@ -56,11 +297,48 @@ func ProcessEventsRequest(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	if r.Method == http.MethodHead {
+		probeStream(w, subInfo)
+		return
+	}
+	release, ok := acquireConnection(r)
+	if !ok {
+		lc.Infof("Refused event stream for subscription %s, too many connections from %s", subid, clientAddress(r))
+		http.Error(w, "Too many connections", http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+	takeover := false
+	if parameter := r.URL.Query().Get(TakeoverParameter); parameter != "" {
+		var err error
+		if takeover, err = strconv.ParseBool(parameter); err != nil {
+			http.Error(w, "Malformed takeover parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	stream, err := openStream(r, subInfo, takeover)
+	if errors.Is(err, errTakeoverPending) {
+		lc.Infof("Refused event stream for subscription %s from %s, the one it takes over hasn't ended", subid, clientAddress(r))
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Event stream taken over hasn't ended yet", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		lc.Infof("Refused event stream for subscription %s from %s, it has one open already", subid, clientAddress(r))
+		http.Error(w, "Subscription already has an event stream", http.StatusConflict)
+		return
+	}
+	defer stream.close()
+	if takeover {
+		lc.Debugf("Event stream of subscription %s taken over by %s", subid, clientAddress(r))
+	}
 	rxchan, err := subs.ReceiveChannel(subInfo)
 	if err != nil || rxchan == nil {
 		http.Error(w, "Subscription not found", http.StatusNotFound)
 		return
 	}
+	out := newStreamWriter(w, stream)
+	defer out.close()
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
@@ -69,21 +347,175 @@ func ProcessEventsRequest(w http.ResponseWriter, r *http.Request) {
 	flusher.Flush()
 	subs.SetActive(subInfo, true)
 	defer subs.SetActive(subInfo, false)
+
+	durations := interfaces.App.Config.SSE.Durations()
+	// Comments keep idle streams from being closed by proxies, and tell us when a client is gone
+	var heartbeat <-chan time.Time
+	heartbeatEvents := interfaces.App.Config.SSE.HeartbeatEvents
+	dropped := subs.Dropped(subInfo)
+	if durations.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(durations.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	// Ended cleanly at MaxConnectionAge, for the client to reconnect, e.g. through another replica
+	var expired <-chan time.Time
+	if durations.MaxConnectionAge > 0 {
+		timer := time.NewTimer(durations.MaxConnectionAge + rand.N(durations.MaxConnectionAge/10+1))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	// Ended cleanly, too, when the service stops or the streams are restarted
+	ending := currentStreams()
+	/*
+	send writes and flushes within WriteTimeout, returning false if the client can't keep up.
+	sendMore is send for when more is written right after, which a compressed stream can
+	hold the flush for.
+	*/
+	rc := http.NewResponseController(w)
+	sendMore := func(write func(), more bool) bool {
+		if durations.WriteTimeout > 0 {
+			// Not supported by every ResponseWriter, writes just block there
+			rc.SetWriteDeadline(time.Now().Add(durations.WriteTimeout))
+		}
+		write()
+		return out.hold(more) || out.flush(rc) == nil
+	}
+	send := func(write func()) bool {
+		return sendMore(write, false)
+	}
+	// sendEvent sends a message, in parts that each get WriteTimeout if it is chunked
+	sendEvent := func(msg submgr.ChannelMessage, more bool) bool {
+		parts := chunks(msg)
+		for i, part := range parts {
+			if !sendMore(func() { writeEvent(out, part) }, more || i < len(parts)-1) {
+				return false
+			}
+		}
+		return true
+	}
+	// endStream sends the retry field, if there is a ReconnectDelay, before a stream is ended on our side
+	endStream := func() {
+		if durations.ReconnectDelay > 0 {
+			send(func() { writeRetry(out, durations.ReconnectDelay) })
+		}
+	}
+	/*
+	When the buffer fills past the mark, the client is told once, and not again until it has
+	drained to half of it, so a buffer hovering around the mark doesn't flood it with warnings.
+	*/
+	bufferSize := subs.ChannelSize(subInfo)
+	mark := bufferSize * int(interfaces.App.Config.SSE.BackpressureMark) / 100
+	warned := false
+	backpressure := func() bool {
+		if mark == 0 {
+			return true
+		}
+		depth := subs.ChannelDepth(subInfo)
+		if warned && depth <= mark/2 {
+			warned = false
+		} else if !warned && depth > mark {
+			warned = true
+			lc.Debugf("Event stream of subscription %s is falling behind, %d of %d buffered", subid, depth, bufferSize)
+			return send(func() { writeBackpressure(out, depth, bufferSize) })
+		}
+		return true
+	}
 	done := false
+	/*
+	A reconnecting client first gets what it missed, that is still kept for replay. If some
+	of it is lost, e.g. the service restarted since, or it is from another replica, whose
+	events are numbered apart, it gets a reset event first, and what
+	is still kept after that. With the acknowledge option, what it missed is everything it
+	didn't acknowledge, whether or not it was sent before.
+	*/
+	var lastSent uint64
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if acked, acking := subs.Acknowledged(subInfo); acking {
+		lastEventId = eventId(acked)
+	}
+	if lastEventId != "" {
+		after, err := parseEventId(lastEventId)
+		replay, from, ok := subs.Resume(subInfo, after)
+		if err != nil || !ok {
+			lc.Debugf("Event stream of subscription %s can't resume after event %s, resetting it", subid, lastEventId)
+			if !send(func() { writeReset(out, lastEventId, from) }) {
+				lc.Debugf("Could not write reset to event stream of subscription %s, closing it", subid)
+				return
+			}
+		}
+		lastSent = from
+		for i, msg := range replay {
+			if subs.Expired(subInfo, msg) {
+				lastSent = msg.Seq
+				continue
+			}
+			if !sendEvent(msg, i < len(replay)-1) {
+				lc.Debugf("Could not replay to event stream of subscription %s, closing it", subid)
+				return
+			}
+			stream.sent.Add(1)
+			lastSent = msg.Seq
+		}
+		if out.pending > 0 && out.flush(rc) != nil {
+			lc.Debugf("Could not replay to event stream of subscription %s, closing it", subid)
+			return
+		}
+	}
 	for !done {
 		select {
 		case msg, ok := <-rxchan:
 			if !ok {
 				// Channel has been closed, exit loop
 				done = true
+			} else if msg.Seq != 0 && msg.Seq <= lastSent {
+				// Already replayed
+			} else if subs.Expired(subInfo, msg) {
+				lc.Tracef("Discarded a message that waited too long for event stream of subscription %s", subid)
+			} else if !traced(r.Context(), msg, func() bool { return sendEvent(msg, len(rxchan) > 0) }) {
+				lc.Debugf("Could not write to event stream of subscription %s, closing it", subid)
+				done = true
 			} else {
-				if msg.EventType == "edgex" {
-					io.WriteString(w, "event: edgex\n")
-				}
-				io.WriteString(w, "data: "+msg.Payload+"\n\n")
-				flusher.Flush()
+				stream.sent.Add(1)
+			}
+			if !done && !backpressure() {
+				lc.Debugf("Could not write backpressure to event stream of subscription %s, closing it", subid)
+				done = true
+			}
+			// What a compressed stream held back goes out once nothing more is waiting
+			if !done && out.pending > 0 && len(rxchan) == 0 && out.flush(rc) != nil {
+				lc.Debugf("Could not write to event stream of subscription %s, closing it", subid)
+				done = true
+			}
+		case <-heartbeat:
+			write := func() { io.WriteString(out, ": heartbeat\n\n") }
+			if heartbeatEvents {
+				total := subs.Dropped(subInfo)
+				depth := subs.ChannelDepth(subInfo)
+				write = func() { writePing(out, depth, total-dropped) }
+				dropped = total
 			}
+			if !send(write) {
+				lc.Debugf("Could not write heartbeat to event stream of subscription %s, closing it", subid)
+				done = true
+			}
+		case <-expired:
+			lc.Debugf("Ending event stream of subscription %s at MaxConnectionAge", subid)
+			endStream()
+			done = true
+		case <-stream.takenOver:
+			lc.Debugf("Ending event stream of subscription %s, taken over by another connection", subid)
+			done = true
+		case <-ending.Done():
+			lc.Debugf("Ending event stream of subscription %s: %s", subid, context.Cause(ending).Error())
+			endStream()
+			done = true
 		case <-r.Context().Done():
+			// Also done when the service stops, as its context is the request's base
+			if ending.Err() != nil {
+				lc.Debugf("Ending event stream of subscription %s: %s", subid, context.Cause(ending).Error())
+				endStream()
+			}
 			done = true
 		}
 	}
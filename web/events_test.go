@@ -11,22 +11,24 @@
 package web
 
 import (
+	"compress/gzip"
 	"context"
 	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
 	"github.com/edgexfoundry-holding/edgex-sse/submgr"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 )
 
-const url_prefix = "/api/v3/events/"
-
 // Create object to handle managing a connection
 type checkEventReq struct {
 	rr      *httptest.ResponseRecorder
@@ -35,6 +37,10 @@ type checkEventReq struct {
 	ec      chan error
 	reqdone chan bool
 	cancel  context.CancelFunc
+	// Sent as the Last-Event-ID header, if not empty
+	lastEventId string
+	// ID of the last event getNextEvent() returned, if it had one
+	eventId string
 }
 
 // Function to run ProcessEventRequest, notifying a channel when it is done
@@ -52,11 +58,14 @@ func (c *checkEventReq) beginReq(subid string, exp_status int) {
 	defer close(c.ec)
 	ctx, cancel := context.WithCancel(context.Background())
 	c.cancel = cancel
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url_prefix+subid, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url_prefix()+subid, nil)
 	if err != nil {
 		c.ec <- err
 		return
 	}
+	if c.lastEventId != "" {
+		req.Header.Set("Last-Event-ID", c.lastEventId)
+	}
 	c.req = req
 	c.rr = httptest.NewRecorder()
 	go c.processReq(c.rr, c.req)
@@ -136,6 +145,7 @@ func (c *checkEventReq) getNextEvent(t *testing.T) (event_type string, event int
 	data_started := false
 	var event_buf string
 	event_type = ""
+	c.eventId = ""
 	for !event_done {
 		select {
 		case thisline, ok := <-c.rc:
@@ -154,10 +164,14 @@ func (c *checkEventReq) getNextEvent(t *testing.T) (event_type string, event int
 				} else {
 					event_buf = event_buf + thisline
 				}
+			} else if strings.HasPrefix(thisline, ":") {
+				// Comment, e.g. a heartbeat
 			} else {
 				if strings.HasPrefix(thisline, "data:") {
 					data_started = true
 					event_buf = strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(thisline, "data:")), "\n")
+				} else if strings.HasPrefix(thisline, "id:") {
+					c.eventId = strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(thisline, "id:")), "\n")
 				} else if strings.HasPrefix(thisline, "event:") {
 					event_type = strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(thisline, "event:")), "\n")
 				} else {
@@ -178,10 +192,10 @@ func (c *checkEventReq) getNextEvent(t *testing.T) (event_type string, event int
 }
 
 func TestBadSubId(t *testing.T) {
-	managerInit()
+	managerInit(t)
 	c := checkEventReq{}
 	// Not running in background because we expect failure
-	c.beginReq("inexist", http.StatusNotFound)
+	c.beginReq(inexist, http.StatusNotFound)
 	select {
 	case err, ok := <-c.ec:
 		if ok {
@@ -193,7 +207,7 @@ func TestBadSubId(t *testing.T) {
 }
 
 func TestOneEvent(t *testing.T) {
-	managerInit()
+	managerInit(t)
 	c := checkEventReq{}
 	if g_subscriptions == nil {
 		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
@@ -220,7 +234,9 @@ func TestOneEvent(t *testing.T) {
 	msg := submgr.ChannelMessage{}
 	msg.EventType = ""
 	msg.Payload = "{\"a\":\"b\", \"c\": {\"d\": 3 }}"
-	chans[0] <- msg
+	if !chans[0].Send(msg) {
+		t.Fatal("Could not send to subscribed channel")
+	}
 	event_type, event := c.getNextEvent(t)
 	if event_type != "" {
 		t.Fatalf("Unexpected event type %s", event_type)
@@ -233,7 +249,7 @@ func TestOneEvent(t *testing.T) {
 }
 
 func TestDisconnect(t *testing.T) {
-	managerInit()
+	managerInit(t)
 	c := checkEventReq{}
 	if g_subscriptions == nil {
 		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
@@ -267,7 +283,7 @@ func TestDisconnect(t *testing.T) {
 
 // Test closing the channel.
 func TestDeleteSubscription(t *testing.T) {
-	managerInit()
+	managerInit(t)
 	c := checkEventReq{}
 	if g_subscriptions == nil {
 		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
@@ -300,9 +316,9 @@ func TestDeleteSubscription(t *testing.T) {
 }
 
 func TestBadRequests(t *testing.T) {
-	managerInit()
+	managerInit(t)
 	rr := httptest.NewRecorder()
-	req, err := http.NewRequest(http.MethodPost, url_prefix+"subid", nil)
+	req, err := http.NewRequest(http.MethodPost, url_prefix()+"subid", nil)
 	if err != nil {
 		t.Fatalf("Could not construct request: %v", err)
 	}
@@ -319,7 +335,7 @@ func TestBadRequests(t *testing.T) {
 	if rr.Code != http.StatusNotFound {
 		t.Fatalf("Got wrong status %d instead of 404", rr.Code)
 	}
-	req, err = http.NewRequest(http.MethodGet, url_prefix, nil)
+	req, err = http.NewRequest(http.MethodGet, url_prefix(), nil)
 	if err != nil {
 		t.Fatalf("Could not construct request: %v", err)
 	}
@@ -328,7 +344,7 @@ func TestBadRequests(t *testing.T) {
 	if rr.Code != http.StatusNotFound {
 		t.Fatalf("Got wrong status %d instead of 404", rr.Code)
 	}
-	req, err = http.NewRequest(http.MethodGet, url_prefix+"a/b/c", nil)
+	req, err = http.NewRequest(http.MethodGet, url_prefix()+"a/b/c", nil)
 	if err != nil {
 		t.Fatalf("Could not construct request: %v", err)
 	}
@@ -341,7 +357,7 @@ func TestBadRequests(t *testing.T) {
 
 // Last bit of coverage: mix EdgeX and non-EdgeX events
 func TestMixedEvents(t *testing.T) {
-	managerInit()
+	managerInit(t)
 	c := checkEventReq{}
 	if g_subscriptions == nil {
 		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
@@ -373,7 +389,9 @@ func TestMixedEvents(t *testing.T) {
 	msg.EventType = "edgex"
 	// actual event copypasta
 	msg.Payload = "{\"apiVersion\":\"v3\",\"requestId\":\"94512292-e68b-458d-9dff-bb7efa7dfe94\",\"event\":{\"apiVersion\":\"v3\",\"id\":\"7d3d60c0-5279-436b-b99d-6ab1de0eb600\",\"deviceName\":\"Virtual-Bacon-Cape-04\",\"profileName\":\"Bacon-Cape\",\"sourceName\":\"mPercentLoad\",\"origin\":1661535695202033126,\"readings\":[{\"id\":\"b4f7b655-5dac-4f34-8dc7-caa2f8c1a34d\",\"origin\":1661535695202033126,\"deviceName\":\"Virtual-Bacon-Cape-04\",\"resourceName\":\"mPercentLoad\",\"profileName\":\"Bacon-Cape\",\"valueType\":\"Uint32\",\"binaryValue\":null,\"mediaType\":\"\",\"value\":\"74\"}]}}"
-	chans[0] <- msg
+	if !chans[0].Send(msg) {
+		t.Fatal("Could not send to subscribed channel")
+	}
 	event_type, event := c.getNextEvent(t)
 	if event_type != "edgex" {
 		t.Fatalf("Unexpected event type %s", event_type)
@@ -390,7 +408,9 @@ func TestMixedEvents(t *testing.T) {
 	msg = submgr.ChannelMessage{}
 	msg.EventType = ""
 	msg.Payload = "{\"deviceId\":1, \"state\": \"CLOSED\"}"
-	chans[0] <- msg
+	if !chans[0].Send(msg) {
+		t.Fatal("Could not send to subscribed channel")
+	}
 	event_type, event = c.getNextEvent(t)
 	if event_type != "" {
 		t.Fatalf("Unexpected event type %s", event_type)
@@ -400,3 +420,764 @@ func TestMixedEvents(t *testing.T) {
 		t.Fatalf("Event returned is not what we expect, got: %v", event)
 	}
 }
+
+// A payload with line breaks has to go out as several data lines
+func TestMultiLineEvent(t *testing.T) {
+	var buf strings.Builder
+	writeEvent(&buf, submgr.ChannelMessage{EventType: "", Payload: "{\r\n  \"state\": \"OPEN\"\n}"})
+	expected := "data: {\ndata:   \"state\": \"OPEN\"\ndata: }\n\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong event-stream text %q, expected %q", buf.String(), expected)
+	}
+}
+
+// The correlation ID goes in a comment, unless it would break the event
+func TestCorrelationIdComment(t *testing.T) {
+	var buf strings.Builder
+	writeEvent(&buf, submgr.ChannelMessage{EventType: "edgex", Payload: "{}", CorrelationID: "9f3c2a8e"})
+	expected := "event: edgex\n: correlation-id 9f3c2a8e\ndata: {}\n\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong event-stream text %q, expected %q", buf.String(), expected)
+	}
+	buf.Reset()
+	writeEvent(&buf, submgr.ChannelMessage{Payload: "{}", CorrelationID: "x\ndata: injected"})
+	if buf.String() != "data: {}\n\n" {
+		t.Fatalf("Wrong event-stream text %q", buf.String())
+	}
+}
+
+func TestDeliveryEnvelope(t *testing.T) {
+	var buf strings.Builder
+	received := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	writeEvent(&buf, submgr.ChannelMessage{EventType: "edgex", Payload: "{\"a\":1}", Seq: 7, CorrelationID: "9f3c2a8e", Delivery: true, Topic: "edgex/events/x", ReceivedAt: received})
+	expected := "id: 7\nevent: edgex\n: correlation-id 9f3c2a8e\ndata: {\"seq\":7,\"topic\":\"edgex/events/x\",\"receivedAt\":\"2025-03-01T12:00:00Z\",\"correlationId\":\"9f3c2a8e\",\"eventType\":\"edgex\",\"payload\":{\"a\":1}}\n\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong event-stream text %q, expected %q", buf.String(), expected)
+	}
+	// Not JSON, and with a line break
+	buf.Reset()
+	writeEvent(&buf, submgr.ChannelMessage{Payload: "on\noff", Delivery: true, Topic: "t", ReceivedAt: received})
+	expected = "data: {\"topic\":\"t\",\"receivedAt\":\"2025-03-01T12:00:00Z\",\"eventType\":\"\",\"payload\":\"on\\noff\"}\n\n"
+	if buf.String() != expected {
+		t.Fatalf("Wrong event-stream text %q, expected %q", buf.String(), expected)
+	}
+}
+
+// Payloads longer than ChunkSize go out in parts, split between characters
+func TestChunks(t *testing.T) {
+	msg := submgr.ChannelMessage{EventType: "edgex", Payload: "{\"a\":1}", Seq: 7, CorrelationID: "9f3c2a8e", ChunkSize: 1024}
+	if parts := chunks(msg); len(parts) != 1 || parts[0] != msg {
+		t.Fatalf("Short payload chunked: %+v", parts)
+	}
+	msg.Payload = strings.Repeat("a", 1023) + strings.Repeat("é", 600)
+	parts := chunks(msg)
+	if len(parts) != 3 {
+		t.Fatalf("Got %d parts, expected 3", len(parts))
+	}
+	joined := ""
+	for i, part := range parts {
+		var chunk dtos.ChunkEvent
+		if err := json.Unmarshal([]byte(part.Payload), &chunk); err != nil || part.EventType != ChunkEventType {
+			t.Fatalf("Part %d is %+v: %v", i, part, err)
+		}
+		if chunk.Part != i || chunk.Last != (i == 2) || chunk.EventType != "edgex" || len(chunk.Data) > 1024 {
+			t.Fatalf("Wrong part %d %+v", i, chunk)
+		}
+		if (part.Seq != 0) != (i == 2) || (part.CorrelationID != "") != (i == 0) {
+			t.Fatalf("Event ID or correlation ID on the wrong part: %+v", part)
+		}
+		joined += chunk.Data
+	}
+	if joined != msg.Payload {
+		t.Fatal("Parts don't add up to the payload")
+	}
+	// The delivery envelope is chunked, not the payload in it
+	msg = submgr.ChannelMessage{Payload: strings.Repeat("x", 1000), Delivery: true, Topic: "t", ChunkSize: 1024}
+	if parts := chunks(msg); len(parts) != 2 || parts[0].Delivery || parts[1].Delivery {
+		t.Fatalf("Delivery envelope not chunked: %+v", parts)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.HeartbeatInterval = "200ms"
+	if err := interfaces.App.Config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	c := checkEventReq{}
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	g_subscriptions[subid] = interfaces.App.Subs.Subscription(subid)
+	go c.beginReq(subid, http.StatusOK)
+	time.Sleep(500 * time.Millisecond)
+	defer c.cancel()
+	select {
+	case line := <-c.rc:
+		if line != ": heartbeat\n" {
+			t.Fatalf("Expected a heartbeat comment, got %q", line)
+		}
+	case err := <-c.ec:
+		t.Fatalf("Error processing request: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for heartbeat")
+	}
+}
+
+func TestEventsPath(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.EventsPath = "/sse/events/"
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	g_subscriptions[subid] = interfaces.App.Subs.Subscription(subid)
+	req, err := http.NewRequest(http.MethodGet, "/api/v3/events/"+subid, nil)
+	if err != nil {
+		t.Fatalf("Could not construct request: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	ProcessEventsRequest(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("Got status %d instead of 404 for the default path", rr.Code)
+	}
+	c := checkEventReq{}
+	go c.beginReq(subid, http.StatusOK)
+	time.Sleep(500 * time.Millisecond)
+	defer c.cancel()
+	interfaces.App.Subs.Include(g_subscriptions[subid], "a/b")
+	chans := interfaces.App.Subs.SubscribedChannels("a/b")
+	if len(chans) != 1 || !chans[0].Send(submgr.ChannelMessage{Payload: "{\"a\":1}"}) {
+		t.Fatal("Stream on the configured path is not active")
+	}
+	if _, event := c.getNextEvent(t); !reflect.DeepEqual(event, map[string]any{"a": float64(1)}) {
+		t.Fatalf("Wrong event: %v", event)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Subs.SetRetention(submgr.Retention{Count: 10, Bytes: 4096, MaxAge: time.Minute})
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	subinfo := interfaces.App.Subs.Subscription(subid)
+	g_subscriptions[subid] = subinfo
+	if err := interfaces.App.Subs.Include(subinfo, "a/b"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	c := checkEventReq{}
+	go c.beginReq(subid, http.StatusOK)
+	time.Sleep(500 * time.Millisecond)
+	chans := interfaces.App.Subs.SubscribedChannels("a/b")
+	if len(chans) != 1 {
+		t.Fatalf("Expected 1 subscribed channel, got %d", len(chans))
+	}
+	for i := 1; i <= 3; i++ {
+		if !chans[0].Send(submgr.ChannelMessage{Payload: fmt.Sprintf("{\"n\": %d}", i)}) {
+			t.Fatal("Could not send to subscribed channel")
+		}
+	}
+	// The client only got the first before the connection dropped
+	_, event := c.getNextEvent(t)
+	if c.eventId != "1" || event.(map[string]any)["n"] != 1.0 {
+		t.Fatalf("Wrong first event %v, id %s", event, c.eventId)
+	}
+	c.cancel()
+	time.Sleep(1000 * time.Millisecond)
+
+	c2 := checkEventReq{lastEventId: "1"}
+	go c2.beginReq(subid, http.StatusOK)
+	time.Sleep(500 * time.Millisecond)
+	defer c2.cancel()
+	for i := 2; i <= 3; i++ {
+		_, event := c2.getNextEvent(t)
+		if c2.eventId != fmt.Sprint(i) || event.(map[string]any)["n"] != float64(i) {
+			t.Fatalf("Wrong replayed event %v, id %s, expected %d", event, c2.eventId, i)
+		}
+	}
+	// New messages carry on the numbering
+	chans = interfaces.App.Subs.SubscribedChannels("a/b")
+	if len(chans) != 1 || !chans[0].Send(submgr.ChannelMessage{Payload: "{\"n\": 4}"}) {
+		t.Fatal("Could not send to subscribed channel")
+	}
+	_, event = c2.getNextEvent(t)
+	if c2.eventId != "4" {
+		t.Fatalf("Wrong event %v after replay, id %s", event, c2.eventId)
+	}
+}
+
+func TestReplayReset(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Subs.SetRetention(submgr.Retention{Count: 10, Bytes: 4096, MaxAge: time.Minute})
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	// Restored after a restart, with what it was numbered up to
+	subid := "restored-replay"
+	if err := RestoreSubscription(submgr.Definition{Id: subid, Include: []string{"a/b/"}, Exclude: []string{}, Seq: 1000}); err != nil {
+		t.Fatalf("Could not restore subscription: %v", err)
+	}
+	c := checkEventReq{lastEventId: "900"}
+	go c.beginReq(subid, http.StatusOK)
+	time.Sleep(500 * time.Millisecond)
+	event_type, event := c.getNextEvent(t)
+	if event_type != ResetEventType || c.eventId != "1000" || event.(map[string]any)["lastEventId"] != "900" {
+		t.Fatalf("Got %s event %v, id %s, expected a reset", event_type, event, c.eventId)
+	}
+	chans := interfaces.App.Subs.SubscribedChannels("a/b")
+	if len(chans) != 1 || !chans[0].Send(submgr.ChannelMessage{Payload: "{\"n\": 1}"}) {
+		t.Fatal("Could not send to subscribed channel")
+	}
+	if _, event = c.getNextEvent(t); c.eventId != "1001" {
+		t.Fatalf("Wrong event %v after reset, id %s", event, c.eventId)
+	}
+	c.cancel()
+	time.Sleep(1000 * time.Millisecond)
+
+	// Resumes cleanly after the reset, and after events since the restart
+	for _, lastEventId := range []string{"1000", "1001"} {
+		c2 := checkEventReq{lastEventId: lastEventId}
+		go c2.beginReq(subid, http.StatusOK)
+		time.Sleep(500 * time.Millisecond)
+		if lastEventId == "1001" {
+			chans = interfaces.App.Subs.SubscribedChannels("a/b")
+			if len(chans) != 1 || !chans[0].Send(submgr.ChannelMessage{Payload: "{\"n\": 2}"}) {
+				t.Fatal("Could not send to subscribed channel")
+			}
+		}
+		if event_type, event = c2.getNextEvent(t); event_type == ResetEventType || c2.eventId == "" {
+			t.Fatalf("Got %s event %v, id %s, resuming after %s", event_type, event, c2.eventId, lastEventId)
+		}
+		c2.cancel()
+		time.Sleep(1000 * time.Millisecond)
+	}
+
+	// An ID that isn't one of ours can't be resumed after either
+	c3 := checkEventReq{lastEventId: "bogus"}
+	go c3.beginReq(subid, http.StatusOK)
+	time.Sleep(500 * time.Millisecond)
+	if event_type, _ = c3.getNextEvent(t); event_type != ResetEventType {
+		t.Fatalf("Got %s event after a bogus ID, expected a reset", event_type)
+	}
+	c3.cancel()
+	time.Sleep(1000 * time.Millisecond)
+
+	// Nor one numbered by another replica, though this one used the same number
+	interfaces.App.ReplicaId = "replica-a"
+	defer func() { interfaces.App.ReplicaId = "" }()
+	c4 := checkEventReq{lastEventId: "replica-b.1001"}
+	go c4.beginReq(subid, http.StatusOK)
+	time.Sleep(500 * time.Millisecond)
+	defer c4.cancel()
+	if event_type, event = c4.getNextEvent(t); event_type != ResetEventType || !strings.HasPrefix(c4.eventId, "replica-a.") {
+		t.Fatalf("Got %s event %v, id %s, after another replica's ID, expected a reset", event_type, event, c4.eventId)
+	}
+}
+
+func TestEventId(t *testing.T) {
+	defer func() { interfaces.App.ReplicaId = "" }()
+	for _, replicaId := range []string{"", "replica-a"} {
+		interfaces.App.ReplicaId = replicaId
+		if seq, err := parseEventId(eventId(42)); err != nil || seq != 42 {
+			t.Fatalf("Event ID %s parsed as %d, %v with replica ID %q", eventId(42), seq, err, replicaId)
+		}
+	}
+	if id := eventId(42); id != "replica-a.42" {
+		t.Fatalf("Wrong event ID %s with a replica ID", id)
+	}
+	for _, id := range []string{"42", "replica-b.42", "replica-a.", "bogus"} {
+		if _, err := parseEventId(id); err == nil {
+			t.Fatalf("Event ID %s accepted by replica-a", id)
+		}
+	}
+}
+
+func TestAcknowledgedReplay(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Subs.SetRetention(submgr.Retention{Count: 10, Bytes: 4096, MaxAge: time.Minute})
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	subinfo := interfaces.App.Subs.Subscription(subid)
+	g_subscriptions[subid] = subinfo
+	if err := interfaces.App.Subs.Include(subinfo, "a/b"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	if err := interfaces.App.Subs.SetOptions(subinfo, submgr.SubscriptionOptions{Acknowledge: true}); err != nil {
+		t.Fatalf("Could not set options: %v", err)
+	}
+	c := checkEventReq{}
+	go c.beginReq(subid, http.StatusOK)
+	time.Sleep(500 * time.Millisecond)
+	chans := interfaces.App.Subs.SubscribedChannels("a/b")
+	if len(chans) != 1 {
+		t.Fatalf("Expected 1 subscribed channel, got %d", len(chans))
+	}
+	for i := 1; i <= 3; i++ {
+		if !chans[0].Send(submgr.ChannelMessage{Payload: fmt.Sprintf("{\"n\": %d}", i)}) {
+			t.Fatal("Could not send to subscribed channel")
+		}
+	}
+	// The client got all three, but only acknowledged the first
+	for i := 1; i <= 3; i++ {
+		_, _ = c.getNextEvent(t)
+	}
+	if c.eventId != "3" {
+		t.Fatalf("Last event id %s, expected 3", c.eventId)
+	}
+	if err := interfaces.App.Subs.Acknowledge(subinfo, 1); err != nil {
+		t.Fatalf("Acknowledge failed: %v", err)
+	}
+	c.cancel()
+	time.Sleep(1000 * time.Millisecond)
+
+	// Sent again, though the client says it got them
+	c2 := checkEventReq{lastEventId: "3"}
+	go c2.beginReq(subid, http.StatusOK)
+	time.Sleep(500 * time.Millisecond)
+	defer c2.cancel()
+	for i := 2; i <= 3; i++ {
+		event_type, event := c2.getNextEvent(t)
+		if event_type == ResetEventType || c2.eventId != fmt.Sprint(i) || event.(map[string]any)["n"] != float64(i) {
+			t.Fatalf("Wrong resent %s event %v, id %s, expected %d", event_type, event, c2.eventId, i)
+		}
+	}
+}
+
+func TestProbeStream(t *testing.T) {
+	managerInit(t)
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	subinfo := interfaces.App.Subs.Subscription(subid)
+	g_subscriptions[subid] = subinfo
+	if err := interfaces.App.Subs.Include(subinfo, "a/b"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	probe := func(subid string, exp_status int) http.Header {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodHead, url_prefix()+subid, nil)
+		rr := httptest.NewRecorder()
+		ProcessEventsRequest(rr, req)
+		if rr.Code != exp_status {
+			t.Fatalf("Got status %d probing %s, expected %d", rr.Code, subid, exp_status)
+		}
+		// The server drops the bodies of error responses to HEAD
+		if exp_status == http.StatusOK && rr.Body.Len() != 0 {
+			t.Fatalf("HEAD response has a body: %s", rr.Body.String())
+		}
+		return rr.Header()
+	}
+	_ = probe(inexist, http.StatusNotFound)
+	header := probe(subid, http.StatusOK)
+	if header.Get(BufferDepthHeader) != "0" || header.Get(LastDeliveryHeader) != "" || header.Get(StreamActiveHeader) != "false" {
+		t.Fatalf("Wrong headers for an unused subscription: %v", header)
+	}
+
+	interfaces.App.Subs.SetActive(subinfo, true)
+	before := time.Now()
+	chans := interfaces.App.Subs.SubscribedChannels("a/b")
+	for i := 0; i < 2; i++ {
+		if len(chans) != 1 || !chans[0].Send(submgr.ChannelMessage{Payload: "{}"}) {
+			t.Fatal("Could not send to subscribed channel")
+		}
+	}
+	header = probe(subid, http.StatusOK)
+	last, err := time.Parse(time.RFC3339Nano, header.Get(LastDeliveryHeader))
+	if header.Get(BufferDepthHeader) != "2" || err != nil || last.Before(before) || header.Get(StreamActiveHeader) != "true" {
+		t.Fatalf("Wrong headers after delivering 2 messages: %v", header)
+	}
+	// Didn't take the messages
+	if depth := interfaces.App.Subs.ChannelDepth(subinfo); depth != 2 {
+		t.Fatalf("Probing changed the channel depth to %d", depth)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	managerInit(t)
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	subinfo := interfaces.App.Subs.Subscription(subid)
+	g_subscriptions[subid] = subinfo
+	if err := interfaces.App.Subs.Include(subinfo, "a/b"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	if err := interfaces.App.Subs.SetOptions(subinfo, submgr.SubscriptionOptions{MaxAge: "200ms"}); err != nil {
+		t.Fatalf("Could not set options: %v", err)
+	}
+	// Waiting while nobody listens, until they are stale
+	interfaces.App.Subs.SetActive(subinfo, true)
+	chans := interfaces.App.Subs.SubscribedChannels("a/b")
+	for i := 1; i <= 2; i++ {
+		if len(chans) != 1 || !chans[0].Send(submgr.ChannelMessage{Payload: fmt.Sprintf("{\"n\": %d}", i)}) {
+			t.Fatal("Could not send to subscribed channel")
+		}
+	}
+	time.Sleep(400 * time.Millisecond)
+	if !chans[0].Send(submgr.ChannelMessage{Payload: "{\"n\": 3}"}) {
+		t.Fatal("Could not send to subscribed channel")
+	}
+
+	c := checkEventReq{}
+	go c.beginReq(subid, http.StatusOK)
+	time.Sleep(500 * time.Millisecond)
+	defer c.cancel()
+	if _, event := c.getNextEvent(t); event.(map[string]any)["n"] != 3.0 {
+		t.Fatalf("Got %v, expected the stale events to be discarded", event)
+	}
+	throughput := interfaces.App.Subs.Throughput()
+	if len(throughput) != 1 || throughput[0].Expired != 2 {
+		t.Fatalf("Expired not counted: %+v", throughput)
+	}
+}
+
+func TestMaxConnectionAge(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.MaxConnectionAge = "1s"
+	interfaces.App.Config.SSE.ReconnectDelay = "2s"
+	if err := interfaces.App.Config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	g_subscriptions[subid] = interfaces.App.Subs.Subscription(subid)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url_prefix()+subid, nil)
+	rr := httptest.NewRecorder()
+	start := time.Now()
+	ProcessEventsRequest(rr, req)
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 1200*time.Millisecond || ctx.Err() != nil {
+		t.Fatalf("Stream ended after %v, expected between 1s and 1.1s", elapsed)
+	}
+	if !strings.HasSuffix(rr.Body.String(), "retry: 2000\n\n") {
+		t.Fatalf("Stream ended without a retry field: %q", rr.Body.String())
+	}
+	if interfaces.App.Subs.IsActive(g_subscriptions[subid]) {
+		t.Fatal("Subscription still active after its stream ended")
+	}
+}
+
+func TestRestartStreams(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.ReconnectDelay = "2s"
+	if err := interfaces.App.Config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	service, stop := context.WithCancel(context.Background())
+	setServiceContext(service)
+	defer setServiceContext(context.Background())
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	// Restarted, then ended by the service stopping
+	for _, end := range []func(){func() { RestartStreams(errors.New("restarted")) }, stop} {
+		subid, err := interfaces.App.Subs.NewSubscription()
+		if err != nil || subid == "" {
+			t.Fatal("Could not add a subscription")
+		}
+		g_subscriptions[subid] = interfaces.App.Subs.Subscription(subid)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url_prefix()+subid, nil)
+		rr := httptest.NewRecorder()
+		ended := make(chan struct{})
+		go func() {
+			ProcessEventsRequest(rr, req)
+			close(ended)
+		}()
+		time.Sleep(200 * time.Millisecond)
+		end()
+		select {
+		case <-ended:
+		case <-time.After(time.Second):
+			t.Fatal("Stream not ended")
+		}
+		if ctx.Err() != nil || !strings.HasSuffix(rr.Body.String(), "retry: 2000\n\n") {
+			t.Fatalf("Stream ended without a retry field: %q", rr.Body.String())
+		}
+		cancel()
+	}
+}
+
+func TestTakeover(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.ExclusiveStreams = true
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	subinfo := interfaces.App.Subs.Subscription(subid)
+	g_subscriptions[subid] = subinfo
+	if err := interfaces.App.Subs.Include(subinfo, "a/b"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	// open starts a stream with the query, returning its recording, and a channel closed when it ends
+	open := func(query string) (*httptest.ResponseRecorder, chan struct{}, context.CancelFunc) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url_prefix()+subid+query, nil)
+		rr := httptest.NewRecorder()
+		ended := make(chan struct{})
+		go func() {
+			ProcessEventsRequest(rr, req)
+			close(ended)
+		}()
+		return rr, ended, cancel
+	}
+	_, first, cancelFirst := open("")
+	defer cancelFirst()
+	time.Sleep(200 * time.Millisecond)
+
+	for query, code := range map[string]int{"": http.StatusConflict, "?takeover=false": http.StatusConflict, "?takeover=maybe": http.StatusBadRequest} {
+		rr, ended, cancel := open(query)
+		<-ended
+		cancel()
+		if rr.Code != code {
+			t.Fatalf("Got %d opening a second stream with %q, expected %d", rr.Code, query, code)
+		}
+	}
+	select {
+	case <-first:
+		t.Fatal("Stream ended by a connection that didn't take it over")
+	default:
+	}
+
+	rr, second, cancelSecond := open("?takeover=true")
+	defer cancelSecond()
+	select {
+	case <-first:
+	case <-time.After(time.Second):
+		t.Fatal("Stream not ended when taken over")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if !interfaces.App.Subs.IsActive(subinfo) {
+		t.Fatal("Subscription not active after its stream was taken over")
+	}
+	for _, handle := range interfaces.App.Subs.SubscribedChannels("a/b") {
+		handle.Send(submgr.ChannelMessage{Payload: "{\"taken\":true}"})
+	}
+	time.Sleep(200 * time.Millisecond)
+	cancelSecond()
+	<-second
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "data: {\"taken\":true}") {
+		t.Fatalf("Stream taking over got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHeartbeatEvents(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.HeartbeatInterval = "300ms"
+	interfaces.App.Config.SSE.HeartbeatEvents = true
+	interfaces.App.Config.SSE.MaxConnectionAge = "1s"
+	interfaces.App.Config.SSE.ReconnectDelay = "0s"
+	if err := interfaces.App.Config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	g_subscriptions[subid] = interfaces.App.Subs.Subscription(subid)
+	req, _ := http.NewRequest(http.MethodGet, url_prefix()+subid, nil)
+	rr := httptest.NewRecorder()
+	before := time.Now()
+	ProcessEventsRequest(rr, req)
+	body := rr.Body.String()
+	if strings.Contains(body, ": heartbeat") || !strings.HasPrefix(body, "event: "+PingEventType+"\ndata: ") {
+		t.Fatalf("Stream has no ping events: %q", body)
+	}
+	var ping dtos.PingEvent
+	data, _, _ := strings.Cut(strings.TrimPrefix(body, "event: "+PingEventType+"\ndata: "), "\n")
+	if err := json.Unmarshal([]byte(data), &ping); err != nil {
+		t.Fatalf("Ping data %s: %v", data, err)
+	}
+	sent, err := time.Parse(time.RFC3339Nano, ping.Time)
+	if err != nil || sent.Before(before) || sent.After(time.Now()) || ping.BufferDepth != 0 || ping.Dropped != 0 {
+		t.Fatalf("Wrong ping %+v", ping)
+	}
+}
+
+// Struct gatedRecorder is a ResponseRecorder whose writes wait until gate is closed, like a slow client.
+type gatedRecorder struct {
+	*httptest.ResponseRecorder
+	gate chan struct{}
+}
+
+func (g *gatedRecorder) Write(b []byte) (int, error) {
+	<-g.gate
+	return g.ResponseRecorder.Write(b)
+}
+
+func (g *gatedRecorder) WriteString(str string) (int, error) {
+	<-g.gate
+	return g.ResponseRecorder.WriteString(str)
+}
+
+func TestBackpressure(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.BackpressureMark = 80
+	if err := interfaces.App.Config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	subinfo := interfaces.App.Subs.Subscription(subid)
+	g_subscriptions[subid] = subinfo
+	if err := interfaces.App.Subs.Include(subinfo, "a/b"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url_prefix()+subid, nil)
+	rr := &gatedRecorder{ResponseRecorder: httptest.NewRecorder(), gate: make(chan struct{})}
+	ended := make(chan struct{})
+	go func() {
+		ProcessEventsRequest(rr, req)
+		close(ended)
+	}()
+	time.Sleep(500 * time.Millisecond)
+	chans := interfaces.App.Subs.SubscribedChannels("a/b")
+	if len(chans) != 1 {
+		t.Fatalf("Expected 1 subscribed channel, got %d", len(chans))
+	}
+	// The first is taken, and its write waits, while the rest fill the buffer past 80% of 25
+	for i := 0; i < 23; i++ {
+		if !chans[0].Send(submgr.ChannelMessage{Payload: strconv.Itoa(i)}) {
+			t.Fatalf("Could not send message %d", i)
+		}
+		if i == 0 {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}
+	close(rr.gate)
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+	<-ended
+	body := rr.Body.String()
+	if strings.Count(body, "event: "+BackpressureEventType+"\n") != 1 {
+		t.Fatalf("Expected one backpressure event: %q", body)
+	}
+	if !strings.Contains(body, "data: 0\n\nevent: "+BackpressureEventType+"\ndata: {\"bufferDepth\":22,\"bufferSize\":25}\n\ndata: 1\n") || !strings.HasSuffix(body, "data: 22\n\n") {
+		t.Fatalf("Wrong backpressure event, or events missing: %q", body)
+	}
+}
+
+func TestCompressedStream(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.CompressEvents = true
+	for accept, expected := range map[string]string{"": "", "gzip": "gzip", "deflate, GZIP;q=0.5": "gzip", "gzip;q=0": "", "br": ""} {
+		req, _ := http.NewRequest(http.MethodGet, url_prefix()+"x", nil)
+		req.Header.Set("Accept-Encoding", accept)
+		if got := streamCompression(req); got != expected {
+			t.Errorf("Accept-Encoding %q compressed with %q, expected %q", accept, got, expected)
+		}
+	}
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil || subid == "" {
+		t.Fatal("Could not add a subscription")
+	}
+	subinfo := interfaces.App.Subs.Subscription(subid)
+	g_subscriptions[subid] = subinfo
+	if err := interfaces.App.Subs.Include(subinfo, "a/b"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url_prefix()+subid, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := &gatedRecorder{ResponseRecorder: httptest.NewRecorder(), gate: make(chan struct{})}
+	ended := make(chan struct{})
+	go func() {
+		ProcessEventsRequest(rr, req)
+		close(ended)
+	}()
+	time.Sleep(500 * time.Millisecond)
+	chans := interfaces.App.Subs.SubscribedChannels("a/b")
+	if len(chans) != 1 {
+		t.Fatalf("Expected 1 subscribed channel, got %d", len(chans))
+	}
+	// The first is flushed on its own, and waits for the client; those behind it go together
+	payload := `{"reading":"` + strings.Repeat("x", 100) + `"}`
+	for i := 0; i < 20; i++ {
+		if !chans[0].Send(submgr.ChannelMessage{EventType: "e", Payload: payload}) {
+			t.Fatalf("Could not send message %d", i)
+		}
+		if i == 0 {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}
+	close(rr.gate)
+	time.Sleep(500 * time.Millisecond)
+	streamsLock.Lock()
+	var stream *eventStream
+	for s := range streams {
+		stream = s
+	}
+	streamsLock.Unlock()
+	if stream == nil || stream.compression != "gzip" || stream.sent.Load() != 20 {
+		t.Fatalf("Wrong stream %+v", stream)
+	}
+	if flushes := stream.flushes.Load(); flushes != 2 {
+		t.Fatalf("Expected 20 events in 2 flushes, got %d", flushes)
+	}
+	written, sent := stream.bytesWritten.Load(), stream.bytesSent.Load()
+	if written != uint64(20*len("event: e\ndata: "+payload+"\n\n")) || sent == 0 || sent >= written/4 {
+		t.Fatalf("Wrote %d bytes, sent %d compressed", written, sent)
+	}
+	cancel()
+	<-ended
+	if rr.Header().Get("Content-Encoding") != "gzip" || rr.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("Wrong headers %v", rr.Header())
+	}
+	reader, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("Stream is not gzip: %v", err)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil || strings.Count(string(body), "data: "+payload+"\n") != 20 {
+		t.Fatalf("Wrong stream %q: %v", body, err)
+	}
+}
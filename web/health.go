@@ -0,0 +1,117 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/notify"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	// Why each address of the events port isn't served, nil while it is - access under eventsListenerLock
+	eventsListeners    = make(map[string]error)
+	eventsListenerLock sync.Mutex
+)
+
+// setEventsListener (an internal API) records whether the events port is served at the address, nil if it is.
+func setEventsListener(addr string, err error) {
+	eventsListenerLock.Lock()
+	defer eventsListenerLock.Unlock()
+	eventsListeners[addr] = err
+}
+
+/*
+EventsListenerStopped records that the events port's server at the address returned, or
+could not be started, with its error, and alerts operators unless it was shut down.
+*/
+func EventsListenerStopped(addr string, err error) {
+	if err == nil {
+		err = http.ErrServerClosed
+	}
+	setEventsListener(addr, err)
+	if alert := interfaces.App.Alert; alert != nil && !errors.Is(err, http.ErrServerClosed) {
+		alert(notify.AlertEventsListener, models.Critical, "Events listener at "+addr+" stopped, event streams can't be served there: "+err.Error())
+	}
+}
+
+// Struct healthCheck is the outcome of one of the checks ProcessHealthRequest makes.
+type healthCheck struct {
+	Healthy bool   `json:"healthy"`
+	Detail  string `json:"detail"`
+}
+
+/*
+ProcessHealthRequest handles GET of the service's health, for orchestrator probes.
+It is 503 unless all of these are healthy:
+
+  messageBus: something came from the message bus within MessageBusIdleLimit
+  eventsListener: the events port is served at each of its addresses, none still waiting
+  to be bound or stopped
+  buffers: not every open event stream has a full buffer, which would mean delivery
+  is stuck as a whole rather than one client being slow
+*/
+func ProcessHealthRequest(c echo.Context) error {
+	type healthReturn struct {
+		commonDTO.BaseResponse `json:",inline"`
+		Checks                 map[string]healthCheck `json:"checks"`
+	}
+	w := c.Response()
+	r := c.Request()
+
+	checks := make(map[string]healthCheck, 3)
+	idleLimit := interfaces.App.Config.SSE.Durations().MessageBusIdleLimit
+	idle := time.Since(interfaces.App.Processor.LastFromBus())
+	checks["messageBus"] = healthCheck{
+		Healthy: idleLimit <= 0 || idle <= idleLimit,
+		Detail:  fmt.Sprintf("last message %v ago", idle.Round(time.Second)),
+	}
+
+	var problems []string
+	eventsListenerLock.Lock()
+	for addr, err := range eventsListeners {
+		if err != nil {
+			problems = append(problems, addr+": "+err.Error())
+		}
+	}
+	eventsListenerLock.Unlock()
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		checks["eventsListener"] = healthCheck{Healthy: false, Detail: strings.Join(problems, "; ")}
+	} else {
+		checks["eventsListener"] = healthCheck{Healthy: true, Detail: "serving"}
+	}
+
+	streaming, full := interfaces.App.Subs.BufferSaturation()
+	checks["buffers"] = healthCheck{
+		Healthy: streaming == 0 || full < streaming,
+		Detail:  fmt.Sprintf("%d of %d event streams have a full buffer", full, streaming),
+	}
+
+	status := http.StatusOK
+	message := "Healthy"
+	for _, check := range checks {
+		if !check.Healthy {
+			status = http.StatusServiceUnavailable
+			message = "Unhealthy"
+		}
+	}
+	rv := healthReturn{Checks: checks}
+	rv.BaseResponse = commonDTO.NewBaseResponse("", message, status)
+	sendResponse(w, r, rv, status)
+	return nil
+}
@@ -0,0 +1,89 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/functions"
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	"github.com/labstack/echo/v4"
+)
+
+// healthRequest GETs the service's health, returning the status and checks
+func healthRequest(t *testing.T) (int, map[string]healthCheck) {
+	req, _ := http.NewRequest(http.MethodGet, interfaces.App.Config.SSE.HealthRoute(), nil)
+	rr := httptest.NewRecorder()
+	router := echo.New()
+	router.GET(interfaces.App.Config.SSE.HealthRoute(), ProcessHealthRequest)
+	router.ServeHTTP(rr, req)
+	var body struct {
+		Checks map[string]healthCheck `json:"checks"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Bad health response %s: %v", rr.Body.String(), err)
+	}
+	return rr.Code, body.Checks
+}
+
+func TestHealth(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	defer func() {
+		eventsListenerLock.Lock()
+		eventsListeners = make(map[string]error)
+		eventsListenerLock.Unlock()
+	}()
+	processor := functions.NewProcessor(interfaces.App.Logger, interfaces.App.Subs, interfaces.App.Config)
+	interfaces.App.Processor = &processor
+	if code, checks := healthRequest(t); code != http.StatusOK || len(checks) != 3 {
+		t.Fatalf("Got %d %v, expected healthy", code, checks)
+	}
+
+	// Nothing from the bus for too long
+	interfaces.App.Config.SSE.MessageBusIdleLimit = "10ms"
+	if err := interfaces.App.Config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if code, checks := healthRequest(t); code != http.StatusServiceUnavailable || checks["messageBus"].Healthy {
+		t.Fatalf("Got %d %v with an idle message bus", code, checks)
+	}
+	processor.Pipeline(pkg.NewAppFuncContextForTest("", interfaces.App.Logger), []byte("{}"))
+	if code, checks := healthRequest(t); code != http.StatusOK {
+		t.Fatalf("Got %d %v after a message from the bus", code, checks)
+	}
+
+	// Every stream's buffer full
+	subid := checkCreateRequest(t, http.StatusCreated)
+	subInfo := interfaces.App.Subs.Subscription(subid)
+	_ = interfaces.App.Subs.Include(subInfo, "a")
+	interfaces.App.Subs.SetActive(subInfo, true)
+	for i := 0; i < buffer; i++ {
+		for _, handle := range interfaces.App.Subs.SubscribedChannels("a/b") {
+			handle.Send(submgr.ChannelMessage{Payload: "stuck"})
+		}
+	}
+	processor.Pipeline(pkg.NewAppFuncContextForTest("", interfaces.App.Logger), []byte("{}"))
+	if code, checks := healthRequest(t); code != http.StatusServiceUnavailable || checks["buffers"].Healthy {
+		t.Fatalf("Got %d %v with every buffer full", code, checks)
+	}
+	interfaces.App.Subs.DeleteSubscription(subid)
+
+	// Events port no longer served
+	EventsListenerStopped("127.0.0.1:59748", errors.New("address already in use"))
+	if code, checks := healthRequest(t); code != http.StatusServiceUnavailable || checks["eventsListener"].Detail != "127.0.0.1:59748: address already in use" {
+		t.Fatalf("Got %d %v with the events listener stopped", code, checks)
+	}
+}
@@ -0,0 +1,68 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+/*
+callerIdentity returns the identity of the caller, the subject of the JWT in its
+Authorization header, or "" if it has none.
+
+The token is not verified here: with security enabled the SDK's authentication has
+already done that before the handler runs. Without security, any caller can claim
+any identity, so roles are only meaningful in secure deployments.
+*/
+func callerIdentity(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(strings.TrimSpace(token), claims); err != nil {
+		return ""
+	}
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return ""
+	}
+	return subject
+}
+
+/*
+verifiedIdentity returns the identity of the caller, like callerIdentity(), if the SDK has
+verified it, or "" without security, where the caller could have made it up.
+*/
+func verifiedIdentity(r *http.Request) string {
+	if !interfaces.App.SecurityEnabled {
+		return ""
+	}
+	return callerIdentity(r)
+}
+
+/*
+mayManage returns true if the caller can see and change the subscription: it has no owner,
+the caller owns it, or the caller is in the AdminRole.
+*/
+func mayManage(r *http.Request, subInfo *submgr.SubscriptionInfo) bool {
+	owner := interfaces.App.Subs.Owner(subInfo)
+	if owner == "" {
+		return true
+	}
+	identity := callerIdentity(r)
+	if identity == owner {
+		return true
+	}
+	sse := &interfaces.App.Config.SSE
+	return identity != "" && sse.AdminRole != "" && sse.RoleOf(identity) == sse.AdminRole
+}
@@ -0,0 +1,91 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"github.com/labstack/echo/v4"
+)
+
+/*
+ProcessLimitsRequest handles GET and PUT of the service's SubscriptionLimit, PrefixesLimit
+and EventBuffer, so operators can react to load without a restart. PUT applies them to
+subscriptions created after, up to MaxSubscriptionLimit and the like; 400 past those.
+
+With an AdminRole configured only its callers may ask. Only they may change the limits, so
+without one nobody can.
+*/
+func ProcessLimitsRequest(c echo.Context) error {
+	lc := interfaces.App.Logger
+	subs := interfaces.App.Subs
+	w := c.Response()
+	r, ok := identifyRequest(c)
+	if !ok {
+		return nil
+	}
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	sse := &interfaces.App.Config.SSE
+	admin := sse.AdminRole != "" && sse.RoleOf(callerIdentity(r)) == sse.AdminRole
+	if sse.AdminRole != "" && !admin {
+		respondBase(w, r, requestId(r), http.StatusForbidden, "Only for callers in the AdminRole")
+		return nil
+	}
+	var maxima dtos.Limits
+	maxima.SubscriptionLimit, maxima.PrefixesLimit, maxima.EventBuffer = sse.LimitMaxima()
+	if r.Method == http.MethodPut {
+		if !admin {
+			respondBase(w, r, requestId(r), http.StatusForbidden, "Limits can only be changed with an AdminRole configured")
+			return nil
+		}
+		var request dtos.LimitsRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			respondBase(w, r, requestId(r), http.StatusBadRequest, err.Error())
+			return nil
+		}
+		if request.SubscriptionLimit > maxima.SubscriptionLimit || request.PrefixesLimit > maxima.PrefixesLimit || request.EventBuffer > maxima.EventBuffer {
+			respondBase(w, r, requestId(r), http.StatusBadRequest, fmt.Sprintf("Limits can be at most %d subscriptions, %d prefixes, %d events buffered", maxima.SubscriptionLimit, maxima.PrefixesLimit, maxima.EventBuffer))
+			return nil
+		}
+		// The same floor as in the configuration, 0 keeping the current buffer
+		if request.EventBuffer != 0 && request.EventBuffer < configuration.MinEventBuffer {
+			respondBase(w, r, requestId(r), http.StatusBadRequest, fmt.Sprintf("eventBuffer must be at least %d events", configuration.MinEventBuffer))
+			return nil
+		}
+		sublimit, prefixes, buffer := subs.Limits()
+		if request.SubscriptionLimit != 0 {
+			sublimit = request.SubscriptionLimit
+		}
+		if request.PrefixesLimit != 0 {
+			prefixes = request.PrefixesLimit
+		}
+		if request.EventBuffer != 0 {
+			buffer = request.EventBuffer
+		}
+		if err := subs.SetLimits(sublimit, prefixes, buffer); err != nil {
+			respondBase(w, r, requestId(r), http.StatusInternalServerError, err.Error())
+			return nil
+		}
+		lc.Infof("Limits set to %d subscriptions, %d entries/sub, event buffer %d by %s", sublimit, prefixes, buffer, clientAddress(r))
+	}
+	rv := dtos.LimitsResponse{
+		BaseResponse: commonDTO.NewBaseResponse(requestId(r), "", http.StatusOK),
+		Maxima:       maxima,
+	}
+	rv.Limits.SubscriptionLimit, rv.Limits.PrefixesLimit, rv.Limits.EventBuffer = subs.Limits()
+	sendResponse(w, r, rv, http.StatusOK)
+	return nil
+}
@@ -0,0 +1,85 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// limitsRequest GETs, or PUTs the body to, the service's limits, returning the status and response
+func limitsRequest(t *testing.T, method string, body string) (int, dtos.LimitsResponse) {
+	req, _ := http.NewRequest(method, interfaces.App.Config.SSE.LimitsRoute(), bytes.NewBufferString(body))
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	rr := httptest.NewRecorder()
+	router := echo.New()
+	router.Add(method, interfaces.App.Config.SSE.LimitsRoute(), ProcessLimitsRequest)
+	router.ServeHTTP(rr, req)
+	var resp dtos.LimitsResponse
+	_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+	return rr.Code, resp
+}
+
+func TestLimits(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	defer func() { authHeader = "" }()
+	sse := &interfaces.App.Config.SSE
+	code, got := limitsRequest(t, http.MethodGet, "")
+	if code != http.StatusOK || got.Limits != (dtos.Limits{SubscriptionLimit: sub_limit, PrefixesLimit: incexc_limit, EventBuffer: buffer}) ||
+		got.Maxima != (dtos.Limits{SubscriptionLimit: sse.SubscriptionLimit, PrefixesLimit: sse.PrefixesLimit, EventBuffer: sse.EventBuffer}) {
+		t.Fatalf("Got %d %+v", code, got)
+	}
+	// Nobody can change them without an AdminRole
+	if code, _ := limitsRequest(t, http.MethodPut, "{\"subscriptionLimit\":2}"); code != http.StatusForbidden {
+		t.Fatalf("Got %d changing limits without an AdminRole", code)
+	}
+
+	sse.Roles = map[string]configuration.RoleConfig{"admin": {Identities: "alice", SubscriptionLimit: 1, PrefixesLimit: 1}}
+	sse.AdminRole = "admin"
+	sse.SubscriptionLimit = sub_limit
+	sse.MaxSubscriptionLimit = sub_limit + 2
+	authHeader = bearerFor(t, "mallory")
+	if code, _ := limitsRequest(t, http.MethodGet, ""); code != http.StatusForbidden {
+		t.Fatalf("Got %d from GET of limits outside the AdminRole", code)
+	}
+	authHeader = bearerFor(t, "alice")
+	if code, _ := limitsRequest(t, http.MethodPut, "{\"subscriptionLimit\":7}"); code != http.StatusBadRequest {
+		t.Fatalf("Got %d raising the subscription limit past its maximum", code)
+	}
+	if code, _ := limitsRequest(t, http.MethodPut, "{\"eventBuffer\":9}"); code != http.StatusBadRequest {
+		t.Fatalf("Got %d lowering the event buffer past its minimum", code)
+	}
+	if code, _ := limitsRequest(t, http.MethodPut, "{\"subscriptionLimit\":\"many\"}"); code != http.StatusBadRequest {
+		t.Fatalf("Got %d for a bad limit", code)
+	}
+	code, got = limitsRequest(t, http.MethodPut, "{\"subscriptionLimit\":6, \"eventBuffer\":50}")
+	if code != http.StatusOK || got.Limits != (dtos.Limits{SubscriptionLimit: sub_limit + 2, PrefixesLimit: incexc_limit, EventBuffer: 50}) {
+		t.Fatalf("Got %d %+v setting limits", code, got)
+	}
+
+	// New subscriptions get them
+	authHeader = ""
+	var subid string
+	for i := 0; i < sub_limit+2; i++ {
+		subid = checkCreateRequest(t, http.StatusCreated)
+	}
+	_ = checkCreateRequest(t, http.StatusServiceUnavailable)
+	if size := interfaces.App.Subs.ChannelSize(interfaces.App.Subs.Subscription(subid)); size != 50 {
+		t.Fatalf("New subscription has buffer size %d", size)
+	}
+}
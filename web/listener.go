@@ -0,0 +1,169 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// How long in-flight requests get to finish once the service stops, before their connections are closed
+const shutdownTimeout = 5 * time.Second
+
+// Cause of the streams ending when the service stops
+var errServiceStopping = errors.New("service stopping")
+
+var (
+	contextLock    sync.Mutex
+	// Of the service, which stops it when done
+	serviceContext = context.Background()
+	// Ended by RestartStreams(), or when serviceContext is
+	streamsContext context.Context
+	restartStreams context.CancelCauseFunc
+)
+
+/*
+setServiceContext (an internal API) makes ctx the service's context, which event streams and
+the events port's servers end with.
+*/
+func setServiceContext(ctx context.Context) {
+	contextLock.Lock()
+	defer contextLock.Unlock()
+	if restartStreams != nil {
+		restartStreams(errServiceStopping)
+	}
+	serviceContext = ctx
+	streamsContext, restartStreams = nil, nil
+}
+
+/*
+currentStreams (an internal API) returns the context event streams opened now end with:
+done when the service stops, or RestartStreams() is called, with the reason as its cause.
+*/
+func currentStreams() context.Context {
+	contextLock.Lock()
+	defer contextLock.Unlock()
+	if streamsContext == nil {
+		streamsContext, restartStreams = context.WithCancelCause(serviceContext)
+	}
+	return streamsContext
+}
+
+/*
+RestartStreams ends the event streams open now, with the retry field if ReconnectDelay is
+set, so their clients reconnect and resume from their Last-Event-ID, e.g. to pick up a
+rotated TLS certificate. Streams opened after it returns aren't ended; reason is logged.
+*/
+func RestartStreams(reason error) {
+	contextLock.Lock()
+	defer contextLock.Unlock()
+	if restartStreams != nil {
+		restartStreams(reason)
+	}
+	streamsContext, restartStreams = nil, nil
+}
+
+/*
+ServeEvents serves the events port's handler at each of the addresses, with TLS if tlsConfig
+isn't nil. The addresses are bound before it returns, so a port in use is found at startup,
+rather than only logged by a server in the background. When ctx, the service's context, is
+done, the event streams end and the servers shut down; requests served have it as their base.
+
+An address that can't be bound is tried up to retries more times in the background, waiting
+interval, doubled each time, and reported by the health check meanwhile. Error is returned,
+and nothing served, if one can't be bound and retries is 0.
+*/
+func ServeEvents(ctx context.Context, addrs []string, handler http.Handler, tlsConfig *tls.Config, retries uint, interval time.Duration) error {
+	lc := interfaces.App.Logger
+	setServiceContext(ctx)
+	listeners := make(map[string]net.Listener, len(addrs))
+	var failed []string
+	for _, addr := range addrs {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil && retries == 0 {
+			for _, listener := range listeners {
+				_ = listener.Close()
+			}
+			return fmt.Errorf("could not listen for EventSource GETs at %s: %w", addr, err)
+		}
+		if err != nil {
+			lc.Warnf("Could not listen for EventSource GETs at %s, retrying in %v: %s", addr, interval, err.Error())
+			setEventsListener(addr, fmt.Errorf("not bound, retrying: %w", err))
+			failed = append(failed, addr)
+			continue
+		}
+		listeners[addr] = listener
+	}
+	for addr, listener := range listeners {
+		serveEvents(ctx, addr, listener, handler, tlsConfig)
+	}
+	for _, addr := range failed {
+		go retryEvents(ctx, addr, handler, tlsConfig, retries, interval)
+	}
+	return nil
+}
+
+// serveEvents (an internal API) serves the handler on a listener bound to the address, in the background.
+func serveEvents(ctx context.Context, addr string, listener net.Listener, handler http.Handler, tlsConfig *tls.Config) {
+	lc := interfaces.App.Logger
+	server := &http.Server{Addr: addr, Handler: handler, TLSConfig: tlsConfig}
+	server.BaseContext = func(net.Listener) context.Context { return ctx }
+	setEventsListener(addr, nil)
+	context.AfterFunc(ctx, func() {
+		// The streams end with ctx, so their connections go idle soon
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			lc.Warnf("Closing connections at %s still busy after %v: %s", addr, shutdownTimeout, err.Error())
+			_ = server.Close()
+		}
+	})
+	if tlsConfig != nil {
+		go func() {
+			EventsListenerStopped(addr, server.ServeTLS(listener, "", ""))
+		}()
+		lc.Infof("Listening for EventSource GETs at %s with TLS", addr)
+		return
+	}
+	go func() {
+		EventsListenerStopped(addr, server.Serve(listener))
+	}()
+	lc.Infof("Listening for EventSource GETs at %s", addr)
+}
+
+/*
+retryEvents (an internal API) binds the address for ServeEvents(), giving up after retries
+attempts, or when ctx is done.
+*/
+func retryEvents(ctx context.Context, addr string, handler http.Handler, tlsConfig *tls.Config, retries uint, interval time.Duration) {
+	for attempt := uint(1); ; attempt++ {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			EventsListenerStopped(addr, http.ErrServerClosed)
+			return
+		}
+		listener, err := net.Listen("tcp", addr)
+		if err == nil {
+			serveEvents(ctx, addr, listener, handler, tlsConfig)
+			return
+		}
+		if attempt >= retries {
+			EventsListenerStopped(addr, fmt.Errorf("could not listen after %d retries: %w", retries, err))
+			return
+		}
+		interval *= 2
+		setEventsListener(addr, fmt.Errorf("not bound, retrying: %w", err))
+	}
+}
@@ -0,0 +1,74 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"context"
+	"github.com/edgexfoundry-holding/edgex-sse/functions"
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeEvents(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	defer func() {
+		eventsListenerLock.Lock()
+		eventsListeners = make(map[string]error)
+		eventsListenerLock.Unlock()
+	}()
+	processor := functions.NewProcessor(interfaces.App.Logger, interfaces.App.Subs, interfaces.App.Config)
+	interfaces.App.Processor = &processor
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "served")
+	})
+	// A port someone else has
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %v", err)
+	}
+	addr := taken.Addr().String()
+	if err := ServeEvents(context.Background(), []string{addr}, handler, nil, 0, time.Second); err == nil || !strings.Contains(err.Error(), addr) {
+		t.Fatalf("Got %v serving at a port in use", err)
+	}
+
+	// Retried until it is free, unhealthy meanwhile
+	if err := ServeEvents(context.Background(), []string{addr}, handler, nil, 5, 100*time.Millisecond); err != nil {
+		t.Fatalf("ServeEvents failed with retries: %v", err)
+	}
+	if code, checks := healthRequest(t); code != http.StatusServiceUnavailable || !strings.Contains(checks["eventsListener"].Detail, "not bound, retrying") {
+		t.Fatalf("Got %d %v waiting to bind", code, checks)
+	}
+	_ = taken.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get("http://" + addr + "/")
+		if err == nil {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != "served" {
+				t.Fatalf("Got %q from the events port", body)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Events port not served once free: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	eventsListenerLock.Lock()
+	err = eventsListeners[addr]
+	eventsListenerLock.Unlock()
+	if err != nil {
+		t.Fatalf("Served, but recorded %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"github.com/edgexfoundry-holding/edgex-sse/token"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Struct lookupFailures counts one client's requests for subscription IDs that don't exist.
+type lookupFailures struct {
+	count       uint
+	since       time.Time
+	lockedUntil time.Time
+}
+
+var (
+	failures       = make(map[string]*lookupFailures)
+	failuresPruned time.Time
+	failuresLock   sync.Mutex
+)
+
+/*
+lookupSubscription finds a subscription by ID. Subscription IDs are all it takes to read
+a stream, but a map lookup hashes the ID, so its timing doesn't tell how close a guess was.
+*/
+func lookupSubscription(subid string) (*submgr.SubscriptionInfo, bool) {
+	found := findSubscription(subid)
+	// Created on another replica, and not synced here yet
+	if fetch := interfaces.App.FetchSubscription; found == nil && fetch != nil && fetch(subid) {
+		found = findSubscription(subid)
+	}
+	return found, found != nil
+}
+
+// findSubscription (an internal API) returns the subscription registered under the ID, or nil.
+func findSubscription(subid string) *submgr.SubscriptionInfo {
+	lockmgt.RLock()
+	defer lockmgt.RUnlock()
+	return g_subscriptions[subid]
+}
+
+/*
+ForgetSubscription is the subscription manager's delete hook: the ID no longer finds the
+subscription it was registered under, e.g. once it is deleted or aged out.
+*/
+func ForgetSubscription(subid string) {
+	lockmgt.Lock()
+	defer lockmgt.Unlock()
+	delete(g_subscriptions, subid)
+	delete(g_fixedIds, subid)
+}
+
+/*
+validSubscriptionId returns true for an ID a subscription could have: a token, or one that
+isn't, e.g. a static subscription's. Requests for others, e.g. from scanners, can be refused
+before they are looked up, logged or counted against the client as guesses.
+*/
+func validSubscriptionId(subid string) bool {
+	if token.Valid(subid) {
+		return true
+	}
+	for i := 0; i < len(subid); i++ {
+		if !token.ValidChar(subid[i]) {
+			return false
+		}
+	}
+	lockmgt.RLock()
+	defer lockmgt.RUnlock()
+	_, ok := g_fixedIds[subid]
+	return ok
+}
+
+/*
+lockedOut returns how long the client still has to wait, if it is locked out for asking
+for LookupFailureLimit subscriptions that don't exist within LookupLockout.
+*/
+func lockedOut(r *http.Request) (time.Duration, bool) {
+	if interfaces.App.Config.SSE.LookupFailureLimit == 0 {
+		return 0, false
+	}
+	failuresLock.Lock()
+	defer failuresLock.Unlock()
+	entry, ok := failures[clientAddress(r)]
+	if !ok {
+		return 0, false
+	}
+	wait := time.Until(entry.lockedUntil)
+	return wait, wait > 0
+}
+
+/*
+lookupFailed counts a request for a subscription that doesn't exist against the client,
+locking it out for LookupLockout once it has made LookupFailureLimit of them within that
+time, so subscription IDs can't be found by trying them one after another.
+*/
+func lookupFailed(r *http.Request) {
+	sse := &interfaces.App.Config.SSE
+	if sse.LookupFailureLimit == 0 {
+		return
+	}
+	lockout := sse.Durations().LookupLockout
+	client := clientAddress(r)
+	now := time.Now()
+	failuresLock.Lock()
+	defer failuresLock.Unlock()
+	if now.Sub(failuresPruned) > lockout {
+		for key, entry := range failures {
+			if now.Sub(entry.since) > lockout && now.After(entry.lockedUntil) {
+				delete(failures, key)
+			}
+		}
+		failuresPruned = now
+	}
+	entry, ok := failures[client]
+	if !ok || now.Sub(entry.since) > lockout {
+		entry = &lookupFailures{since: now}
+		failures[client] = entry
+	}
+	entry.count++
+	if entry.count >= sse.LookupFailureLimit && now.After(entry.lockedUntil) {
+		entry.lockedUntil = now.Add(lockout)
+		interfaces.App.Logger.Warnf("Client %s asked for %d subscriptions that don't exist, locked out for %v", client, entry.count, lockout)
+	}
+}
+
+// respondLockedOut tells a locked-out client to come back later.
+func respondLockedOut(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	respondBase(w, r, requestId(r), http.StatusTooManyRequests, "Too many requests for unknown subscriptions")
+}
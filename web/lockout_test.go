@@ -0,0 +1,137 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// lookupRequest GETs a subscription from addr, returning the status
+func lookupRequest(subid string, addr string) (int, string) {
+	req, _ := http.NewRequest(http.MethodGet, uri_base()+"/id/"+subid, nil)
+	req.RemoteAddr = addr + ":40000"
+	rr := httptest.NewRecorder()
+	router := echo.New()
+	router.GET(uri_base()+"/id/:subscriptionid", ProcessSubscriptionRequest)
+	router.ServeHTTP(rr, req)
+	return rr.Code, rr.Header().Get("Retry-After")
+}
+
+func TestLookupLockout(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	interfaces.App.Config.SSE.LookupFailureLimit = 3
+	subid := checkCreateRequest(t, http.StatusCreated)
+	if subInfo, ok := lookupSubscription(subid); !ok || subInfo == nil {
+		t.Fatal("lookupSubscription did not find the subscription")
+	}
+	if _, ok := lookupSubscription(subid[:len(subid)-1]); ok {
+		t.Fatal("lookupSubscription found a subscription by part of its ID")
+	}
+	// Asks for subscriptions made on another replica
+	asked := ""
+	interfaces.App.FetchSubscription = func(id string) bool {
+		asked = id
+		return false
+	}
+	if _, ok := lookupSubscription("elsewhere"); ok || asked != "elsewhere" {
+		t.Fatalf("lookupSubscription found it, or asked for %q", asked)
+	}
+	asked = ""
+	if _, ok := lookupSubscription(subid); !ok || asked != "" {
+		t.Fatalf("lookupSubscription asked for %q, a subscription it has", asked)
+	}
+	interfaces.App.FetchSubscription = nil
+	for i := 0; i < 3; i++ {
+		if code, _ := lookupRequest(inexist, "192.0.2.20"); code != http.StatusNotFound {
+			t.Fatalf("Got status %d for an unknown subscription, expected 404", code)
+		}
+	}
+	// Now locked out, even for the right ID, and for the event stream
+	if code, retry := lookupRequest(subid, "192.0.2.20"); code != http.StatusTooManyRequests || retry != "300" {
+		t.Fatalf("Got status %d, Retry-After %s when locked out, expected 429 and 300", code, retry)
+	}
+	req, _ := http.NewRequest(http.MethodGet, url_prefix()+subid, nil)
+	req.RemoteAddr = "192.0.2.20:40000"
+	rr := httptest.NewRecorder()
+	ProcessEventsRequest(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Got status %d for the event stream when locked out, expected 429", rr.Code)
+	}
+	// Other clients are not
+	if code, _ := lookupRequest(subid, "192.0.2.21"); code != http.StatusOK {
+		t.Fatalf("Got status %d from another client, expected 200", code)
+	}
+	// Nor anyone, with lockouts off
+	interfaces.App.Config.SSE.LookupFailureLimit = 0
+	if code, _ := lookupRequest(subid, "192.0.2.20"); code != http.StatusOK {
+		t.Fatalf("Got status %d with lockouts off, expected 200", code)
+	}
+}
+
+func TestValidSubscriptionId(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	interfaces.App.Config.SSE.LookupFailureLimit = 2
+	subid := checkCreateRequest(t, http.StatusCreated)
+	if err := AddStaticSubscription("fixed-name", []string{"a"}, nil, submgr.SubscriptionOptions{}); err != nil {
+		t.Fatalf("AddStaticSubscription failed: %v", err)
+	}
+	for _, id := range []string{subid, inexist, "fixed-name"} {
+		if !validSubscriptionId(id) {
+			t.Errorf("%s is not a valid subscription ID", id)
+		}
+	}
+	for _, id := range []string{"", "guess", subid + "x", subid[1:], "fixed-name.", "..%2f..%2fetc", strings.Repeat("A", 23) + "+"} {
+		if validSubscriptionId(id) {
+			t.Errorf("%q is a valid subscription ID", id)
+		}
+	}
+	// Refused before they're looked up, so they don't count as guesses
+	for i := 0; i < 3; i++ {
+		if code, _ := lookupRequest("wp-admin.php", "192.0.2.30"); code != http.StatusBadRequest {
+			t.Fatalf("Got status %d for a malformed ID, expected 400", code)
+		}
+	}
+	req, _ := http.NewRequest(http.MethodGet, url_prefix()+"wp-admin.php", nil)
+	req.RemoteAddr = "192.0.2.30:40000"
+	rr := httptest.NewRecorder()
+	ProcessEventsRequest(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Got status %d for the event stream of a malformed ID, expected 400", rr.Code)
+	}
+	if code, _ := lookupRequest(subid, "192.0.2.30"); code != http.StatusOK {
+		t.Fatalf("Got status %d after malformed IDs, expected 200", code)
+	}
+}
+
+func TestForgetSubscription(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	registered := func(subid string) bool {
+		lockmgt.RLock()
+		defer lockmgt.RUnlock()
+		_, ok := g_subscriptions[subid]
+		return ok
+	}
+	// Deleted through the API, and by the subscription manager, e.g. aged out
+	deleted := checkCreateRequest(t, http.StatusCreated)
+	_ = checkRequest(t, http.MethodDelete, uri_base()+"/id/"+deleted, "", http.StatusOK, "application/json")
+	agedOut := checkCreateRequest(t, http.StatusCreated)
+	interfaces.App.Subs.DeleteSubscription(agedOut)
+	kept := checkCreateRequest(t, http.StatusCreated)
+	if registered(deleted) || registered(agedOut) || !registered(kept) {
+		t.Fatalf("Registered: deleted %t, aged out %t, kept %t", registered(deleted), registered(agedOut), registered(kept))
+	}
+}
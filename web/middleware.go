@@ -0,0 +1,93 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"context"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/google/uuid"
+)
+
+/*
+Struct recordingWriter is a ResponseWriter that remembers the status and size of the response,
+for the access log. It can still be flushed, and unwrapped by http.ResponseController.
+*/
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *recordingWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+/*
+EventsMiddleware wraps the events port's handler, which the SDK doesn't manage, with what
+the SDK does for its own routes: each request gets a correlation ID (its X-Correlation-ID,
+or a new one), returned in the response and logged with the request when it ends, and a
+panicking handler is logged with its stack and answered with 500 instead of taking the
+connection down unexplained. The query is not logged, as it can have a stream token.
+*/
+func EventsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lc := interfaces.App.Logger
+		correlationID := r.Header.Get(common.CorrelationHeader)
+		if correlationID == "" {
+			correlationID = uuid.NewString()
+		}
+		w.Header().Set(common.CorrelationHeader, correlationID)
+		r = r.WithContext(context.WithValue(r.Context(), common.CorrelationHeader, correlationID))
+		recorder := &recordingWriter{ResponseWriter: w}
+		start := time.Now()
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					// The handler's way of cutting the connection, not a failure
+					panic(p)
+				}
+				lc.Errorf("Panic serving %s %s, correlation ID %s: %v\n%s", r.Method, r.URL.Path, correlationID, p, debug.Stack())
+				if recorder.status == 0 {
+					http.Error(recorder, "Internal server error", http.StatusInternalServerError)
+				}
+			}
+			lc.Debug("Events request", "method", r.Method, "path", r.URL.Path, "status", recorder.status, "bytes", recorder.bytes,
+				"duration", time.Since(start).String(), "client", clientAddress(r), common.CorrelationHeader, correlationID)
+		}()
+		next.ServeHTTP(recorder, r)
+	})
+}
@@ -0,0 +1,60 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+)
+
+func TestEventsMiddleware(t *testing.T) {
+	interfaces.App.Config = &configuration.Config{}
+	interfaces.App.Config.SetDefaults()
+	interfaces.App.Logger = logger.NewMockClient()
+	var gotID any
+	dut := EventsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = r.Context().Value(common.CorrelationHeader)
+		if r.URL.Path == "/panic" {
+			panic("handler failed")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		// The events handler needs these through the wrapper
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("Wrapped writer is not a Flusher")
+		}
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush through ResponseController failed: %v", err)
+		}
+		_, _ = w.Write([]byte(": hello\n\n"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set(common.CorrelationHeader, "given-id")
+	rec := httptest.NewRecorder()
+	dut.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !rec.Flushed || rec.Body.String() != ": hello\n\n" {
+		t.Errorf("Got status %d, flushed %v, body %q", rec.Code, rec.Flushed, rec.Body.String())
+	}
+	if rec.Header().Get(common.CorrelationHeader) != "given-id" || gotID != "given-id" {
+		t.Errorf("Correlation ID given-id came back as %q, handler saw %v", rec.Header().Get(common.CorrelationHeader), gotID)
+	}
+
+	rec = httptest.NewRecorder()
+	dut.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Panicking handler got status %d", rec.Code)
+	}
+	if id := rec.Header().Get(common.CorrelationHeader); id == "" || gotID != id {
+		t.Errorf("Generated correlation ID %q, handler saw %v", id, gotID)
+	}
+}
@@ -0,0 +1,48 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/openapi"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/labstack/echo/v4"
+)
+
+/*
+ProcessOpenAPIRequest handles GET of the API's OpenAPI document. The event stream path is
+documented on the events port of the host the document was asked from.
+*/
+func ProcessOpenAPIRequest(c echo.Context) error {
+	w := c.Response()
+	r := c.Request()
+	sse := &interfaces.App.Config.SSE
+
+	host := r.Host
+	if name, _, err := net.SplitHostPort(r.Host); err == nil {
+		host = name
+	}
+	scheme := "http"
+	if sse.EventsTLSSecretName != "" {
+		scheme = "https"
+	}
+	eventsServer := scheme + "://" + net.JoinHostPort(host, strconv.FormatUint(uint64(sse.EventsPort), 10))
+
+	data, err := openapi.Document(sse, eventsServer)
+	if err != nil {
+		respondBase(w, r, requestId(r), http.StatusInternalServerError, "Could not make OpenAPI document: "+err.Error())
+		return nil
+	}
+	w.Header().Set(common.ContentType, common.ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+	return nil
+}
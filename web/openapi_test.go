@@ -0,0 +1,47 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestOpenAPI(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	interfaces.App.Config.SSE.EventsTLSSecretName = "events-tls"
+	route := interfaces.App.Config.SSE.OpenAPIRoute()
+	req, _ := http.NewRequest(http.MethodGet, "http://gateway:59747"+route, nil)
+	rr := httptest.NewRecorder()
+	router := echo.New()
+	router.GET(route, ProcessOpenAPIRequest)
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]struct {
+			Servers []struct {
+				URL string `json:"url"`
+			} `json:"servers"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Bad OpenAPI document: %v", err)
+	}
+	events := doc.Paths[interfaces.App.Config.SSE.EventsRoute()+"{subscription_id}"]
+	if doc.OpenAPI == "" || len(events.Servers) != 1 || events.Servers[0].URL != "https://gateway:59748" {
+		t.Fatalf("Wrong document %+v", doc)
+	}
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
+)
+
+// How long a client's rate limiter is kept after its last request
+const limiterIdleTime = 10 * time.Minute
+
+// Struct clientLimiter is the rate limiter of one client, by verified identity or address.
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+var (
+	limiters       = make(map[string]*clientLimiter)
+	limitersPruned time.Time
+	limitersLock   sync.Mutex
+)
+
+/*
+allowRequest returns true if the client has not gone over ManagementRate requests per
+second, allowing ManagementBurst at once. Clients are told apart by their identity if it
+is verified, else by their address, as a client could claim a new identity every request.
+*/
+func allowRequest(r *http.Request) bool {
+	sse := &interfaces.App.Config.SSE
+	if sse.ManagementRate <= 0 {
+		return true
+	}
+	client := verifiedIdentity(r)
+	if client == "" {
+		client = clientAddress(r)
+	}
+	now := time.Now()
+	limitersLock.Lock()
+	defer limitersLock.Unlock()
+	if now.Sub(limitersPruned) > limiterIdleTime {
+		for key, entry := range limiters {
+			if now.Sub(entry.lastSeen) > limiterIdleTime {
+				delete(limiters, key)
+			}
+		}
+		limitersPruned = now
+	}
+	entry, ok := limiters[client]
+	if !ok {
+		entry = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(sse.ManagementRate), int(sse.ManagementBurst))}
+		limiters[client] = entry
+	} else if entry.limiter.Limit() != rate.Limit(sse.ManagementRate) || entry.limiter.Burst() != int(sse.ManagementBurst) {
+		// The limits were changed
+		entry.limiter.SetLimitAt(now, rate.Limit(sse.ManagementRate))
+		entry.limiter.SetBurstAt(now, int(sse.ManagementBurst))
+	}
+	entry.lastSeen = now
+	return entry.limiter.AllowN(now, 1)
+}
+
+/*
+RateLimited wraps a subscription management handler so that requests creating or changing
+subscriptions are refused with 429 once a client goes over its rate, e.g. a frontend
+stuck retrying, before they can use up the subscription limit.
+*/
+func RateLimited(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		r := c.Request()
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			return next(c)
+		}
+		if allowRequest(r) {
+			return next(c)
+		}
+		interfaces.App.Logger.Debugf("Rate limited %s %s from %s", r.Method, r.URL.Path, clientAddress(r))
+		retryAfter := int(math.Ceil(1 / interfaces.App.Config.SSE.ManagementRate))
+		c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		respondBase(c.Response(), r, requestId(r), http.StatusTooManyRequests, "Too many requests")
+		return nil
+	}
+}
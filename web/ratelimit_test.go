@@ -0,0 +1,74 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// rateLimitedRequest makes a request to the rate-limited subscription handler, from addr
+func rateLimitedRequest(method string, uri string, addr string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, uri, nil)
+	req.RemoteAddr = addr + ":40000"
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	rr := httptest.NewRecorder()
+	router := echo.New()
+	router.POST(uri_base(), RateLimited(ProcessSubscriptionRequest))
+	router.GET(uri_base()+"/id/:subscriptionid", RateLimited(ProcessSubscriptionRequest))
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRateLimit(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	interfaces.App.Config.SSE.ManagementRate = 0.5
+	interfaces.App.Config.SSE.ManagementBurst = 2
+	for i := 0; i < 2; i++ {
+		rr := rateLimitedRequest(http.MethodPost, uri_base(), "192.0.2.10")
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Got status %d within the burst, expected 201", rr.Code)
+		}
+	}
+	rr := rateLimitedRequest(http.MethodPost, uri_base(), "192.0.2.10")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "2" {
+		t.Fatalf("Got status %d, Retry-After %s past the burst, expected 429 and 2", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	if interfaces.App.Subs.NumSubscriptions() != 2 {
+		t.Fatalf("Rate limited request created a subscription, have %d", interfaces.App.Subs.NumSubscriptions())
+	}
+	// Other clients have their own limit, reads are not limited
+	if rr := rateLimitedRequest(http.MethodPost, uri_base(), "192.0.2.11"); rr.Code != http.StatusCreated {
+		t.Fatalf("Got status %d from another client, expected 201", rr.Code)
+	}
+	if rr := rateLimitedRequest(http.MethodGet, uri_base()+"/id/"+inexist, "192.0.2.10"); rr.Code != http.StatusNotFound {
+		t.Fatalf("Got status %d for a GET, expected it not to be rate limited", rr.Code)
+	}
+	// No limit at all
+	interfaces.App.Config.SSE.ManagementRate = 0
+	if rr := rateLimitedRequest(http.MethodPost, uri_base(), "192.0.2.10"); rr.Code != http.StatusCreated {
+		t.Fatalf("Got status %d with rate limiting off, expected 201", rr.Code)
+	}
+	interfaces.App.Config.SSE.ManagementRate = 0.5
+	// Made-up identities don't get their own limits, verified ones do
+	defer func() { authHeader = ""; interfaces.App.SecurityEnabled = false }()
+	authHeader = bearerFor(t, "mallory")
+	if rr := rateLimitedRequest(http.MethodPost, uri_base(), "192.0.2.10"); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Got status %d with a new identity without security, expected 429", rr.Code)
+	}
+	interfaces.App.SecurityEnabled = true
+	if rr := rateLimitedRequest(http.MethodPost, uri_base(), "192.0.2.10"); rr.Code == http.StatusTooManyRequests {
+		t.Fatalf("Got status %d with a verified identity, expected it not to be rate limited", rr.Code)
+	}
+}
@@ -0,0 +1,66 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Struct requestIdKey is the context key of a request's ID.
+type requestIdKey struct{}
+
+/*
+identifyRequest gives a subscription management request its ID, as EdgeX services do: the
+requestId of its BaseRequest body if it has one, or a new UUID, so the response carries it
+and the logs can be matched to it. The body is read through limitBody() first. The request
+with its ID is set in c and returned. If the body is too large, or its requestId is not a
+UUID, the response is sent and false returned.
+*/
+func identifyRequest(c echo.Context) (*http.Request, bool) {
+	r := c.Request()
+	if !limitBody(c.Response(), r) {
+		return r, false
+	}
+	var request commonDTO.BaseRequest
+	// Only those have a body, buffered by limitBody()
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		if r.Body == nil {
+			break
+		}
+		body, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		// The handler reports a body that doesn't parse, there is no requestId to find in it
+		if err == nil {
+			_ = json.Unmarshal(body, &request)
+		}
+	}
+	if request.RequestId == "" {
+		request.RequestId = uuid.NewString()
+	} else if _, err := uuid.Parse(request.RequestId); err != nil {
+		respondBase(c.Response(), r, "", http.StatusBadRequest, "requestId must be a UUID")
+		return r, false
+	}
+	r = r.WithContext(context.WithValue(r.Context(), requestIdKey{}, request.RequestId))
+	c.SetRequest(r)
+	return r, true
+}
+
+// requestId returns the ID identifyRequest() gave a request, "" if it has none.
+func requestId(r *http.Request) string {
+	id, _ := r.Context().Value(requestIdKey{}).(string)
+	return id
+}
@@ -0,0 +1,98 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Topic prefix EdgeX device services publish their devices' Events under, followed by the service's name
+const deviceEventsTopic = "edgex/events/device/"
+
+/*
+ProcessServiceSubscriptionRequest handles POST /subscription/service/{servicename}: it
+creates a subscription to the Events of a device service's devices, as core-metadata has
+them. It includes the service's topics, with the devices option picking the service's
+devices, so those it adds later join the subscription, as the devices option keeps up
+with core-metadata's system events. Responds with the subscription's ID, and the service's
+devices now. Needs core-metadata in Clients.
+*/
+func ProcessServiceSubscriptionRequest(c echo.Context) error {
+	lc := interfaces.App.Logger
+	subs := interfaces.App.Subs
+	w := c.Response()
+	r, ok := identifyRequest(c)
+	if !ok {
+		return nil
+	}
+	service := c.Param("servicename")
+	if service == "" || strings.ContainsAny(service, "/#+") {
+		respondBase(w, r, requestId(r), http.StatusBadRequest, "A device service name is required")
+		return nil
+	}
+	serviceClient := interfaces.App.Service.DeviceServiceClient()
+	deviceClient := interfaces.App.Service.DeviceClient()
+	if serviceClient == nil || deviceClient == nil {
+		respondBase(w, r, requestId(r), http.StatusServiceUnavailable, "core-metadata is not in Clients")
+		return nil
+	}
+	if _, err := serviceClient.DeviceServiceByName(r.Context(), service); err != nil {
+		if err.Code() == http.StatusNotFound {
+			respondBase(w, r, requestId(r), http.StatusNotFound, "No device service "+service)
+			return nil
+		}
+		lc.Warnf("Could not get device service %s from core-metadata: %s", service, err.Error())
+		respondBase(w, r, requestId(r), http.StatusServiceUnavailable, "Could not reach core-metadata")
+		return nil
+	}
+	response, err := deviceClient.DevicesByServiceName(r.Context(), service, 0, -1)
+	if err != nil {
+		lc.Warnf("Could not get devices of device service %s from core-metadata: %s", service, err.Error())
+		respondBase(w, r, requestId(r), http.StatusServiceUnavailable, "Could not reach core-metadata")
+		return nil
+	}
+	devices := make([]string, 0, len(response.Devices))
+	for _, device := range response.Devices {
+		devices = append(devices, device.Name)
+	}
+	slices.Sort(devices)
+
+	subid, subInfo, ok := createSubscription(w, r)
+	if !ok {
+		return nil
+	}
+	if err := subs.Include(subInfo, deviceEventsTopic+service); err != nil {
+		subs.DeleteSubscription(subid)
+		if errors.Is(err, submgr.ErrTopicNotAllowed) {
+			lc.Infof("Topics of device service %s not allowed for subscription of %s", service, clientAddress(r))
+			respondBase(w, r, requestId(r), http.StatusForbidden, "Topic "+deviceEventsTopic+service+" not allowed")
+			return nil
+		}
+		respondBase(w, r, requestId(r), http.StatusServiceUnavailable, err.Error())
+		return nil
+	}
+	if err := subs.SetOptions(subInfo, submgr.SubscriptionOptions{Devices: &submgr.DeviceSelector{Service: service}}); err != nil {
+		subs.DeleteSubscription(subid)
+		respondBase(w, r, requestId(r), http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	lc.Debugf("Created subscription %s to device service %s, %d devices, for %s", subid, service, len(devices), clientAddress(r))
+	sendResponse(w, r, dtos.ServiceSubscriptionResponse{
+		SubscriptionIdResponse: dtos.NewSubscriptionIdResponse(requestId(r), subid, "Subscription created", http.StatusCreated),
+		Service:                service,
+		Devices:                devices,
+	}, http.StatusCreated)
+	return nil
+}
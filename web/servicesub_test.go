@@ -0,0 +1,104 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	appint "github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	clientint "github.com/edgexfoundry/go-mod-core-contracts/v4/clients/interfaces"
+	edgexDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/responses"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+	"github.com/labstack/echo/v4"
+)
+
+// Stands in for the SDK's service with core-metadata clients that know one device service, or none if missing
+type metadataService struct {
+	appint.ApplicationService
+	missing bool
+}
+
+func (m metadataService) DeviceServiceClient() clientint.DeviceServiceClient {
+	if m.missing {
+		return nil
+	}
+	return fakeDeviceServices{}
+}
+
+func (m metadataService) DeviceClient() clientint.DeviceClient {
+	if m.missing {
+		return nil
+	}
+	return fakeDevices{}
+}
+
+type fakeDeviceServices struct {
+	clientint.DeviceServiceClient
+}
+
+func (f fakeDeviceServices) DeviceServiceByName(ctx context.Context, name string) (responses.DeviceServiceResponse, errors.EdgeX) {
+	if name != "device-virtual" {
+		return responses.DeviceServiceResponse{}, errors.NewCommonEdgeX(errors.KindEntityDoesNotExist, "device service "+name+" does not exist", nil)
+	}
+	return responses.DeviceServiceResponse{Service: edgexDTO.DeviceService{Name: name}}, nil
+}
+
+type fakeDevices struct {
+	clientint.DeviceClient
+}
+
+func (f fakeDevices) DevicesByServiceName(ctx context.Context, name string, offset int, limit int) (responses.MultiDevicesResponse, errors.EdgeX) {
+	return responses.MultiDevicesResponse{Devices: []edgexDTO.Device{{Name: "Random-Integer-Device"}, {Name: "Random-Boolean-Device"}}}, nil
+}
+
+func doServiceRequest(t *testing.T, service string) (int, string) {
+	uri := interfaces.App.Config.SSE.SubscriptionRoute() + "/service/" + service
+	req, err := http.NewRequest(http.MethodPost, uri, nil)
+	if err != nil {
+		t.Fatalf("Error constructing request: %s", err.Error())
+	}
+	rr := httptest.NewRecorder()
+	router := echo.New()
+	router.POST(interfaces.App.Config.SSE.SubscriptionRoute()+"/service/:servicename", ProcessServiceSubscriptionRequest)
+	router.ServeHTTP(rr, req)
+	return rr.Code, rr.Body.String()
+}
+
+func TestServiceSubscription(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	defer func() { interfaces.App.Service = nil }()
+	interfaces.App.Service = metadataService{missing: true}
+	if code, _ := doServiceRequest(t, "device-virtual"); code != http.StatusServiceUnavailable {
+		t.Fatalf("Got status %d instead of 503 without core-metadata", code)
+	}
+
+	interfaces.App.Service = metadataService{}
+	if code, _ := doServiceRequest(t, "device-missing"); code != http.StatusNotFound {
+		t.Fatalf("Got status %d instead of 404 for an unknown device service", code)
+	}
+	code, body := doServiceRequest(t, "device-virtual")
+	var created dtos.ServiceSubscriptionResponse
+	if err := json.Unmarshal([]byte(body), &created); code != http.StatusCreated || err != nil || created.Service != "device-virtual" {
+		t.Fatalf("Got status %d, %s", code, body)
+	}
+	if !slices.Equal(created.Devices, []string{"Random-Boolean-Device", "Random-Integer-Device"}) {
+		t.Fatalf("Got devices %v", created.Devices)
+	}
+	contents := checkGetRequest(t, created.SubscriptionId, http.StatusOK)
+	if !slices.Equal(contents.Include, []string{"edgex/events/device/device-virtual/"}) || contents.Options.Devices == nil || contents.Options.Devices.Service != "device-virtual" {
+		t.Fatalf("Subscription to device service has lists %v, options %+v", contents.Include, contents.Options)
+	}
+}
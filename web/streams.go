@@ -0,0 +1,174 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"github.com/labstack/echo/v4"
+)
+
+// Struct eventStream is an open event stream, as listed by ProcessConnectionsRequest.
+type eventStream struct {
+	subInfo   *submgr.SubscriptionInfo
+	address   string
+	connected time.Time
+	// Events written to the stream, not counting heartbeats
+	sent      atomic.Uint64
+	// Content coding the stream is compressed with, empty if it isn't
+	compression  string
+	// Bytes of event stream written, and sent to the client after compression
+	bytesWritten atomic.Uint64
+	bytesSent    atomic.Uint64
+	// Times the stream was flushed to the client
+	flushes      atomic.Uint64
+	// Closed when another connection takes the stream over, and when it has ended - close under streamsLock
+	takenOver    chan struct{}
+	ended        chan struct{}
+}
+
+// How long a connection taking a stream over waits for the one it ends to stop reading the subscription's events
+var takeoverWait = 5 * time.Second
+
+var (
+	streams     = make(map[*eventStream]struct{})
+	streamsLock sync.Mutex
+)
+
+// Errors of openStream()
+var (
+	errStreamOpen      = errors.New("subscription already has an event stream")
+	errTakeoverPending = errors.New("event stream taken over hasn't ended yet")
+)
+
+/*
+openStream records an event stream for the subscription. Call close() when it ends.
+
+With ExclusiveStreams, errStreamOpen is returned if the subscription has one open already,
+unless takeover is set: then that one is ended, and waited for, up to takeoverWait, so it
+doesn't take events meant for this one, or mark the subscription inactive once this one
+has marked it active. errTakeoverPending is returned if it hasn't ended by then, e.g. stuck
+writing to a client that isn't reading, until WriteTimeout; it still ends.
+*/
+func openStream(r *http.Request, subInfo *submgr.SubscriptionInfo, takeover bool) (*eventStream, error) {
+	stream := &eventStream{subInfo: subInfo, address: clientAddress(r), connected: time.Now(), compression: streamCompression(r),
+		takenOver: make(chan struct{}), ended: make(chan struct{})}
+	var previous []*eventStream
+	streamsLock.Lock()
+	if interfaces.App.Config.SSE.ExclusiveStreams {
+		for open := range streams {
+			if open.subInfo == subInfo {
+				previous = append(previous, open)
+			}
+		}
+		if len(previous) > 0 && !takeover {
+			streamsLock.Unlock()
+			return nil, errStreamOpen
+		}
+		for _, open := range previous {
+			select {
+			case <-open.takenOver:
+			default:
+				close(open.takenOver)
+			}
+		}
+	}
+	streams[stream] = struct{}{}
+	streamsLock.Unlock()
+	deadline := time.After(takeoverWait)
+	for _, open := range previous {
+		select {
+		case <-open.ended:
+		case <-deadline:
+			stream.close()
+			return nil, errTakeoverPending
+		}
+	}
+	return stream, nil
+}
+
+func (s *eventStream) close() {
+	streamsLock.Lock()
+	defer streamsLock.Unlock()
+	delete(streams, s)
+	close(s.ended)
+}
+
+/*
+ProcessConnectionsRequest handles GET of the open event streams: which subscription,
+from where, since when, how many events were sent, and how many are waiting in the
+subscription's buffer, so operators can see who is falling behind; and how many bytes were
+written, how many went over the wire after compression, in how many flushes, so they can
+see what compression saves on each.
+
+With an AdminRole configured only its callers may ask. Subscription IDs are all it takes
+to read a stream, so only they are shown them; without one, nobody is.
+*/
+func ProcessConnectionsRequest(c echo.Context) error {
+	type connection struct {
+		SubscriptionId string `json:"subscriptionId"`
+		RemoteAddress  string `json:"remoteAddress"`
+		ConnectedAt    string `json:"connectedAt"`
+		EventsSent     uint64 `json:"eventsSent"`
+		BufferDepth    int    `json:"bufferDepth"`
+		Compression    string `json:"compression,omitempty"`
+		BytesWritten   uint64 `json:"bytesWritten"`
+		BytesSent      uint64 `json:"bytesSent"`
+		Flushes        uint64 `json:"flushes"`
+	}
+	type connectionsReturn struct {
+		commonDTO.BaseResponse `json:",inline"`
+		Connections            []connection `json:"connections"`
+	}
+	subs := interfaces.App.Subs
+	w := c.Response()
+	r := c.Request()
+
+	sse := &interfaces.App.Config.SSE
+	admin := sse.AdminRole != "" && sse.RoleOf(callerIdentity(r)) == sse.AdminRole
+	if sse.AdminRole != "" && !admin {
+		respondBase(w, r, requestId(r), http.StatusForbidden, "Only for callers in the AdminRole")
+		return nil
+	}
+	streamsLock.Lock()
+	open := make([]*eventStream, 0, len(streams))
+	for stream := range streams {
+		open = append(open, stream)
+	}
+	streamsLock.Unlock()
+	sort.Slice(open, func(i, j int) bool { return open[i].connected.Before(open[j].connected) })
+
+	rv := connectionsReturn{Connections: make([]connection, 0, len(open))}
+	rv.BaseResponse = commonDTO.NewBaseResponse("", "", http.StatusOK)
+	for _, stream := range open {
+		subid := redacted
+		if admin {
+			subid = subs.SubscriptionId(stream.subInfo)
+		}
+		rv.Connections = append(rv.Connections, connection{
+			SubscriptionId: subid,
+			RemoteAddress:  stream.address,
+			ConnectedAt:    stream.connected.UTC().Format(time.RFC3339),
+			EventsSent:     stream.sent.Load(),
+			BufferDepth:    subs.ChannelDepth(stream.subInfo),
+			Compression:    stream.compression,
+			BytesWritten:   stream.bytesWritten.Load(),
+			BytesSent:      stream.bytesSent.Load(),
+			Flushes:        stream.flushes.Load(),
+		})
+	}
+	sendResponse(w, r, rv, http.StatusOK)
+	return nil
+}
@@ -0,0 +1,125 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/configuration"
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// connectionsRequest GETs the open event streams, returning the status and listing
+func connectionsRequest(t *testing.T) (int, []map[string]any) {
+	req, _ := http.NewRequest(http.MethodGet, interfaces.App.Config.SSE.ConnectionsRoute(), nil)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	rr := httptest.NewRecorder()
+	router := echo.New()
+	router.GET(interfaces.App.Config.SSE.ConnectionsRoute(), ProcessConnectionsRequest)
+	router.ServeHTTP(rr, req)
+	var body struct {
+		Connections []map[string]any `json:"connections"`
+	}
+	if rr.Code == http.StatusOK {
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Bad connections response %s: %v", rr.Body.String(), err)
+		}
+	}
+	return rr.Code, body.Connections
+}
+
+func TestConnections(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	subs := interfaces.App.Subs
+	if code, list := connectionsRequest(t); code != http.StatusOK || len(list) != 0 {
+		t.Fatalf("Got %d %v with no streams open", code, list)
+	}
+	subid := checkCreateRequest(t, http.StatusCreated)
+	subInfo := subs.Subscription(subid)
+	_ = subs.Include(subInfo, "a")
+	subs.SetActive(subInfo, true)
+	req, _ := http.NewRequest(http.MethodGet, interfaces.App.Config.SSE.EventsRoute()+subid, nil)
+	req.RemoteAddr = "192.0.2.40:40000"
+	stream, _ := openStream(req, subInfo, false)
+	stream.sent.Add(3)
+	for _, handle := range subs.SubscribedChannels("a/b") {
+		handle.Send(submgr.ChannelMessage{Payload: "waiting"})
+	}
+	code, list := connectionsRequest(t)
+	if code != http.StatusOK || len(list) != 1 {
+		t.Fatalf("Got %d %v with one stream open", code, list)
+	}
+	conn := list[0]
+	// Without an AdminRole, nobody may see the subscription IDs
+	if conn["subscriptionId"] != redacted || conn["remoteAddress"] != "192.0.2.40" || conn["eventsSent"] != 3.0 || conn["bufferDepth"] != 1.0 || conn["connectedAt"] == "" {
+		t.Fatalf("Wrong connection %v", conn)
+	}
+	stream.close()
+	if _, list = connectionsRequest(t); len(list) != 0 {
+		t.Fatalf("Closed stream still listed: %v", list)
+	}
+	stream, _ = openStream(req, subInfo, false)
+	defer stream.close()
+
+	// Only for admins, if there are any
+	interfaces.App.Config.SSE.Roles = map[string]configuration.RoleConfig{
+		"admin": {Identities: "root", SubscriptionLimit: sub_limit, PrefixesLimit: incexc_limit},
+	}
+	interfaces.App.Config.SSE.AdminRole = "admin"
+	defer func() { authHeader = "" }()
+	authHeader = bearerFor(t, "alice")
+	if code, _ = connectionsRequest(t); code != http.StatusForbidden {
+		t.Fatalf("Got %d for a caller not in the AdminRole, expected 403", code)
+	}
+	authHeader = bearerFor(t, "root")
+	if code, list = connectionsRequest(t); code != http.StatusOK || len(list) != 1 || list[0]["subscriptionId"] != subid {
+		t.Fatalf("Got %d %v for a caller in the AdminRole, expected 200 with the subscription ID", code, list)
+	}
+}
+
+func TestTakeoverPending(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	interfaces.App.Config.SSE.ExclusiveStreams = true
+	defer func(wait time.Duration) { takeoverWait = wait }(takeoverWait)
+	takeoverWait = 100 * time.Millisecond
+	subid := checkCreateRequest(t, http.StatusCreated)
+	subInfo := interfaces.App.Subs.Subscription(subid)
+	req, _ := http.NewRequest(http.MethodGet, url_prefix()+subid, nil)
+	// Told it is taken over, but never ends
+	old, err := openStream(req, subInfo, false)
+	if err != nil {
+		t.Fatalf("openStream failed: %v", err)
+	}
+	if _, err := openStream(req, subInfo, false); !errors.Is(err, errStreamOpen) {
+		t.Fatalf("Got %v opening a second stream", err)
+	}
+	if _, err := openStream(req, subInfo, true); !errors.Is(err, errTakeoverPending) {
+		t.Fatalf("Got %v taking over a stream that doesn't end", err)
+	}
+	select {
+	case <-old.takenOver:
+	default:
+		t.Fatal("Stream not told it is taken over")
+	}
+	old.close()
+	stream, err := openStream(req, subInfo, true)
+	if err != nil {
+		t.Fatalf("Got %v taking over once the old stream ended", err)
+	}
+	stream.close()
+}
@@ -0,0 +1,148 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// Where /events looks for a stream token, as EventSource can't send an Authorization header
+const (
+	StreamTokenParameter = "token"
+	StreamTokenCookie    = "edgex-sse-token"
+)
+
+// Key stream tokens are signed with. Made up at startup, so tokens don't outlive the service, unless set.
+var (
+	streamKey     []byte
+	streamKeyLock sync.Mutex
+)
+
+func streamTokenKey() []byte {
+	streamKeyLock.Lock()
+	defer streamKeyLock.Unlock()
+	if streamKey == nil {
+		streamKey = make([]byte, 32)
+		if _, err := rand.Read(streamKey); err != nil {
+			panic("could not generate stream token key: " + err.Error())
+		}
+	}
+	return streamKey
+}
+
+/*
+SetStreamTokenKey sets the key stream tokens are signed with, e.g. one shared by replicas
+of the service, so a token from one works on the others. Tokens signed with the previous
+key stop working.
+
+Error is returned if the key is shorter than 32 bytes.
+*/
+func SetStreamTokenKey(key []byte) error {
+	if len(key) < 32 {
+		return errors.New("stream token key must be at least 32 bytes")
+	}
+	streamKeyLock.Lock()
+	defer streamKeyLock.Unlock()
+	streamKey = bytes.Clone(key)
+	return nil
+}
+
+// newStreamToken returns a token for the event stream of one subscription, and when it expires.
+func newStreamToken(subid string) (string, time.Time, error) {
+	expires := time.Now().Add(interfaces.App.Config.SSE.Durations().StreamTokenLifetime)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   subid,
+		ExpiresAt: jwt.NewNumericDate(expires),
+	})
+	signed, err := token.SignedString(streamTokenKey())
+	return signed, expires, err
+}
+
+// checkStreamToken returns an error unless token is one of ours, for subid, and not expired.
+func checkStreamToken(token string, subid string) error {
+	claims := jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return streamTokenKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired(), jwt.WithSubject(subid))
+	return err
+}
+
+/*
+streamToken returns the stream token of an /events request, from its query parameter or
+else its cookie, or "" if it has none.
+*/
+func streamToken(r *http.Request) string {
+	if token := r.URL.Query().Get(StreamTokenParameter); token != "" {
+		return token
+	}
+	if cookie, err := r.Cookie(StreamTokenCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+/*
+authorizeStream checks the stream token of an /events request for subid. A request with
+no token is only allowed when RequireStreamToken is off.
+*/
+func authorizeStream(r *http.Request, subid string) error {
+	token := streamToken(r)
+	if token == "" {
+		if interfaces.App.Config.SSE.RequireStreamToken {
+			return errors.New("stream token required")
+		}
+		return nil
+	}
+	return checkStreamToken(token, subid)
+}
+
+/*
+ProcessStreamTokenRequest handles POST /subscription/id/{subscriptionid}/token: it returns a
+short-lived token that lets a browser's EventSource, which can't authenticate itself,
+open that subscription's event stream, passed as the token query parameter or cookie.
+*/
+func ProcessStreamTokenRequest(c echo.Context) error {
+	type tokenReturn struct {
+		commonDTO.BaseResponse `json:",inline"`
+		StreamToken            string `json:"streamToken"`
+		ExpiresAt              string `json:"expiresAt"`
+	}
+	lc := interfaces.App.Logger
+	w := c.Response()
+	r, ok := identifyRequest(c)
+	if !ok {
+		return nil
+	}
+
+	subid := c.Param("subscriptionid")
+	if _, ok := findManagedSubscription(w, r, subid); !ok {
+		return nil
+	}
+	token, expires, err := newStreamToken(subid)
+	if err != nil {
+		lc.Errorf("Could not sign stream token: %s", err.Error())
+		respondBase(w, r, requestId(r), http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	lc.Debugf("Stream token for subscription %s issued to %s", subid, clientAddress(r))
+	rv := tokenReturn{}
+	rv.BaseResponse = commonDTO.NewBaseResponse(requestId(r), "Stream token issued", http.StatusOK)
+	rv.StreamToken = token
+	rv.ExpiresAt = expires.UTC().Format(time.RFC3339)
+	sendResponse(w, r, rv, http.StatusOK)
+	return nil
+}
@@ -0,0 +1,146 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// requestStreamToken POSTs for a stream token, returning it if the status is as expected
+func requestStreamToken(t *testing.T, subid string, exp_code int) string {
+	req, _ := http.NewRequest(http.MethodPost, uri_base()+"/id/"+subid+"/token", nil)
+	rr := httptest.NewRecorder()
+	router := echo.New()
+	router.POST(uri_base()+"/id/:subscriptionid/token", ProcessStreamTokenRequest)
+	router.ServeHTTP(rr, req)
+	if rr.Code != exp_code {
+		t.Fatalf("Got status %d for stream token, expected %d", rr.Code, exp_code)
+	}
+	var resp struct {
+		StreamToken string `json:"streamToken"`
+		ExpiresAt   string `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Could not parse stream token response %s: %v", rr.Body.String(), err)
+	}
+	if exp_code == http.StatusOK {
+		if expires, err := time.Parse(time.RFC3339, resp.ExpiresAt); err != nil || time.Until(expires) > 2*time.Minute {
+			t.Fatalf("Wrong stream token expiry %s", resp.ExpiresAt)
+		}
+	}
+	return resp.StreamToken
+}
+
+// eventsStatus returns the status of an /events request that is refused
+func eventsStatus(subid string, token string, cookie bool) int {
+	uri := url_prefix() + subid
+	if token != "" && !cookie {
+		uri += "?" + StreamTokenParameter + "=" + token
+	}
+	req, _ := http.NewRequest(http.MethodGet, uri, nil)
+	if cookie {
+		req.AddCookie(&http.Cookie{Name: StreamTokenCookie, Value: token})
+	}
+	rr := httptest.NewRecorder()
+	ProcessEventsRequest(rr, req)
+	return rr.Code
+}
+
+func TestStreamToken(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	subid := checkCreateRequest(t, http.StatusCreated)
+	other := checkCreateRequest(t, http.StatusCreated)
+	_ = requestStreamToken(t, inexist, http.StatusNotFound)
+	token := requestStreamToken(t, subid, http.StatusOK)
+
+	interfaces.App.Config.SSE.RequireStreamToken = true
+	if code := eventsStatus(subid, "", false); code != http.StatusUnauthorized {
+		t.Fatalf("Got status %d without a stream token, expected 401", code)
+	}
+	if code := eventsStatus(other, token, false); code != http.StatusUnauthorized {
+		t.Fatalf("Got status %d with another subscription's stream token, expected 401", code)
+	}
+	if code := eventsStatus(subid, token+"x", true); code != http.StatusUnauthorized {
+		t.Fatalf("Got status %d with a bad stream token, expected 401", code)
+	}
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   subid,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Second)),
+	}).SignedString(streamTokenKey())
+	if code := eventsStatus(subid, expired, false); code != http.StatusUnauthorized {
+		t.Fatalf("Got status %d with an expired stream token, expected 401", code)
+	}
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   subid,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}).SignedString([]byte("not our key"))
+	if code := eventsStatus(subid, forged, false); code != http.StatusUnauthorized {
+		t.Fatalf("Got status %d with a forged stream token, expected 401", code)
+	}
+	for _, cookie := range []bool{false, true} {
+		uri := url_prefix() + subid + "?" + StreamTokenParameter + "=" + token
+		req, _ := http.NewRequest(http.MethodGet, uri, nil)
+		if cookie {
+			req, _ = http.NewRequest(http.MethodGet, url_prefix()+subid, nil)
+			req.AddCookie(&http.Cookie{Name: StreamTokenCookie, Value: token})
+		}
+		if err := authorizeStream(req, subid); err != nil {
+			t.Fatalf("Valid stream token refused: %v", err)
+		}
+	}
+	// Without RequireStreamToken, only a bad token is refused
+	interfaces.App.Config.SSE.RequireStreamToken = false
+	req, _ := http.NewRequest(http.MethodGet, url_prefix()+subid, nil)
+	if err := authorizeStream(req, subid); err != nil {
+		t.Fatalf("Request without a stream token refused: %v", err)
+	}
+	if code := eventsStatus(subid, forged, false); code != http.StatusUnauthorized {
+		t.Fatalf("Got status %d with a forged stream token, expected 401", code)
+	}
+}
+
+func TestSetStreamTokenKey(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	defer func() {
+		streamKeyLock.Lock()
+		streamKey = nil
+		streamKeyLock.Unlock()
+	}()
+	subid := checkCreateRequest(t, http.StatusCreated)
+	if SetStreamTokenKey([]byte("too short")) == nil {
+		t.Fatal("Key shorter than 32 bytes set")
+	}
+	old := requestStreamToken(t, subid, http.StatusOK)
+	key := []byte("0123456789abcdef0123456789abcdef")
+	if err := SetStreamTokenKey(key); err != nil {
+		t.Fatalf("SetStreamTokenKey failed: %v", err)
+	}
+	if checkStreamToken(old, subid) == nil {
+		t.Fatal("Token signed with the previous key accepted")
+	}
+	// Another replica with the same key accepts it
+	token := requestStreamToken(t, subid, http.StatusOK)
+	shared, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   subid,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}).SignedString(key)
+	for _, valid := range []string{token, shared} {
+		if err := checkStreamToken(valid, subid); err != nil {
+			t.Fatalf("Token signed with the key refused: %v", err)
+		}
+	}
+}
@@ -9,18 +9,30 @@ package web
 
 import (
 	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/notify"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
 	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"github.com/edgexfoundry-holding/edgex-sse/token"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
 	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
 	"github.com/labstack/echo/v4"
+	"io"
 	"net/http"
-	"strings"
+	"slices"
 	"sync"
+	"time"
 )
 
 var g_subscriptions map[string]*submgr.SubscriptionInfo
 
+// IDs of subscriptions that aren't tokens, e.g. static ones, so requests for them are valid - access under lockmgt
+var g_fixedIds = make(map[string]struct{})
+
 var lockmgt   sync.RWMutex
 
 func sendResponse(w http.ResponseWriter, r *http.Request, response interface{}, statusCode int) {
@@ -44,21 +56,34 @@ func respondBase(w http.ResponseWriter, r *http.Request, requestId string, statu
 }
 
 func addSubscription(w http.ResponseWriter, r *http.Request) {
-	type postReturn struct {
-		commonDTO.BaseResponse `json:",inline"`
-		SubscriptionId         string `json:"subscriptionId"`
+	subid, _, ok := createSubscription(w, r)
+	if !ok {
+		return
 	}
+	rv := dtos.NewSubscriptionIdResponse(requestId(r), subid, "Subscription created", http.StatusCreated)
+	sendResponse(w, r, rv, http.StatusCreated)
+}
+
+/*
+createSubscription creates a subscription for the caller of a request, with its role's
+limits and allowed topics, and the caller as owner. Responds to the request, and returns
+false, if it can't be created.
+*/
+func createSubscription(w http.ResponseWriter, r *http.Request) (string, *submgr.SubscriptionInfo, bool) {
 	lc := interfaces.App.Logger
 	subs := interfaces.App.Subs
-	subid, err := subs.NewSubscription()
+	// Callers in a role get its limits, and only they (or admins) can manage what they create
+	identity := callerIdentity(r)
+	role := interfaces.App.Config.SSE.RoleOf(identity)
+	subid, err := subs.NewSubscriptionWithQuota(role)
 	if err != nil {
-		lc.Infof("Subscription creation request error: %s", err.Error())
-		respondBase(w, r, "", http.StatusServiceUnavailable, err.Error())
-		return
+		lc.Infof("Subscription creation request %s from %s error: %s", requestId(r), clientAddress(r), err.Error())
+		if alert := interfaces.App.Alert; alert != nil && errors.Is(err, submgr.ErrSubscriptionLimit) {
+			alert(notify.AlertSubscriptionLimit, models.Minor, fmt.Sprintf("Subscription from %s refused: %s", clientAddress(r), err.Error()))
+		}
+		respondBase(w, r, requestId(r), http.StatusServiceUnavailable, err.Error())
+		return "", nil, false
 	}
-	rv := postReturn{}
-	rv.BaseResponse = commonDTO.NewBaseResponse("", "Subscription created", http.StatusCreated)
-	rv.SubscriptionId = subid
 	lockmgt.Lock()	
 	if g_subscriptions == nil {
 		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
@@ -67,41 +92,121 @@ func addSubscription(w http.ResponseWriter, r *http.Request) {
 	if subInfo == nil {
 		w.WriteHeader(http.StatusNotFound)
 		lockmgt.Unlock()
-		return
+		return "", nil, false
 	}
+	subs.SetOwner(subInfo, identity)
+	subs.SetAllowedTopics(subInfo, interfaces.App.Config.SSE.AllowedTopicList(role))
 	g_subscriptions[subid] = subInfo
 	lockmgt.Unlock()	
-	sendResponse(w, r, rv, http.StatusCreated)
+	return subid, subInfo, true
+}
+
+/*
+AddStaticSubscription creates a subscription with a fixed ID, e.g. one declared in the
+configuration, so it can be listened to and managed like one created by a POST.
+*/
+func AddStaticSubscription(subid string, includes []string, excludes []string, options submgr.SubscriptionOptions) error {
+	subs := interfaces.App.Subs
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	if err := subs.NewStaticSubscription(subid); err != nil {
+		return err
+	}
+	subInfo := subs.Subscription(subid)
+	for _, i := range includes {
+		if err := subs.Include(subInfo, i); err != nil {
+			subs.DeleteSubscription(subid)
+			return err
+		}
+	}
+	for _, e := range excludes {
+		if err := subs.Exclude(subInfo, e); err != nil {
+			subs.DeleteSubscription(subid)
+			return err
+		}
+	}
+	if err := subs.SetOptions(subInfo, options); err != nil {
+		subs.DeleteSubscription(subid)
+		return err
+	}
+	lockmgt.Lock()
+	defer lockmgt.Unlock()
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	g_subscriptions[subid] = subInfo
+	g_fixedIds[subid] = struct{}{}
+	return nil
+}
+
+/*
+RestoreSubscription recreates a subscription from its definition, e.g. one persisted before
+the service restarted, under the same ID, so its clients can reconnect to it. It is limited
+to the topics its role is allowed now, which may have changed since it was persisted.
+*/
+func RestoreSubscription(def submgr.Definition) error {
+	subs := interfaces.App.Subs
+	if err := subs.Restore(def); err != nil {
+		return err
+	}
+	subInfo := subs.Subscription(def.Id)
+	subs.SetAllowedTopics(subInfo, interfaces.App.Config.SSE.AllowedTopicList(def.Quota))
+	lockmgt.Lock()
+	defer lockmgt.Unlock()
+	if g_subscriptions == nil {
+		g_subscriptions = make(map[string]*submgr.SubscriptionInfo)
+	}
+	g_subscriptions[def.Id] = subInfo
+	if !token.Valid(def.Id) {
+		g_fixedIds[def.Id] = struct{}{}
+	}
+	return nil
+}
+
+/*
+ReplaceSubscription changes a subscription to its definition, e.g. as changed on another
+replica of the service. Like RestoreSubscription(), it is limited to the topics its role is
+allowed now.
+*/
+func ReplaceSubscription(def submgr.Definition) error {
+	subs := interfaces.App.Subs
+	if err := subs.Replace(def); err != nil {
+		return err
+	}
+	subs.SetAllowedTopics(subs.Subscription(def.Id), interfaces.App.Config.SSE.AllowedTopicList(def.Quota))
+	return nil
 }
 
 func deleteSubscription(w http.ResponseWriter, r *http.Request, subid string) {
 	lc := interfaces.App.Logger
 	subs := interfaces.App.Subs
-	lc.Debugf("Deleting subscription %s", subid)
+	lc.Debugf("Deleting subscription %s for %s", subid, clientAddress(r))
 	subs.DeleteSubscription(subid)
-	respondBase(w, r, "", http.StatusOK, "Subscription deleted")
+	respondBase(w, r, requestId(r), http.StatusOK, "Subscription deleted")
 }
 
-func getSubscription(w http.ResponseWriter, r *http.Request, includes []string, excludes []string) {
-	type getReturn struct {
-		commonDTO.BaseResponse `json:",inline"`
-		Include                []string `json:"include"`
-		Exclude                []string `json:"exclude"`
+func getSubscription(w http.ResponseWriter, r *http.Request, version subscriptionVersion, subInfo *submgr.SubscriptionInfo, includes []string, excludes []string) {
+	subs := interfaces.App.Subs
+	state := subscriptionState{include: includes, exclude: excludes, options: subs.Options(subInfo), prefixLimit: subs.PrefixLimit(subInfo)}
+	if expiresIn, ok := subs.ExpiresIn(subInfo); ok {
+		state.expiresIn = expiresIn.Round(time.Second).String()
 	}
-	rv := getReturn{}
-	rv.BaseResponse = commonDTO.NewBaseResponse("", "", http.StatusOK)
-	rv.Include = includes
-	rv.Exclude = excludes
-	sendResponse(w, r, rv, http.StatusOK)
+	sendResponse(w, r, version.encodeState(requestId(r), state), http.StatusOK)
 }
 
-func putSubscription(w http.ResponseWriter, r *http.Request, subInfo *submgr.SubscriptionInfo, existing_includes []string, existing_excludes []string) {
-	// Delete everything, then do the same processing as "patch"
+func putSubscription(w http.ResponseWriter, r *http.Request, version subscriptionVersion, subInfo *submgr.SubscriptionInfo, existing_includes []string, existing_excludes []string) {
+	// Delete everything, then make the same changes as "patch", once the request is known to be valid
 	lc := interfaces.App.Logger
 	subs := interfaces.App.Subs
+	// Everything is deleted, so only the request's lists can conflict
+	request, ok := decodeChange(w, r, version, nil)
+	if !ok {
+		return
+	}
 	someError := false
 	for _, e := range existing_excludes {
-		err := subs.Include(subInfo, e)
+		err := subs.RemoveExclude(subInfo, e)
 		if err != nil {
 			lc.Errorf("Error deleting exclude %s from subscription during PUT: %s", e, err.Error())
 			someError = true
@@ -115,66 +220,354 @@ func putSubscription(w http.ResponseWriter, r *http.Request, subInfo *submgr.Sub
 		}
 	}
 	if someError {
-		respondBase(w, r, "", http.StatusInternalServerError, "Error deleting existing subscription list items")
+		respondBase(w, r, requestId(r), http.StatusInternalServerError, "Error deleting existing subscription list items")
+		return
+	}
+	// Options not given in the request go back to their defaults
+	err := subs.SetOptions(subInfo, submgr.SubscriptionOptions{})
+	if err != nil {
+		respondBase(w, r, requestId(r), http.StatusInternalServerError, err.Error())
 		return
 	}
-	patchSubscription(w, r, subInfo)
+	applyChange(w, r, subInfo, request)
 }
 
-func patchSubscription(w http.ResponseWriter, r *http.Request, subInfo *submgr.SubscriptionInfo) {
-	lc := interfaces.App.Logger
-	subs := interfaces.App.Subs
-	type subreq struct {
-		commonDTO.BaseRequest `json:",inline"`
-		Include               []string `json:"include"`
-		Exclude               []string `json:"exclude"`
+func patchSubscription(w http.ResponseWriter, r *http.Request, version subscriptionVersion, subInfo *submgr.SubscriptionInfo) {
+	request, ok := decodeChange(w, r, version, subInfo)
+	if !ok {
+		return
 	}
-	var request subreq
+	applyChange(w, r, subInfo, request)
+}
+
+/*
+decodeChange (an internal API) decodes the body of a PUT or PATCH and checks the change can
+be made to current, or with current nil, to a subscription with no lists, before anything
+is changed. If it can't, the response is sent and false returned.
+*/
+func decodeChange(w http.ResponseWriter, r *http.Request, version subscriptionVersion, current *submgr.SubscriptionInfo) (subscriptionChange, bool) {
+	subs := interfaces.App.Subs
 	defer func() {
 		_ = r.Body.Close()
 	}()
-	err := json.NewDecoder(r.Body).Decode(&request)
+	request, err := version.decodeChange(r.Body)
 	if err != nil {
-		respondBase(w, r, "", http.StatusBadRequest, err.Error())
-		return
+		respondBase(w, r, requestId(r), http.StatusBadRequest, err.Error())
+		return request, false
+	}
+	for _, entry := range append(slices.Clone(request.include), request.exclude...) {
+		if err := submgr.ValidateEntry(entry); err != nil {
+			respondBase(w, r, requestId(r), http.StatusBadRequest, "Topic "+entry+": "+err.Error())
+			return request, false
+		}
+	}
+	// The service only calls webhooks its clients give it if told it may
+	if request.options != nil && request.options.ExpiryNotify != "" && !interfaces.App.Config.SSE.ExpiryNotifications {
+		respondBase(w, r, requestId(r), http.StatusBadRequest, "expiryNotify needs ExpiryNotifications enabled in the service")
+		return request, false
 	}
-	for _, i := range request.Include {
+	if request.options != nil && request.options.Acknowledge && interfaces.App.Config.SSE.Replay.Count == 0 {
+		respondBase(w, r, requestId(r), http.StatusBadRequest, "acknowledge needs Replay enabled in the service")
+		return request, false
+	}
+	if request.options != nil && request.options.IdleExpiration != "" {
+		// Validated already; shorter than two checks would be aged out late, longer than the limit would pile up
+		idle, _ := time.ParseDuration(request.options.IdleExpiration)
+		durations := interfaces.App.Config.SSE.Durations()
+		if shortest := 2 * durations.SubscriptionExpirationCheckInterval; idle < shortest || idle > durations.MaxIdleExpiration {
+			respondBase(w, r, requestId(r), http.StatusBadRequest, fmt.Sprintf("idleExpiration must be from %v to %v", shortest, durations.MaxIdleExpiration))
+			return request, false
+		}
+	}
+	if request.options != nil {
+		for _, units := range request.options.Units {
+			if !interfaces.App.Config.SSE.ConvertsTo(units) {
+				respondBase(w, r, requestId(r), http.StatusBadRequest, "No UnitConversions to units "+units)
+				return request, false
+			}
+		}
+	}
+	if request.strict {
+		if prefix := subs.Conflict(current, request.include, request.exclude); prefix != "" {
+			respondBase(w, r, requestId(r), http.StatusConflict, "Topic prefix "+prefix+" would be both included and excluded")
+			return request, false
+		}
+	}
+	return request, true
+}
+
+// applyChange (an internal API) makes a change decodeChange() checked, and sends the response.
+func applyChange(w http.ResponseWriter, r *http.Request, subInfo *submgr.SubscriptionInfo, request subscriptionChange) {
+	lc := interfaces.App.Logger
+	subs := interfaces.App.Subs
+	for _, i := range request.include {
 		err := subs.Include(subInfo, i)
+		if errors.Is(err, submgr.ErrTopicNotAllowed) {
+			lc.Infof("Topic %s not allowed for subscription of %s", i, clientAddress(r))
+			respondBase(w, r, requestId(r), http.StatusForbidden, "Topic "+i+" not allowed")
+			return
+		}
 		if err != nil {
-			lc.Infof("Error including topic %s for subscription: %s", i, err.Error())
-			respondBase(w, r, "", http.StatusServiceUnavailable, err.Error())
+			lc.Infof("Error including topic %s for subscription, request %s: %s", i, requestId(r), err.Error())
+			respondBase(w, r, requestId(r), http.StatusServiceUnavailable, err.Error())
 			return
 		}
 	}
-	for _, e := range request.Exclude {
+	for _, e := range request.exclude {
 		err := subs.Exclude(subInfo, e)
 		if err != nil {
-			lc.Infof("Error excluding topic %s from subscription: %s", e, err.Error())
-			respondBase(w, r, "", http.StatusServiceUnavailable, err.Error())
+			lc.Infof("Error excluding topic %s from subscription, request %s: %s", e, requestId(r), err.Error())
+			respondBase(w, r, requestId(r), http.StatusServiceUnavailable, err.Error())
+			return
+		}
+	}
+	if request.options != nil {
+		err := subs.SetOptions(subInfo, *request.options)
+		if err != nil {
+			respondBase(w, r, requestId(r), http.StatusInternalServerError, err.Error())
 			return
 		}
 	}
-	respondBase(w, r, "", http.StatusOK, "Subscription updated.")
+	respondBase(w, r, requestId(r), http.StatusOK, "Subscription updated.")
 }
 
-func ProcessSubscriptionRequest(c echo.Context) error {
+/*
+findManagedSubscription looks up a subscription for a request on one of its endpoints,
+the same way as ProcessSubscriptionRequest. If the ID is malformed, it doesn't exist, or
+the caller can't manage it, the response is sent and false returned.
+*/
+func findManagedSubscription(w http.ResponseWriter, r *http.Request, subid string) (*submgr.SubscriptionInfo, bool) {
+	if !validSubscriptionId(subid) {
+		respondBase(w, r, requestId(r), http.StatusBadRequest, "Malformed subscription ID")
+		return nil, false
+	}
+	if wait, locked := lockedOut(r); locked {
+		respondLockedOut(w, r, wait)
+		return nil, false
+	}
+	subInfo, ok := lookupSubscription(subid)
+	if !ok {
+		lookupFailed(r)
+	}
+	if !ok || interfaces.App.Subs.IsSubscriptionDeleted(subInfo) {
+		respondBase(w, r, requestId(r), http.StatusNotFound, "Subscription not found")
+		return nil, false
+	}
+	if !mayManage(r, subInfo) {
+		respondBase(w, r, requestId(r), http.StatusForbidden, "Subscription belongs to someone else")
+		return nil, false
+	}
+	return subInfo, true
+}
+
+/*
+ProcessRotateRequest handles POST /subscription/id/{subscriptionid}/rotate: the subscription
+gets a new ID, returned like from a POST /subscription, and keeps its lists and options.
+The old ID stops working, e.g. because it leaked in a shared URL, and its event stream
+ends, so the client has to reconnect with the new one.
+*/
+func ProcessRotateRequest(c echo.Context) error {
 	lc := interfaces.App.Logger
 	subs := interfaces.App.Subs
 	w := c.Response()
-	r := c.Request()
+	r, ok := identifyRequest(c)
+	if !ok {
+		return nil
+	}
 
-	lc.Tracef("Processing subscription management %s at %s", r.Method, r.URL.Path)
-	// We don't know our path leading up to /subscription, so remove
-	// /subscription and everything before it
-	idx := strings.Index(r.URL.Path, "/subscription")
-	if idx < 0 {
-		w.WriteHeader(http.StatusNotFound)
+	subid := c.Param("subscriptionid")
+	subInfo, ok := findManagedSubscription(w, r, subid)
+	if !ok {
+		return nil
+	}
+	// Under the lock, so nobody finds the subscription under its old ID once it's rotated
+	lockmgt.Lock()
+	newid, err := subs.RotateSubscription(subid)
+	if err == nil {
+		delete(g_subscriptions, subid)
+		g_subscriptions[newid] = subInfo
+	}
+	lockmgt.Unlock()
+	if err != nil {
+		respondBase(w, r, requestId(r), http.StatusConflict, err.Error())
+		return nil
+	}
+	lc.Infof("Subscription %s rotated for %s", subid, clientAddress(r))
+	rv := dtos.NewSubscriptionIdResponse(requestId(r), newid, "Subscription ID rotated", http.StatusOK)
+	sendResponse(w, r, rv, http.StatusOK)
+	return nil
+}
+
+/*
+ProcessAcknowledgeRequest handles POST /subscription/id/{subscriptionid}/ack: a client of a
+subscription with the acknowledge option says it received the messages up to the sequence
+number in the body, so they aren't sent to it again when it reconnects.
+*/
+func ProcessAcknowledgeRequest(c echo.Context) error {
+	lc := interfaces.App.Logger
+	w := c.Response()
+	r, ok := identifyRequest(c)
+	if !ok {
 		return nil
 	}
-	idx = idx + len("/subscription")
-	subpath := r.URL.Path[idx:len(r.URL.Path)]
-	lc.Tracef("subpath: %s", subpath)
-	if subpath == "" || subpath == "/" {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	subid := c.Param("subscriptionid")
+	subInfo, ok := findManagedSubscription(w, r, subid)
+	if !ok {
+		return nil
+	}
+	var request dtos.AcknowledgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondBase(w, r, requestId(r), http.StatusBadRequest, err.Error())
+		return nil
+	}
+	err := interfaces.App.Subs.Acknowledge(subInfo, request.Seq)
+	switch {
+	case errors.Is(err, submgr.ErrNotAcknowledging):
+		respondBase(w, r, requestId(r), http.StatusConflict, err.Error())
+	case err != nil:
+		respondBase(w, r, requestId(r), http.StatusBadRequest, err.Error())
+	default:
+		lc.Tracef("Subscription %s acknowledged up to %d", subid, request.Seq)
+		respondBase(w, r, requestId(r), http.StatusOK, "Acknowledged")
+	}
+	return nil
+}
+
+/*
+ProcessStatsRequest handles GET and DELETE of /subscription/id/{subscriptionid}/stats: GET
+returns what happened to the subscription's events across all its event streams, DELETE
+starts counting from zero again, e.g. when a long-running integration starts a new period.
+*/
+func ProcessStatsRequest(c echo.Context) error {
+	lc := interfaces.App.Logger
+	subs := interfaces.App.Subs
+	w := c.Response()
+	r, ok := identifyRequest(c)
+	if !ok {
+		return nil
+	}
+
+	subid := c.Param("subscriptionid")
+	subInfo, ok := findManagedSubscription(w, r, subid)
+	if !ok {
+		return nil
+	}
+	if r.Method == http.MethodDelete {
+		if err := subs.ResetStats(subInfo); err != nil {
+			respondBase(w, r, requestId(r), http.StatusNotFound, err.Error())
+			return nil
+		}
+		lc.Debugf("Stats of subscription %s reset by %s", subid, clientAddress(r))
+		respondBase(w, r, requestId(r), http.StatusOK, "Stats reset")
+		return nil
+	}
+	stats, err := subs.Stats(subInfo)
+	if err != nil {
+		respondBase(w, r, requestId(r), http.StatusNotFound, err.Error())
+		return nil
+	}
+	rv := dtos.SubscriptionStatsResponse{
+		BaseResponse: commonDTO.NewBaseResponse(requestId(r), "", http.StatusOK),
+		Delivered:    stats.Delivered,
+		Dropped:      stats.Dropped,
+		Expired:      stats.Expired,
+		Bytes:        stats.Bytes,
+		Connections:  stats.Connections,
+		Since:        stats.Since.UTC().Format(time.RFC3339),
+	}
+	sendResponse(w, r, rv, http.StatusOK)
+	return nil
+}
+
+/*
+ProcessCountRequest handles GET /subscription/count: how many subscriptions the caller's
+limits, those of its role if it has one, allow, and how many are in use, so clients can
+hold back before a POST is refused with 503.
+*/
+func ProcessCountRequest(c echo.Context) error {
+	subs := interfaces.App.Subs
+	w := c.Response()
+	r, ok := identifyRequest(c)
+	if !ok {
+		return nil
+	}
+
+	usage, err := subs.Usage(interfaces.App.Config.SSE.RoleOf(callerIdentity(r)))
+	if err != nil {
+		respondBase(w, r, requestId(r), http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	rv := dtos.SubscriptionCountResponse{
+		BaseResponse: commonDTO.NewBaseResponse(requestId(r), "", http.StatusOK),
+		Count:        usage.Subscriptions,
+		Limit:        usage.SubscriptionLimit,
+		PrefixLimit:  usage.PrefixLimit,
+	}
+	sendResponse(w, r, rv, http.StatusOK)
+	return nil
+}
+
+/*
+limitBody reads the body of a request that can have one, up to MaxRequestBodySize, so an
+oversized one is rejected with 413 before any of it is decoded or the subscription changed.
+The body is put back for the handlers. Returns false if a response was already sent.
+*/
+func limitBody(w http.ResponseWriter, r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return true
+	}
+	if r.Body == nil {
+		// Only client requests can have none, but be safe
+		return true
+	}
+	limit := int64(interfaces.App.Config.SSE.MaxRequestBodySize)
+	if r.ContentLength > limit {
+		respondBase(w, r, requestId(r), http.StatusRequestEntityTooLarge, "Request body too large")
+		return false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	_ = r.Body.Close()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondBase(w, r, requestId(r), http.StatusRequestEntityTooLarge, "Request body too large")
+		} else {
+			respondBase(w, r, requestId(r), http.StatusBadRequest, err.Error())
+		}
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return true
+}
+
+// ProcessSubscriptionRequest handles the v3 subscription API.
+func ProcessSubscriptionRequest(c echo.Context) error {
+	return processSubscriptionRequest(c, subscriptionV3{})
+}
+
+/*
+processSubscriptionRequest handles the subscription API in a version: POST to create a
+subscription, and GET, PUT, PATCH and DELETE of one, with the bodies of that version.
+*/
+func processSubscriptionRequest(c echo.Context, version subscriptionVersion) error {
+	lc := interfaces.App.Logger
+	subs := interfaces.App.Subs
+	w := c.Response()
+	r, ok := identifyRequest(c)
+	if !ok {
+		return nil
+	}
+
+	lc.Tracef("Processing subscription management %s at %s from %s, request %s", r.Method, r.URL.Path, clientAddress(r), requestId(r))
+	// Only the id routes have the subscription ID, whatever SubscriptionPath is
+	subid := c.Param("subscriptionid")
+	if subid == "" {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return nil
@@ -182,19 +575,24 @@ func ProcessSubscriptionRequest(c echo.Context) error {
 		addSubscription(w, r)
 		return nil
 	}
-	subid := c.Param("subscriptionid")
-	if subid == "" {
-		w.WriteHeader(http.StatusNotFound)
+	if !validSubscriptionId(subid) {
+		respondBase(w, r, requestId(r), http.StatusBadRequest, "Malformed subscription ID")
+		return nil
+	}
+	if wait, locked := lockedOut(r); locked {
+		respondLockedOut(w, r, wait)
 		return nil
 	}
-	lockmgt.RLock()
-	subInfo, ok := g_subscriptions[subid]
+	subInfo, ok := lookupSubscription(subid)
 	if !ok {
+		lookupFailed(r)
 		http.Error(w, "Subscription not found", http.StatusNotFound)
-		lockmgt.RUnlock()
 		return nil
 	}
-	lockmgt.RUnlock()
+	if !mayManage(r, subInfo) {
+		respondBase(w, r, requestId(r), http.StatusForbidden, "Subscription belongs to someone else")
+		return nil
+	}
 	subs.SetProcess(subInfo, true)
 	check1 := subs.IsSubscriptionDeleted(subInfo)
 	if check1 {
@@ -213,22 +611,22 @@ func ProcessSubscriptionRequest(c echo.Context) error {
 	}
 	switch r.Method {
 	case http.MethodGet:
-		getSubscription(w, r, includes, excludes)
+		getSubscription(w, r, version, subInfo, includes, excludes)
 		subs.SetProcess(subInfo, false)
 		return nil
 	case http.MethodDelete:
 		deleteSubscription(w, r, subid)
 		return nil
 	case http.MethodPut:
-		putSubscription(w, r, subInfo, includes, excludes)
+		putSubscription(w, r, version, subInfo, includes, excludes)
 		subs.SetProcess(subInfo, false)
 		return nil
 	case http.MethodPatch:
-		patchSubscription(w, r, subInfo)
+		patchSubscription(w, r, version, subInfo)
 		subs.SetProcess(subInfo, false)
 		return nil
 	default:
-		respondBase(w, r, "", http.StatusMethodNotAllowed, "Method not allowed")
+		respondBase(w, r, requestId(r), http.StatusMethodNotAllowed, "Method not allowed")
 		subs.SetProcess(subInfo, false)
 		return nil
 	}
@@ -10,10 +10,13 @@ import (
 	"bytes"
 	"github.com/edgexfoundry-holding/edgex-sse/configuration"
 	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
 	"github.com/edgexfoundry-holding/edgex-sse/submgr"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -21,51 +24,83 @@ import (
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
 	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
-type subCreateResponse struct {
-	commonDTO.BaseResponse `json:",inline"`
-	SubscriptionId         string `json:"subscriptionId"`
-}
-
-type subInfoResponse struct {
-	commonDTO.BaseResponse `json:",inline"`
-	Include                []string `json:"include"`
-	Exclude                []string `json:"exclude"`
-}
-
 const sub_limit = 4
 const incexc_limit = 3
 const buffer = 25
 const ageout = 90*time.Second
 const ageout_check = 10*time.Second
-const uri_base = "/api/v3/subscription"
+// A well-formed subscription ID no subscription has
+const inexist = "AAAAAAAAAAAAAAAAAAAAAAAA"
+// uri_base returns the configured subscription path
+func uri_base() string {
+	return interfaces.App.Config.SSE.SubscriptionRoute()
+}
 
-func managerInit() {
+// url_prefix returns the configured events path, which subscription IDs follow
+func url_prefix() string {
+	return interfaces.App.Config.SSE.EventsRoute()
+}
+
+func managerInit(t *testing.T) {
 	interfaces.App.Config = &configuration.Config{}
 	interfaces.App.Config.SetDefaults()
 	interfaces.App.Subs = &submgr.SubscriptionManager{}
 	interfaces.App.Logger = logger.NewMockClient()
-	interfaces.App.Subs.Init(sub_limit, incexc_limit, buffer, ageout, ageout_check)
+	// Earlier tests' requests don't count against later ones
+	failuresLock.Lock()
+	failures = make(map[string]*lookupFailures)
+	failuresLock.Unlock()
+	limitersLock.Lock()
+	limiters = make(map[string]*clientLimiter)
+	limitersLock.Unlock()
+	connectionsLock.Lock()
+	connections = make(map[string]uint)
+	connectionsLock.Unlock()
+	streamsLock.Lock()
+	streams = make(map[*eventStream]struct{})
+	streamsLock.Unlock()
+	if err := interfaces.App.Subs.Init(sub_limit, incexc_limit, buffer, ageout, ageout_check); err != nil {
+		t.Fatalf("Subscription manager Init failed: %v", err)
+	}
+	interfaces.App.Subs.SetDeleteHook(ForgetSubscription)
 }
 
 func managerClose() {
 	interfaces.App.Subs.Close()
 }
 
+// Authorization header doRequest() sends, if not empty
+var authHeader string
+
+// bearerFor returns an Authorization header with a JWT for the given identity
+func bearerFor(t *testing.T, identity string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: identity}).SignedString([]byte("test key"))
+	if err != nil {
+		t.Fatalf("Could not sign token: %v", err)
+	}
+	return "Bearer " + token
+}
+
 func doRequest(t *testing.T, method string, uri string, body_in string) (code int, body string, contenttype string) {
 	req, err := http.NewRequest(method, uri, bytes.NewBuffer([]byte(body_in)))
 	if err != nil {
 		t.Fatalf("Error constructing request: %s", err.Error())
 	}
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
 	rr := httptest.NewRecorder()
 	router := echo.New()
-	router.POST("/api/v3/subscription", ProcessSubscriptionRequest)
-	router.GET("/api/v3/subscription/id/:subscriptionid", ProcessSubscriptionRequest)
-	router.PUT("/api/v3/subscription/id/:subscriptionid", ProcessSubscriptionRequest)
-	router.PATCH("/api/v3/subscription/id/:subscriptionid", ProcessSubscriptionRequest)
-	router.DELETE("/api/v3/subscription/id/:subscriptionid", ProcessSubscriptionRequest)
+	router.POST(uri_base(), ProcessSubscriptionRequest)
+	router.GET(uri_base()+"/id/:subscriptionid", ProcessSubscriptionRequest)
+	router.PUT(uri_base()+"/id/:subscriptionid", ProcessSubscriptionRequest)
+	router.PATCH(uri_base()+"/id/:subscriptionid", ProcessSubscriptionRequest)
+	router.DELETE(uri_base()+"/id/:subscriptionid", ProcessSubscriptionRequest)
 	router.ServeHTTP(rr, req)
 	code = rr.Code
 	body = rr.Body.String()
@@ -104,9 +139,9 @@ func checkCreateRequest(t *testing.T, exp_code int) (subid string) {
 	if exp_code != http.StatusCreated {
 		exp_ct = ""
 	}
-	body := checkRequest(t, http.MethodPost, uri_base, "", exp_code, exp_ct)
+	body := checkRequest(t, http.MethodPost, uri_base(), "", exp_code, exp_ct)
 	subid = ""
-	var resp subCreateResponse
+	var resp dtos.SubscriptionIdResponse
 	if exp_code == http.StatusCreated {
 		err := json.Unmarshal([]byte(body), &resp)
 		if err != nil {
@@ -120,13 +155,13 @@ func checkCreateRequest(t *testing.T, exp_code int) (subid string) {
 	return
 }
 
-func checkGetRequest(t *testing.T, subid string, exp_code int) (resp subInfoResponse) {
+func checkGetRequest(t *testing.T, subid string, exp_code int) (resp dtos.SubscriptionResponse) {
 	exp_ct := ""
-	resp = subInfoResponse{}
+	resp = dtos.SubscriptionResponse{}
 	if exp_code == http.StatusOK {
 		exp_ct = "application/json"
 	}
-	body := checkRequest(t, http.MethodGet, uri_base+"/id/"+subid, "", exp_code, exp_ct)
+	body := checkRequest(t, http.MethodGet, uri_base()+"/id/"+subid, "", exp_code, exp_ct)
 	if exp_code == http.StatusOK {
 		err := json.Unmarshal([]byte(body), &resp)
 		if err != nil {
@@ -137,14 +172,15 @@ func checkGetRequest(t *testing.T, subid string, exp_code int) (resp subInfoResp
 }
 
 func TestCreateDelete(t *testing.T) {
-	managerInit()
+	managerInit(t)
 	subid := checkCreateRequest(t, http.StatusCreated)
-	_ = checkGetRequest(t, subid+"badsuffix", http.StatusNotFound)
+	_ = checkGetRequest(t, subid+"badsuffix", http.StatusBadRequest)
+	_ = checkGetRequest(t, inexist, http.StatusNotFound)
 	contents := checkGetRequest(t, subid, http.StatusOK)
 	if len(contents.Include) != 0 || len(contents.Exclude) != 0 {
 		t.Fatal("Unexpected include/exclude present in new subscription")
 	}
-	_ = checkRequest(t, http.MethodDelete, uri_base+"/id/"+subid, "", http.StatusOK, "application/json")
+	_ = checkRequest(t, http.MethodDelete, uri_base()+"/id/"+subid, "", http.StatusOK, "application/json")
 	_ = checkGetRequest(t, subid, http.StatusNotFound)
 	managerClose()
 }
@@ -152,9 +188,9 @@ func TestCreateDelete(t *testing.T) {
 func TestNotAllowed(t *testing.T) {
 	disallow_top := [...]string{http.MethodGet, http.MethodDelete, http.MethodPut, http.MethodPatch}
 	disallow_subid := [...]string{http.MethodPost}
-	managerInit()
+	managerInit(t)
 	subid := checkCreateRequest(t, http.StatusCreated)
-	_ = checkRequest(t, http.MethodPut, uri_base+"/id/"+subid, "{\"apiVersion\":\"v3\", \"requestId\":\"284115e7-d047-4553-8339-97ffa6b1934b\", \"include\":[\"edgex/events/device\"]}", http.StatusOK, "application/json")
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, "{\"apiVersion\":\"v3\", \"requestId\":\"284115e7-d047-4553-8339-97ffa6b1934b\", \"include\":[\"edgex/events/device\"]}", http.StatusOK, "application/json")
 
 	contents := checkGetRequest(t, subid, http.StatusOK)
 	if len(contents.Exclude) != 0 || len(contents.Include) != 1 {
@@ -165,16 +201,16 @@ func TestNotAllowed(t *testing.T) {
 	}
 	// Now that we're set up, try all the disallowed methods
 	for _, m := range disallow_top {
-		_ = checkRequest(t, m, uri_base, "", http.StatusMethodNotAllowed, "")
+		_ = checkRequest(t, m, uri_base(), "", http.StatusMethodNotAllowed, "")
 	}
 	for _, m := range disallow_subid {
-		_ = checkRequest(t, m, uri_base+"/id/"+subid, "", http.StatusMethodNotAllowed, "")
+		_ = checkRequest(t, m, uri_base()+"/id/"+subid, "", http.StatusMethodNotAllowed, "")
 	}
 	managerClose()
 }
 
 func TestTheLimits(t *testing.T) {
-	managerInit()
+	managerInit(t)
 	subid := checkCreateRequest(t, http.StatusCreated)
 	for i := 1; i < sub_limit; i++ {
 		_ = checkCreateRequest(t, http.StatusCreated)
@@ -189,29 +225,55 @@ func TestTheLimits(t *testing.T) {
 		topicNum++
 	}
 	req += "\"a/b/c/" + strconv.FormatInt(topicNum, 10) + "\"]}"
-	_ = checkRequest(t, http.MethodPut, uri_base+"/id/"+subid, req, http.StatusServiceUnavailable, "application/json")
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusServiceUnavailable, "application/json")
 	exc_req := strings.Replace(req, "include", "exclude", 1)
 	// This resets the subscription back to 0
-	_ = checkRequest(t, http.MethodPatch, uri_base+"/id/"+subid, exc_req, http.StatusOK, "application/json")
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, exc_req, http.StatusOK, "application/json")
 	// Adding the excludes again should hit the limit
-	_ = checkRequest(t, http.MethodPatch, uri_base+"/id/"+subid, exc_req, http.StatusServiceUnavailable, "application/json")
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, exc_req, http.StatusServiceUnavailable, "application/json")
 	// Unparseable
-	_ = checkRequest(t, http.MethodPut, uri_base+"/id/"+subid, "this is not json", http.StatusBadRequest, "application/json")
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, "this is not json", http.StatusBadRequest, "application/json")
 	managerClose()
 }
 
 func TestBadUri(t *testing.T) {
-	managerInit()
+	managerInit(t)
 	_ = checkRequest(t, http.MethodGet, "/some/uri", "", http.StatusNotFound, "")
-	_ = checkRequest(t, http.MethodGet, "/api/v3/subscriptionmanager", "", http.StatusNotFound, "")
+	_ = checkRequest(t, http.MethodGet, uri_base()+"manager", "", http.StatusNotFound, "")
 	managerClose()
 }
 
+func TestStrictRequest(t *testing.T) {
+	managerInit(t)
+	subid := checkCreateRequest(t, http.StatusCreated)
+	req := "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device\"], \"exclude\":[\"edgex/events/device/ProfileA\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	// Including what is excluded, nothing changes
+	req = "{\"apiVersion\":\"v3\", \"strict\":true, \"include\":[\"edgex/events/device/ProfileB\", \"edgex/events/device/ProfileA/\"]}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusConflict, "application/json")
+	contents := checkGetRequest(t, subid, http.StatusOK)
+	if len(contents.Include) != 1 || len(contents.Exclude) != 1 {
+		t.Fatalf("Refused request changed the lists: %v %v", contents.Include, contents.Exclude)
+	}
+	// Both in one request
+	req = "{\"apiVersion\":\"v3\", \"strict\":true, \"include\":[\"edgex/events/x\"], \"exclude\":[\"edgex/events/x\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusConflict, "application/json")
+	// Without strict, the exclude is taken off
+	req = "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device\"], \"exclude\":[\"edgex/events/device/ProfileA\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	req = "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device/ProfileA\"]}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	contents = checkGetRequest(t, subid, http.StatusOK)
+	if len(contents.Include) != 1 || len(contents.Exclude) != 0 {
+		t.Fatalf("Exclude not coalesced away: %v %v", contents.Include, contents.Exclude)
+	}
+}
+
 func TestReplacement(t *testing.T) {
-	managerInit()
+	managerInit(t)
 	subid := checkCreateRequest(t, http.StatusCreated)
 	req := "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device/ProfileA\", \"edgex/events/device/ProfileB\"], \"exclude\":[\"edgex/events/device/ProfileA/DeviceC\"]}"
-	_ = checkRequest(t, http.MethodPut, uri_base+"/id/"+subid, req, http.StatusOK, "application/json")
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
 	contents := checkGetRequest(t, subid, http.StatusOK)
 	if len(contents.Include) != 2 || len(contents.Exclude) != 1 {
 		t.Fatalf("Wrong number of includes/excludes %d/%d, expected 2/1", len(contents.Include), len(contents.Exclude))
@@ -231,12 +293,12 @@ func TestReplacement(t *testing.T) {
 		t.Fatalf("Wrong include list: %v", contents.Include)
 	}
 	req = "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device/ProfileC/\"]}"
-	_ = checkRequest(t, http.MethodPatch, uri_base+"/id/"+subid, req, http.StatusOK, "application/json")
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
 	contents = checkGetRequest(t, subid, http.StatusOK)
 	if len(contents.Include) != 3 || len(contents.Exclude) != 1 {
 		t.Fatalf("Wrong number of includes/excludes %d/%d, expected 3/1", len(contents.Include), len(contents.Exclude))
 	}
-	_ = checkRequest(t, http.MethodPut, uri_base+"/id/"+subid, req, http.StatusOK, "application/json")
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
 	contents = checkGetRequest(t, subid, http.StatusOK)
 	if len(contents.Include) != 1 || len(contents.Exclude) != 0 {
 		t.Fatalf("Wrong number of includes/excludes %d/%d, expected 1/0", len(contents.Include), len(contents.Exclude))
@@ -246,3 +308,515 @@ func TestReplacement(t *testing.T) {
 	}
 	managerClose()
 } 
+
+func TestInvalidReplacement(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	subid := checkCreateRequest(t, http.StatusCreated)
+	req := "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device\"], \"exclude\":[\"edgex/events/device/ProfileA\"], \"options\":{\"format\":\"simple\"}}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	// A PUT that is refused leaves the subscription as it was
+	for _, invalid := range []struct {
+		body string
+		code int
+	}{
+		{"{\"apiVersion\":\"v3\", \"include\":[\"edgex/events\"], \"options\":{\"expiryNotify\":\"https://backend.example.com/expiry\"}}", http.StatusBadRequest},
+		{"{\"apiVersion\":\"v3\", \"include\":[\"edgex/events\"], \"options\":{\"units\":[\"furlongs\"]}}", http.StatusBadRequest},
+		{"{\"apiVersion\":\"v3\", \"include\":[\"edgex/events\"], \"options\":{\"acknowledge\":true}}", http.StatusBadRequest},
+		{"{\"apiVersion\":\"v3\", \"include\":[\"edgex/events\"], \"options\":{\"idleExpiration\":\"1ms\"}}", http.StatusBadRequest},
+		{"{\"apiVersion\":\"v3\", \"strict\":true, \"include\":[\"edgex/events/x\"], \"exclude\":[\"edgex/events/x\"]}", http.StatusConflict},
+	} {
+		_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, invalid.body, invalid.code, "application/json")
+		contents := checkGetRequest(t, subid, http.StatusOK)
+		if len(contents.Include) != 1 || len(contents.Exclude) != 1 || contents.Options.Format != submgr.FormatSimple {
+			t.Fatalf("Refused PUT %s changed the subscription: %+v", invalid.body, contents)
+		}
+	}
+}
+
+func TestRequestId(t *testing.T) {
+	managerInit(t)
+	subid := checkCreateRequest(t, http.StatusCreated)
+	var resp commonDTO.BaseResponse
+	req := "{\"apiVersion\":\"v3\", \"requestId\":\"284115e7-d047-4553-8339-97ffa6b1934b\", \"include\":[\"edgex/events/device\"]}"
+	body := checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	if err := json.Unmarshal([]byte(body), &resp); err != nil || resp.RequestId != "284115e7-d047-4553-8339-97ffa6b1934b" {
+		t.Fatalf("Request's requestId not in response %s", body)
+	}
+	// Without one, the response has a new one
+	contents := checkGetRequest(t, subid, http.StatusOK)
+	if _, err := uuid.Parse(contents.RequestId); err != nil {
+		t.Fatalf("Response requestId %q not a UUID", contents.RequestId)
+	}
+	req = "{\"apiVersion\":\"v3\", \"requestId\":\"guess\", \"include\":[\"edgex/events/device\"]}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusBadRequest, "application/json")
+	managerClose()
+}
+
+func TestListOrder(t *testing.T) {
+	managerInit(t)
+	subid := checkCreateRequest(t, http.StatusCreated)
+	req := "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device/Zeta\", \"edgex/events/device/Alpha/DeviceLong\"], \"exclude\":[\"edgex/events/device/Zeta/B\", \"edgex/events/device/Alpha/DeviceLong/Reading\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	contents := checkGetRequest(t, subid, http.StatusOK)
+	if !slices.Equal(contents.Include, []string{"edgex/events/device/Alpha/DeviceLong/", "edgex/events/device/Zeta/"}) {
+		t.Fatalf("Include list %v not in lexicographic order", contents.Include)
+	}
+	if !slices.Equal(contents.Exclude, []string{"edgex/events/device/Alpha/DeviceLong/Reading/", "edgex/events/device/Zeta/B/"}) {
+		t.Fatalf("Exclude list %v not in lexicographic order", contents.Exclude)
+	}
+	// Matching keeps its own order, shortest first
+	includes, excludes, _ := interfaces.App.Subs.SubscriptionInfo(interfaces.App.Subs.Subscription(subid))
+	if includes[0] != "edgex/events/device/Zeta/" || excludes[0] != "edgex/events/device/Zeta/B/" {
+		t.Fatalf("Subscription lists reordered: %v %v", includes, excludes)
+	}
+	managerClose()
+}
+
+func TestWildcardEntries(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	subid := checkCreateRequest(t, http.StatusCreated)
+	req := "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device/Bacon-Cape/dev1/+\"], \"exclude\":[]}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	req = "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device\"], \"exclude\":[\"edgex/events/+/Bacon-Cape\"]}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusBadRequest, "application/json")
+	// Nothing of a refused request is applied
+	contents := checkGetRequest(t, subid, http.StatusOK)
+	if !slices.Equal(contents.Include, []string{"edgex/events/device/Bacon-Cape/dev1/+/"}) || len(contents.Exclude) != 0 {
+		t.Fatalf("Got lists %v %v", contents.Include, contents.Exclude)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	managerInit(t)
+	subid := checkCreateRequest(t, http.StatusCreated)
+	contents := checkGetRequest(t, subid, http.StatusOK)
+	if contents.Options.PassThrough {
+		t.Fatal("New subscription has passThrough set")
+	}
+	req := "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device/ProfileA\"], \"options\":{\"passThrough\":true}}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	contents = checkGetRequest(t, subid, http.StatusOK)
+	if !contents.Options.PassThrough {
+		t.Fatal("PATCH did not set passThrough")
+	}
+	// PATCH without options leaves them alone
+	req = "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device/ProfileB\"]}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	contents = checkGetRequest(t, subid, http.StatusOK)
+	if !contents.Options.PassThrough {
+		t.Fatal("PATCH without options cleared passThrough")
+	}
+	req = "{\"apiVersion\":\"v3\", \"options\":{\"format\":\"simple\"}}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	contents = checkGetRequest(t, subid, http.StatusOK)
+	if contents.Options.Format != "simple" || contents.Options.PassThrough {
+		t.Fatalf("PATCH did not replace options: %v", contents.Options)
+	}
+	req = "{\"apiVersion\":\"v3\", \"options\":{\"format\":\"xml\"}}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusBadRequest, "application/json")
+	req = "{\"apiVersion\":\"v3\", \"options\":{\"envelope\":\"cloudevents\"}}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	contents = checkGetRequest(t, subid, http.StatusOK)
+	if contents.Options.Envelope != "cloudevents" {
+		t.Fatalf("PATCH did not set envelope: %v", contents.Options)
+	}
+	req = "{\"apiVersion\":\"v3\", \"options\":{\"envelope\":\"soap\"}}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusBadRequest, "application/json")
+	// Webhooks only if the service may call them
+	req = "{\"apiVersion\":\"v3\", \"options\":{\"expiryNotify\":\"https://backend.example.com/expiry\"}}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusBadRequest, "application/json")
+	interfaces.App.Config.SSE.ExpiryNotifications = true
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	interfaces.App.Config.SSE.ExpiryNotifications = false
+	contents = checkGetRequest(t, subid, http.StatusOK)
+	if contents.Options.ExpiryNotify != "https://backend.example.com/expiry" {
+		t.Fatalf("PATCH did not set expiryNotify: %v", contents.Options)
+	}
+	// Only units there is a conversion to
+	req = "{\"apiVersion\":\"v3\", \"options\":{\"units\":[\"degC\"]}}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusBadRequest, "application/json")
+	interfaces.App.Config.SSE.UnitConversions = map[string]configuration.UnitConversionConfig{"f-c": {From: "degF", To: "degC", Scale: 1}}
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	interfaces.App.Config.SSE.UnitConversions = nil
+	contents = checkGetRequest(t, subid, http.StatusOK)
+	if !slices.Equal(contents.Options.Units, []string{"degC"}) {
+		t.Fatalf("PATCH did not set units: %v", contents.Options)
+	}
+	// PUT without options resets them
+	req = "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device/ProfileB\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	contents = checkGetRequest(t, subid, http.StatusOK)
+	if contents.Options.PassThrough || contents.Options.Format != "" || contents.Options.Envelope != "" {
+		t.Fatal("PUT without options did not reset them")
+	}
+	managerClose()
+}
+
+func TestIdleExpiration(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	subid := checkCreateRequest(t, http.StatusCreated)
+	if contents := checkGetRequest(t, subid, http.StatusOK); contents.ExpiresIn != ageout.String() {
+		t.Fatalf("New subscription expires in %s, expected %v", contents.ExpiresIn, ageout)
+	}
+	for _, idle := range []string{"1s", "25h", "soon"} {
+		req := "{\"apiVersion\":\"v3\", \"options\":{\"idleExpiration\":\"" + idle + "\"}}"
+		_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusBadRequest, "application/json")
+	}
+	req := "{\"apiVersion\":\"v3\", \"options\":{\"idleExpiration\":\"2h\"}}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	contents := checkGetRequest(t, subid, http.StatusOK)
+	if contents.Options.IdleExpiration != "2h" || contents.ExpiresIn != "2h0m0s" {
+		t.Fatalf("Subscription with idleExpiration %s expires in %s", contents.Options.IdleExpiration, contents.ExpiresIn)
+	}
+	// Listened to, it doesn't expire
+	interfaces.App.Subs.SetActive(interfaces.App.Subs.Subscription(subid), true)
+	if contents := checkGetRequest(t, subid, http.StatusOK); contents.ExpiresIn != "" {
+		t.Fatalf("Active subscription expires in %s", contents.ExpiresIn)
+	}
+}
+
+func TestSubscriptionPath(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.SubscriptionPath = "/sse/subs"
+	subid := checkCreateRequest(t, http.StatusCreated)
+	contents := checkGetRequest(t, subid, http.StatusOK)
+	if len(contents.Include) != 0 {
+		t.Fatalf("New subscription has includes: %v", contents.Include)
+	}
+	_ = checkRequest(t, http.MethodGet, "/api/v3/subscription/id/"+subid, "", http.StatusNotFound, "application/json")
+	managerClose()
+}
+
+func TestStaticSubscription(t *testing.T) {
+	managerInit(t)
+	err := AddStaticSubscription("dashboard", []string{"edgex/events"}, []string{"edgex/events/device/bad"}, submgr.SubscriptionOptions{Format: submgr.FormatSimple})
+	if err != nil {
+		t.Fatalf("AddStaticSubscription failed: %v", err)
+	}
+	if err := AddStaticSubscription("dashboard", []string{"edgex/events"}, nil, submgr.SubscriptionOptions{}); err == nil {
+		t.Fatal("AddStaticSubscription succeeded with an ID already in use")
+	}
+	if err := AddStaticSubscription("badoptions", []string{"edgex/events"}, nil, submgr.SubscriptionOptions{Format: "xml"}); err == nil {
+		t.Fatal("AddStaticSubscription succeeded with bad options")
+	}
+	contents := checkGetRequest(t, "dashboard", http.StatusOK)
+	if len(contents.Include) != 1 || contents.Include[0] != "edgex/events/" || len(contents.Exclude) != 1 || contents.Options.Format != submgr.FormatSimple {
+		t.Fatalf("Wrong static subscription contents: %+v", contents)
+	}
+	managerClose()
+}
+
+func TestRestoreSubscription(t *testing.T) {
+	managerInit(t)
+	def := submgr.Definition{Id: "restored", Include: []string{"edgex/events/"}, Exclude: []string{}, Options: submgr.SubscriptionOptions{Format: submgr.FormatSimple}}
+	if err := RestoreSubscription(def); err != nil {
+		t.Fatalf("RestoreSubscription failed: %v", err)
+	}
+	if err := RestoreSubscription(def); err == nil {
+		t.Fatal("RestoreSubscription succeeded with an ID already in use")
+	}
+	contents := checkGetRequest(t, "restored", http.StatusOK)
+	if len(contents.Include) != 1 || contents.Include[0] != "edgex/events/" || contents.Options.Format != submgr.FormatSimple {
+		t.Fatalf("Wrong restored subscription contents: %+v", contents)
+	}
+	// Topics narrowed since it was persisted: limited to them, and includes beyond them dropped
+	interfaces.App.Config.SSE.AllowedTopics = "edgex/events/device/public"
+	def = submgr.Definition{Id: "narrowed", Include: []string{"edgex/events/device/public/a/", "edgex/events/device/tenant1/"}, Exclude: []string{}, Allowed: []string{"edgex/events/"}}
+	if err := RestoreSubscription(def); err != nil {
+		t.Fatalf("RestoreSubscription failed: %v", err)
+	}
+	restored, _ := interfaces.App.Subs.Definition("narrowed")
+	if !slices.Equal(restored.Include, []string{"edgex/events/device/public/a/"}) || !slices.Equal(restored.Allowed, []string{"edgex/events/device/public/"}) {
+		t.Fatalf("Restored with includes %v, allowed %v", restored.Include, restored.Allowed)
+	}
+	// The same when replicated from another replica
+	if err := ReplaceSubscription(def); err != nil {
+		t.Fatalf("ReplaceSubscription failed: %v", err)
+	}
+	if replaced, _ := interfaces.App.Subs.Definition("narrowed"); !reflect.DeepEqual(replaced, restored) {
+		t.Fatalf("Replaced as %+v", replaced)
+	}
+	managerClose()
+}
+
+func TestBodyLimit(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.MaxRequestBodySize = 1024
+	subid := checkCreateRequest(t, http.StatusCreated)
+	req := "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events/device/ProfileA\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	big := "{\"apiVersion\":\"v3\", \"include\":[\"" + strings.Repeat("x", 2000) + "\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, big, http.StatusRequestEntityTooLarge, "application/json")
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, big, http.StatusRequestEntityTooLarge, "application/json")
+	_ = checkRequest(t, http.MethodPost, uri_base(), big, http.StatusRequestEntityTooLarge, "application/json")
+	// A rejected PUT must not have cleared the subscription
+	contents := checkGetRequest(t, subid, http.StatusOK)
+	if len(contents.Include) != 1 || contents.Include[0] != "edgex/events/device/ProfileA/" {
+		t.Fatalf("Subscription changed by rejected requests: %v", contents.Include)
+	}
+	managerClose()
+}
+
+func TestRoleLimits(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.Roles = map[string]configuration.RoleConfig{
+		"admin": {Identities: "alice, bob", SubscriptionLimit: sub_limit + 2, PrefixesLimit: incexc_limit + 2},
+	}
+	if err := interfaces.App.Subs.SetQuota("admin", submgr.Quota{Subscriptions: sub_limit + 2, Prefixes: incexc_limit + 2}); err != nil {
+		t.Fatalf("SetQuota failed: %v", err)
+	}
+	defer func() { authHeader = "" }()
+	count := func() (resp dtos.SubscriptionCountResponse) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, uri_base()+"/count", nil)
+		if authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+		rr := httptest.NewRecorder()
+		router := echo.New()
+		router.GET(uri_base()+"/count", ProcessCountRequest)
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Got status %d from GET of the subscription count", rr.Code)
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return
+	}
+	// Anonymous callers use up the default limit
+	for i := 0; i < sub_limit; i++ {
+		_ = checkCreateRequest(t, http.StatusCreated)
+	}
+	if got := count(); got.Count != sub_limit || got.Limit != sub_limit || got.PrefixLimit != incexc_limit {
+		t.Fatalf("Wrong default count %+v", got)
+	}
+	_ = checkCreateRequest(t, http.StatusServiceUnavailable)
+	// Callers not in a role share it
+	authHeader = bearerFor(t, "mallory")
+	_ = checkCreateRequest(t, http.StatusServiceUnavailable)
+	// The role's callers share its limits
+	authHeader = bearerFor(t, "alice")
+	subid := checkCreateRequest(t, http.StatusCreated)
+	for i := 1; i < sub_limit+2; i++ {
+		authHeader = bearerFor(t, []string{"alice", "bob"}[i%2])
+		_ = checkCreateRequest(t, http.StatusCreated)
+	}
+	_ = checkCreateRequest(t, http.StatusServiceUnavailable)
+	authHeader = bearerFor(t, "alice")
+	if got := count(); got.Count != sub_limit+2 || got.Limit != sub_limit+2 || got.PrefixLimit != incexc_limit+2 {
+		t.Fatalf("Wrong role count %+v", got)
+	}
+	req := "{\"apiVersion\": \"v3\", \"include\":[\"a/0\", \"a/1\", \"a/2\", \"a/3\", \"a/4\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	if contents := checkGetRequest(t, subid, http.StatusOK); contents.PrefixLimit != incexc_limit+2 {
+		t.Fatalf("Subscription prefix limit %d", contents.PrefixLimit)
+	}
+	req = "{\"apiVersion\": \"v3\", \"include\":[\"a/5\"]}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusServiceUnavailable, "application/json")
+	managerClose()
+}
+
+func TestOwnership(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.Roles = map[string]configuration.RoleConfig{
+		"admin": {Identities: "root", SubscriptionLimit: sub_limit, PrefixesLimit: incexc_limit},
+	}
+	interfaces.App.Config.SSE.AdminRole = "admin"
+	if err := interfaces.App.Subs.SetQuota("admin", submgr.Quota{Subscriptions: sub_limit, Prefixes: incexc_limit}); err != nil {
+		t.Fatalf("SetQuota failed: %v", err)
+	}
+	defer func() { authHeader = "" }()
+	anonymous := checkCreateRequest(t, http.StatusCreated)
+	authHeader = bearerFor(t, "alice")
+	owned := checkCreateRequest(t, http.StatusCreated)
+	req := "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+owned, req, http.StatusOK, "application/json")
+	_ = checkGetRequest(t, anonymous, http.StatusOK)
+
+	// Others can't see or change it, with or without an identity
+	for _, other := range []string{"", bearerFor(t, "bob")} {
+		authHeader = other
+		_ = checkRequest(t, http.MethodGet, uri_base()+"/id/"+owned, "", http.StatusForbidden, "application/json")
+		_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+owned, req, http.StatusForbidden, "application/json")
+		_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+owned, req, http.StatusForbidden, "application/json")
+		_ = checkRequest(t, http.MethodDelete, uri_base()+"/id/"+owned, "", http.StatusForbidden, "application/json")
+		_ = requestStreamToken(t, owned, http.StatusForbidden)
+		_ = checkGetRequest(t, anonymous, http.StatusOK)
+	}
+
+	// Admins can
+	authHeader = bearerFor(t, "root")
+	contents := checkGetRequest(t, owned, http.StatusOK)
+	if len(contents.Include) != 1 {
+		t.Fatalf("Wrong include list %v", contents.Include)
+	}
+	_ = checkRequest(t, http.MethodDelete, uri_base()+"/id/"+owned, "", http.StatusOK, "application/json")
+	managerClose()
+}
+
+func TestTopicACL(t *testing.T) {
+	managerInit(t)
+	interfaces.App.Config.SSE.Roles = map[string]configuration.RoleConfig{
+		"tenant1": {Identities: "alice", SubscriptionLimit: sub_limit, PrefixesLimit: incexc_limit, AllowedTopics: "edgex/events/device/tenant1"},
+	}
+	interfaces.App.Config.SSE.AllowedTopics = "edgex/events/device/public"
+	if err := interfaces.App.Subs.SetQuota("tenant1", submgr.Quota{Subscriptions: sub_limit, Prefixes: incexc_limit}); err != nil {
+		t.Fatalf("SetQuota failed: %v", err)
+	}
+	defer func() { authHeader = ""; interfaces.App.Config.SSE.AllowedTopics = "" }()
+	authHeader = bearerFor(t, "alice")
+	subid := checkCreateRequest(t, http.StatusCreated)
+	req := "{\"apiVersion\": \"v3\", \"include\":[\"edgex/events/device/tenant1/dev1\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	for _, topic := range []string{"edgex/events/device/tenant2", "edgex/events/device/public", "edgex/events"} {
+		req = "{\"apiVersion\": \"v3\", \"include\":[\"" + topic + "\"]}"
+		_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusForbidden, "application/json")
+	}
+	// Excludes outside the allowed topics can be dropped by a PUT
+	req = "{\"apiVersion\": \"v3\", \"exclude\":[\"edgex/events/device/tenant2\"]}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	req = "{\"apiVersion\": \"v3\", \"include\":[\"edgex/events/device/tenant1/dev2\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	if contents := checkGetRequest(t, subid, http.StatusOK); len(contents.Exclude) != 0 || len(contents.Include) != 1 {
+		t.Fatalf("PUT left %+v", contents)
+	}
+	// Callers not in a role get the default restriction
+	authHeader = ""
+	subid = checkCreateRequest(t, http.StatusCreated)
+	req = "{\"apiVersion\": \"v3\", \"include\":[\"edgex/events/device/public\"]}"
+	_ = checkRequest(t, http.MethodPut, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	req = "{\"apiVersion\": \"v3\", \"include\":[\"edgex/events/device/tenant1\"]}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusForbidden, "application/json")
+	managerClose()
+}
+
+func TestRotate(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	rotate := func(subid string, exp_code int) string {
+		req, _ := http.NewRequest(http.MethodPost, uri_base()+"/id/"+subid+"/rotate", nil)
+		rr := httptest.NewRecorder()
+		router := echo.New()
+		router.POST(uri_base()+"/id/:subscriptionid/rotate", ProcessRotateRequest)
+		router.ServeHTTP(rr, req)
+		if rr.Code != exp_code {
+			t.Fatalf("Got status %d rotating %s, expected %d", rr.Code, subid, exp_code)
+		}
+		var resp dtos.SubscriptionIdResponse
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return resp.SubscriptionId
+	}
+	oldid := checkCreateRequest(t, http.StatusCreated)
+	req := "{\"apiVersion\":\"v3\", \"include\":[\"edgex/events\"], \"options\":{\"format\":\"simple\"}}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+oldid, req, http.StatusOK, "application/json")
+	_ = rotate(inexist, http.StatusNotFound)
+	newid := rotate(oldid, http.StatusOK)
+	if newid == "" || newid == oldid {
+		t.Fatalf("Wrong new ID %s", newid)
+	}
+	_ = checkGetRequest(t, oldid, http.StatusNotFound)
+	contents := checkGetRequest(t, newid, http.StatusOK)
+	if len(contents.Include) != 1 || contents.Options.Format != submgr.FormatSimple {
+		t.Fatalf("Rotated subscription lost its lists or options: %+v", contents)
+	}
+	if err := AddStaticSubscription("static", []string{"a"}, nil, submgr.SubscriptionOptions{}); err != nil {
+		t.Fatalf("AddStaticSubscription failed: %v", err)
+	}
+	_ = rotate("static", http.StatusConflict)
+}
+
+func TestAcknowledge(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	ack := func(subid string, body string, exp_code int) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, uri_base()+"/id/"+subid+"/ack", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		router := echo.New()
+		router.POST(uri_base()+"/id/:subscriptionid/ack", ProcessAcknowledgeRequest)
+		router.ServeHTTP(rr, req)
+		if rr.Code != exp_code {
+			t.Fatalf("Got status %d acknowledging %s for %s, expected %d", rr.Code, body, subid, exp_code)
+		}
+	}
+	subid := checkCreateRequest(t, http.StatusCreated)
+	// The service keeps nothing to send again
+	req := "{\"apiVersion\":\"v3\", \"include\":[\"a/b\"], \"options\":{\"acknowledge\":true}}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusBadRequest, "application/json")
+	ack(subid, "{\"seq\":0}", http.StatusConflict)
+
+	interfaces.App.Config.SSE.Replay.Count = 10
+	interfaces.App.Subs.SetRetention(submgr.Retention{Count: 10, Bytes: 4096, MaxAge: time.Minute})
+	subid = checkCreateRequest(t, http.StatusCreated)
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	interfaces.App.Subs.SetActive(g_subscriptions[subid], true)
+	chans := interfaces.App.Subs.SubscribedChannels("a/b")
+	if len(chans) != 1 || !chans[0].Send(submgr.ChannelMessage{Payload: "{}"}) {
+		t.Fatal("Could not send to subscribed channel")
+	}
+	ack(subid, "{\"seq\":1}", http.StatusOK)
+	ack(subid, "{\"seq\":2}", http.StatusBadRequest)
+	ack(subid, "{\"seq\":\"one\"}", http.StatusBadRequest)
+	ack(inexist, "{\"seq\":1}", http.StatusNotFound)
+	if acked, _ := interfaces.App.Subs.Acknowledged(g_subscriptions[subid]); acked != 1 {
+		t.Fatalf("Acknowledged up to %d", acked)
+	}
+}
+
+func TestStats(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	stats := func(method string, subid string, exp_code int) dtos.SubscriptionStatsResponse {
+		t.Helper()
+		req, _ := http.NewRequest(method, uri_base()+"/id/"+subid+"/stats", nil)
+		rr := httptest.NewRecorder()
+		router := echo.New()
+		router.Add(method, uri_base()+"/id/:subscriptionid/stats", ProcessStatsRequest)
+		router.ServeHTTP(rr, req)
+		if rr.Code != exp_code {
+			t.Fatalf("Got status %d from %s of stats of %s, expected %d", rr.Code, method, subid, exp_code)
+		}
+		var resp dtos.SubscriptionStatsResponse
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return resp
+	}
+	_ = stats(http.MethodGet, inexist, http.StatusNotFound)
+	subid := checkCreateRequest(t, http.StatusCreated)
+	req := "{\"apiVersion\":\"v3\", \"include\":[\"a/b\"]}"
+	_ = checkRequest(t, http.MethodPatch, uri_base()+"/id/"+subid, req, http.StatusOK, "application/json")
+	// Two connections, the first gone
+	subInfo := g_subscriptions[subid]
+	interfaces.App.Subs.SetActive(subInfo, true)
+	interfaces.App.Subs.SetActive(subInfo, false)
+	interfaces.App.Subs.SetActive(subInfo, true)
+	chans := interfaces.App.Subs.SubscribedChannels("a/b")
+	for i := 0; i < 2; i++ {
+		if len(chans) != 1 || !chans[0].Send(submgr.ChannelMessage{Payload: "{\"n\":1}"}) {
+			t.Fatal("Could not send to subscribed channel")
+		}
+	}
+	got := stats(http.MethodGet, subid, http.StatusOK)
+	if got.Delivered != 2 || got.Bytes != 14 || got.Connections != 2 || got.Dropped != 0 {
+		t.Fatalf("Wrong stats %+v", got)
+	}
+	since, err := time.Parse(time.RFC3339, got.Since)
+	if err != nil {
+		t.Fatalf("Bad since %q", got.Since)
+	}
+
+	time.Sleep(time.Second)
+	_ = stats(http.MethodDelete, subid, http.StatusOK)
+	got = stats(http.MethodGet, subid, http.StatusOK)
+	if got.Delivered != 0 || got.Bytes != 0 || got.Connections != 0 {
+		t.Fatalf("Stats not reset: %+v", got)
+	}
+	if reset, _ := time.Parse(time.RFC3339, got.Since); !reset.After(since) {
+		t.Fatalf("Since not moved on by the reset: %s", got.Since)
+	}
+	// The throughput summary still counts from the start
+	if throughput := interfaces.App.Subs.Throughput(); len(throughput) != 1 || throughput[0].Delivered != 2 {
+		t.Fatalf("Reset changed the throughput: %+v", throughput)
+	}
+}
@@ -0,0 +1,55 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"crypto/tls"
+	"errors"
+	"sync/atomic"
+)
+
+// Keys of the certificate chain and private key, both PEM, in EventsTLSSecretName
+const (
+	TLSCertSecretKey = "cert"
+	TLSKeySecretKey  = "key"
+)
+
+/*
+Struct CertificateStore holds the events port's TLS certificate. It can be replaced while
+serving, when rotated in the secret provider: new connections get the new certificate,
+and open event streams carry on undisturbed, so nothing has to be re-bound.
+*/
+type CertificateStore struct {
+	cert atomic.Pointer[tls.Certificate]
+}
+
+// Set parses and stores the certificate. On error, the previous certificate stays in use.
+func (c *CertificateStore) Set(certPEM, keyPEM []byte) error {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+	c.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate is the tls.Config callback handing out the current certificate.
+func (c *CertificateStore) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := c.cert.Load()
+	if cert == nil {
+		return nil, errors.New("no TLS certificate loaded")
+	}
+	return cert, nil
+}
+
+// TLSConfig returns the TLS configuration for the events port.
+func (c *CertificateStore) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.GetCertificate,
+	}
+}
@@ -0,0 +1,68 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// selfSigned returns a PEM certificate and key for the common name.
+func selfSigned(t *testing.T, name string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestCertificateStore(t *testing.T) {
+	var dut CertificateStore
+	if _, err := dut.TLSConfig().GetCertificate(nil); err == nil {
+		t.Fatal("Got a certificate before one was set")
+	}
+	cert, key := selfSigned(t, "first")
+	if err := dut.Set(cert, key); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if dut.Set([]byte("bogus"), key) == nil {
+		t.Fatal("Set accepted a bad certificate")
+	}
+	got, err := dut.GetCertificate(nil)
+	if err != nil || got.Leaf.Subject.CommonName != "first" {
+		t.Fatalf("Bad certificate replaced the good one: %v", err)
+	}
+	// Rotation
+	cert, key = selfSigned(t, "second")
+	if err := dut.Set(cert, key); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got, _ = dut.GetCertificate(nil); got.Leaf.Subject.CommonName != "second" {
+		t.Fatalf("Certificate not rotated, have %s", got.Leaf.Subject.CommonName)
+	}
+}
@@ -0,0 +1,63 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+/*
+ProcessTriggerRequest handles POST /trigger/{topic}: the request body is processed exactly
+as if it had been received from the message bus on that topic, with the request's
+Content-Type as its content type. This lets clients be tested without a device, and
+devices that only speak HTTP feed the streams.
+
+The topic is in the path because the SDK reserves /trigger itself, for its HTTP trigger.
+*/
+func ProcessTriggerRequest(c echo.Context) error {
+	lc := interfaces.App.Logger
+	w := c.Response()
+	r := c.Request()
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	topic := strings.Trim(c.Param("*"), "/")
+	if topic == "" || strings.ContainsAny(topic, "#+") {
+		respondBase(w, r, requestId(r), http.StatusBadRequest, "A topic without wildcards is required")
+		return nil
+	}
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondBase(w, r, requestId(r), http.StatusBadRequest, err.Error())
+		return nil
+	}
+	if len(payload) == 0 {
+		respondBase(w, r, requestId(r), http.StatusBadRequest, "A payload is required")
+		return nil
+	}
+	correlationID := r.Header.Get(common.CorrelationHeader)
+	if correlationID == "" {
+		correlationID = uuid.NewString()
+	}
+	contentType := r.Header.Get(common.ContentType)
+	if contentType == "" {
+		contentType = common.ContentTypeJSON
+	}
+	lc.Debugf("Injecting message on topic %s from trigger request", topic)
+	ctx := interfaces.App.Service.BuildContext(correlationID, contentType)
+	interfaces.App.Processor.Process(ctx, topic, payload)
+	respondBase(w, r, requestId(r), http.StatusOK, "Message processed")
+	return nil
+}
@@ -0,0 +1,82 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"bytes"
+	"github.com/edgexfoundry-holding/edgex-sse/functions"
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v4/pkg"
+	appint "github.com/edgexfoundry/app-functions-sdk-go/v4/pkg/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/labstack/echo/v4"
+)
+
+// Stands in for the SDK's service; only BuildContext is used by the trigger endpoint
+type fakeService struct {
+	appint.ApplicationService
+}
+
+func (f fakeService) BuildContext(correlationId string, contentType string) appint.AppFunctionContext {
+	return pkg.NewAppFuncContextForTest(correlationId, logger.NewMockClient())
+}
+
+func doTrigger(t *testing.T, topic string, body string) int {
+	req, err := http.NewRequest(http.MethodPost, interfaces.App.Config.SSE.TriggerRoute()+"/"+topic, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Error constructing request: %s", err.Error())
+	}
+	rr := httptest.NewRecorder()
+	router := echo.New()
+	router.POST(interfaces.App.Config.SSE.TriggerRoute()+"/*", ProcessTriggerRequest)
+	router.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestTrigger(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	interfaces.App.Service = fakeService{}
+	processor := functions.NewProcessor(interfaces.App.Logger, interfaces.App.Subs, interfaces.App.Config)
+	interfaces.App.Processor = &processor
+	subid, err := interfaces.App.Subs.NewSubscription()
+	if err != nil {
+		t.Fatal("Could not add a subscription")
+	}
+	subinfo := interfaces.App.Subs.Subscription(subid)
+	if err := interfaces.App.Subs.Include(subinfo, "test/http"); err != nil {
+		t.Fatalf("Could not add include: %v", err)
+	}
+	interfaces.App.Subs.SetActive(subinfo, true)
+	rxchan, _ := interfaces.App.Subs.ReceiveChannel(subinfo)
+
+	if code := doTrigger(t, "test/http/door", "{\"state\":\"OPEN\"}"); code != http.StatusOK {
+		t.Fatalf("Got status %d instead of 200", code)
+	}
+	select {
+	case msg := <-rxchan:
+		if msg.Payload != "{\"state\":\"OPEN\"}" {
+			t.Fatalf("Wrong payload delivered: %s", msg.Payload)
+		}
+	default:
+		t.Fatal("Triggered message not delivered")
+	}
+
+	if code := doTrigger(t, "", "{\"state\":\"OPEN\"}"); code != http.StatusBadRequest {
+		t.Fatalf("Got status %d instead of 400 without topic", code)
+	}
+	if code := doTrigger(t, "test/http/+", "{\"state\":\"OPEN\"}"); code != http.StatusBadRequest {
+		t.Fatalf("Got status %d instead of 400 with wildcard topic", code)
+	}
+	if code := doTrigger(t, "test/http/door", ""); code != http.StatusBadRequest {
+		t.Fatalf("Got status %d instead of 400 without payload", code)
+	}
+}
@@ -0,0 +1,77 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/pkg/dtos"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"io"
+)
+
+/*
+Struct subscriptionChange is a PUT or PATCH of a subscription, whatever version of the API
+it came through: topic prefixes to include and exclude, and options, nil to leave them as
+they are. It is what the handlers act on.
+*/
+type subscriptionChange struct {
+	include []string
+	exclude []string
+	options *submgr.SubscriptionOptions
+	// Refuse a prefix that would be both included and excluded
+	strict  bool
+}
+
+// Struct subscriptionState is a subscription as GET shows it, whatever version of the API it is shown through.
+type subscriptionState struct {
+	include     []string
+	exclude     []string
+	options     submgr.SubscriptionOptions
+	prefixLimit uint
+	// Empty if it isn't aging out
+	expiresIn   string
+}
+
+/*
+Type subscriptionVersion is one version of the subscription API's request and response
+bodies. The handlers work on subscriptionChange and subscriptionState, so a version whose
+bodies say the same in another way, e.g. richer filter objects rather than prefix lists,
+only needs one of these and its routes, e.g. at /api/v4/subscription, beside the others,
+and clients of each keep theirs while they move over. What can't be said in a version's
+bodies is refused when they are decoded, and left out when they are encoded.
+*/
+type subscriptionVersion interface {
+	/*
+	decodeChange decodes the body of a PUT or PATCH.
+
+	Error is returned, for a 400, if it isn't a valid body of the version.
+	*/
+	decodeChange(body io.Reader) (subscriptionChange, error)
+	// encodeState returns the body of the response to a GET.
+	encodeState(requestId string, state subscriptionState) any
+}
+
+// Struct subscriptionV3 is the v3 subscription API, whose bodies are those of package dtos.
+type subscriptionV3 struct{}
+
+func (subscriptionV3) decodeChange(body io.Reader) (subscriptionChange, error) {
+	var request dtos.SubscriptionRequest
+	if err := json.NewDecoder(body).Decode(&request); err != nil {
+		return subscriptionChange{}, err
+	}
+	if err := request.Validate(); err != nil {
+		return subscriptionChange{}, err
+	}
+	return subscriptionChange{include: request.Include, exclude: request.Exclude, options: request.Options, strict: request.Strict}, nil
+}
+
+func (subscriptionV3) encodeState(requestId string, state subscriptionState) any {
+	rv := dtos.NewSubscriptionResponse(requestId, state.include, state.exclude, state.options)
+	rv.PrefixLimit = state.prefixLimit
+	rv.ExpiresIn = state.expiresIn
+	return rv
+}
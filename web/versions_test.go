@@ -0,0 +1,122 @@
+//
+// Copyright (C) 2025 Eaton
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package web
+
+import (
+	"github.com/edgexfoundry-holding/edgex-sse/interfaces"
+	"github.com/edgexfoundry-holding/edgex-sse/submgr"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+/*
+Struct filtersVersion is a version of the subscription API with topic filter objects instead
+of prefix lists, as a later one might have, to check versions can be served side by side.
+*/
+type filtersVersion struct{}
+
+type topicFilter struct {
+	Topic   string `json:"topic"`
+	Exclude bool   `json:"exclude,omitempty"`
+}
+
+type filtersBody struct {
+	ApiVersion string                      `json:"apiVersion"`
+	Filters    []topicFilter               `json:"filters"`
+	Options    *submgr.SubscriptionOptions `json:"options,omitempty"`
+}
+
+func (filtersVersion) decodeChange(body io.Reader) (subscriptionChange, error) {
+	var request filtersBody
+	if err := json.NewDecoder(body).Decode(&request); err != nil {
+		return subscriptionChange{}, err
+	}
+	if request.ApiVersion != "v4" {
+		return subscriptionChange{}, errors.New("apiVersion must be v4")
+	}
+	var rv subscriptionChange
+	for _, filter := range request.Filters {
+		if filter.Exclude {
+			rv.exclude = append(rv.exclude, filter.Topic)
+		} else {
+			rv.include = append(rv.include, filter.Topic)
+		}
+	}
+	rv.options = request.Options
+	return rv, nil
+}
+
+func (filtersVersion) encodeState(requestId string, state subscriptionState) any {
+	rv := filtersBody{ApiVersion: "v4", Options: &state.options}
+	for _, topic := range state.include {
+		rv.Filters = append(rv.Filters, topicFilter{Topic: topic})
+	}
+	for _, topic := range state.exclude {
+		rv.Filters = append(rv.Filters, topicFilter{Topic: topic, Exclude: true})
+	}
+	return rv
+}
+
+func TestSubscriptionVersions(t *testing.T) {
+	managerInit(t)
+	defer managerClose()
+	router := echo.New()
+	v3 := uri_base()
+	v4 := strings.Replace(uri_base(), "/v3/", "/v4/", 1)
+	router.POST(v3, ProcessSubscriptionRequest)
+	router.Any(v3+"/id/:subscriptionid", ProcessSubscriptionRequest)
+	router.Any(v4+"/id/:subscriptionid", func(c echo.Context) error {
+		return processSubscriptionRequest(c, filtersVersion{})
+	})
+	request := func(method string, uri string, body string) (int, string) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, uri, strings.NewReader(body)))
+		return rr.Code, rr.Body.String()
+	}
+
+	subid := checkCreateRequest(t, http.StatusCreated)
+	// Changed through one version, shown through both
+	code, _ := request(http.MethodPatch, v4+"/id/"+subid, `{"apiVersion":"v4","filters":[{"topic":"a"},{"topic":"a/b","exclude":true}],"options":{"format":"simple"}}`)
+	if code != http.StatusOK {
+		t.Fatalf("PATCH through v4 returned %d", code)
+	}
+	current := checkGetRequest(t, subid, http.StatusOK)
+	if !reflect.DeepEqual(current.Include, []string{"a/"}) || !reflect.DeepEqual(current.Exclude, []string{"a/b/"}) || current.Options.Format != "simple" {
+		t.Fatalf("Wrong subscription through v3 %+v", current)
+	}
+	code, body := request(http.MethodGet, v4+"/id/"+subid, "")
+	var state filtersBody
+	if code != http.StatusOK || json.Unmarshal([]byte(body), &state) != nil {
+		t.Fatalf("GET through v4 returned %d %s", code, body)
+	}
+	if !reflect.DeepEqual(state.Filters, []topicFilter{{Topic: "a/"}, {Topic: "a/b/", Exclude: true}}) || state.Options.Format != "simple" {
+		t.Fatalf("Wrong subscription through v4 %+v", state)
+	}
+	// Each version refuses the other's bodies
+	if code, _ = request(http.MethodPatch, v4+"/id/"+subid, `{"apiVersion":"v3","include":["c"]}`); code != http.StatusBadRequest {
+		t.Fatalf("v4 took a v3 body, %d", code)
+	}
+	if code, _ = request(http.MethodPatch, v3+"/id/"+subid, `{"apiVersion":"v3","include":["c"],"options":{"format":"bogus"}}`); code != http.StatusBadRequest {
+		t.Fatalf("v3 took invalid options, %d", code)
+	}
+	// PUT replaces, whichever version it comes through
+	if code, _ = request(http.MethodPut, v4+"/id/"+subid, `{"apiVersion":"v4","filters":[{"topic":"d"}]}`); code != http.StatusOK {
+		t.Fatalf("PUT through v4 returned %d", code)
+	}
+	includes, excludes, _ := interfaces.App.Subs.SubscriptionInfo(interfaces.App.Subs.Subscription(subid))
+	if !reflect.DeepEqual(includes, []string{"d/"}) || len(excludes) != 0 || interfaces.App.Subs.Options(interfaces.App.Subs.Subscription(subid)).Format != "" {
+		t.Fatalf("Wrong subscription after PUT %v %v", includes, excludes)
+	}
+}